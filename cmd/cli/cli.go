@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -36,22 +37,52 @@ func main() {
 		log.Fatal(err)
 	}
 	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "Server address")
+	flag.StringVar(&cfg.GRPCAddress, "g", cfg.GRPCAddress, "gRPC server address")
+	flag.StringVar(&cfg.Transport, "t", cfg.Transport, "Transport to talk to the server: http or grpc")
 	flag.StringVar(&cfg.OutputFolder, "o", cfg.OutputFolder, "Output folder for files")
+	flag.StringVar(&cfg.CACertFile, "ca", cfg.CACertFile, "CA bundle to trust the server by")
+	flag.StringVar(&cfg.ClientCertFile, "c", cfg.ClientCertFile, "Client certificate for the server requiring one")
+	flag.StringVar(&cfg.ClientKeyFile, "k", cfg.ClientKeyFile, "Key of the client certificate")
 	flag.Parse()
 
 	fmt.Println("Welcome to Gophkeeper")
 	printBuildData()
 
-	var api = client.NewApi(cfg.ServerAddress)
-	var storage, err = client.NewStorage(cfg.OutputFolder)
+	tlsConfig, err := client.NewTLSConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var api client.Api
+	switch cfg.Transport {
+	case client.TransportHTTP:
+		api = client.NewApi(cfg.ServerAddress, tlsConfig)
+	case client.TransportGRPC:
+		grpcApi, err := client.NewGRPCApi(cfg.GRPCAddress, tlsConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer grpcApi.Close()
+		api = grpcApi
+	default:
+		log.Fatalf("unknown transport %q, use %q or %q", cfg.Transport, client.TransportHTTP, client.TransportGRPC)
+	}
+
+	storage, err := client.NewStorage(cfg.OutputFolder)
 	if err != nil {
 		log.Fatal(err)
 	}
 	var service = client.NewService(cfg, api, storage)
 
+	go service.WatchEvents(context.Background())
+
+	// the data is polled only while the changes can't be watched, e.g. the remote is unreachable
 	tickerUpdate := time.NewTicker(client.UpdateDataTimer)
 	go func() {
 		for range tickerUpdate.C {
+			if service.Watching() {
+				continue
+			}
 			log.Printf("Updating data from server")
 			err = service.UpdateAll()
 			if err != nil {
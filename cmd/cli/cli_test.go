@@ -1,20 +1,24 @@
 package main
 
 import (
+	"archive/zip"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/caarlos0/env/v6"
-	"github.com/gorilla/sessions"
 	"github.com/stretchr/testify/require"
 	"gophkeeper/cmd/cli/client"
 	"gophkeeper/internal/app"
 	"gophkeeper/internal/config"
 	"gophkeeper/internal/service"
 	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
 	"gorm.io/gorm"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -32,11 +36,12 @@ func TestClient(t *testing.T) {
 	flag.StringVar(&serverCfg.ServerAddress, "a", serverCfg.ServerAddress, "Server address")
 	log.Println(serverCfg.DatabaseDSN)
 
-	var secretKey = "watch?v=Qw4w9WgXcQ"
-	userStorage := storage.NewUserStorage(serverCfg.DatabaseDSN)
-	cookieStorage := sessions.NewCookieStore([]byte(secretKey))
-	var application = app.NewApp(serverCfg, userStorage, *cookieStorage)
-	go application.Run()
+	userStorage := storage.NewUserStorage(serverCfg.DatabaseDSN, storage.Quotas{MaxBytes: serverCfg.QuotaBytes,
+		MaxBinarySize: serverCfg.QuotaBinarySize, MaxItems: serverCfg.QuotaItems})
+	defer userStorage.Close()
+	var application = app.NewApp(serverCfg, userStorage, userStorage)
+	go application.Start(context.Background())
+	defer application.Shutdown(context.Background())
 
 	var cfg client.Config
 
@@ -47,7 +52,7 @@ func TestClient(t *testing.T) {
 	cfg.ServerAddress = "http://" + serverCfg.ServerAddress
 	flag.Parse()
 
-	var api = client.NewApi(cfg.ServerAddress)
+	var api = client.NewApi(cfg.ServerAddress, nil)
 	var clientStorage, err = client.NewStorage(cfg.OutputFolder)
 	if err != nil {
 		log.Fatal(err)
@@ -83,6 +88,15 @@ func TestClient(t *testing.T) {
 	GetTextsTest(t, clientService)
 	GetCreditCardsTest(t, clientService)
 	GetBinaryListTest(t, clientService)
+	SyncTest(t, clientService, clientStorage)
+	EventsTest(t, clientService)
+	UsageTest(t, clientService, serverCfg)
+	ExportTest(t, clientService)
+	HistoryTest(t, clientService)
+	ShareTest(t, clientService, cfg.ServerAddress)
+	OrgTest(t, clientService, cfg.ServerAddress)
+	ActivityTest(t, clientService)
+	SendTest(t, clientService, cfg)
 
 	application.UserStorage.DeleteAll()
 	err = clientStorage.ClearAll()
@@ -192,7 +206,7 @@ func PutLogoPassTest(t *testing.T, svc *client.LocalService) {
 					UpdatedAt: time.Now().Add(time.Minute * -20),
 				},
 			},
-			want: client.ErrAlreadyExists,
+			want: client.ErrOldData,
 		},
 		{
 			name: "update logoPass ok",
@@ -209,13 +223,19 @@ func PutLogoPassTest(t *testing.T, svc *client.LocalService) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "update logoPass ok" {
+				logoPasses, err := svc.Api.GetLogoPasses()
+				require.NoError(t, err)
+				for _, logoPass := range logoPasses {
+					if logoPass.Description == tt.data.Description {
+						tt.data.Revision = logoPass.Revision
+					}
+				}
+			}
 			err := svc.PutLogoPass(tt.data)
 			if errors.Is(err, tt.want) {
 				err = nil
 			}
-			if tt.name == "update logoPass ok" {
-				err = svc.Api.UploadLogoPass(tt.data)
-			}
 			require.NoError(t, err)
 		})
 	}
@@ -253,7 +273,17 @@ func PutTextTest(t *testing.T, svc *client.LocalService) {
 					UpdatedAt: time.Now().Add(time.Minute * -10),
 				},
 			},
-			want: client.ErrAlreadyExists,
+			want: client.ErrOldData,
+		},
+		{
+			name: "update text fail: stale revision",
+			data: service.TextData{
+				Text:        "sublieutenant",
+				Description: "young fellow",
+				Overwrite:   true,
+				Revision:    1 << 40,
+			},
+			want: client.ErrOldData,
 		},
 		{
 			name: "update text ok",
@@ -269,13 +299,19 @@ func PutTextTest(t *testing.T, svc *client.LocalService) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "update text ok" {
+				texts, err := svc.Api.GetTexts()
+				require.NoError(t, err)
+				for _, text := range texts {
+					if text.Description == tt.data.Description {
+						tt.data.Revision = text.Revision
+					}
+				}
+			}
 			err := svc.PutText(tt.data)
 			if errors.Is(err, tt.want) {
 				err = nil
 			}
-			if tt.name == "update text ok" {
-				err = svc.Api.UploadText(tt.data)
-			}
 			require.NoError(t, err)
 		})
 	}
@@ -322,7 +358,7 @@ func PutCreditCardTest(t *testing.T, svc *client.LocalService) {
 					UpdatedAt: time.Now().Add(time.Minute * -10),
 				},
 			},
-			want: client.ErrAlreadyExists,
+			want: client.ErrOldData,
 		},
 		{
 			name: "update CreditCard ok",
@@ -341,13 +377,19 @@ func PutCreditCardTest(t *testing.T, svc *client.LocalService) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "update CreditCard ok" {
+				creditCards, err := svc.Api.GetCreditCards()
+				require.NoError(t, err)
+				for _, card := range creditCards {
+					if card.Number == tt.data.Number {
+						tt.data.Revision = card.Revision
+					}
+				}
+			}
 			err := svc.PutCreditCard(tt.data)
 			if errors.Is(err, tt.want) {
 				err = nil
 			}
-			if tt.name == "update CreditCard ok" {
-				err = svc.Api.UploadCreditCard(tt.data)
-			}
 			require.NoError(t, err)
 		})
 	}
@@ -385,7 +427,7 @@ func PutBinaryTest(t *testing.T, svc *client.LocalService) {
 					UpdatedAt: time.Now().Add(time.Minute * -10),
 				},
 			},
-			want: client.ErrAlreadyExists,
+			want: client.ErrOldData,
 		},
 		{
 			name: "update binary ok",
@@ -401,13 +443,19 @@ func PutBinaryTest(t *testing.T, svc *client.LocalService) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := svc.Api.UploadBinary(tt.data)
+			if tt.name == "update binary ok" {
+				binaries, err := svc.Api.GetBinaryList()
+				require.NoError(t, err)
+				for _, binary := range binaries {
+					if binary.Description == tt.data.Description {
+						tt.data.Revision = binary.Revision
+					}
+				}
+			}
+			_, err := svc.Api.UploadBinary(tt.data)
 			if errors.Is(err, tt.want) {
 				err = nil
 			}
-			if tt.name == "update binary ok" {
-				err = svc.Api.UploadBinary(tt.data)
-			}
 			require.NoError(t, err)
 		})
 	}
@@ -580,3 +628,323 @@ func GetBinaryListTest(t *testing.T, svc *client.LocalService) {
 		})
 	}
 }
+
+func SyncTest(t *testing.T, svc *client.LocalService, clientStorage *client.FileStorage) {
+	var cursor string
+	t.Run("update all ok: full sync", func(t *testing.T) {
+		require.NoError(t, svc.UpdateAll())
+
+		var err error
+		cursor, err = clientStorage.GetCursor()
+		require.NoError(t, err)
+		require.NotEmpty(t, cursor)
+	})
+
+	t.Run("update all ok: delta sync", func(t *testing.T) {
+		changes, err := svc.Api.Sync(cursor)
+		require.NoError(t, err)
+		require.False(t, changes.Complete)
+		require.Empty(t, changes.LogoPasses)
+		require.Empty(t, changes.Texts)
+
+		require.NoError(t, svc.UpdateAll())
+		next, err := clientStorage.GetCursor()
+		require.NoError(t, err)
+		require.Equal(t, cursor, next)
+	})
+
+	t.Run("sync ok: invalid cursor", func(t *testing.T) {
+		_, err := svc.Api.Sync("never")
+		require.ErrorIs(t, err, client.ErrCorruptedData)
+	})
+}
+
+func EventsTest(t *testing.T, svc *client.LocalService) {
+	t.Run("events ok: change announced", func(t *testing.T) {
+		events, err := svc.Api.Events()
+		require.NoError(t, err)
+		defer events.Close()
+
+		_, err = svc.Api.UploadText(service.TextData{Text: "never gonna", Description: "events",
+			Model: gorm.Model{UpdatedAt: time.Now()}})
+		require.NoError(t, err)
+
+		event, err := events.Next()
+		require.NoError(t, err)
+		require.Equal(t, storage.ItemText, event.Item)
+	})
+}
+
+func UsageTest(t *testing.T, svc *client.LocalService, serverCfg config.Config) {
+	t.Run("get usage ok", func(t *testing.T) {
+		usage, err := svc.Api.GetUsage()
+		require.NoError(t, err)
+		require.Positive(t, usage.Bytes)
+		require.Positive(t, usage.Items[storage.ItemText])
+		require.Equal(t, serverCfg.QuotaBytes, usage.MaxBytes)
+	})
+}
+
+func ExportTest(t *testing.T, svc *client.LocalService) {
+	t.Run("export ok", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "export.zip")
+		require.NoError(t, svc.ExportVault(path))
+
+		archive, err := zip.OpenReader(path)
+		require.NoError(t, err)
+		defer archive.Close()
+		var names []string
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+		}
+		require.Contains(t, names, "texts.json")
+		require.Contains(t, names, "binaries.json")
+	})
+}
+
+func HistoryTest(t *testing.T, svc *client.LocalService) {
+	var revision int64
+	for i, text := range []string{"never gonna give you up", "never gonna let you down"} {
+		var err error
+		revision, err = svc.Api.UploadText(service.TextData{Text: text, Description: "history", Overwrite: true,
+			Revision: revision, Model: gorm.Model{UpdatedAt: time.Now().Add(time.Duration(i) * time.Minute)}})
+		require.NoError(t, err)
+	}
+
+	var versions []service.SecretVersion
+	t.Run("get history ok", func(t *testing.T) {
+		var err error
+		versions, err = svc.Api.GetHistory(storage.ItemText, 0)
+		require.NoError(t, err)
+		require.NotNil(t, versions[0].Text)
+		require.Equal(t, "never gonna give you up", versions[0].Text.Text)
+	})
+
+	t.Run("get history fail: no history", func(t *testing.T) {
+		_, err := svc.Api.GetHistory(storage.ItemCreditCard, 1<<30)
+		require.ErrorIs(t, err, client.ErrEmpty)
+	})
+
+	t.Run("restore version ok", func(t *testing.T) {
+		require.NotEmpty(t, versions)
+		// the version is restored over the text as it is synced locally
+		require.NoError(t, svc.UpdateAll())
+		require.NoError(t, svc.RestoreVersion(versions[0]))
+
+		texts, err := svc.Api.GetTexts()
+		require.NoError(t, err)
+		var restored string
+		for _, text := range texts {
+			if text.Description == "history" {
+				restored = text.Text
+			}
+		}
+		require.Equal(t, "never gonna give you up", restored)
+	})
+
+	t.Run("restore version fail: unknown version", func(t *testing.T) {
+		err := svc.Api.RestoreVersion(service.SecretVersion{ID: 1 << 30})
+		require.ErrorIs(t, err, client.ErrEmpty)
+	})
+
+	t.Run("restore version fail: text changed since", func(t *testing.T) {
+		require.NotEmpty(t, versions)
+		version := versions[0]
+		version.SecretRevision = revision
+		err := svc.Api.RestoreVersion(version)
+		require.ErrorIs(t, err, client.ErrOldData)
+	})
+}
+
+func ShareTest(t *testing.T, svc *client.LocalService, address string) {
+	key := tools.GenerateKey("Ground control")
+	secret, err := tools.EncryptString("planet earth is blue", key)
+	require.NoError(t, err)
+	_, err = svc.Api.UploadText(service.TextData{Text: secret, Description: "shared"})
+	require.NoError(t, err)
+
+	recipient := client.NewApi(address, nil)
+	err = recipient.Register(service.User{Login: "Ziggy", Password: "Stardust"})
+	if errors.Is(err, client.ErrUserExists) {
+		err = recipient.Login(service.User{Login: "Ziggy", Password: "Stardust"})
+	}
+	require.NoError(t, err)
+	var keys service.KeyPair
+	keys.PublicKey, keys.PrivateKey, err = tools.GenerateKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, recipient.PutKeyPair(keys))
+
+	t.Run("key pair ok: made on sync", func(t *testing.T) {
+		require.NoError(t, svc.UpdateAll())
+		stored, err := svc.Api.GetKeyPair("")
+		require.NoError(t, err)
+		require.NotEmpty(t, stored.PublicKey)
+		require.NotEmpty(t, stored.PrivateKey)
+	})
+
+	t.Run("share fail: recipient has no keys", func(t *testing.T) {
+		_, err := svc.ShareSecret(storage.ItemText, "shared", "Hallo Spaceboy", service.AccessRead)
+		require.ErrorIs(t, err, client.ErrNoKeyPair)
+	})
+
+	var shareID uint
+	t.Run("share ok", func(t *testing.T) {
+		_, err := svc.ShareSecret(storage.ItemText, "shared", "Ziggy", service.AccessWrite)
+		require.NoError(t, err)
+
+		shares, err := recipient.GetShares()
+		require.NoError(t, err)
+		require.Len(t, shares, 1)
+		require.NotNil(t, shares[0].Text)
+		text, err := tools.OpenString(shares[0].Text.Text, keys.PublicKey, keys.PrivateKey)
+		require.NoError(t, err)
+		require.Equal(t, "planet earth is blue", text)
+		shareID = shares[0].ID
+		require.NoError(t, recipient.AcceptShare(shareID))
+	})
+
+	t.Run("change ok: taken in by the owner", func(t *testing.T) {
+		owner, err := recipient.GetKeyPair("Major Tom")
+		require.NoError(t, err)
+		changed, err := tools.SealString("and there's nothing I can do", owner.PublicKey)
+		require.NoError(t, err)
+		require.NoError(t, recipient.ChangeShare(service.Share{ID: shareID, Text: &service.TextData{Text: changed}}))
+
+		require.NoError(t, svc.UpdateAll())
+		texts, err := svc.Api.GetTexts()
+		require.NoError(t, err)
+		var text string
+		for _, stored := range texts {
+			if stored.Description == "shared" {
+				text, err = tools.DecryptString(stored.Text, key)
+				require.NoError(t, err)
+			}
+		}
+		require.Equal(t, "and there's nothing I can do", text)
+
+		shares, err := recipient.GetShares()
+		require.NoError(t, err)
+		require.Len(t, shares, 1)
+		text, err = tools.OpenString(shares[0].Text.Text, keys.PublicKey, keys.PrivateKey)
+		require.NoError(t, err)
+		require.Equal(t, "and there's nothing I can do", text)
+	})
+}
+
+func OrgTest(t *testing.T, svc *client.LocalService, address string) {
+	member := client.NewApi(address, nil)
+	require.NoError(t, member.Login(service.User{Login: "Ziggy", Password: "Stardust"}))
+	keys, err := member.GetKeyPair("")
+	require.NoError(t, err)
+
+	var org service.Organization
+	var collection service.Collection
+	t.Run("create org ok", func(t *testing.T) {
+		org, err = svc.CreateOrg("Spiders from Mars")
+		require.NoError(t, err)
+		require.NotZero(t, org.ID)
+		collection, err = svc.Api.PutCollection(service.Collection{OrgID: org.ID, Name: "Starman"})
+		require.NoError(t, err)
+		require.NotZero(t, collection.ID)
+	})
+
+	t.Run("add member fail: no keys", func(t *testing.T) {
+		require.ErrorIs(t, svc.PutMember(org, "Hallo Spaceboy", service.OrgRoleMember), client.ErrNoKeyPair)
+	})
+
+	t.Run("add member ok", func(t *testing.T) {
+		require.NoError(t, svc.PutMember(org, "Ziggy", service.OrgRoleMember))
+		_, err := member.SyncCollection(collection.ID, "")
+		require.ErrorIs(t, err, client.ErrForbidden)
+		require.NoError(t, svc.Api.PutCollectionMember(service.CollectionMember{CollectionID: collection.ID,
+			Login: "Ziggy"}))
+	})
+
+	t.Run("collection secret ok", func(t *testing.T) {
+		require.NoError(t, svc.PutCollectionSecret(org, collection.ID,
+			service.Share{Text: &service.TextData{Text: "waiting in the sky", Description: "Starman"}}))
+		entries, err := svc.CollectionSecrets(org, collection.ID)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.NotNil(t, entries[0].Text)
+		require.Equal(t, "waiting in the sky", entries[0].Text.Text)
+
+		orgs, err := member.GetOrgs()
+		require.NoError(t, err)
+		require.Len(t, orgs, 1)
+		orgKey, err := tools.OpenString(orgs[0].OrgKey, keys.PublicKey, keys.PrivateKey)
+		require.NoError(t, err)
+		changes, err := member.SyncCollection(collection.ID, "")
+		require.NoError(t, err)
+		require.Len(t, changes.Texts, 1)
+		text, err := tools.DecryptString(changes.Texts[0].Text, orgKey)
+		require.NoError(t, err)
+		require.Equal(t, "waiting in the sky", text)
+	})
+
+	t.Run("delete org ok", func(t *testing.T) {
+		require.NoError(t, svc.Api.DeleteOrg(org.ID))
+		_, err := member.GetOrgs()
+		require.ErrorIs(t, err, client.ErrEmpty)
+	})
+}
+
+func ActivityTest(t *testing.T, svc *client.LocalService) {
+	t.Run("get audit log ok", func(t *testing.T) {
+		page, err := svc.Api.GetAuditLog("")
+		require.NoError(t, err)
+		require.NotEmpty(t, page.Events)
+		actions := map[string]bool{}
+		for _, event := range page.Events {
+			actions[event.Action] = true
+		}
+		require.True(t, actions[service.AuditLogin])
+		require.True(t, actions[service.AuditUpload])
+	})
+}
+
+func SendTest(t *testing.T, svc *client.LocalService, cfg client.Config) {
+	// the sends are opened by someone who has never signed in
+	stranger := client.NewService(cfg, client.NewApi(cfg.ServerAddress, nil), nil)
+
+	var link string
+	t.Run("send text ok", func(t *testing.T) {
+		var send service.Send
+		var err error
+		link, send, err = svc.Send(storage.ItemText, "", []byte("Ground Control to Major Tom"), 1, time.Hour)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(link, cfg.ServerAddress))
+		require.Contains(t, link, send.ID)
+		require.NotContains(t, link[:strings.Index(link, "#")], "Ground Control")
+	})
+
+	t.Run("open fail: no key", func(t *testing.T) {
+		_, err := stranger.OpenSend(link[:strings.Index(link, "#")])
+		require.ErrorIs(t, err, client.ErrInvalidLink)
+	})
+
+	t.Run("open ok", func(t *testing.T) {
+		send, err := stranger.OpenSend(link)
+		require.NoError(t, err)
+		require.Equal(t, storage.ItemText, send.Item)
+		require.Equal(t, "Ground Control to Major Tom", string(send.Data))
+	})
+
+	t.Run("open fail: viewed already", func(t *testing.T) {
+		_, err := stranger.OpenSend(link)
+		require.ErrorIs(t, err, client.ErrEmpty)
+	})
+
+	t.Run("send file ok", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "space oddity.txt")
+		require.NoError(t, os.WriteFile(path, []byte("Check ignition"), 0644))
+		link, _, err := svc.SendFile(path, 2, time.Hour)
+		require.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			send, err := stranger.OpenSend(link)
+			require.NoError(t, err)
+			require.Equal(t, "space oddity.txt", send.Name)
+			require.Equal(t, "Check ignition", string(send.Data))
+		}
+	})
+}
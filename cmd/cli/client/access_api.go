@@ -1,15 +1,22 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"gophkeeper/cmd/cli/client/openapi"
 	"gophkeeper/internal/app"
 	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // Api is an interface of all api interactions needed for Gophkeeper
@@ -21,276 +28,1317 @@ type Api interface {
 	GetCreditCards() ([]service.CreditCard, error)
 	GetBinaryList() ([]service.BinaryData, error)
 	GetBinary(binary service.BinaryData) (service.BinaryData, error)
-	UploadLogoPass(logoPass service.LogoPass) error
-	UploadText(text service.TextData) error
-	UploadCreditCard(card service.CreditCard) error
-	UploadBinary(binary service.BinaryData) error
+	Sync(cursor string) (service.SyncData, error)
+	SyncCollection(collection uint, cursor string) (service.SyncData, error)
+	Events() (EventStream, error)
+	GetUsage() (service.Usage, error)
+	GetAuditLog(before string) (service.AuditPage, error)
+	GetHistory(item string, itemID uint) ([]service.SecretVersion, error)
+	RestoreVersion(version service.SecretVersion) error
+	GetKeyPair(login string) (service.KeyPair, error)
+	PutKeyPair(keys service.KeyPair) error
+	GetShares() ([]service.Share, error)
+	PutShare(share service.Share) (service.Share, error)
+	AcceptShare(id uint) error
+	ChangeShare(share service.Share) error
+	DeleteShare(id uint) error
+	GetOrgs() ([]service.Organization, error)
+	CreateOrg(org service.Organization) (service.Organization, error)
+	DeleteOrg(id uint) error
+	PutMember(member service.OrgMember) error
+	DeleteMember(member service.OrgMember) error
+	PutCollection(collection service.Collection) (service.Collection, error)
+	DeleteCollection(id uint) error
+	PutCollectionMember(member service.CollectionMember) error
+	DeleteCollectionMember(member service.CollectionMember) error
+	CreateSend(send service.Send) (service.Send, error)
+	GetSend(id string) (service.Send, error)
+	ChangePassword(change service.PasswordChange) error
+	ExportVault() (io.ReadCloser, error)
+	DeleteAccount(password string) error
+	UploadLogoPass(logoPass service.LogoPass) (int64, error)
+	UploadText(text service.TextData) (int64, error)
+	UploadCreditCard(card service.CreditCard) (int64, error)
+	UploadBinary(binary service.BinaryData) (int64, error)
+	DeleteLogoPass(logoPass service.LogoPass) (int64, error)
+	DeleteText(text service.TextData) (int64, error)
+	DeleteCreditCard(card service.CreditCard) (int64, error)
+	DeleteBinary(binary service.BinaryData) (int64, error)
+	StartBinaryUpload(session service.UploadSession) (service.UploadSession, error)
+	GetBinaryUpload(session service.UploadSession) (service.UploadSession, error)
+	UploadBinaryChunk(session service.UploadSession, part int, chunk []byte) error
+	CompleteBinaryUpload(session service.UploadSession) (int64, error)
+	DownloadBinary(binary service.BinaryData, offset int64) (service.BinaryData, io.ReadCloser, error)
+	Logout() error
+	GetSessions() ([]service.Session, error)
+	RevokeSession(session service.Session) error
+	LoginTwoFactor(code string) error
+	EnrollTwoFactor() (service.TOTPEnrollment, error)
+	VerifyTwoFactor(code string) ([]string, error)
+	DisableTwoFactor(code string) error
 }
 
-// ServerApi holds the url of remote and user cookie for requests
-type ServerApi struct {
-	BaseURL string
-	cookie  *http.Cookie
+// EventStream is the stream of the changes of the user's secrets made on the remote, Close stops it
+type EventStream interface {
+	// Next blocks until the next change, io.EOF is returned once the remote ends the stream
+	Next() (service.ChangeEvent, error)
+	io.Closer
 }
 
-// NewApi creates a new instance of ServerApi according to the settings
-func NewApi(url string) *ServerApi {
-	return &ServerApi{BaseURL: url, cookie: &http.Cookie{Name: "session.id", Value: ""}}
+// ServerApi holds the url of remote and user tokens for requests. The requests are built by the client
+// generated from the OpenAPI specification of the remote: public sends them as they are,
+// authorized sends them through do with the access token of the user.
+type ServerApi struct {
+	BaseURL    string
+	client     *http.Client
+	tokens     tokenHolder
+	public     *openapi.ClientWithResponses
+	authorized *openapi.ClientWithResponses
 }
 
-// downloadData sends a get request and returns []bytes of response
-func (api *ServerApi) downloadData(url string) ([]byte, error) {
+// NewApi creates a new instance of ServerApi according to the settings, tlsConfig is used for https:// urls
+// if it's not nil
+func NewApi(url string, tlsConfig *tls.Config) *ServerApi {
 	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodGet, api.BaseURL+url, nil)
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+	}
+	api := &ServerApi{BaseURL: url, client: client}
+	api.public = newGeneratedClient(url, client)
+	api.authorized = newGeneratedClient(url, doerFunc(api.do))
+	return api
+}
+
+// doerFunc lets a function send the requests of the generated client
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+// Do implements openapi.HttpRequestDoer
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newGeneratedClient creates the generated client sending the requests to the url with the doer
+func newGeneratedClient(url string, doer openapi.HttpRequestDoer) *openapi.ClientWithResponses {
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	return &openapi.ClientWithResponses{ClientInterface: &openapi.Client{Server: url, Client: doer}}
+}
+
+// unexpectedStatus is returned when the remote answers with a status code the request does not expect
+func unexpectedStatus(statusCode int) error {
+	return fmt.Errorf("server returned status code %d", statusCode)
+}
+
+// do sends the request with the access token of the user. If the access token is about to expire
+// or is refused by the remote, the tokens are refreshed transparently and the request is sent again.
+func (api *ServerApi) do(req *http.Request) (*http.Response, error) {
+	accessToken, expiring := api.tokens.accessToken()
+	if accessToken == "" {
+		return nil, ErrSessionExpired
+	}
+	if expiring {
+		var err error
+		accessToken, err = api.tokens.refresh(accessToken, api.refreshTokens)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := api.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !api.tokens.canRefresh() {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	accessToken, err = api.tokens.refresh(accessToken, api.refreshTokens)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
 	}
-	req.AddCookie(api.cookie)
-	resp, err := client.Do(req)
+	retry.Header.Set("Authorization", "Bearer "+accessToken)
+	return api.client.Do(retry)
+}
+
+// refreshTokens sends post request exchanging the refresh token for a new pair of tokens
+func (api *ServerApi) refreshTokens(refreshToken string) (service.Tokens, error) {
+	resp, err := api.public.RefreshTokenWithResponse(context.Background(),
+		openapi.RefreshTokenJSONRequestBody{RefreshToken: refreshToken})
 	if err != nil {
-		return nil, fmt.Errorf("request: %w", err)
+		return service.Tokens{}, err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusUnauthorized {
+			return service.Tokens{}, ErrSessionExpired
+		}
+		return service.Tokens{}, unexpectedStatus(resp.StatusCode())
 	}
-	defer resp.Body.Close()
+	if resp.JSON200 == nil {
+		return service.Tokens{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
 
-	jsonBytes, err := io.ReadAll(resp.Body)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return nil, fmt.Errorf("read esponse body: %w", err)
+// authorize keeps the tokens sent back by the remote in response to the credentials.
+// If the remote asks for two-factor authentication, the challenge is kept and ErrTwoFactorRequired is returned.
+// LockoutError is returned if the remote refuses to check the credentials for too many failed attempts.
+func (api *ServerApi) authorize(resp *http.Response, tokens *service.Tokens, challenge *service.TwoFactorChallenge) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if tokens == nil {
+			return ErrUnexpectedResponse
+		}
+		api.tokens.set(*tokens)
+		return nil
+	case http.StatusAccepted:
+		if challenge == nil {
+			return ErrUnexpectedResponse
+		}
+		api.tokens.setChallenge(challenge.ChallengeToken)
+		return ErrTwoFactorRequired
+	case http.StatusTooManyRequests:
+		return newLockoutError(resp.Header.Get("Retry-After"))
 	}
-	return jsonBytes, nil
+	return unexpectedStatus(resp.StatusCode)
 }
 
-// uploadData sends a post request marshalling input data and checks for proper response
-func (api *ServerApi) uploadData(data interface{}, url string) error {
-	jsonBody, err := json.Marshal(data)
+// Register sends post request with service.User with 'login' and 'password' fields
+// returns any errors occurred in the process
+func (api *ServerApi) Register(user service.User) error {
+	resp, err := api.public.RegisterWithResponse(context.Background(), openapi.RegisterJSONRequestBody(user))
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode() == http.StatusConflict {
+		return ErrUserExists
+	}
+	return api.authorize(resp.HTTPResponse, resp.JSON200, nil)
+}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodPost, api.BaseURL+url, bytes.NewBuffer(jsonBody))
+// Login sends post request with service.User with 'login' and 'password' fields
+// returns any errors occurred in the process
+func (api *ServerApi) Login(user service.User) error {
+	resp, err := api.public.LoginWithResponse(context.Background(),
+		openapi.LoginJSONRequestBody{Login: user.Login, Password: user.Password, Device: user.Device})
 	if err != nil {
 		return err
 	}
-	req.AddCookie(api.cookie)
-	req.Header.Set("Content-Type", "application/json")
+	switch resp.StatusCode() {
+	case http.StatusUnauthorized:
+		return ErrInvalidCredentials
+	case http.StatusForbidden:
+		return ErrAccountDisabled
+	}
+	return api.authorize(resp.HTTPResponse, resp.JSON200, resp.JSON202)
+}
 
-	resp, err := client.Do(req)
+// LoginTwoFactor sends post request finishing the login with TOTP code or a recovery code,
+// it is called once Login returns ErrTwoFactorRequired
+func (api *ServerApi) LoginTwoFactor(code string) error {
+	resp, err := api.public.LoginTwoFactorWithResponse(context.Background(),
+		openapi.LoginTwoFactorJSONRequestBody{ChallengeToken: api.tokens.challengeToken(), Code: code})
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	switch resp.StatusCode() {
+	case http.StatusUnauthorized:
+		return ErrInvalidCode
+	case http.StatusForbidden:
+		return ErrAccountDisabled
+	}
+	return api.authorize(resp.HTTPResponse, resp.JSON200, nil)
+}
 
-	if resp.StatusCode != http.StatusCreated {
-		if resp.StatusCode == http.StatusConflict {
-			return ErrAlreadyExists
-		}
-		return fmt.Errorf("server returned status code %d", resp.StatusCode)
+// GetLogoPasses sends a http.Get request and returns the list of service.LogoPass acquired from the remote
+func (api *ServerApi) GetLogoPasses() ([]service.LogoPass, error) {
+	resp, err := api.authorized.BatchDownloadLogoPassesWithResponse(context.Background(),
+		&openapi.BatchDownloadLogoPassesParams{})
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
 	}
-	return nil
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
 }
 
-// Register sends post request with service.User with 'login' and 'password' fields
-// returns any errors occurred in the process
-func (api *ServerApi) Register(user service.User) error {
-	req, err := json.Marshal(user)
+// GetTexts sends a http.Get request and returns the list of service.TextData acquired from the remote
+func (api *ServerApi) GetTexts() ([]service.TextData, error) {
+	resp, err := api.authorized.BatchDownloadTextsWithResponse(context.Background(),
+		&openapi.BatchDownloadTextsParams{})
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// GetCreditCards sends a http.Get request and returns the list of service.CreditCard acquired from the remote
+func (api *ServerApi) GetCreditCards() ([]service.CreditCard, error) {
+	resp, err := api.authorized.BatchDownloadCreditCardsWithResponse(context.Background(),
+		&openapi.BatchDownloadCreditCardsParams{})
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// GetBinaryList sends a http.Get request and returns the list of service.BinaryData acquired from the remote
+func (api *ServerApi) GetBinaryList() ([]service.BinaryData, error) {
+	resp, err := api.authorized.DownloadBinaryListWithResponse(context.Background(),
+		&openapi.DownloadBinaryListParams{})
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// Sync sends a http.Get request and returns service.SyncData holding the secrets changed on the remote
+// since the cursor, every secret is returned if the cursor is empty
+func (api *ServerApi) Sync(cursor string) (service.SyncData, error) {
+	return api.SyncCollection(0, cursor)
+}
+
+// SyncCollection does what Sync does for the collection of an organization, 0 standing for the personal vault.
+// ErrForbidden is returned if the user can't see the collection.
+func (api *ServerApi) SyncCollection(collection uint, cursor string) (service.SyncData, error) {
+	var params openapi.SyncParams
+	if cursor != "" {
+		params.Since = &cursor
+	}
+	if collection != 0 {
+		id := openapi.CollectionID(collection)
+		params.CollectionId = &id
+	}
+	resp, err := api.authorized.SyncWithResponse(context.Background(), &params)
+	if err != nil {
+		return service.SyncData{}, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusBadRequest:
+		return service.SyncData{}, fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	case resp.StatusCode() == http.StatusForbidden:
+		return service.SyncData{}, ErrForbidden
+	case resp.StatusCode() != http.StatusOK:
+		return service.SyncData{}, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return service.SyncData{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// GetUsage sends a http.Get request and returns service.Usage with how much space the user takes on the remote
+// and the quotas limiting it
+func (api *ServerApi) GetUsage() (service.Usage, error) {
+	resp, err := api.authorized.GetUsageWithResponse(context.Background())
+	if err != nil {
+		return service.Usage{}, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() != http.StatusOK:
+		return service.Usage{}, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return service.Usage{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// GetAuditLog sends a http.Get request and returns a page of the user's audit log, the most recent events first.
+// before is the cursor of the page got along with the previous one, empty for the first page.
+func (api *ServerApi) GetAuditLog(before string) (service.AuditPage, error) {
+	var params openapi.GetAuditLogParams
+	if before != "" {
+		params.Before = &before
+	}
+	resp, err := api.authorized.GetAuditLogWithResponse(context.Background(), &params)
+	if err != nil {
+		return service.AuditPage{}, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusBadRequest:
+		return service.AuditPage{}, fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	case resp.StatusCode() != http.StatusOK:
+		return service.AuditPage{}, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return service.AuditPage{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// GetHistory sends a http.Get request and returns the previous versions of the secret of the kind
+// and with the ID given, the most recent ones first. Every version of the kind is returned if itemID is 0,
+// every version of the user if item is empty as well. ErrEmpty is returned if there are none.
+func (api *ServerApi) GetHistory(item string, itemID uint) ([]service.SecretVersion, error) {
+	var params openapi.GetHistoryParams
+	if item != "" {
+		kind := openapi.GetHistoryParamsItem(item)
+		params.Item = &kind
+	}
+	if itemID != 0 {
+		id := int(itemID)
+		params.ItemId = &id
+	}
+	resp, err := api.authorized.GetHistoryWithResponse(context.Background(), &params)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusBadRequest:
+		return nil, fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	case len(*resp.JSON200) == 0:
+		return nil, ErrEmpty
+	}
+	return *resp.JSON200, nil
+}
+
+// RestoreVersion sends a http.Post request to make the version the current one on the remote,
+// ErrOldData is returned if the secret has been changed since version.SecretRevision
+func (api *ServerApi) RestoreVersion(version service.SecretVersion) error {
+	resp, err := api.authorized.RestoreVersionWithResponse(context.Background(),
+		&openapi.RestoreVersionParams{IfMatch: ifMatch(version.SecretRevision)},
+		openapi.RestoreVersionJSONRequestBody(version))
 	if err != nil {
 		return err
 	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		log.Println("The version has been successfully restored")
+		return nil
+	case http.StatusNotFound:
+		return ErrEmpty
+	case http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		return ErrOldData
+	case http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage:
+		return ErrQuotaExceeded
+	}
+	return unexpectedStatus(resp.StatusCode())
+}
+
+// GetKeyPair sends a http.Get request and returns the key pair of the user if login is empty,
+// the public key of the user named by login otherwise. ErrEmpty is returned if the user has no key pair.
+func (api *ServerApi) GetKeyPair(login string) (service.KeyPair, error) {
+	var params openapi.GetKeyPairParams
+	if login != "" {
+		params.Login = &login
+	}
+	resp, err := api.authorized.GetKeyPairWithResponse(context.Background(), &params)
+	if err != nil {
+		return service.KeyPair{}, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return service.KeyPair{}, ErrEmpty
+	case resp.StatusCode() != http.StatusOK:
+		return service.KeyPair{}, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return service.KeyPair{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
 
-	resp, err := http.Post(api.BaseURL+app.RegisterEndpoint, "application/octet-stream", bytes.NewReader(req))
+// PutKeyPair sends a http.Put request storing the key pair of the user on the remote
+func (api *ServerApi) PutKeyPair(keys service.KeyPair) error {
+	resp, err := api.authorized.PutKeyPairWithResponse(context.Background(), openapi.PutKeyPairJSONRequestBody(keys))
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	}
+	return unexpectedStatus(resp.StatusCode())
+}
 
-	cookies := resp.Cookies()
-	for _, cookie := range cookies {
-		if cookie.Name == "session.id" {
-			api.cookie = cookie
-			break
-		}
+// GetShares sends a http.Get request and returns the shares made by the user and the ones made for the user.
+// ErrEmpty is returned if there are none.
+func (api *ServerApi) GetShares() ([]service.Share, error) {
+	resp, err := api.authorized.ListSharesWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	case len(*resp.JSON200) == 0:
+		return nil, ErrEmpty
 	}
+	return *resp.JSON200, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusConflict {
-			return ErrUserExists
+// PutShare sends a http.Post request sharing the secret with the recipient and returns the share made.
+// ErrEmpty is returned if the secret is not on the remote, ErrNoKeyPair if the recipient has no keys.
+func (api *ServerApi) PutShare(share service.Share) (service.Share, error) {
+	resp, err := api.authorized.PutShareWithResponse(context.Background(), openapi.PutShareJSONRequestBody(share))
+	if err != nil {
+		return share, err
+	}
+	switch resp.StatusCode() {
+	case http.StatusCreated:
+		if resp.JSON201 == nil {
+			return share, ErrUnexpectedResponse
 		}
-		return fmt.Errorf("server returned status code %d", resp.StatusCode)
+		return *resp.JSON201, nil
+	case http.StatusBadRequest:
+		return share, fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	case http.StatusNotFound:
+		return share, ErrEmpty
+	case http.StatusConflict:
+		return share, ErrNoKeyPair
 	}
-	return nil
+	return share, unexpectedStatus(resp.StatusCode())
 }
 
-// Login sends post request with service.User with 'login' and 'password' fields
-// returns any errors occurred in the process
-func (api *ServerApi) Login(user service.User) error {
-	req, err := json.Marshal(user)
+// AcceptShare sends a http.Post request accepting the share made for the user
+func (api *ServerApi) AcceptShare(id uint) error {
+	resp, err := api.authorized.AcceptShareWithResponse(context.Background(),
+		&openapi.AcceptShareParams{ShareId: int(id)})
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrEmpty
+	}
+	return unexpectedStatus(resp.StatusCode())
+}
+
+// ChangeShare sends a http.Post request with the change of the secret shared with the user for writing.
+// ErrReadOnly is returned if the share is read-only.
+func (api *ServerApi) ChangeShare(share service.Share) error {
+	resp, err := api.authorized.ChangeShareWithResponse(context.Background(), openapi.ChangeShareJSONRequestBody(share))
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	case http.StatusForbidden:
+		return ErrReadOnly
+	case http.StatusNotFound:
+		return ErrEmpty
+	}
+	return unexpectedStatus(resp.StatusCode())
+}
+
+// DeleteShare sends a delete request revoking the share made by the user or declining the one made for the user
+func (api *ServerApi) DeleteShare(id uint) error {
+	resp, err := api.authorized.DeleteShareWithResponse(context.Background(),
+		&openapi.DeleteShareParams{ShareId: int(id)})
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrEmpty
+	}
+	return unexpectedStatus(resp.StatusCode())
+}
+
+// memberConflict tells `409` of the requests managing the members of an organization apart by the message:
+// either the member is the last owner or the member has no keys to get the key of the organization with
+func memberConflict(message string) error {
+	if strings.Contains(message, storage.ErrLastOwner.Error()) {
+		return ErrLastOwner
+	}
+	return ErrNoKeyPair
+}
+
+// orgResult converts the status code of the requests managing the organizations to the errors of the package
+func orgResult(statusCode int, body []byte) error {
+	switch statusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(body)))
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrEmpty
+	case http.StatusConflict:
+		return ErrAlreadyExists
+	}
+	return unexpectedStatus(statusCode)
+}
+
+// GetOrgs sends a http.Get request and returns the organizations of the user with the key of every organization
+// sealed with the public key of the user. ErrEmpty is returned if there are none.
+func (api *ServerApi) GetOrgs() ([]service.Organization, error) {
+	resp, err := api.authorized.ListOrgsWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	case len(*resp.JSON200) == 0:
+		return nil, ErrEmpty
+	}
+	return *resp.JSON200, nil
+}
+
+// CreateOrg sends a http.Post request creating the organization owned by the user and returns it with its ID.
+// ErrAlreadyExists is returned if the name is taken.
+func (api *ServerApi) CreateOrg(org service.Organization) (service.Organization, error) {
+	resp, err := api.authorized.CreateOrgWithResponse(context.Background(), openapi.CreateOrgJSONRequestBody(org))
+	if err != nil {
+		return org, err
+	}
+	err = orgResult(resp.StatusCode(), resp.Body)
+	if err != nil {
+		return org, err
+	}
+	if resp.JSON201 == nil {
+		return org, ErrUnexpectedResponse
+	}
+	return *resp.JSON201, nil
+}
+
+// DeleteOrg sends a delete request removing the organization along with all of its collections
+func (api *ServerApi) DeleteOrg(id uint) error {
+	resp, err := api.authorized.DeleteOrgWithResponse(context.Background(), &openapi.DeleteOrgParams{OrgId: int(id)})
+	if err != nil {
+		return err
+	}
+	return orgResult(resp.StatusCode(), resp.Body)
+}
+
+// PutMember sends a http.Post request adding the member to the organization or changing the role of the member.
+// ErrNoKeyPair is returned if the member has no keys, ErrLastOwner if the last owner is demoted.
+func (api *ServerApi) PutMember(member service.OrgMember) error {
+	resp, err := api.authorized.PutMemberWithResponse(context.Background(), openapi.PutMemberJSONRequestBody(member))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() == http.StatusConflict {
+		return memberConflict(string(resp.Body))
+	}
+	return orgResult(resp.StatusCode(), resp.Body)
+}
+
+// DeleteMember sends a delete request removing the member from the organization, the user leaves it
+// if the login of the member is empty. ErrLastOwner is returned if the member is the last owner.
+func (api *ServerApi) DeleteMember(member service.OrgMember) error {
+	params := openapi.DeleteMemberParams{OrgId: int(member.OrgID)}
+	if member.Login != "" {
+		params.Login = &member.Login
+	}
+	resp, err := api.authorized.DeleteMemberWithResponse(context.Background(), &params)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() == http.StatusConflict {
+		return ErrLastOwner
+	}
+	return orgResult(resp.StatusCode(), resp.Body)
+}
+
+// PutCollection sends a http.Post request creating the collection, or renaming it if its ID is set,
+// and returns the collection stored. ErrAlreadyExists is returned if the organization has one with the name.
+func (api *ServerApi) PutCollection(collection service.Collection) (service.Collection, error) {
+	resp, err := api.authorized.PutCollectionWithResponse(context.Background(),
+		openapi.PutCollectionJSONRequestBody(collection))
+	if err != nil {
+		return collection, err
+	}
+	err = orgResult(resp.StatusCode(), resp.Body)
+	if err != nil {
+		return collection, err
+	}
+	stored := resp.JSON201
+	if stored == nil {
+		stored = resp.JSON200
+	}
+	if stored == nil {
+		return collection, ErrUnexpectedResponse
+	}
+	return *stored, nil
+}
+
+// DeleteCollection sends a delete request removing the collection along with all of its secrets
+func (api *ServerApi) DeleteCollection(id uint) error {
+	resp, err := api.authorized.DeleteCollectionWithResponse(context.Background(),
+		&openapi.DeleteCollectionParams{CollectionId: int(id)})
 	if err != nil {
 		return err
 	}
+	return orgResult(resp.StatusCode(), resp.Body)
+}
+
+// PutCollectionMember sends a http.Post request adding the member of the organization to the collection
+func (api *ServerApi) PutCollectionMember(member service.CollectionMember) error {
+	resp, err := api.authorized.PutCollectionMemberWithResponse(context.Background(),
+		openapi.PutCollectionMemberJSONRequestBody(member))
+	if err != nil {
+		return err
+	}
+	return orgResult(resp.StatusCode(), resp.Body)
+}
 
-	resp, err := http.Post(api.BaseURL+app.LoginEndpoint, "application/octet-stream", bytes.NewReader(req))
+// DeleteCollectionMember sends a delete request removing the member from the collection
+func (api *ServerApi) DeleteCollectionMember(member service.CollectionMember) error {
+	resp, err := api.authorized.DeleteCollectionMemberWithResponse(context.Background(),
+		&openapi.DeleteCollectionMemberParams{CollectionId: int(member.CollectionID), Login: member.Login})
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	return orgResult(resp.StatusCode(), resp.Body)
+}
 
-	cookies := resp.Cookies()
-	for _, cookie := range cookies {
-		if cookie.Name == "session.id" {
-			api.cookie = cookie
-			break
+// CreateSend sends a http.Post request storing the send with its content encrypted, returns the send stored
+// with its ID and expiry
+func (api *ServerApi) CreateSend(send service.Send) (service.Send, error) {
+	resp, err := api.authorized.CreateSendWithResponse(context.Background(), openapi.CreateSendJSONRequestBody(send))
+	if err != nil {
+		return send, err
+	}
+	switch resp.StatusCode() {
+	case http.StatusCreated:
+		if resp.JSON201 == nil {
+			return send, ErrUnexpectedResponse
 		}
+		return *resp.JSON201, nil
+	case http.StatusBadRequest:
+		return send, fmt.Errorf("%w: %s", ErrCorruptedData, strings.TrimSpace(string(resp.Body)))
+	case http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage:
+		return send, ErrQuotaExceeded
 	}
+	return send, unexpectedStatus(resp.StatusCode())
+}
 
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusUnauthorized {
-			return ErrInvalidCredentials
+// GetSend sends a http.Get request for the send with the id without authorization, the view is counted
+// by the remote. ErrEmpty is returned if the send is gone.
+func (api *ServerApi) GetSend(id string) (service.Send, error) {
+	resp, err := api.public.GetSendWithResponse(context.Background(), &openapi.GetSendParams{SendId: id})
+	if err != nil {
+		return service.Send{}, err
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return service.Send{}, ErrEmpty
+	case resp.StatusCode() == http.StatusTooManyRequests:
+		return service.Send{}, newLockoutError(resp.HTTPResponse.Header.Get("Retry-After"))
+	case resp.StatusCode() != http.StatusOK:
+		return service.Send{}, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return service.Send{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// GetBinary sends a http.Post request and returns service.BinaryData acquired from the remote
+func (api *ServerApi) GetBinary(binary service.BinaryData) (service.BinaryData, error) {
+	resp, err := api.authorized.DownloadBinaryWithResponse(context.Background(),
+		openapi.DownloadBinaryJSONRequestBody(binary))
+	if err != nil {
+		return binary, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return binary, ErrEmpty
 		}
-		return fmt.Errorf("server returned status code %d", resp.StatusCode)
+		return binary, unexpectedStatus(resp.StatusCode())
 	}
-	return nil
+	if resp.JSON200 == nil {
+		return binary, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
 }
 
-// GetLogoPasses sends a http.Get request and returns the list of service.LogoPass acquired from the remote
-func (api *ServerApi) GetLogoPasses() ([]service.LogoPass, error) {
-	var listLogoPasses []service.LogoPass
+// ifMatch returns If-Match header holding the revision the change is made to, there is none for a new entry
+func ifMatch(revision int64) *openapi.IfMatch {
+	if revision == 0 {
+		return nil
+	}
+	etag := strconv.Quote(strconv.FormatInt(revision, 10))
+	return &etag
+}
 
-	resp, err := api.downloadData(app.GetLogoPassesEndpoint)
+// etagRevision returns the revision the remote has stored the entry at, taken from ETag header of the response
+func etagRevision(header http.Header) (int64, error) {
+	revision, err := strconv.ParseInt(strings.Trim(header.Get("ETag"), `"`), 10, 64)
 	if err != nil {
-		return nil, err
+		return 0, ErrUnexpectedResponse
 	}
+	return revision, nil
+}
 
-	if len(resp) != 0 {
-		err = json.Unmarshal(resp, &listLogoPasses)
-		if err != nil {
-			return nil, fmt.Errorf("json unmarshall: %w", err)
+// uploadResult checks the response of the remote to an upload request
+func uploadResult(statusCode int) error {
+	if statusCode != http.StatusCreated {
+		if statusCode == http.StatusConflict {
+			return ErrAlreadyExists
 		}
+		if statusCode == http.StatusForbidden {
+			return ErrForbidden
+		}
+		if statusCode == http.StatusPreconditionFailed || statusCode == http.StatusPreconditionRequired {
+			return ErrOldData
+		}
+		if statusCode == http.StatusRequestEntityTooLarge || statusCode == http.StatusInsufficientStorage {
+			return ErrQuotaExceeded
+		}
+		return unexpectedStatus(statusCode)
 	}
-	return listLogoPasses, nil
+	return nil
 }
 
-// GetTexts sends a http.Get request and returns the list of service.TextData acquired from the remote
-func (api *ServerApi) GetTexts() ([]service.TextData, error) {
-	var listTexts []service.TextData
+// UploadLogoPass sends post request that contains service.LogoPass along with the revision it is made to,
+// returns the revision the pair is stored at
+func (api *ServerApi) UploadLogoPass(logoPass service.LogoPass) (int64, error) {
+	resp, err := api.authorized.UploadLogoPassWithResponse(context.Background(),
+		&openapi.UploadLogoPassParams{IfMatch: ifMatch(logoPass.Revision)}, openapi.UploadLogoPassJSONRequestBody(logoPass))
+	if err != nil {
+		return 0, err
+	}
+	if err = uploadResult(resp.StatusCode()); err != nil {
+		return 0, err
+	}
+	log.Println("login password pair has been successfully updated")
+	return etagRevision(resp.HTTPResponse.Header)
+}
 
-	resp, err := api.downloadData(app.GetTextsEndpoint)
+// UploadText sends post request that contains service.TextData along with the revision it is made to,
+// returns the revision the text is stored at
+func (api *ServerApi) UploadText(text service.TextData) (int64, error) {
+	resp, err := api.authorized.UploadTextWithResponse(context.Background(),
+		&openapi.UploadTextParams{IfMatch: ifMatch(text.Revision)}, openapi.UploadTextJSONRequestBody(text))
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	if err = uploadResult(resp.StatusCode()); err != nil {
+		return 0, err
+	}
+	log.Println("The secret text has been successfully updated. What's it about, I wonder")
+	return etagRevision(resp.HTTPResponse.Header)
+}
 
-	if len(resp) != 0 {
-		err = json.Unmarshal(resp, &listTexts)
-		if err != nil {
-			return nil, fmt.Errorf("json unmarshall: %w", err)
+// UploadCreditCard sends post request that contains service.CreditCard along with the revision it is made to,
+// returns the revision the card is stored at
+func (api *ServerApi) UploadCreditCard(card service.CreditCard) (int64, error) {
+	resp, err := api.authorized.UploadCreditCardWithResponse(context.Background(),
+		&openapi.UploadCreditCardParams{IfMatch: ifMatch(card.Revision)}, openapi.UploadCreditCardJSONRequestBody(card))
+	if err != nil {
+		return 0, err
+	}
+	if err = uploadResult(resp.StatusCode()); err != nil {
+		return 0, err
+	}
+	log.Println("The credit card info has been successfully updated")
+	return etagRevision(resp.HTTPResponse.Header)
+}
+
+// UploadBinary sends post request that contains service.BinaryData along with the revision it is made to,
+// returns the revision the binary is stored at
+func (api *ServerApi) UploadBinary(binary service.BinaryData) (int64, error) {
+	resp, err := api.authorized.UploadBinaryWithResponse(context.Background(),
+		&openapi.UploadBinaryParams{IfMatch: ifMatch(binary.Revision)}, openapi.UploadBinaryJSONRequestBody(binary))
+	if err != nil {
+		return 0, err
+	}
+	if err = uploadResult(resp.StatusCode()); err != nil {
+		return 0, err
+	}
+	log.Println("Your binary data has been successfully updated")
+	return etagRevision(resp.HTTPResponse.Header)
+}
+
+// deleteResult checks the response of the remote to a delete request
+func deleteResult(statusCode int) error {
+	if statusCode != http.StatusOK {
+		if statusCode == http.StatusNotFound {
+			return ErrEmpty
+		}
+		if statusCode == http.StatusForbidden {
+			return ErrForbidden
+		}
+		if statusCode == http.StatusPreconditionFailed || statusCode == http.StatusPreconditionRequired {
+			return ErrOldData
 		}
+		return unexpectedStatus(statusCode)
 	}
-	return listTexts, nil
+	return nil
 }
 
-// GetCreditCards sends a http.Get request and returns the list of service.CreditCard acquired from the remote
-func (api *ServerApi) GetCreditCards() ([]service.CreditCard, error) {
-	var listCreditCards []service.CreditCard
+// DeleteLogoPass sends delete request that contains service.LogoPass to be removed along with the revision
+// it is made to, returns the revision of the tombstone
+func (api *ServerApi) DeleteLogoPass(logoPass service.LogoPass) (int64, error) {
+	resp, err := api.authorized.DeleteLogoPassWithResponse(context.Background(),
+		&openapi.DeleteLogoPassParams{IfMatch: ifMatch(logoPass.Revision)}, openapi.DeleteLogoPassJSONRequestBody(logoPass))
+	if err != nil {
+		return 0, err
+	}
+	if err = deleteResult(resp.StatusCode()); err != nil {
+		return 0, err
+	}
+	log.Println("login password pair has been successfully deleted")
+	return etagRevision(resp.HTTPResponse.Header)
+}
 
-	resp, err := api.downloadData(app.GetCreditCardsEndpoint)
+// DeleteText sends delete request that contains service.TextData to be removed along with the revision
+// it is made to, returns the revision of the tombstone
+func (api *ServerApi) DeleteText(text service.TextData) (int64, error) {
+	resp, err := api.authorized.DeleteTextWithResponse(context.Background(),
+		&openapi.DeleteTextParams{IfMatch: ifMatch(text.Revision)}, openapi.DeleteTextJSONRequestBody(text))
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if err = deleteResult(resp.StatusCode()); err != nil {
+		return 0, err
 	}
+	log.Println("The secret text has been successfully deleted. Now no one will ever know")
+	return etagRevision(resp.HTTPResponse.Header)
+}
 
-	if len(resp) != 0 {
-		err = json.Unmarshal(resp, &listCreditCards)
-		if err != nil {
-			return nil, fmt.Errorf("json unmarshall: %w", err)
-		}
+// DeleteCreditCard sends delete request that contains service.CreditCard to be removed along with the revision
+// it is made to, returns the revision of the tombstone
+func (api *ServerApi) DeleteCreditCard(card service.CreditCard) (int64, error) {
+	resp, err := api.authorized.DeleteCreditCardWithResponse(context.Background(),
+		&openapi.DeleteCreditCardParams{IfMatch: ifMatch(card.Revision)}, openapi.DeleteCreditCardJSONRequestBody(card))
+	if err != nil {
+		return 0, err
+	}
+	if err = deleteResult(resp.StatusCode()); err != nil {
+		return 0, err
 	}
-	return listCreditCards, nil
+	log.Println("The credit card info has been successfully deleted")
+	return etagRevision(resp.HTTPResponse.Header)
 }
 
-// GetBinaryList sends a http.Get request and returns the list of service.BinaryData acquired from the remote
-func (api *ServerApi) GetBinaryList() ([]service.BinaryData, error) {
-	var binaryList []service.BinaryData
+// DeleteBinary sends delete request that contains service.BinaryData to be removed along with the revision
+// it is made to, returns the revision of the tombstone
+func (api *ServerApi) DeleteBinary(binary service.BinaryData) (int64, error) {
+	resp, err := api.authorized.DeleteBinaryWithResponse(context.Background(),
+		&openapi.DeleteBinaryParams{IfMatch: ifMatch(binary.Revision)}, openapi.DeleteBinaryJSONRequestBody(binary))
+	if err != nil {
+		return 0, err
+	}
+	if err = deleteResult(resp.StatusCode()); err != nil {
+		return 0, err
+	}
+	log.Println("Your binary data has been successfully deleted")
+	return etagRevision(resp.HTTPResponse.Header)
+}
 
-	resp, err := api.downloadData(app.GetBinaryListEndpoint)
+// StartBinaryUpload sends post request that contains service.UploadSession and returns the session created
+// on the remote, its 'upload_id' is used for all the further upload requests
+func (api *ServerApi) StartBinaryUpload(session service.UploadSession) (service.UploadSession, error) {
+	resp, err := api.authorized.StartBinaryUploadWithResponse(context.Background(),
+		&openapi.StartBinaryUploadParams{IfMatch: ifMatch(session.Revision)}, openapi.StartBinaryUploadJSONRequestBody(session))
 	if err != nil {
-		return nil, err
+		return session, err
 	}
+	if err = uploadResult(resp.StatusCode()); err != nil {
+		return session, err
+	}
+	if resp.JSON201 == nil {
+		return session, ErrUnexpectedResponse
+	}
+	return *resp.JSON201, nil
+}
 
-	if len(resp) != 0 {
-		err = json.Unmarshal(resp, &binaryList)
-		if err != nil {
-			return nil, fmt.Errorf("json unmarshall: %w", err)
+// GetBinaryUpload sends a http.Get request and returns service.UploadSession with the list of parts
+// already received by the remote
+func (api *ServerApi) GetBinaryUpload(session service.UploadSession) (service.UploadSession, error) {
+	resp, err := api.authorized.GetBinaryUploadWithResponse(context.Background(),
+		&openapi.GetBinaryUploadParams{UploadId: session.ID})
+	if err != nil {
+		return session, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return session, ErrEmpty
 		}
+		return session, unexpectedStatus(resp.StatusCode())
 	}
-	return binaryList, nil
+	if resp.JSON200 == nil {
+		return session, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
 }
 
-// GetBinary sends a http.Get request and returns service.BinaryData acquired from the remote
-func (api *ServerApi) GetBinary(binary service.BinaryData) (service.BinaryData, error) {
-	jsonBody, err := json.Marshal(binary)
+// UploadBinaryChunk sends put request that contains a single encrypted part of a binary along with its checksum
+func (api *ServerApi) UploadBinaryChunk(session service.UploadSession, part int, chunk []byte) error {
+	params := &openapi.UploadBinaryChunkParams{UploadId: session.ID, Part: part,
+		XChunkChecksum: tools.ChecksumBytes(chunk)}
+	resp, err := api.authorized.UploadBinaryChunkWithBodyWithResponse(context.Background(), params,
+		"application/octet-stream", bytes.NewReader(chunk))
 	if err != nil {
-		return binary, err
+		return err
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodPost, api.BaseURL+app.GetBinaryEndpoint, bytes.NewBuffer(jsonBody))
+	if resp.StatusCode() != http.StatusCreated {
+		if resp.StatusCode() == http.StatusNotFound {
+			return ErrEmpty
+		}
+		return uploadResult(resp.StatusCode())
+	}
+	return nil
+}
+
+// CompleteBinaryUpload sends post request that turns all the uploaded parts into a binary on the remote,
+// returns the revision the binary is stored at
+func (api *ServerApi) CompleteBinaryUpload(session service.UploadSession) (int64, error) {
+	resp, err := api.authorized.CompleteBinaryUploadWithResponse(context.Background(),
+		&openapi.CompleteBinaryUploadParams{UploadId: session.ID})
 	if err != nil {
-		return binary, err
+		return 0, err
+	}
+
+	if resp.StatusCode() != http.StatusCreated {
+		if resp.StatusCode() == http.StatusNotFound {
+			return 0, ErrEmpty
+		}
+		return 0, uploadResult(resp.StatusCode())
 	}
+	log.Println("Your binary data has been successfully updated")
+	return etagRevision(resp.HTTPResponse.Header)
+}
 
-	req.AddCookie(api.cookie)
-	req.Header.Set("Content-Type", "application/json")
+// DownloadBinary sends a http.Get request for the encrypted content of a chunked binary starting at offset.
+// Returns service.BinaryData with 'size' and 'chunk_size' fields set and the body to read the content from,
+// which must be closed by the caller.
+func (api *ServerApi) DownloadBinary(binary service.BinaryData, offset int64) (service.BinaryData, io.ReadCloser, error) {
+	params := &openapi.StreamBinaryParams{Description: binary.Description}
+	if offset > 0 {
+		byteRange := fmt.Sprintf("bytes=%d-", offset)
+		params.Range = &byteRange
+	}
+	if binary.CollectionID != 0 {
+		collection := openapi.CollectionID(binary.CollectionID)
+		params.CollectionId = &collection
+	}
+	// compressing encrypted data is of no use and would break the ranges
+	identity := func(_ context.Context, req *http.Request) error {
+		req.Header.Set("Accept-Encoding", "identity")
+		return nil
+	}
 
-	resp, err := client.Do(req)
+	// the content is streamed, so the response is not read by the generated client
+	resp, err := api.authorized.StreamBinary(context.Background(), params, identity)
 	if err != nil {
-		return binary, err
+		return binary, nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
-		return binary, ErrEmpty
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return binary, nil, ErrEmpty
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return binary, nil, ErrForbidden
+		}
+		return binary, nil, unexpectedStatus(resp.StatusCode)
+	}
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return binary, nil, fmt.Errorf("server ignored the range")
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&binary)
+	binary.Size, err = strconv.ParseInt(resp.Header.Get(app.BinarySizeHeader), 10, 64)
 	if err != nil {
-		return binary, err
+		resp.Body.Close()
+		return binary, nil, fmt.Errorf("parse binary size: %w", err)
 	}
-	return binary, nil
+	binary.ChunkSize, err = strconv.ParseInt(resp.Header.Get(app.ChunkSizeHeader), 10, 64)
+	if err != nil {
+		resp.Body.Close()
+		return binary, nil, fmt.Errorf("parse chunk size: %w", err)
+	}
+	if binary.ChunkSize <= 0 {
+		resp.Body.Close()
+		return binary, nil, ErrCorruptedData
+	}
+	return binary, resp.Body, nil
 }
 
-// UploadLogoPass sends post request that contains service.LogoPass
-func (api *ServerApi) UploadLogoPass(logoPass service.LogoPass) error {
-	err := api.uploadData(logoPass, app.PutLogoPassEndpoint)
+// Logout sends post request revoking the session of the user on the remote and drops the tokens
+func (api *ServerApi) Logout() error {
+	resp, err := api.authorized.LogoutWithResponse(context.Background())
 	if err != nil {
 		return err
 	}
-	log.Println("login password pair has been successfully updated")
+
+	if resp.StatusCode() != http.StatusOK {
+		return unexpectedStatus(resp.StatusCode())
+	}
+	api.tokens.set(service.Tokens{})
 	return nil
 }
 
-// UploadText sends post request that contains service.TextData
-func (api *ServerApi) UploadText(text service.TextData) error {
-	err := api.uploadData(text, app.PutTextEndpoint)
+// GetSessions sends a http.Get request and returns the list of service.Session of the devices signed in by the user
+func (api *ServerApi) GetSessions() ([]service.Session, error) {
+	resp, err := api.authorized.ListSessionsWithResponse(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	switch {
+	case resp.StatusCode() == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode() != http.StatusOK:
+		return nil, unexpectedStatus(resp.StatusCode())
+	case resp.JSON200 == nil:
+		return nil, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// RevokeSession sends a delete request signing the device of service.Session out
+func (api *ServerApi) RevokeSession(session service.Session) error {
+	resp, err := api.authorized.RevokeSessionWithResponse(context.Background(),
+		&openapi.RevokeSessionParams{SessionId: session.ID})
 	if err != nil {
 		return err
 	}
-	log.Println("The secret text has been successfully updated. What's it about, I wonder")
+
+	if resp.StatusCode() != http.StatusOK {
+		if resp.StatusCode() == http.StatusNotFound {
+			return ErrEmpty
+		}
+		return unexpectedStatus(resp.StatusCode())
+	}
+	log.Println("The device has been successfully signed out")
 	return nil
 }
 
-// UploadCreditCard sends post request that contains service.CreditCard
-func (api *ServerApi) UploadCreditCard(card service.CreditCard) error {
-	err := api.uploadData(card, app.PutCreditCardEndpoint)
+// twoFactorResult checks the response of the remote to a request managing two-factor authentication
+func twoFactorResult(statusCode int) error {
+	switch statusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return ErrInvalidCode
+	case http.StatusNotFound:
+		return ErrEmpty
+	case http.StatusConflict:
+		return ErrAlreadyExists
+	}
+	return unexpectedStatus(statusCode)
+}
+
+// EnrollTwoFactor sends post request for a new TOTP secret to set up the authenticator with.
+// Returns ErrAlreadyExists if two-factor authentication is already enabled.
+func (api *ServerApi) EnrollTwoFactor() (service.TOTPEnrollment, error) {
+	resp, err := api.authorized.EnrollTwoFactorWithResponse(context.Background())
+	if err != nil {
+		return service.TOTPEnrollment{}, err
+	}
+	if err = twoFactorResult(resp.StatusCode()); err != nil {
+		return service.TOTPEnrollment{}, err
+	}
+	if resp.JSON200 == nil {
+		return service.TOTPEnrollment{}, ErrUnexpectedResponse
+	}
+	return *resp.JSON200, nil
+}
+
+// VerifyTwoFactor sends post request enabling two-factor authentication with TOTP code
+// and returns the recovery codes
+func (api *ServerApi) VerifyTwoFactor(code string) ([]string, error) {
+	resp, err := api.authorized.VerifyTwoFactorWithResponse(context.Background(),
+		openapi.VerifyTwoFactorJSONRequestBody{Code: code})
+	if err != nil {
+		return nil, err
+	}
+	if err = twoFactorResult(resp.StatusCode()); err != nil {
+		return nil, err
+	}
+	if resp.JSON200 == nil {
+		return nil, ErrUnexpectedResponse
+	}
+	return resp.JSON200.RecoveryCodes, nil
+}
+
+// ChangePassword sends post request replacing the password along with every secret re-encrypted with the new key.
+// ErrInvalidCredentials is returned if the old password is wrong, ErrOldData if the secrets have changed
+// since the cursor of the change.
+func (api *ServerApi) ChangePassword(change service.PasswordChange) error {
+	resp, err := api.authorized.ChangePasswordWithResponse(context.Background(),
+		openapi.ChangePasswordJSONRequestBody(change))
 	if err != nil {
 		return err
 	}
-	log.Println("The credit card info has been successfully updated")
-	return nil
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden:
+		return ErrInvalidCredentials
+	case http.StatusConflict:
+		return ErrOldData
+	case http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage:
+		return ErrQuotaExceeded
+	case http.StatusTooManyRequests:
+		return newLockoutError(resp.HTTPResponse.Header.Get("Retry-After"))
+	}
+	return unexpectedStatus(resp.StatusCode())
 }
 
-// UploadBinary sends post request that contains service.BinaryData
-func (api *ServerApi) UploadBinary(binary service.BinaryData) error {
-	err := api.uploadData(binary, app.PutBinaryEndpoint)
+// ExportVault sends get request for every secret of the user as a zip archive, the archive is streamed
+// from the body returned, it is to be closed once read
+func (api *ServerApi) ExportVault() (io.ReadCloser, error) {
+	// compressing encrypted data is of no use
+	identity := func(_ context.Context, req *http.Request) error {
+		req.Header.Set("Accept-Encoding", "identity")
+		return nil
+	}
+
+	// the archive is streamed, so the response is not read by the generated client
+	resp, err := api.authorized.ExportVault(context.Background(), identity)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, unexpectedStatus(resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// DeleteAccount sends post request deleting the account along with all the data and drops the tokens.
+// ErrInvalidCredentials is returned if the password is wrong.
+func (api *ServerApi) DeleteAccount(password string) error {
+	resp, err := api.authorized.DeleteAccountWithResponse(context.Background(),
+		openapi.DeleteAccountJSONRequestBody{Password: password})
 	if err != nil {
 		return err
 	}
-	log.Println("Your binary data has been successfully updated")
-	return nil
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		api.tokens.set(service.Tokens{})
+		return nil
+	case http.StatusForbidden:
+		return ErrInvalidCredentials
+	case http.StatusConflict:
+		return ErrLastOwner
+	case http.StatusTooManyRequests:
+		return newLockoutError(resp.HTTPResponse.Header.Get("Retry-After"))
+	}
+	return unexpectedStatus(resp.StatusCode())
+}
+
+// DisableTwoFactor sends delete request turning two-factor authentication off with TOTP code or a recovery code
+func (api *ServerApi) DisableTwoFactor(code string) error {
+	resp, err := api.authorized.DisableTwoFactorWithResponse(context.Background(),
+		openapi.DisableTwoFactorJSONRequestBody{Code: code})
+	if err != nil {
+		return err
+	}
+	return twoFactorResult(resp.StatusCode())
+}
+
+// Events sends a http.Get request for the Server-Sent Events announcing the changes of the user's secrets.
+// The stream lasts until it is closed or the remote ends it.
+func (api *ServerApi) Events() (EventStream, error) {
+	// the events must come as soon as they are sent, not once a compressed block is full
+	identity := func(_ context.Context, req *http.Request) error {
+		req.Header.Set("Accept-Encoding", "identity")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := api.authorized.StreamEvents(ctx, identity)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, unexpectedStatus(resp.StatusCode)
+	}
+	return &eventReader{body: resp.Body, scanner: bufio.NewScanner(resp.Body), cancel: cancel}, nil
+}
+
+// eventReader reads the changes from the stream of Server-Sent Events
+type eventReader struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	cancel  context.CancelFunc
+}
+
+// Next reads the stream until the next change, the comments and the events of other names are skipped
+func (reader *eventReader) Next() (service.ChangeEvent, error) {
+	var name, data string
+	for reader.scanner.Scan() {
+		line := reader.scanner.Text()
+		switch {
+		case line == "":
+			if name == app.EventChange && data != "" {
+				var event service.ChangeEvent
+				err := json.Unmarshal([]byte(data), &event)
+				if err != nil {
+					return event, fmt.Errorf("%w: %s", ErrUnexpectedResponse, err)
+				}
+				return event, nil
+			}
+			name, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := reader.scanner.Err(); err != nil {
+		return service.ChangeEvent{}, err
+	}
+	return service.ChangeEvent{}, io.EOF
+}
+
+// Close ends the stream
+func (reader *eventReader) Close() error {
+	reader.cancel()
+	return reader.body.Close()
 }
@@ -0,0 +1,902 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	pb "gophkeeper/internal/proto"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"io"
+	"log"
+	"strconv"
+)
+
+// grpcMaxMessageSize is the largest message GRPCApi sends or accepts, enough for a chunk of any size the server takes
+const grpcMaxMessageSize = 17 << 20
+
+// grpcPublicMethods are the methods called without the access token
+var grpcPublicMethods = map[string]bool{
+	pb.Keeper_Register_FullMethodName:       true,
+	pb.Keeper_Login_FullMethodName:          true,
+	pb.Keeper_RefreshToken_FullMethodName:   true,
+	pb.Keeper_LoginTwoFactor_FullMethodName: true,
+	pb.Keeper_GetSend_FullMethodName:        true,
+}
+
+// GRPCApi holds the connection to the gRPC server of remote and user tokens for calls.
+// It is an alternative to ServerApi implementing the same Api.
+type GRPCApi struct {
+	conn   *grpc.ClientConn
+	keeper pb.KeeperClient
+	tokens tokenHolder
+}
+
+// NewGRPCApi creates a new instance of GRPCApi connected to the address, over TLS unless tlsConfig is nil.
+// The connection is established lazily, so the remote does not have to be up yet.
+func NewGRPCApi(address string, tlsConfig *tls.Config) (*GRPCApi, error) {
+	api := &GRPCApi{}
+	transportCredentials := insecure.NewCredentials()
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.Dial(address,
+		grpc.WithTransportCredentials(transportCredentials),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(grpcMaxMessageSize), grpc.MaxCallSendMsgSize(grpcMaxMessageSize)),
+		grpc.WithUnaryInterceptor(api.unaryInterceptor),
+		grpc.WithStreamInterceptor(api.streamInterceptor),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("grpc dial: %w", err)
+	}
+	api.conn = conn
+	api.keeper = pb.NewKeeperClient(conn)
+	return api, nil
+}
+
+// Close closes the connection to the remote
+func (api *GRPCApi) Close() error {
+	return api.conn.Close()
+}
+
+// callContext returns the context of a call, the access token is added to it by the interceptors
+func (api *GRPCApi) callContext() context.Context {
+	return context.Background()
+}
+
+// freshAccessToken returns the access token of the user refreshing it if it is about to expire
+func (api *GRPCApi) freshAccessToken() (string, error) {
+	accessToken, expiring := api.tokens.accessToken()
+	if !expiring {
+		return accessToken, nil
+	}
+	return api.tokens.refresh(accessToken, api.refreshTokens)
+}
+
+// withAccessToken returns the context carrying the access token in pb.AuthorizationMetadata
+func withAccessToken(ctx context.Context, accessToken string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, pb.AuthorizationMetadata, "Bearer "+accessToken)
+}
+
+// unaryInterceptor adds the access token to the calls. If the access token is refused by the remote,
+// the tokens are refreshed transparently and the call is made again.
+func (api *GRPCApi) unaryInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if grpcPublicMethods[method] {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	accessToken, err := api.freshAccessToken()
+	if err != nil {
+		return err
+	}
+	err = invoker(withAccessToken(ctx, accessToken), method, req, reply, cc, opts...)
+	if status.Code(err) != codes.Unauthenticated || !api.tokens.canRefresh() {
+		return err
+	}
+
+	accessToken, err = api.tokens.refresh(accessToken, api.refreshTokens)
+	if err != nil {
+		return err
+	}
+	return invoker(withAccessToken(ctx, accessToken), method, req, reply, cc, opts...)
+}
+
+// streamInterceptor adds the access token to the streams refreshing it beforehand if it is about to expire
+func (api *GRPCApi) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	accessToken, err := api.freshAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	return streamer(withAccessToken(ctx, accessToken), desc, cc, method, opts...)
+}
+
+// refreshTokens exchanges the refresh token for a new pair of tokens
+func (api *GRPCApi) refreshTokens(refreshToken string) (service.Tokens, error) {
+	resp, err := api.keeper.RefreshToken(context.Background(), &pb.RefreshRequest{RefreshToken: refreshToken})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			return service.Tokens{}, ErrSessionExpired
+		}
+		return service.Tokens{}, err
+	}
+	return pb.ToTokens(resp), nil
+}
+
+// statusError converts gRPC status of a failed call to the errors of the package.
+// conflict is the error returned for codes.AlreadyExists and codes.FailedPrecondition, as they mean
+// different things for different calls, just like `409` does for ServerApi.
+func statusError(err error, conflict error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return ErrEmpty
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		return conflict
+	case codes.Unauthenticated:
+		return ErrInvalidCredentials
+	case codes.InvalidArgument, codes.OutOfRange:
+		return fmt.Errorf("%w: %s", ErrCorruptedData, status.Convert(err).Message())
+	case codes.ResourceExhausted:
+		return ErrQuotaExceeded
+	case codes.PermissionDenied:
+		return ErrForbidden
+	}
+	return err
+}
+
+// revisionStatusError converts the status of the calls storing or deleting an entry: the ones refused
+// for the revision the change is made to are ErrOldData, other statuses are converted by statusError
+func revisionStatusError(err error, conflict error) error {
+	switch status.Code(err) {
+	case codes.Aborted, codes.FailedPrecondition:
+		return ErrOldData
+	}
+	return statusError(err, conflict)
+}
+
+// headerRevision returns the revision the remote has stored the entry at, taken from the etag header
+func headerRevision(header metadata.MD) (int64, error) {
+	values := header.Get(pb.ETagMetadata)
+	if len(values) == 0 {
+		return 0, ErrUnexpectedResponse
+	}
+	revision, err := strconv.ParseInt(values[0], 10, 64)
+	if err != nil {
+		return 0, ErrUnexpectedResponse
+	}
+	return revision, nil
+}
+
+// lockoutError converts ResourceExhausted status of the calls checking the credentials to LockoutError
+// with the time to retry after taken from the header, other statuses are converted by statusError
+func lockoutError(err error, header metadata.MD, conflict error) error {
+	if status.Code(err) != codes.ResourceExhausted {
+		return statusError(err, conflict)
+	}
+	var retryAfter string
+	if values := header.Get(pb.RetryAfterMetadata); len(values) != 0 {
+		retryAfter = values[0]
+	}
+	return newLockoutError(retryAfter)
+}
+
+// Register calls Register with service.User 'login' and 'password' fields
+// returns any errors occurred in the process
+func (api *GRPCApi) Register(user service.User) error {
+	var header metadata.MD
+	resp, err := api.keeper.Register(context.Background(), &pb.AuthRequest{Login: user.Login,
+		Password: user.Password, Device: user.Device}, grpc.Header(&header))
+	if err != nil {
+		return lockoutError(err, header, ErrUserExists)
+	}
+	api.tokens.set(pb.ToTokens(resp))
+	return nil
+}
+
+// Login calls Login with service.User 'login' and 'password' fields
+// returns any errors occurred in the process, ErrTwoFactorRequired if the remote asks for the code
+func (api *GRPCApi) Login(user service.User) error {
+	var header metadata.MD
+	resp, err := api.keeper.Login(context.Background(), &pb.AuthRequest{Login: user.Login,
+		Password: user.Password, Device: user.Device}, grpc.Header(&header))
+	if err != nil {
+		if status.Code(err) == codes.PermissionDenied {
+			return ErrAccountDisabled
+		}
+		return lockoutError(err, header, ErrAlreadyExists)
+	}
+	if resp.GetChallenge() != nil {
+		api.tokens.setChallenge(resp.GetChallenge().GetChallengeToken())
+		return ErrTwoFactorRequired
+	}
+	api.tokens.set(pb.ToTokens(resp.GetTokens()))
+	return nil
+}
+
+// LoginTwoFactor finishes the login with TOTP code or a recovery code, it is called once Login returns ErrTwoFactorRequired
+func (api *GRPCApi) LoginTwoFactor(code string) error {
+	var header metadata.MD
+	resp, err := api.keeper.LoginTwoFactor(context.Background(),
+		&pb.TwoFactorLogin{ChallengeToken: api.tokens.challengeToken(), Code: code}, grpc.Header(&header))
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			return ErrInvalidCode
+		case codes.PermissionDenied:
+			return ErrAccountDisabled
+		}
+		return lockoutError(err, header, ErrAlreadyExists)
+	}
+	api.tokens.set(pb.ToTokens(resp))
+	return nil
+}
+
+// GetLogoPasses returns the list of service.LogoPass acquired from the remote
+func (api *GRPCApi) GetLogoPasses() ([]service.LogoPass, error) {
+	resp, err := api.keeper.GetLogoPasses(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+
+	var listLogoPasses []service.LogoPass
+	for _, logoPass := range resp.GetLogoPasses() {
+		listLogoPasses = append(listLogoPasses, pb.ToLogoPass(logoPass))
+	}
+	return listLogoPasses, nil
+}
+
+// GetTexts returns the list of service.TextData acquired from the remote
+func (api *GRPCApi) GetTexts() ([]service.TextData, error) {
+	resp, err := api.keeper.GetTexts(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+
+	var listTexts []service.TextData
+	for _, text := range resp.GetTexts() {
+		listTexts = append(listTexts, pb.ToTextData(text))
+	}
+	return listTexts, nil
+}
+
+// GetCreditCards returns the list of service.CreditCard acquired from the remote
+func (api *GRPCApi) GetCreditCards() ([]service.CreditCard, error) {
+	resp, err := api.keeper.GetCreditCards(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+
+	var listCreditCards []service.CreditCard
+	for _, card := range resp.GetCreditCards() {
+		listCreditCards = append(listCreditCards, pb.ToCreditCard(card))
+	}
+	return listCreditCards, nil
+}
+
+// GetBinaryList returns the list of service.BinaryData acquired from the remote
+func (api *GRPCApi) GetBinaryList() ([]service.BinaryData, error) {
+	resp, err := api.keeper.GetBinaryList(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+
+	var binaryList []service.BinaryData
+	for _, binary := range resp.GetBinaries() {
+		binaryList = append(binaryList, pb.ToBinaryData(binary))
+	}
+	return binaryList, nil
+}
+
+// Sync returns service.SyncData holding the secrets changed on the remote since the cursor,
+// every secret is returned if the cursor is empty
+func (api *GRPCApi) Sync(cursor string) (service.SyncData, error) {
+	return api.SyncCollection(0, cursor)
+}
+
+// SyncCollection does what Sync does for the collection of an organization, 0 standing for the personal vault.
+// ErrForbidden is returned if the user can't see the collection.
+func (api *GRPCApi) SyncCollection(collection uint, cursor string) (service.SyncData, error) {
+	resp, err := api.keeper.Sync(api.callContext(), &pb.SyncRequest{Since: cursor, CollectionId: uint64(collection)})
+	if err != nil {
+		return service.SyncData{}, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToSyncData(resp), nil
+}
+
+// GetUsage returns service.Usage with how much space the user takes on the remote and the quotas limiting it
+func (api *GRPCApi) GetUsage() (service.Usage, error) {
+	resp, err := api.keeper.GetUsage(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return service.Usage{}, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToUsage(resp), nil
+}
+
+// GetAuditLog returns a page of the user's audit log, the most recent events first.
+// before is the cursor of the page got along with the previous one, empty for the first page.
+func (api *GRPCApi) GetAuditLog(before string) (service.AuditPage, error) {
+	resp, err := api.keeper.GetAuditLog(api.callContext(), &pb.AuditRequest{Before: before})
+	if err != nil {
+		return service.AuditPage{}, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToAuditPage(resp), nil
+}
+
+// GetHistory returns the previous versions of the secret of the kind and with the ID given,
+// the most recent ones first. Every version of the kind is returned if itemID is 0,
+// every version of the user if item is empty as well. ErrEmpty is returned if there are none.
+func (api *GRPCApi) GetHistory(item string, itemID uint) ([]service.SecretVersion, error) {
+	resp, err := api.keeper.GetHistory(api.callContext(), &pb.HistoryRequest{Item: item, ItemId: uint64(itemID)})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+	if len(resp.GetVersions()) == 0 {
+		return nil, ErrEmpty
+	}
+	versions := make([]service.SecretVersion, 0, len(resp.GetVersions()))
+	for _, version := range resp.GetVersions() {
+		versions = append(versions, pb.ToSecretVersion(version))
+	}
+	return versions, nil
+}
+
+// RestoreVersion asks the remote to make the version the current one,
+// ErrOldData is returned if the secret has been changed since version.SecretRevision
+func (api *GRPCApi) RestoreVersion(version service.SecretVersion) error {
+	_, err := api.keeper.RestoreVersion(api.callContext(), pb.FromSecretVersion(version))
+	if err != nil {
+		return revisionStatusError(err, ErrOldData)
+	}
+	log.Println("The version has been successfully restored")
+	return nil
+}
+
+// GetKeyPair returns the key pair of the user if login is empty, the public key of the user named by login
+// otherwise. ErrEmpty is returned if the user has no key pair.
+func (api *GRPCApi) GetKeyPair(login string) (service.KeyPair, error) {
+	resp, err := api.keeper.GetKeyPair(api.callContext(), &pb.UserRequest{Login: login})
+	if err != nil {
+		return service.KeyPair{}, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToKeyPair(resp), nil
+}
+
+// PutKeyPair stores the key pair of the user on the remote
+func (api *GRPCApi) PutKeyPair(keys service.KeyPair) error {
+	_, err := api.keeper.PutKeyPair(api.callContext(), pb.FromKeyPair(keys))
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// GetShares returns the shares made by the user and the ones made for the user, ErrEmpty if there are none
+func (api *GRPCApi) GetShares() ([]service.Share, error) {
+	resp, err := api.keeper.GetShares(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+	if len(resp.GetShares()) == 0 {
+		return nil, ErrEmpty
+	}
+	shares := make([]service.Share, 0, len(resp.GetShares()))
+	for _, share := range resp.GetShares() {
+		shares = append(shares, pb.ToShare(share))
+	}
+	return shares, nil
+}
+
+// PutShare shares the secret with the recipient and returns the share made. ErrEmpty is returned
+// if the secret is not on the remote, ErrNoKeyPair if the recipient has no keys.
+func (api *GRPCApi) PutShare(share service.Share) (service.Share, error) {
+	resp, err := api.keeper.PutShare(api.callContext(), pb.FromShare(share))
+	if err != nil {
+		return share, statusError(err, ErrNoKeyPair)
+	}
+	return pb.ToShare(resp), nil
+}
+
+// AcceptShare accepts the share made for the user
+func (api *GRPCApi) AcceptShare(id uint) error {
+	_, err := api.keeper.AcceptShare(api.callContext(), &pb.ShareRequest{ShareId: uint64(id)})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// ChangeShare sends the change of the secret shared with the user for writing.
+// ErrReadOnly is returned if the share is read-only.
+func (api *GRPCApi) ChangeShare(share service.Share) error {
+	_, err := api.keeper.ChangeShare(api.callContext(), pb.FromShare(share))
+	if err != nil {
+		if status.Code(err) == codes.PermissionDenied {
+			return ErrReadOnly
+		}
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// DeleteShare revokes the share made by the user or declines the one made for the user
+func (api *GRPCApi) DeleteShare(id uint) error {
+	_, err := api.keeper.DeleteShare(api.callContext(), &pb.ShareRequest{ShareId: uint64(id)})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// GetOrgs returns the organizations of the user with the key of every organization sealed with the public key
+// of the user, ErrEmpty if there are none
+func (api *GRPCApi) GetOrgs() ([]service.Organization, error) {
+	resp, err := api.keeper.GetOrgs(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+	if len(resp.GetOrgs()) == 0 {
+		return nil, ErrEmpty
+	}
+	orgs := make([]service.Organization, 0, len(resp.GetOrgs()))
+	for _, org := range resp.GetOrgs() {
+		orgs = append(orgs, pb.ToOrganization(org))
+	}
+	return orgs, nil
+}
+
+// CreateOrg creates the organization owned by the user and returns it with its ID.
+// ErrAlreadyExists is returned if the name is taken.
+func (api *GRPCApi) CreateOrg(org service.Organization) (service.Organization, error) {
+	resp, err := api.keeper.CreateOrg(api.callContext(), pb.FromOrganization(org))
+	if err != nil {
+		return org, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToOrganization(resp), nil
+}
+
+// DeleteOrg removes the organization along with all of its collections
+func (api *GRPCApi) DeleteOrg(id uint) error {
+	_, err := api.keeper.DeleteOrg(api.callContext(), &pb.OrgRequest{OrgId: uint64(id)})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// PutMember adds the member to the organization or changes the role of the member.
+// ErrNoKeyPair is returned if the member has no keys, ErrLastOwner if the last owner is demoted.
+func (api *GRPCApi) PutMember(member service.OrgMember) error {
+	_, err := api.keeper.PutMember(api.callContext(), pb.FromOrgMember(member))
+	if err != nil {
+		return statusError(err, memberConflict(status.Convert(err).Message()))
+	}
+	return nil
+}
+
+// DeleteMember removes the member from the organization, the user leaves it if the login of the member is empty.
+// ErrLastOwner is returned if the member is the last owner.
+func (api *GRPCApi) DeleteMember(member service.OrgMember) error {
+	_, err := api.keeper.DeleteMember(api.callContext(), pb.FromOrgMember(member))
+	if err != nil {
+		return statusError(err, ErrLastOwner)
+	}
+	return nil
+}
+
+// PutCollection creates the collection, or renames it if its ID is set, and returns the collection stored.
+// ErrAlreadyExists is returned if the organization has one with the name.
+func (api *GRPCApi) PutCollection(collection service.Collection) (service.Collection, error) {
+	resp, err := api.keeper.PutCollection(api.callContext(), pb.FromCollection(collection))
+	if err != nil {
+		return collection, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToCollection(resp), nil
+}
+
+// DeleteCollection removes the collection along with all of its secrets
+func (api *GRPCApi) DeleteCollection(id uint) error {
+	_, err := api.keeper.DeleteCollection(api.callContext(), &pb.Collection{CollectionId: uint64(id)})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// PutCollectionMember adds the member of the organization to the collection
+func (api *GRPCApi) PutCollectionMember(member service.CollectionMember) error {
+	_, err := api.keeper.PutCollectionMember(api.callContext(),
+		&pb.CollectionMember{CollectionId: uint64(member.CollectionID), Login: member.Login})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// DeleteCollectionMember removes the member from the collection
+func (api *GRPCApi) DeleteCollectionMember(member service.CollectionMember) error {
+	_, err := api.keeper.DeleteCollectionMember(api.callContext(),
+		&pb.CollectionMember{CollectionId: uint64(member.CollectionID), Login: member.Login})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// CreateSend stores the send with its content encrypted, returns the send stored with its ID and expiry
+func (api *GRPCApi) CreateSend(send service.Send) (service.Send, error) {
+	resp, err := api.keeper.CreateSend(api.callContext(), pb.FromSend(send))
+	if err != nil {
+		return send, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToSend(resp), nil
+}
+
+// GetSend returns the send with the id without authorization, the view is counted by the remote.
+// ErrEmpty is returned if the send is gone.
+func (api *GRPCApi) GetSend(id string) (service.Send, error) {
+	var header metadata.MD
+	resp, err := api.keeper.GetSend(context.Background(), &pb.SendRequest{SendId: id}, grpc.Header(&header))
+	if err != nil {
+		return service.Send{}, lockoutError(err, header, ErrAlreadyExists)
+	}
+	return pb.ToSend(resp), nil
+}
+
+// GetBinary returns service.BinaryData acquired from the remote
+func (api *GRPCApi) GetBinary(binary service.BinaryData) (service.BinaryData, error) {
+	resp, err := api.keeper.GetBinary(api.callContext(), pb.FromBinaryData(binary))
+	if err != nil {
+		return binary, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToBinaryData(resp), nil
+}
+
+// UploadLogoPass sends service.LogoPass to the remote, returns the revision the pair is stored at
+func (api *GRPCApi) UploadLogoPass(logoPass service.LogoPass) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.PutLogoPass(api.callContext(), pb.FromLogoPass(logoPass), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrAlreadyExists)
+	}
+	log.Println("login password pair has been successfully updated")
+	return headerRevision(header)
+}
+
+// UploadText sends service.TextData to the remote, returns the revision the text is stored at
+func (api *GRPCApi) UploadText(text service.TextData) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.PutText(api.callContext(), pb.FromTextData(text), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrAlreadyExists)
+	}
+	log.Println("The secret text has been successfully updated. What's it about, I wonder")
+	return headerRevision(header)
+}
+
+// UploadCreditCard sends service.CreditCard to the remote, returns the revision the card is stored at
+func (api *GRPCApi) UploadCreditCard(card service.CreditCard) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.PutCreditCard(api.callContext(), pb.FromCreditCard(card), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrAlreadyExists)
+	}
+	log.Println("The credit card info has been successfully updated")
+	return headerRevision(header)
+}
+
+// UploadBinary sends service.BinaryData to the remote, returns the revision the binary is stored at
+func (api *GRPCApi) UploadBinary(binary service.BinaryData) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.PutBinary(api.callContext(), pb.FromBinaryData(binary), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrAlreadyExists)
+	}
+	log.Println("Your binary data has been successfully updated")
+	return headerRevision(header)
+}
+
+// DeleteLogoPass asks the remote to remove service.LogoPass, returns the revision of the tombstone
+func (api *GRPCApi) DeleteLogoPass(logoPass service.LogoPass) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.DeleteLogoPass(api.callContext(), pb.FromLogoPass(logoPass), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrOldData)
+	}
+	log.Println("login password pair has been successfully deleted")
+	return headerRevision(header)
+}
+
+// DeleteText asks the remote to remove service.TextData, returns the revision of the tombstone
+func (api *GRPCApi) DeleteText(text service.TextData) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.DeleteText(api.callContext(), pb.FromTextData(text), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrOldData)
+	}
+	log.Println("The secret text has been successfully deleted. Now no one will ever know")
+	return headerRevision(header)
+}
+
+// DeleteCreditCard asks the remote to remove service.CreditCard, returns the revision of the tombstone
+func (api *GRPCApi) DeleteCreditCard(card service.CreditCard) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.DeleteCreditCard(api.callContext(), pb.FromCreditCard(card), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrOldData)
+	}
+	log.Println("The credit card info has been successfully deleted")
+	return headerRevision(header)
+}
+
+// DeleteBinary asks the remote to remove service.BinaryData, returns the revision of the tombstone
+func (api *GRPCApi) DeleteBinary(binary service.BinaryData) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.DeleteBinary(api.callContext(), pb.FromBinaryData(binary), grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrOldData)
+	}
+	log.Println("Your binary data has been successfully deleted")
+	return headerRevision(header)
+}
+
+// StartBinaryUpload starts a chunked upload and returns the session created on the remote
+func (api *GRPCApi) StartBinaryUpload(session service.UploadSession) (service.UploadSession, error) {
+	resp, err := api.keeper.StartBinaryUpload(api.callContext(), pb.FromUploadSession(session))
+	if err != nil {
+		return session, revisionStatusError(err, ErrAlreadyExists)
+	}
+	return pb.ToUploadSession(resp), nil
+}
+
+// GetBinaryUpload returns service.UploadSession with the list of parts already received by the remote
+func (api *GRPCApi) GetBinaryUpload(session service.UploadSession) (service.UploadSession, error) {
+	resp, err := api.keeper.GetBinaryUpload(api.callContext(), pb.FromUploadSession(session))
+	if err != nil {
+		return session, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToUploadSession(resp), nil
+}
+
+// UploadBinaryChunk sends a single encrypted part of a binary along with its checksum
+func (api *GRPCApi) UploadBinaryChunk(session service.UploadSession, part int, chunk []byte) error {
+	_, err := api.keeper.UploadBinaryChunk(api.callContext(), &pb.BinaryChunk{UploadId: session.ID,
+		Part: int32(part), Data: chunk, Checksum: tools.ChecksumBytes(chunk)})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	return nil
+}
+
+// CompleteBinaryUpload turns all the uploaded parts into a binary on the remote,
+// returns the revision the binary is stored at
+func (api *GRPCApi) CompleteBinaryUpload(session service.UploadSession) (int64, error) {
+	var header metadata.MD
+	_, err := api.keeper.CompleteBinaryUpload(api.callContext(), &pb.UploadSession{UploadId: session.ID},
+		grpc.Header(&header))
+	if err != nil {
+		return 0, revisionStatusError(err, ErrAlreadyExists)
+	}
+	log.Println("Your binary data has been successfully updated")
+	return headerRevision(header)
+}
+
+// DownloadBinary opens a stream of the encrypted content of a chunked binary starting at offset.
+// Returns service.BinaryData with 'size' and 'chunk_size' fields set and the reader of the content,
+// which must be closed by the caller.
+func (api *GRPCApi) DownloadBinary(binary service.BinaryData, offset int64) (service.BinaryData, io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(api.callContext())
+	stream, err := api.keeper.DownloadBinary(ctx, &pb.DownloadRequest{Description: binary.Description, Offset: offset,
+		CollectionId: uint64(binary.CollectionID)})
+	if err != nil {
+		cancel()
+		return binary, nil, statusError(err, ErrAlreadyExists)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		cancel()
+		return binary, nil, statusError(err, ErrAlreadyExists)
+	}
+	binary.Size = first.GetSize()
+	binary.ChunkSize = first.GetChunkSize()
+	if binary.ChunkSize <= 0 {
+		cancel()
+		return binary, nil, ErrCorruptedData
+	}
+	return binary, &streamReader{stream: stream, cancel: cancel}, nil
+}
+
+// Logout revokes the session of the user on the remote and drops the tokens
+func (api *GRPCApi) Logout() error {
+	_, err := api.keeper.Logout(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	api.tokens.set(service.Tokens{})
+	return nil
+}
+
+// GetSessions returns the list of service.Session of the devices signed in by the user
+func (api *GRPCApi) GetSessions() ([]service.Session, error) {
+	resp, err := api.keeper.GetSessions(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+
+	var sessions []service.Session
+	for _, session := range resp.GetSessions() {
+		sessions = append(sessions, pb.ToSession(session))
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the device of service.Session out
+func (api *GRPCApi) RevokeSession(session service.Session) error {
+	_, err := api.keeper.RevokeSession(api.callContext(), pb.FromSession(session))
+	if err != nil {
+		return statusError(err, ErrAlreadyExists)
+	}
+	log.Println("The device has been successfully signed out")
+	return nil
+}
+
+// EnrollTwoFactor returns a new TOTP secret to set up the authenticator with.
+// Returns ErrAlreadyExists if two-factor authentication is already enabled.
+func (api *GRPCApi) EnrollTwoFactor() (service.TOTPEnrollment, error) {
+	resp, err := api.keeper.EnrollTwoFactor(api.callContext(), &emptypb.Empty{})
+	if err != nil {
+		return service.TOTPEnrollment{}, twoFactorError(err)
+	}
+	return service.TOTPEnrollment{Secret: resp.GetSecret(), URI: resp.GetUri()}, nil
+}
+
+// VerifyTwoFactor enables two-factor authentication with TOTP code and returns the recovery codes
+func (api *GRPCApi) VerifyTwoFactor(code string) ([]string, error) {
+	resp, err := api.keeper.VerifyTwoFactor(api.callContext(), &pb.TwoFactorCode{Code: code})
+	if err != nil {
+		return nil, twoFactorError(err)
+	}
+	return resp.GetRecoveryCodes(), nil
+}
+
+// ChangePassword replaces the password along with every secret re-encrypted with the new key.
+// ErrInvalidCredentials is returned if the old password is wrong, ErrOldData if the secrets have changed
+// since the cursor of the change.
+func (api *GRPCApi) ChangePassword(change service.PasswordChange) error {
+	var header metadata.MD
+	_, err := api.keeper.ChangePassword(api.callContext(), pb.FromPasswordChange(change), grpc.Header(&header))
+	if err != nil {
+		if status.Code(err) == codes.PermissionDenied {
+			return ErrInvalidCredentials
+		}
+		return lockoutError(err, header, ErrOldData)
+	}
+	return nil
+}
+
+// ExportVault returns every secret of the user as a zip archive streamed by the remote,
+// it is to be closed once read
+func (api *GRPCApi) ExportVault() (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(api.callContext())
+	stream, err := api.keeper.ExportVault(ctx, &emptypb.Empty{})
+	if err != nil {
+		cancel()
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+	return &streamReader{stream: stream, cancel: cancel}, nil
+}
+
+// DeleteAccount deletes the account along with all the data and drops the tokens.
+// ErrInvalidCredentials is returned if the password is wrong.
+func (api *GRPCApi) DeleteAccount(password string) error {
+	var header metadata.MD
+	_, err := api.keeper.DeleteAccount(api.callContext(), &pb.AuthRequest{Password: password}, grpc.Header(&header))
+	if err != nil {
+		if status.Code(err) == codes.PermissionDenied {
+			return ErrInvalidCredentials
+		}
+		return lockoutError(err, header, ErrLastOwner)
+	}
+	api.tokens.set(service.Tokens{})
+	return nil
+}
+
+// DisableTwoFactor turns two-factor authentication off with TOTP code or a recovery code
+func (api *GRPCApi) DisableTwoFactor(code string) error {
+	_, err := api.keeper.DisableTwoFactor(api.callContext(), &pb.TwoFactorCode{Code: code})
+	if err != nil {
+		return twoFactorError(err)
+	}
+	return nil
+}
+
+// twoFactorError converts the status of two-factor authentication calls to the errors of the package
+func twoFactorError(err error) error {
+	if status.Code(err) == codes.PermissionDenied {
+		return ErrInvalidCode
+	}
+	return statusError(err, ErrAlreadyExists)
+}
+
+// Events opens the stream of the changes of the user's secrets, it lasts until it is closed or the remote ends it
+func (api *GRPCApi) Events() (EventStream, error) {
+	ctx, cancel := context.WithCancel(api.callContext())
+	stream, err := api.keeper.Events(ctx, &emptypb.Empty{})
+	if err == nil {
+		// the remote sends the headers once subscribed, so no change made after Events returns is missed
+		_, err = stream.Header()
+	}
+	if err != nil {
+		cancel()
+		return nil, statusError(err, ErrAlreadyExists)
+	}
+	return &grpcEventStream{stream: stream, cancel: cancel}, nil
+}
+
+// grpcEventStream reads the changes from the stream of Events
+type grpcEventStream struct {
+	stream pb.Keeper_EventsClient
+	cancel context.CancelFunc
+}
+
+// Next receives the next change
+func (events *grpcEventStream) Next() (service.ChangeEvent, error) {
+	msg, err := events.stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return service.ChangeEvent{}, io.EOF
+		}
+		return service.ChangeEvent{}, statusError(err, ErrAlreadyExists)
+	}
+	return pb.ToChangeEvent(msg), nil
+}
+
+// Close cancels the stream
+func (events *grpcEventStream) Close() error {
+	events.cancel()
+	return nil
+}
+
+// chunkStream is the stream of DownloadBinary or ExportVault
+type chunkStream interface {
+	Recv() (*pb.BinaryChunk, error)
+}
+
+// streamReader reads the content of a binary or the archive of the export from the stream
+type streamReader struct {
+	stream chunkStream
+	cancel context.CancelFunc
+	buffer []byte
+}
+
+// Read reads the content of the binary, receiving the next message once the previous one is read
+func (reader *streamReader) Read(p []byte) (int, error) {
+	for len(reader.buffer) == 0 {
+		msg, err := reader.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, io.EOF
+			}
+			return 0, statusError(err, ErrAlreadyExists)
+		}
+		reader.buffer = msg.GetData()
+	}
+	n := copy(p, reader.buffer)
+	reader.buffer = reader.buffer[n:]
+	return n, nil
+}
+
+// Close cancels the stream
+func (reader *streamReader) Close() error {
+	reader.cancel()
+	return nil
+}
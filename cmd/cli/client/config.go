@@ -1,16 +1,60 @@
 package client
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"gophkeeper/internal/tools"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// Config holds the address of remote server and the output folder for local storage
+// Config holds the address of remote server and the output folder for local storage.
+// Transport chooses the Api used to talk to the remote: TransportHTTP or TransportGRPC.
+// The remote is talked to over TLS if ServerAddress is https:// or any of the certificates is set.
+// CACertFile is the CA bundle to trust the remote by, the system one is used if it's empty.
+// ClientCertFile and ClientKeyFile are presented to the remote requiring client certificates.
 type Config struct {
-	ServerAddress string `env:"SERVER_ADDRESS"   envDefault:"http://localhost:8080"`
-	OutputFolder  string `env:"OUTPUT_FOLDER"     envDefault:"C:/temp/gophkeeper"`
+	ServerAddress  string `env:"SERVER_ADDRESS"   envDefault:"http://localhost:8080"`
+	GRPCAddress    string `env:"GRPC_ADDRESS"     envDefault:"localhost:3200"`
+	Transport      string `env:"TRANSPORT"        envDefault:"http"`
+	OutputFolder   string `env:"OUTPUT_FOLDER"     envDefault:"C:/temp/gophkeeper"`
+	CACertFile     string `env:"CA_CERT_FILE"`
+	ClientCertFile string `env:"CLIENT_CERT_FILE"`
+	ClientKeyFile  string `env:"CLIENT_KEY_FILE"`
 }
 
+// NewTLSConfig builds TLS configuration to talk to the remote with, it is nil if TLS is not configured
+func NewTLSConfig(cfg Config) (*tls.Config, error) {
+	if !strings.HasPrefix(cfg.ServerAddress, "https://") && cfg.CACertFile == "" && cfg.ClientCertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CACertFile != "" {
+		pool, err := tools.LoadCertPool(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("load CA bundle: %w", err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// transports available for Config.Transport
+const (
+	TransportHTTP = "http"
+	TransportGRPC = "grpc"
+)
+
 const dateTimeLayout = "02.01.2006 15:04:05"
 
 // holds common file names for local storage
@@ -19,13 +63,60 @@ const (
 	textFile        = "TextData.json"
 	creditCardFile  = "CreditCards.json"
 	binaryListFile  = "BinaryList.json"
+	uploadsFile     = "Uploads.json"
+	cursorFile      = "SyncCursor"
 	UpdateDataTimer = 300 * time.Second
 )
 
+// eventsRetryDelay is how long to wait before reopening the broken stream of events of the remote
+const eventsRetryDelay = 10 * time.Second
+
+// settings of chunked binary transfer
+const (
+	// binaryChunkSize is the size of a file part encrypted and uploaded at once
+	binaryChunkSize = 4 << 20
+	// binaryRetries is how many times a failed part of a transfer is retried before giving up
+	binaryRetries = 5
+)
+
 // self-explanatory errors for the package
 var (
 	ErrUserExists         = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrAlreadyExists      = errors.New("already exists")
 	ErrEmpty              = errors.New("no data")
+	ErrOldData            = errors.New("newer data available on remote storage")
+	ErrCorruptedData      = errors.New("data is corrupted")
+	ErrSessionExpired     = errors.New("session expired, please log in again")
+	ErrTwoFactorRequired  = errors.New("two-factor authentication code required")
+	ErrInvalidCode        = errors.New("invalid code")
+	ErrUnexpectedResponse = errors.New("unexpected response from remote")
+	ErrQuotaExceeded      = errors.New("quota exceeded on remote storage")
+	ErrAccountDisabled    = errors.New("account is disabled, please contact the administrator")
+	ErrNoKeyPair          = errors.New("the user has no keys to share with")
+	ErrReadOnly           = errors.New("the secret is shared read-only")
+	ErrForbidden          = errors.New("not allowed for your role in the organization")
+	ErrLastOwner          = errors.New("the organization must keep an owner")
+	ErrInvalidLink        = errors.New("the link is not a link to a send")
+	ErrSendTooLarge       = errors.New("the file is too large to send, store it in the vault instead")
 )
+
+// LockoutError is returned when the remote refuses to check the credentials for a while
+// after too many failed attempts, RetryAfter is how long to wait before trying again
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+// Error implements error interface
+func (err *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", err.RetryAfter)
+}
+
+// newLockoutError parses the number of seconds to retry after sent by the remote
+func newLockoutError(retryAfter string) *LockoutError {
+	seconds, err := strconv.Atoi(retryAfter)
+	if err != nil || seconds < 0 {
+		seconds = 0
+	}
+	return &LockoutError{RetryAfter: time.Duration(seconds) * time.Second}
+}
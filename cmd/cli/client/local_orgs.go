@@ -0,0 +1,441 @@
+package client
+
+// Here are the organizations and their collections. The key of an organization is generated by the client
+// of the owner and kept on the remote sealed with the public key of every member, so the remote never learns it.
+// The secrets of the collections are encrypted with the key of the organization and are not kept locally,
+// they are taken from the remote every time they are shown.
+
+import (
+	"errors"
+	"fmt"
+	"github.com/olekukonko/tablewriter"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// orgKeySize is the number of random bytes of the key of an organization, the key is their hex encoding
+// making a key of 32 bytes for AES-256 just like tools.GenerateKey does
+const orgKeySize = 16
+
+// newOrgKey returns a new random key of an organization
+func newOrgKey() (string, error) {
+	return tools.GenerateRandomString(orgKeySize)
+}
+
+// encryptWithOrgKey returns the conversion of the plain secret fields to the ones encrypted with the key
+// of the organization
+func encryptWithOrgKey(key string) func(string) (string, error) {
+	return func(secret string) (string, error) {
+		return tools.EncryptString(secret, key)
+	}
+}
+
+// decryptWithOrgKey returns the conversion of the secret fields encrypted with the key of the organization
+// to the plain ones
+func decryptWithOrgKey(key string) func(string) (string, error) {
+	return func(secret string) (string, error) {
+		data, err := tools.DecryptString(secret, key)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrCorruptedData, err)
+		}
+		return data, nil
+	}
+}
+
+// openOrgKey returns the key of the organization opened with the key pair of the user
+func (svc *LocalService) openOrgKey(org service.Organization) (string, error) {
+	keys, err := svc.keyPair()
+	if err != nil {
+		return "", err
+	}
+	key, err := openWith(keys)(org.OrgKey)
+	if err != nil {
+		return "", fmt.Errorf("organization %s: %w", org.Name, err)
+	}
+	return key, nil
+}
+
+// CreateOrg creates the organization owned by the user with a new key sealed with the public key of the user.
+// ErrAlreadyExists is returned if the name is taken.
+func (svc *LocalService) CreateOrg(name string) (service.Organization, error) {
+	keys, err := svc.keyPair()
+	if err != nil {
+		return service.Organization{}, err
+	}
+	key, err := newOrgKey()
+	if err != nil {
+		return service.Organization{}, err
+	}
+	sealed, err := tools.SealString(key, keys.PublicKey)
+	if err != nil {
+		return service.Organization{}, err
+	}
+	return svc.Api.CreateOrg(service.Organization{Name: name, OrgKey: sealed})
+}
+
+// PutMember adds the user with the login to the organization with the role, or changes the role of the member.
+// The key of the organization is sealed with the public key of the member every time, so a member who has
+// made a new key pair since gets it back. ErrNoKeyPair is returned if the user has never signed in to get keys.
+func (svc *LocalService) PutMember(org service.Organization, login string, role string) error {
+	key, err := svc.openOrgKey(org)
+	if err != nil {
+		return err
+	}
+	memberKeys, err := svc.Api.GetKeyPair(login)
+	if errors.Is(err, ErrEmpty) {
+		return ErrNoKeyPair
+	}
+	if err != nil {
+		return err
+	}
+	sealed, err := tools.SealString(key, memberKeys.PublicKey)
+	if err != nil {
+		return err
+	}
+	return svc.Api.PutMember(service.OrgMember{OrgID: org.ID, Login: login, Role: role, OrgKey: sealed})
+}
+
+// CollectionSecrets returns the logo-pass pairs, the texts and the credit cards of the collection
+// with their secret fields decrypted with the key of the organization. Every secret is held by the share
+// of its kind, as the shares are the only entries holding the secrets of any kind.
+func (svc *LocalService) CollectionSecrets(org service.Organization, collection uint) ([]service.Share, error) {
+	key, err := svc.openOrgKey(org)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := svc.Api.SyncCollection(collection, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []service.Share
+	for i, logoPass := range changes.LogoPasses {
+		if !logoPass.DeletedAt.Valid {
+			entries = append(entries, service.Share{Item: storage.ItemLogoPass, ItemID: logoPass.ID,
+				Revision: logoPass.Revision, LogoPass: &changes.LogoPasses[i]})
+		}
+	}
+	for i, text := range changes.Texts {
+		if !text.DeletedAt.Valid {
+			entries = append(entries, service.Share{Item: storage.ItemText, ItemID: text.ID,
+				Revision: text.Revision, Text: &changes.Texts[i]})
+		}
+	}
+	for i, card := range changes.CreditCards {
+		if !card.DeletedAt.Valid {
+			entries = append(entries, service.Share{Item: storage.ItemCreditCard, ItemID: card.ID,
+				Revision: card.Revision, CreditCard: &changes.CreditCards[i]})
+		}
+	}
+
+	for i := range entries {
+		err = convertShare(&entries[i], decryptWithOrgKey(key))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// PutCollectionSecret stores the secret held by the entry in the collection with its secret fields encrypted
+// with the key of the organization. The secret replaces the stored one if the entry holds its revision.
+func (svc *LocalService) PutCollectionSecret(org service.Organization, collection uint, entry service.Share) error {
+	key, err := svc.openOrgKey(org)
+	if err != nil {
+		return err
+	}
+	err = convertShare(&entry, encryptWithOrgKey(key))
+	if err != nil {
+		return err
+	}
+
+	// the secret is overwritten at the revision it has been taken at, a new one has none
+	overwrite := entry.Revision != 0
+	switch {
+	case entry.LogoPass != nil:
+		logoPass := *entry.LogoPass
+		logoPass.CollectionID, logoPass.Overwrite = collection, overwrite
+		_, err = svc.Api.UploadLogoPass(logoPass)
+	case entry.Text != nil:
+		text := *entry.Text
+		text.CollectionID, text.Overwrite = collection, overwrite
+		_, err = svc.Api.UploadText(text)
+	case entry.CreditCard != nil:
+		card := *entry.CreditCard
+		card.CollectionID, card.Overwrite = collection, overwrite
+		_, err = svc.Api.UploadCreditCard(card)
+	default:
+		return ErrEmpty
+	}
+	return err
+}
+
+// DeleteCollectionSecret removes the secret held by the entry taken from CollectionSecrets from the collection
+func (svc *LocalService) DeleteCollectionSecret(entry service.Share) error {
+	var err error
+	switch {
+	case entry.LogoPass != nil:
+		_, err = svc.Api.DeleteLogoPass(*entry.LogoPass)
+	case entry.Text != nil:
+		_, err = svc.Api.DeleteText(*entry.Text)
+	case entry.CreditCard != nil:
+		_, err = svc.Api.DeleteCreditCard(*entry.CreditCard)
+	default:
+		return ErrEmpty
+	}
+	return err
+}
+
+// askID asks for the ID of an organization or a collection, false is returned if the answer is not one
+func (svc *LocalService) askID(ask string) (uint, bool) {
+	id, err := strconv.ParseUint(svc.getAnswer(ask), 10, 32)
+	if err != nil || id == 0 {
+		fmt.Println("I feel you bro, but i just dont get it, please try again")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findOrg returns the organization with the ID among the ones of the user, false is returned if there is none
+func findOrg(orgs []service.Organization, id uint) (service.Organization, bool) {
+	for _, org := range orgs {
+		if org.ID == id {
+			return org, true
+		}
+	}
+	fmt.Println("There is no such ID, try again")
+	return service.Organization{}, false
+}
+
+// showOrgs prints the organizations of the user with their members and collections in a cute table
+// and asks for further instructions, the options are:
+//   - create a new organization
+//   - open certain collection to see and change its secrets
+//   - add a member to certain organization, change the role of a member or remove one
+//   - create a collection in certain organization, or rename or delete one
+//   - leave certain organization or delete it
+//   - exit back to the choice of available actions
+func (svc *LocalService) showOrgs() error {
+showOrgs:
+	orgs, err := svc.Api.GetOrgs()
+	if err != nil && !errors.Is(err, ErrEmpty) {
+		return err
+	}
+
+	if len(orgs) == 0 {
+		fmt.Println("You are not a member of any organization")
+	} else {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "Name", "Your role", "Members", "Collections"})
+		for _, org := range orgs {
+			var members, collections []string
+			for _, member := range org.Members {
+				members = append(members, member.Login+" ("+member.Role+")")
+			}
+			for _, collection := range org.Collections {
+				collections = append(collections, strconv.FormatUint(uint64(collection.ID), 10)+": "+collection.Name)
+			}
+			table.Append([]string{strconv.FormatUint(uint64(org.ID), 10), org.Name, org.Role,
+				strings.Join(members, "\n"), strings.Join(collections, "\n")})
+		}
+		table.Render()
+	}
+
+	choice := svc.getAnswer("To create an organization type create\nto open a collection type open\n" +
+		"to manage the members type members\nto manage the collections type collections\n" +
+		"to leave an organization type leave\nto delete an organization type delete\notherwise type exit")
+	switch choice {
+	case "exit":
+		return nil
+	case "create":
+		_, err = svc.CreateOrg(svc.getAnswer("Please, enter the name of the organization"))
+	case "open", "members", "collections", "leave", "delete":
+		id, ok := svc.askID("Please, enter the ID of the organization")
+		if !ok {
+			goto showOrgs
+		}
+		org, ok := findOrg(orgs, id)
+		if !ok {
+			goto showOrgs
+		}
+		switch choice {
+		case "open":
+			err = svc.openCollection(org)
+		case "members":
+			err = svc.manageMembers(org)
+		case "collections":
+			err = svc.manageCollections(org)
+		case "leave":
+			err = svc.Api.DeleteMember(service.OrgMember{OrgID: org.ID})
+		case "delete":
+			if svc.getAnswer("All the collections of "+org.Name+" are deleted along with it, type yes to go on") == "yes" {
+				err = svc.Api.DeleteOrg(org.ID)
+			}
+		}
+	default:
+		fmt.Println("I feel you bro, but i just dont get it, please try again")
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+	goto showOrgs
+}
+
+// manageMembers asks for the login of the member of the organization and the role to give, the member is removed
+// from the organization if the role is remove
+func (svc *LocalService) manageMembers(org service.Organization) error {
+	login := svc.getAnswer("Please, enter the login of the member")
+	role := svc.getAnswer("Please, enter the role: owner, admin, member or read-only\nto remove the member type remove")
+	switch role {
+	case "remove":
+		return svc.Api.DeleteMember(service.OrgMember{OrgID: org.ID, Login: login})
+	case service.OrgRoleOwner, service.OrgRoleAdmin, service.OrgRoleMember, service.OrgRoleReadOnly:
+		err := svc.PutMember(org, login, role)
+		if errors.Is(err, ErrNoKeyPair) {
+			fmt.Println("The user has to sign in once before joining an organization")
+			return nil
+		}
+		return err
+	}
+	fmt.Println("There is no such role")
+	return nil
+}
+
+// manageCollections asks what to do with the collections of the organization: create, rename or delete one,
+// add a member to one or remove the member from it
+func (svc *LocalService) manageCollections(org service.Organization) error {
+	choice := svc.getAnswer("To create a collection type create\nto rename a collection type rename\n" +
+		"to delete a collection type delete\nto add a member to a collection type add\n" +
+		"to remove a member from a collection type remove\notherwise type exit")
+	if choice == "create" {
+		collection, err := svc.Api.PutCollection(service.Collection{OrgID: org.ID,
+			Name: svc.getAnswer("Please, enter the name of the collection")})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Collection %s has been created with ID %d\n", collection.Name, collection.ID)
+		return nil
+	}
+	if choice != "rename" && choice != "delete" && choice != "add" && choice != "remove" {
+		return nil
+	}
+
+	id, ok := svc.askID("Please, enter the ID of the collection")
+	if !ok {
+		return nil
+	}
+	switch choice {
+	case "rename":
+		_, err := svc.Api.PutCollection(service.Collection{ID: id, OrgID: org.ID,
+			Name: svc.getAnswer("Please, enter the new name of the collection")})
+		return err
+	case "delete":
+		return svc.Api.DeleteCollection(id)
+	case "add":
+		return svc.Api.PutCollectionMember(service.CollectionMember{CollectionID: id,
+			Login: svc.getAnswer("Please, enter the login of the member")})
+	default:
+		return svc.Api.DeleteCollectionMember(service.CollectionMember{CollectionID: id,
+			Login: svc.getAnswer("Please, enter the login of the member")})
+	}
+}
+
+// openCollection prints the secrets of the collection of the organization in a cute table
+// and asks for further instructions, the options are:
+//   - add a new secret or update certain one
+//   - delete certain secret
+//   - exit back to the organizations
+func (svc *LocalService) openCollection(org service.Organization) error {
+	id, ok := svc.askID("Please, enter the ID of the collection")
+	if !ok {
+		return nil
+	}
+
+showCollection:
+	entries, err := svc.CollectionSecrets(org, id)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"#", "Kind", "Description", "Secret", "Last updated"})
+	for i, entry := range entries {
+		row := []string{strconv.Itoa(i + 1), entry.Item}
+		switch {
+		case entry.LogoPass != nil:
+			row = append(row, entry.LogoPass.Description, entry.LogoPass.SecretLogin+" / "+entry.LogoPass.SecretPass,
+				entry.LogoPass.UpdatedAt.Local().Format(dateTimeLayout))
+		case entry.Text != nil:
+			row = append(row, entry.Text.Description, entry.Text.Text, entry.Text.UpdatedAt.Local().Format(dateTimeLayout))
+		case entry.CreditCard != nil:
+			row = append(row, entry.CreditCard.Description, strings.Join([]string{entry.CreditCard.Number,
+				entry.CreditCard.Holder, entry.CreditCard.DueDate, entry.CreditCard.CVV}, " "),
+				entry.CreditCard.UpdatedAt.Local().Format(dateTimeLayout))
+		}
+		table.Append(row)
+	}
+	table.Render()
+
+	choice := svc.getAnswer("To add a secret type add\nto update a secret type update\n" +
+		"to delete a secret type delete\notherwise type exit")
+	switch choice {
+	case "exit":
+		return nil
+	case "add":
+		var entry service.Share
+		switch svc.getAnswer("What kind of secret is it?\nLogin password pair: type 1\nText: type 2\nCredit card: type 3") {
+		case "1":
+			entry.LogoPass = &service.LogoPass{Description: svc.getAnswer("Please, enter description for the pair")}
+		case "2":
+			entry.Text = &service.TextData{Description: svc.getAnswer("Please, enter description for the text")}
+		case "3":
+			entry.CreditCard = &service.CreditCard{Number: svc.getAnswer("Please, enter card number"),
+				Description: svc.getAnswer("Please, enter description for the card")}
+		default:
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto showCollection
+		}
+		err = svc.putCollectionEntry(org, id, entry)
+	case "update", "delete":
+		number, err := strconv.Atoi(svc.getAnswer("Please, enter the # of the secret"))
+		if err != nil || number < 1 || number > len(entries) {
+			fmt.Println("There is no such secret, try again")
+			goto showCollection
+		}
+		if choice == "update" {
+			err = svc.putCollectionEntry(org, id, entries[number-1])
+		} else {
+			err = svc.DeleteCollectionSecret(entries[number-1])
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto showCollection
+	default:
+		fmt.Println("I feel you bro, but i just dont get it, please try again")
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+	goto showCollection
+}
+
+// putCollectionEntry asks for the secret fields of the secret held by the entry and stores it in the collection
+func (svc *LocalService) putCollectionEntry(org service.Organization, collection uint, entry service.Share) error {
+	switch {
+	case entry.LogoPass != nil:
+		entry.LogoPass.SecretLogin = svc.getAnswer("Please, enter login")
+		entry.LogoPass.SecretPass = svc.getAnswer("Please, enter password")
+	case entry.Text != nil:
+		entry.Text.Text = svc.getAnswer("Please, enter text")
+	case entry.CreditCard != nil:
+		entry.CreditCard.Holder = svc.getAnswer("Please, enter card holder name")
+		entry.CreditCard.DueDate = svc.getAnswer("Please, enter card due date")
+		entry.CreditCard.CVV = svc.getAnswer("Please, enter card CVV")
+	}
+	return svc.PutCollectionSecret(org, collection, entry)
+}
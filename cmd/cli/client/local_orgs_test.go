@@ -0,0 +1,34 @@
+package client
+
+import (
+	"github.com/stretchr/testify/require"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"testing"
+)
+
+func TestOrgKey(t *testing.T) {
+	key, err := newOrgKey()
+	require.NoError(t, err)
+
+	publicKey, privateKey, err := tools.GenerateKeyPair()
+	require.NoError(t, err)
+	sealed, err := tools.SealString(key, publicKey)
+	require.NoError(t, err)
+	opened, err := openWith(service.KeyPair{PublicKey: publicKey, PrivateKey: privateKey})(sealed)
+	require.NoError(t, err)
+	require.Equal(t, key, opened)
+
+	entry := service.Share{Text: &service.TextData{Text: "Ground Control to Major Tom", Description: "Space Oddity"}}
+	require.NoError(t, convertShare(&entry, encryptWithOrgKey(opened)))
+	require.NotEqual(t, "Ground Control to Major Tom", entry.Text.Text)
+	require.Equal(t, "Space Oddity", entry.Text.Description)
+
+	require.NoError(t, convertShare(&entry, decryptWithOrgKey(key)))
+	require.Equal(t, "Ground Control to Major Tom", entry.Text.Text)
+
+	other, err := newOrgKey()
+	require.NoError(t, err)
+	require.NoError(t, convertShare(&entry, encryptWithOrgKey(key)))
+	require.ErrorIs(t, convertShare(&entry, decryptWithOrgKey(other)), ErrCorruptedData)
+}
@@ -0,0 +1,200 @@
+package client
+
+// Here are the sends: a text or a small file handed by a link to someone without an account. The content is
+// encrypted with a random key that is put to the fragment of the link only, the fragment is never sent over
+// the network, so the remote keeps the content it can't open. Anyone holding the link opens the send
+// without signing in, until it expires or runs out of views.
+
+import (
+	"fmt"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sendKeySize is the number of random bytes of the key of a send, the key is their hex encoding
+	// making a key of 32 bytes for AES-256 just like tools.GenerateKey does
+	sendKeySize = 16
+	// sendPath is the path of the sends on the remote the links are made to
+	sendPath = "/api/send"
+	// maxSendFileSize is the largest file that can be sent, the remote takes a megabyte of encrypted content at most
+	maxSendFileSize = 1<<20 - tools.CipherOverhead
+)
+
+// sendLink returns the link to the send with the id on the remote, the key being carried by the fragment
+func sendLink(address string, id string, key string) string {
+	return strings.TrimSuffix(address, "/") + sendPath + "?" + url.Values{"send_id": {id}}.Encode() + "#" + key
+}
+
+// parseSendLink returns the ID of the send and its key taken from the link, ErrInvalidLink is returned
+// if the link has no ID or no key
+func parseSendLink(link string) (string, string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", "", ErrInvalidLink
+	}
+	id := parsed.Query().Get("send_id")
+	if id == "" || parsed.Fragment == "" {
+		return "", "", ErrInvalidLink
+	}
+	return id, parsed.Fragment, nil
+}
+
+// Send encrypts the content of the kind, either a text or a binary named by name, with a new random key
+// and stores it on the remote to be viewed maxViews times within ttl. Returns the link to the send
+// along with the send stored.
+func (svc *LocalService) Send(item string, name string, data []byte, maxViews int, ttl time.Duration) (string, service.Send, error) {
+	key, err := tools.GenerateRandomString(sendKeySize)
+	if err != nil {
+		return "", service.Send{}, err
+	}
+	send := service.Send{Item: item, MaxViews: maxViews, ExpiresIn: int64(ttl.Seconds())}
+	send.Data, err = tools.EncryptBytes(data, key)
+	if err != nil {
+		return "", service.Send{}, err
+	}
+	if name != "" {
+		send.Name, err = tools.EncryptString(name, key)
+		if err != nil {
+			return "", service.Send{}, err
+		}
+	}
+
+	send, err = svc.Api.CreateSend(send)
+	if err != nil {
+		return "", send, err
+	}
+	return sendLink(svc.config.ServerAddress, send.ID, key), send, nil
+}
+
+// SendFile sends the file at the path in the same way Send does, the file name goes along with the content.
+// ErrSendTooLarge is returned if the file is larger than the remote takes.
+func (svc *LocalService) SendFile(path string, maxViews int, ttl time.Duration) (string, service.Send, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", service.Send{}, err
+	}
+	if info.Size() > maxSendFileSize {
+		return "", service.Send{}, ErrSendTooLarge
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", service.Send{}, err
+	}
+	return svc.Send(storage.ItemBinary, filepath.Base(path), data, maxViews, ttl)
+}
+
+// OpenSend takes the send the link leads to from the remote and returns it with the content and the name
+// decrypted with the key of the link. No sign in is needed, the view is counted by the remote.
+// ErrEmpty is returned if the send has expired or has been viewed as many times as allowed.
+func (svc *LocalService) OpenSend(link string) (service.Send, error) {
+	id, key, err := parseSendLink(link)
+	if err != nil {
+		return service.Send{}, err
+	}
+	send, err := svc.Api.GetSend(id)
+	if err != nil {
+		return send, err
+	}
+	send.Data, err = tools.DecryptBytes(send.Data, key)
+	if err != nil {
+		return send, fmt.Errorf("%w: %s", ErrCorruptedData, err)
+	}
+	if send.Name != "" {
+		send.Name, err = tools.DecryptString(send.Name, key)
+		if err != nil {
+			return send, fmt.Errorf("%w: %s", ErrCorruptedData, err)
+		}
+	}
+	return send, nil
+}
+
+// askNumber asks for a positive number, def is taken if the answer is empty
+func (svc *LocalService) askNumber(ask string, def int) (int, bool) {
+	answer := svc.getAnswer(ask)
+	if answer == "" {
+		return def, true
+	}
+	number, err := strconv.Atoi(answer)
+	if err != nil || number <= 0 {
+		fmt.Println("I feel you bro, but i just dont get it, please try again")
+		return 0, false
+	}
+	return number, true
+}
+
+// sendSecret asks for a text or a path to a file along with the limits of the send, sends it
+// and prints the link to be handed over
+func (svc *LocalService) sendSecret() error {
+	choice := svc.getAnswer("Send a text: type 1\nSend a file: type 2")
+	if choice != "1" && choice != "2" {
+		fmt.Println("Houston we got a problem!")
+		return nil
+	}
+	views, ok := svc.askNumber("How many times can it be viewed? Press enter for once", 1)
+	if !ok {
+		return nil
+	}
+	hours, ok := svc.askNumber("How many hours does it live? Press enter for a day", 24)
+	if !ok {
+		return nil
+	}
+	ttl := time.Duration(hours) * time.Hour
+
+	var link string
+	var send service.Send
+	var err error
+	if choice == "1" {
+		text := svc.getAnswer("Please, enter the text to send")
+		link, send, err = svc.Send(storage.ItemText, "", []byte(text), views, ttl)
+	} else {
+		path := svc.getAnswer("Please, enter a path to the file to send")
+		link, send, err = svc.SendFile(path, views, ttl)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Hand over this link, it can be opened %d time(s) until %s:\n%s\n", send.MaxViews,
+		send.ExpiresAt.Format(dateTimeLayout), link)
+	fmt.Println("Anyone holding the link can open it, the key is in the part after #, so keep the link whole")
+	return nil
+}
+
+// openSendLink asks for the link to a send and shows the text sent or saves the file sent to the folder asked for
+func (svc *LocalService) openSendLink() error {
+	link := svc.getAnswer("Please, enter the link")
+	send, err := svc.OpenSend(link)
+	if err != nil {
+		return err
+	}
+	if send.Item != storage.ItemBinary {
+		fmt.Printf("Here is the text sent:\n%s\n", send.Data)
+	} else {
+		folder := svc.getAnswer("Please enter a path to folder where you want to save the file")
+		err = os.MkdirAll(folder, os.ModePerm)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(send.Name)
+		if name == "." || name == string(filepath.Separator) {
+			name = "send"
+		}
+		path := filepath.Join(folder, name)
+		err = os.WriteFile(path, send.Data, 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("The file sent is saved to %s\n", path)
+	}
+	if send.Views >= send.MaxViews {
+		fmt.Println("It was the last view, the link doesn't work anymore")
+	}
+	return nil
+}
@@ -3,15 +3,22 @@ package client
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/olekukonko/tablewriter"
 	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
 	"gophkeeper/internal/tools"
+	"gorm.io/gorm"
+	"io"
+	"log"
 	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,6 +28,14 @@ type LocalService struct {
 	Api     Api
 	storage Storage
 	key     string
+	login   string
+	// syncing makes the syncs triggered by the events, the timer and the user wait for each other
+	syncing sync.Mutex
+	// watching is set while the stream of events is open, so polling is not needed
+	watching atomic.Bool
+	// mu guards events, the stream being watched
+	mu     sync.Mutex
+	events EventStream
 }
 
 // NewService creates new instance of a LocalService
@@ -45,7 +60,15 @@ ask:
 // StartCommunicate starts the dialog with the user politely asking for authorization credentials
 func (svc *LocalService) StartCommunicate() error {
 auth:
-	choice := svc.getAnswer("login: type 1\nRegister: type 2")
+	choice := svc.getAnswer("login: type 1\nRegister: type 2\nOpen a send link: type 3")
+	if choice == "3" {
+		// the sends are opened without signing in
+		err := svc.openSendLink()
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto auth
+	}
 	login := svc.getAnswer("Enter your login")
 	password := svc.getAnswer("Enter your password")
 
@@ -60,14 +83,21 @@ auth:
 		goto auth
 	}
 	if err != nil {
+		var lockout *LockoutError
+		if errors.As(err, &lockout) {
+			fmt.Printf("Too many failed attempts, please wait %s before trying again\n", lockout.RetryAfter)
+			goto auth
+		}
 		fmt.Println(err)
 		goto auth
 	}
 	svc.getActionFromUser()
-	return nil
+	// the user has logged out or deleted the account
+	goto auth
 }
 
-// getActionFromUser continues the pleasant chat by showing the options and asking for user's today's intentions
+// getActionFromUser continues the pleasant chat by showing the options and asking for user's today's intentions.
+// It returns once the user logs out or deletes the account.
 func (svc *LocalService) getActionFromUser() {
 initialActionChoice:
 
@@ -79,7 +109,19 @@ initialActionChoice:
 		"View all credit cards' info:          type 5\n" +
 		"Create a new credit card entry:       type 6\n" +
 		"Review all binary data:               type 7\n" +
-		"Upload a new binary:                  type 8")
+		"Upload a new binary:                  type 8\n" +
+		"Delete an entry:                      type 9\n" +
+		"Show signed in devices:               type 10\n" +
+		"Log out:                              type 11\n" +
+		"Two-factor authentication:            type 12\n" +
+		"Show storage usage:                   type 13\n" +
+		"Change password:                      type 14\n" +
+		"Export all secrets:                   type 15\n" +
+		"Delete account:                       type 16\n" +
+		"Show activity:                        type 17\n" +
+		"Shared secrets:                       type 18\n" +
+		"Organizations:                        type 19\n" +
+		"Send a secret by link:                type 20")
 
 	var err error
 	switch choice {
@@ -107,6 +149,37 @@ initialActionChoice:
 		var newBinary service.BinaryData
 		newBinary.Overwrite = false
 		err = svc.putBinary(newBinary)
+	case "9":
+		err = svc.deleteEntry()
+	case "10":
+		err = svc.showSessions()
+	case "11":
+		err = svc.Logout()
+		if err == nil {
+			return
+		}
+	case "12":
+		err = svc.setUpTwoFactor()
+	case "13":
+		err = svc.showUsage()
+	case "14":
+		err = svc.changePassword()
+	case "15":
+		err = svc.exportVault()
+	case "16":
+		var deleted bool
+		deleted, err = svc.deleteAccount()
+		if deleted {
+			return
+		}
+	case "17":
+		err = svc.showActivity()
+	case "18":
+		err = svc.showShares()
+	case "19":
+		err = svc.showOrgs()
+	case "20":
+		err = svc.sendSecret()
 	default:
 		fmt.Println("Houston we got a problem!")
 		goto initialActionChoice
@@ -118,16 +191,23 @@ initialActionChoice:
 }
 
 // Auth is responsible for creating service.User needed further for request.
+// The code is asked for if the user has two-factor authentication enabled.
 // In case of successful authorization it merge-updates all local and remote data for this users
 func (svc *LocalService) Auth(login, password string) error {
 	var user service.User
 	user.Login = login
 	user.Password = password
+	user.Device = deviceName()
 	err := svc.Api.Login(user)
+	if errors.Is(err, ErrTwoFactorRequired) {
+		code := svc.getAnswer("Enter the code from your authenticator app or one of your recovery codes")
+		err = svc.Api.LoginTwoFactor(code)
+	}
 	if err != nil {
 		return err
 	}
 	svc.key = tools.GenerateKey(password)
+	svc.login = login
 
 	err = svc.storage.UpdatePath(fmt.Sprintf("%s/%s/", svc.config.OutputFolder, login))
 	if err != nil {
@@ -153,11 +233,13 @@ func (svc *LocalService) Register(login, password string) error {
 	var user service.User
 	user.Login = login
 	user.Password = password
+	user.Device = deviceName()
 	err := svc.Api.Register(user)
 	if err != nil {
 		return err
 	}
 	svc.key = tools.GenerateKey(password)
+	svc.login = login
 
 	err = svc.storage.UpdatePath(fmt.Sprintf("%s/%s/", svc.config.OutputFolder, login))
 	if err != nil {
@@ -168,73 +250,666 @@ func (svc *LocalService) Register(login, password string) error {
 	return nil
 }
 
-// UpdateAll upon called, tries to merge and update all user's info stored locally and remotely
+// Logout signs the user out on the remote and forgets the key, so the local data can't be decrypted anymore
+func (svc *LocalService) Logout() error {
+	err := svc.Api.Logout()
+	if err != nil {
+		return err
+	}
+	svc.key = ""
+	svc.login = ""
+	svc.closeEvents()
+	fmt.Println("Logged out, see you soon")
+	return nil
+}
+
+// showSessions prints all the devices the user is signed in from in a cute table and asks for further instructions
+// the options are:
+//   - sign out certain device
+//   - exit back to the choice of available actions
+func (svc *LocalService) showSessions() error {
+showSessions:
+	sessions, err := svc.Api.GetSessions()
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Device", "IP", "Last seen", "Signed in", "This device"})
+
+	for _, session := range sessions {
+		current := ""
+		if session.Current {
+			current = "yes"
+		}
+		row := []string{session.ID, session.Device, session.IP, session.LastSeen.Format(dateTimeLayout),
+			session.CreatedAt.Format(dateTimeLayout), current}
+		table.Append(row)
+	}
+	table.Render()
+
+	choice := svc.getAnswer("If you want to sign out any device enter it's ID\notherwise type exit")
+	switch choice {
+	case "exit":
+		return nil
+	default:
+		var revoked service.Session
+		for _, session := range sessions {
+			if session.ID == choice {
+				revoked = session
+			}
+		}
+		if revoked.ID == "" {
+			fmt.Println("There is no such ID, try again")
+			goto showSessions
+		}
+		if revoked.Current {
+			fmt.Println("That's this very device, use log out instead")
+			goto showSessions
+		}
+		err = svc.Api.RevokeSession(revoked)
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto showSessions
+	}
+}
+
+// showUsage prints how much space the user takes on the remote and how much is left in a cute table
+func (svc *LocalService) showUsage() error {
+	usage, err := svc.Api.GetUsage()
+	if err != nil {
+		return err
+	}
+
+	limit := func(max int64) string {
+		if max == 0 {
+			return "unlimited"
+		}
+		return strconv.FormatInt(max, 10)
+	}
+	left := func(used, max int64) string {
+		if max == 0 {
+			return "unlimited"
+		}
+		return strconv.FormatInt(max-used, 10)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"", "Used", "Quota", "Left"})
+	table.Append([]string{"Bytes", strconv.FormatInt(usage.Bytes, 10), limit(usage.MaxBytes),
+		left(usage.Bytes, usage.MaxBytes)})
+	for _, item := range []string{storage.ItemLogoPass, storage.ItemText, storage.ItemCreditCard, storage.ItemBinary} {
+		table.Append([]string{"Items of " + item, strconv.FormatInt(usage.Items[item], 10), limit(usage.MaxItems),
+			left(usage.Items[item], usage.MaxItems)})
+	}
+	table.Append([]string{"Binary size", "", limit(usage.MaxBinarySize), ""})
+	table.Render()
+	return nil
+}
+
+// showActivity prints the audit log of the user in a cute table page by page, the most recent events first
+func (svc *LocalService) showActivity() error {
+	var cursor string
+	for {
+		page, err := svc.Api.GetAuditLog(cursor)
+		if err != nil {
+			return err
+		}
+		if len(page.Events) == 0 {
+			fmt.Println("No activity recorded yet")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Time", "Action", "Item", "Description", "IP", "User agent"})
+		for _, event := range page.Events {
+			table.Append([]string{event.CreatedAt.Local().Format(dateTimeLayout), event.Action, event.Item,
+				event.Description, event.IP, event.UserAgent})
+		}
+		table.Render()
+
+		if page.Next == "" {
+			return nil
+		}
+		choice := svc.getAnswer("To see the earlier activity type more\notherwise type exit")
+		if choice != "more" {
+			return nil
+		}
+		cursor = page.Next
+	}
+}
+
+// setUpTwoFactor walks the user through enabling two-factor authentication, or turning it off if it is enabled
+func (svc *LocalService) setUpTwoFactor() error {
+	enrollment, err := svc.Api.EnrollTwoFactor()
+	if errors.Is(err, ErrAlreadyExists) {
+	disable:
+		code := svc.getAnswer("Two-factor authentication is on. If you want to turn it off enter a code " +
+			"from your authenticator app or one of your recovery codes\notherwise type exit")
+		if code == "exit" {
+			return nil
+		}
+		err = svc.Api.DisableTwoFactor(code)
+		if errors.Is(err, ErrInvalidCode) {
+			fmt.Println("Wrong code, try again")
+			goto disable
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("Two-factor authentication is off")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Add this secret to your authenticator app: ", enrollment.Secret)
+	fmt.Println("or set it up with this URI: ", enrollment.URI)
+verify:
+	code := svc.getAnswer("Enter the code from your authenticator app to turn two-factor authentication on\n" +
+		"otherwise type exit")
+	if code == "exit" {
+		return nil
+	}
+	recoveryCodes, err := svc.Api.VerifyTwoFactor(code)
+	if errors.Is(err, ErrInvalidCode) {
+		fmt.Println("Wrong code, try again")
+		goto verify
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Two-factor authentication is on. Keep these recovery codes somewhere safe, " +
+		"each of them lets you in once if you lose your authenticator:")
+	for _, recoveryCode := range recoveryCodes {
+		fmt.Println(recoveryCode)
+	}
+	return nil
+}
+
+// changePassword asks for the current password and the new one and changes the password
+func (svc *LocalService) changePassword() error {
+	oldPassword := svc.getAnswer("Enter your current password")
+	newPassword := svc.getAnswer("Enter the new password")
+	if newPassword == "" {
+		fmt.Println("The password can't be empty, nothing has changed")
+		return nil
+	}
+	if svc.getAnswer("Enter the new password once again") != newPassword {
+		fmt.Println("The passwords don't match, nothing has changed")
+		return nil
+	}
+
+	fmt.Println("Re-encrypting all your secrets, please wait")
+	err := svc.ChangePassword(oldPassword, newPassword)
+	if errors.Is(err, ErrOldData) {
+		fmt.Println("Your secrets have changed on another device meanwhile, nothing has changed, please try again")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Password changed, your other devices are signed out and have to sign in with the new one")
+	return nil
+}
+
+// ChangePassword changes the password of the user. The secrets are encrypted with the key derived from the password,
+// so every one of them is taken from the remote, decrypted with the old key and encrypted with the new one.
+// The remote replaces all of them along with the password at once, so no secret is ever left encrypted
+// with the old key. ErrOldData is returned if the secrets have changed on the remote meanwhile.
+func (svc *LocalService) ChangePassword(oldPassword, newPassword string) error {
+	if tools.GenerateKey(oldPassword) != svc.key {
+		return ErrInvalidCredentials
+	}
+	// the local changes are sent first, so that they are re-encrypted along with the rest
+	err := svc.UpdateAll()
+	if err != nil {
+		return err
+	}
+
+	svc.syncing.Lock()
+	newKey := tools.GenerateKey(newPassword)
+	change, err := svc.reencryptAll(newKey)
+	if err == nil {
+		change.OldPassword = oldPassword
+		change.NewPassword = newPassword
+		err = svc.Api.ChangePassword(change)
+	}
+	if err == nil {
+		svc.key = newKey
+		// every secret is compared once again, so that the local ones are replaced with the re-encrypted ones
+		err = svc.storage.StoreCursor("")
+	}
+	svc.syncing.Unlock()
+	if err != nil {
+		return err
+	}
+	return svc.UpdateAll()
+}
+
+// exportVault asks for path where to save the archive with all the secrets and downloads it
+func (svc *LocalService) exportVault() error {
+	path := svc.getAnswer("Please enter a path to folder where you want to save the archive")
+
+	err := os.MkdirAll(path, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	path += "/gophkeeper-export.zip"
+	err = svc.ExportVault(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("All your secrets are saved to %s, they stay encrypted with your password\n", path)
+	return nil
+}
+
+// ExportVault downloads every secret of the user from the remote as a zip archive to a file,
+// the archive is streamed to the file as it is, so it is never held in memory as a whole
+func (svc *LocalService) ExportVault(path string) error {
+	archive, err := svc.Api.ExportVault()
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, archive)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a part of the archive is of no use
+		_ = os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// deleteAccount asks for the password and for a confirmation and deletes the account,
+// it reports whether the account is gone
+func (svc *LocalService) deleteAccount() (bool, error) {
+	fmt.Println("The account is deleted along with all your secrets on every device, it can't be undone")
+	password := svc.getAnswer("Enter your password")
+	if svc.getAnswer("Type 'delete' to confirm") != "delete" {
+		fmt.Println("Nothing has changed")
+		return false, nil
+	}
+
+	err := svc.DeleteAccount(password)
+	if err != nil {
+		return false, err
+	}
+	fmt.Println("Your account has been deleted, farewell")
+	return true, nil
+}
+
+// DeleteAccount deletes the account of the user along with all the data on the remote
+// and the local copy of the secrets, the user is signed out then
+func (svc *LocalService) DeleteAccount(password string) error {
+	err := svc.Api.DeleteAccount(password)
+	if err != nil {
+		return err
+	}
+	svc.key = ""
+	svc.login = ""
+	svc.closeEvents()
+	return svc.storage.ClearAll()
+}
+
+// reencryptAll takes every secret of the user from the remote and encrypts it with the new key.
+// The chunked binaries are uploaded to the remote right away, so that none of them is held in memory as a whole,
+// the uploads are completed by the remote along with the change of the password.
+func (svc *LocalService) reencryptAll(newKey string) (service.PasswordChange, error) {
+	changes, err := svc.Api.Sync("")
+	if err != nil {
+		return service.PasswordChange{}, fmt.Errorf("sync: %w", err)
+	}
+	change := service.PasswordChange{Cursor: changes.Cursor}
+	// the re-encrypted secrets are newer than the ones stored on every device
+	now := time.Now()
+
+	for _, logoPass := range changes.LogoPasses {
+		if logoPass.DeletedAt.Valid {
+			continue
+		}
+		logoPass.SecretLogin, err = svc.reencrypt(logoPass.SecretLogin, newKey)
+		if err != nil {
+			return change, err
+		}
+		logoPass.SecretPass, err = svc.reencrypt(logoPass.SecretPass, newKey)
+		if err != nil {
+			return change, err
+		}
+		logoPass.UpdatedAt = now
+		change.LogoPasses = append(change.LogoPasses, logoPass)
+	}
+	for _, text := range changes.Texts {
+		if text.DeletedAt.Valid {
+			continue
+		}
+		text.Text, err = svc.reencrypt(text.Text, newKey)
+		if err != nil {
+			return change, err
+		}
+		text.UpdatedAt = now
+		change.Texts = append(change.Texts, text)
+	}
+	for _, card := range changes.CreditCards {
+		if card.DeletedAt.Valid {
+			continue
+		}
+		card.Holder, err = svc.reencrypt(card.Holder, newKey)
+		if err != nil {
+			return change, err
+		}
+		card.DueDate, err = svc.reencrypt(card.DueDate, newKey)
+		if err != nil {
+			return change, err
+		}
+		card.CVV, err = svc.reencrypt(card.CVV, newKey)
+		if err != nil {
+			return change, err
+		}
+		card.UpdatedAt = now
+		change.CreditCards = append(change.CreditCards, card)
+	}
+	for _, binary := range changes.Binaries {
+		if binary.DeletedAt.Valid {
+			continue
+		}
+		binary.UpdatedAt = now
+		if binary.ChunkCount > 0 {
+			upload, err := svc.reencryptChunks(binary, newKey)
+			if err != nil {
+				return change, fmt.Errorf("re-encrypt %s: %w", binary.Description, err)
+			}
+			change.Uploads = append(change.Uploads, upload)
+			continue
+		}
+		// binaries uploaded before chunked transfer was introduced are stored as a whole
+		whole, err := svc.Api.GetBinary(binary)
+		if err != nil {
+			return change, err
+		}
+		binary.Binary, err = svc.reencrypt(whole.Binary, newKey)
+		if err != nil {
+			return change, err
+		}
+		change.Binaries = append(change.Binaries, binary)
+	}
+
+	versions, err := svc.Api.GetHistory("", 0)
+	if err != nil && !errors.Is(err, ErrEmpty) {
+		return change, fmt.Errorf("history: %w", err)
+	}
+	for _, version := range versions {
+		version, err = svc.reencryptVersion(version, newKey)
+		if err != nil {
+			return change, err
+		}
+		change.Versions = append(change.Versions, version)
+	}
+
+	keys, err := svc.Api.GetKeyPair("")
+	if err != nil && !errors.Is(err, ErrEmpty) {
+		return change, fmt.Errorf("key pair: %w", err)
+	}
+	if err == nil {
+		change.PrivateKey, err = svc.reencrypt(keys.PrivateKey, newKey)
+		if err != nil {
+			return change, err
+		}
+	}
+	return change, nil
+}
+
+// reencryptVersion encrypts the secret of the version taken from the history with the new key
+func (svc *LocalService) reencryptVersion(version service.SecretVersion, newKey string) (service.SecretVersion, error) {
+	var err error
+	switch {
+	case version.LogoPass != nil:
+		logoPass := *version.LogoPass
+		logoPass.SecretLogin, err = svc.reencrypt(logoPass.SecretLogin, newKey)
+		if err != nil {
+			return version, err
+		}
+		logoPass.SecretPass, err = svc.reencrypt(logoPass.SecretPass, newKey)
+		version.LogoPass = &logoPass
+	case version.Text != nil:
+		text := *version.Text
+		text.Text, err = svc.reencrypt(text.Text, newKey)
+		version.Text = &text
+	case version.CreditCard != nil:
+		card := *version.CreditCard
+		card.Holder, err = svc.reencrypt(card.Holder, newKey)
+		if err != nil {
+			return version, err
+		}
+		card.DueDate, err = svc.reencrypt(card.DueDate, newKey)
+		if err != nil {
+			return version, err
+		}
+		card.CVV, err = svc.reencrypt(card.CVV, newKey)
+		version.CreditCard = &card
+	}
+	return version, err
+}
+
+// reencrypt decrypts the secret with the key of the user and encrypts it with the new key
+func (svc *LocalService) reencrypt(secret string, newKey string) (string, error) {
+	data, err := tools.DecryptString(secret, svc.key)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrCorruptedData, err)
+	}
+	return tools.EncryptString(data, newKey)
+}
+
+// reencryptChunks uploads the chunked binary encrypted with the new key chunk by chunk and returns the ID
+// of the upload, it is left for the remote to complete. The binary is downloaded in the same way
+// DownloadBinaryFile does, so a download interrupted by a disconnect is resumed from the last complete chunk.
+func (svc *LocalService) reencryptChunks(binary service.BinaryData, newKey string) (string, error) {
+	session, err := svc.Api.StartBinaryUpload(service.UploadSession{Description: binary.Description,
+		ChunkCount: binary.ChunkCount, Overwrite: true, Revision: binary.Revision, UpdatedAt: binary.UpdatedAt})
+	if err != nil {
+		return "", err
+	}
+
+	uploader := &chunkUploader{api: svc.Api, session: session, key: newKey}
+	var offset int64
+	err = retry(func() error {
+		consumed, err := svc.downloadFrom(binary, offset, uploader)
+		offset += consumed
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return session.ID, nil
+}
+
+// chunkUploader uploads every piece of data written to it as the next part of the upload encrypted with the key.
+// downloadFrom writes a single chunk at a time, so the chunks of the binary are kept as they are.
+type chunkUploader struct {
+	api     Api
+	session service.UploadSession
+	key     string
+	part    int
+}
+
+// Write encrypts the data and uploads it as the next part
+func (uploader *chunkUploader) Write(data []byte) (int, error) {
+	chunk, err := tools.EncryptBytes(data, uploader.key)
+	if err != nil {
+		return 0, err
+	}
+	err = retry(func() error {
+		return uploader.api.UploadBinaryChunk(uploader.session, uploader.part, chunk)
+	})
+	if err != nil {
+		return 0, err
+	}
+	uploader.part++
+	return len(data), nil
+}
+
+// deviceName names the device for the list of the signed in devices of the user
+func deviceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "gophkeeper cli"
+	}
+	return "gophkeeper cli on " + hostname
+}
+
+// UpdateAll upon called, tries to merge and update all user's info stored locally and remotely.
+// Only the changes made on the remote since the cursor of the last sync are requested,
+// everything is compared if there is no cursor yet or some local change failed to reach the remote.
 func (svc *LocalService) UpdateAll() error {
 	// means no auth yet, so no update required
 	if svc.key == "" {
 		return nil
 	}
+	svc.syncing.Lock()
+	defer svc.syncing.Unlock()
 
-	listLogoPasses, err := svc.Api.GetLogoPasses()
+	cursor, err := svc.storage.GetCursor()
 	if err != nil {
-		return fmt.Errorf("get logopass: %w", err)
+		return fmt.Errorf("get sync cursor: %w", err)
+	}
+	changes, err := svc.Api.Sync(cursor)
+	if errors.Is(err, ErrCorruptedData) && cursor != "" {
+		// the remote doesn't know the cursor, so everything is compared once again
+		changes, err = svc.Api.Sync("")
 	}
-	updLogoPasses, err := svc.storage.StoreLogoPasses(listLogoPasses)
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	updLogoPasses, err := svc.storage.StoreLogoPasses(changes.LogoPasses, changes.Complete)
 	if err != nil {
 		return fmt.Errorf("store logopass: %w", err)
 	}
 	for _, logoPass := range updLogoPasses {
-		err = svc.Api.UploadLogoPass(logoPass)
+		var revision int64
+		if logoPass.DeletedAt.Valid {
+			revision, err = svc.Api.DeleteLogoPass(logoPass)
+		} else {
+			revision, err = svc.Api.UploadLogoPass(logoPass)
+		}
+		if errors.Is(err, ErrEmpty) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
+		logoPass.Revision = revision
+		err = svc.storage.UpdateLogoPass(logoPass)
+		if err != nil {
+			return fmt.Errorf("store logopass: %w", err)
+		}
 	}
 
-	listTexts, err := svc.Api.GetTexts()
+	updTexts, err := svc.storage.StoreTexts(changes.Texts, changes.Complete)
 	if err != nil {
-		return fmt.Errorf("get texts: %w", err)
-	}
-	updTexts, err := svc.storage.StoreTexts(listTexts)
-	if err != nil {
-		return fmt.Errorf("store cards: %w", err)
+		return fmt.Errorf("store texts: %w", err)
 	}
 	for _, text := range updTexts {
-		err = svc.Api.UploadText(text)
+		var revision int64
+		if text.DeletedAt.Valid {
+			revision, err = svc.Api.DeleteText(text)
+		} else {
+			revision, err = svc.Api.UploadText(text)
+		}
+		if errors.Is(err, ErrEmpty) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
+		text.Revision = revision
+		err = svc.storage.UpdateText(text)
+		if err != nil {
+			return fmt.Errorf("store texts: %w", err)
+		}
 	}
 
-	listCreditCards, err := svc.Api.GetCreditCards()
+	updCreditCards, err := svc.storage.StoreCreditCards(changes.CreditCards, changes.Complete)
 	if err != nil {
-		return fmt.Errorf("get cards: %w", err)
+		return fmt.Errorf("store cards: %w", err)
+	}
+	for _, card := range updCreditCards {
+		var revision int64
+		if card.DeletedAt.Valid {
+			revision, err = svc.Api.DeleteCreditCard(card)
+		} else {
+			revision, err = svc.Api.UploadCreditCard(card)
+		}
+		if errors.Is(err, ErrEmpty) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		card.Revision = revision
+		err = svc.storage.UpdateCreditCard(card)
+		if err != nil {
+			return fmt.Errorf("store cards: %w", err)
+		}
 	}
-	updCreditCards, err := svc.storage.StoreCreditCards(listCreditCards)
+
+	updBinaries, err := svc.storage.StoreBinaries(changes.Binaries)
 	if err != nil {
-		return fmt.Errorf("store texts: %w", err)
+		return fmt.Errorf("store binarylist: %w", err)
 	}
-	for _, card := range updCreditCards {
-		err = svc.Api.UploadCreditCard(card)
+	for _, binary := range updBinaries {
+		revision, err := svc.Api.DeleteBinary(binary)
+		if errors.Is(err, ErrEmpty) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
+		binary.Revision = revision
+		err = svc.storage.UpdateBinaryList(binary)
+		if err != nil {
+			return fmt.Errorf("store binarylist: %w", err)
+		}
 	}
 
-	binaryList, err := svc.Api.GetBinaryList()
+	// the cursor is moved on only once everything newer here has reached the remote
+	err = svc.storage.StoreCursor(changes.Cursor)
 	if err != nil {
-		return fmt.Errorf("get binarylist: %w", err)
+		return fmt.Errorf("store sync cursor: %w", err)
 	}
-	err = svc.storage.StoreBinaries(binaryList)
+	err = svc.updateShares()
 	if err != nil {
-		return fmt.Errorf("store binarylist: %w", err)
+		return fmt.Errorf("update shares: %w", err)
 	}
-
 	return nil
 }
 
+// keepPending makes the next UpdateAll compare every entry with the remote, as the local change
+// that failed to reach the remote is not going to be in any of its deltas
+func (svc *LocalService) keepPending(err error) error {
+	cursorErr := svc.storage.StoreCursor("")
+	if cursorErr != nil {
+		return fmt.Errorf("%w, reset sync cursor: %s", err, cursorErr)
+	}
+	return err
+}
+
 // showLogoPasses prints all available logo-pass pairs in a cute table and asks for further instructions
 // the options are:
 //   - update certain data
+//   - see the history of certain data and restore a previous version of it
+//   - share certain data with another user
 //   - exit back to the choice of available actions
 func (svc *LocalService) showLogoPasses() error {
 updateLogoPass:
@@ -263,10 +938,45 @@ updateLogoPass:
 	}
 	table.Render()
 
-	choice := svc.getAnswer("If you want to update any pair enter it's ID\notherwise type exit")
+	svc.showSharedWithUser(storage.ItemLogoPass)
+
+	choice := svc.getAnswer("If you want to update any pair enter it's ID\nto see the history of a pair type history\n" +
+		"to share a pair type share\notherwise type exit")
 	switch choice {
 	case "exit":
 		return nil
+	case "history":
+		id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+		if err != nil {
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto updateLogoPass
+		}
+		err = svc.showHistory(storage.ItemLogoPass, uint(id))
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto updateLogoPass
+	case "share":
+		id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+		if err != nil {
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto updateLogoPass
+		}
+		key := ""
+		for _, logoPass := range listLogoPasses {
+			if logoPass.ID == uint(id) {
+				key = logoPass.Description
+			}
+		}
+		if key == "" {
+			fmt.Println("There is no such ID, try again")
+			goto updateLogoPass
+		}
+		err = svc.shareEntry(storage.ItemLogoPass, key)
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto updateLogoPass
 	default:
 		var updLogoPass service.LogoPass
 		updLogoPass.Overwrite = true
@@ -280,19 +990,161 @@ updateLogoPass:
 		for _, logoPass := range listLogoPasses {
 			if updLogoPass.ID == logoPass.ID {
 				updLogoPass.Description = logoPass.Description
+				updLogoPass.Revision = logoPass.Revision
+			}
+		}
+		if updLogoPass.Description == "" {
+			fmt.Println("There is no such ID, try again")
+			goto updateLogoPass
+		}
+		err = svc.PutLogoPass(updLogoPass)
+		if err != nil {
+			fmt.Println(err)
+			goto updateLogoPass
+		}
+	}
+	return nil
+}
+
+// showHistory prints the previous versions of the secret in a cute table and offers to restore one of them
+func (svc *LocalService) showHistory(item string, id uint) error {
+	versions, err := svc.Api.GetHistory(item, id)
+	if errors.Is(err, ErrEmpty) {
+		fmt.Println("There are no previous versions of it")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	switch item {
+	case storage.ItemLogoPass:
+		table.SetHeader([]string{"Version", "login", "Password", "Description", "Replaced"})
+	case storage.ItemText:
+		table.SetHeader([]string{"Version", "Description", "Text", "Replaced"})
+	case storage.ItemCreditCard:
+		table.SetHeader([]string{"Version", "Number", "Holder", "Due date", "CVV", "Description", "Replaced"})
+	}
+	for _, version := range versions {
+		row, err := svc.versionRow(version)
+		if err != nil {
+			return err
+		}
+		table.Append(row)
+	}
+	table.Render()
+
+	choice := svc.getAnswer("If you want to restore any version enter it's number\notherwise type exit")
+	if choice == "exit" {
+		return nil
+	}
+	versionID, err := strconv.ParseUint(choice, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid version: %s", choice)
+	}
+	for _, version := range versions {
+		if version.ID == uint(versionID) {
+			return svc.RestoreVersion(version)
+		}
+	}
+	return fmt.Errorf("there is no version %d", versionID)
+}
+
+// versionRow decrypts the secret of the version and returns the row of the history table for it
+func (svc *LocalService) versionRow(version service.SecretVersion) ([]string, error) {
+	var err error
+	row := []string{strconv.FormatUint(uint64(version.ID), 10)}
+	switch {
+	case version.LogoPass != nil:
+		logoPass := *version.LogoPass
+		logoPass.SecretLogin, err = tools.DecryptString(logoPass.SecretLogin, svc.key)
+		if err != nil {
+			return nil, err
+		}
+		logoPass.SecretPass, err = tools.DecryptString(logoPass.SecretPass, svc.key)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, logoPass.SecretLogin, logoPass.SecretPass, logoPass.Description)
+	case version.Text != nil:
+		text := *version.Text
+		text.Text, err = tools.DecryptString(text.Text, svc.key)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, text.Description, text.Text)
+	case version.CreditCard != nil:
+		card := *version.CreditCard
+		card.Holder, err = tools.DecryptString(card.Holder, svc.key)
+		if err != nil {
+			return nil, err
+		}
+		card.DueDate, err = tools.DecryptString(card.DueDate, svc.key)
+		if err != nil {
+			return nil, err
+		}
+		card.CVV, err = tools.DecryptString(card.CVV, svc.key)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, card.Number, card.Holder, card.DueDate, card.CVV, card.Description)
+	}
+	return append(row, version.CreatedAt.Local().Format(dateTimeLayout)), nil
+}
+
+// RestoreVersion makes the version of the secret the current one on the remote and syncs the local storage.
+// The version is restored over the secret as it is stored locally, ErrOldData is returned
+// if the remote one has been changed since the last sync.
+func (svc *LocalService) RestoreVersion(version service.SecretVersion) error {
+	var err error
+	version.SecretRevision, err = svc.secretRevision(version.Item, version.ItemID)
+	if err != nil {
+		return err
+	}
+	err = svc.Api.RestoreVersion(version)
+	if err != nil {
+		return err
+	}
+	return svc.UpdateAll()
+}
+
+// secretRevision returns the revision of the secret of the kind named by item with the ID given
+// as it is stored locally, 0 if there is no such secret
+func (svc *LocalService) secretRevision(item string, id uint) (int64, error) {
+	switch item {
+	case storage.ItemLogoPass:
+		logoPasses, err := svc.storage.GetLogoPasses()
+		if err != nil {
+			return 0, err
+		}
+		for _, logoPass := range logoPasses {
+			if logoPass.ID == id {
+				return logoPass.Revision, nil
 			}
 		}
-		if updLogoPass.Description == "" {
-			fmt.Println("There is no such ID, try again")
-			goto updateLogoPass
+	case storage.ItemText:
+		texts, err := svc.storage.GetTexts()
+		if err != nil {
+			return 0, err
 		}
-		err = svc.PutLogoPass(updLogoPass)
+		for _, text := range texts {
+			if text.ID == id {
+				return text.Revision, nil
+			}
+		}
+	case storage.ItemCreditCard:
+		cards, err := svc.storage.GetCreditCards()
 		if err != nil {
-			fmt.Println(err)
-			goto updateLogoPass
+			return 0, err
+		}
+		for _, card := range cards {
+			if card.ID == id {
+				return card.Revision, nil
+			}
 		}
 	}
-	return nil
+	return 0, nil
 }
 
 // PutLogoPass asks user to enter all the info needed to create an instance of service.LogoPass in storage
@@ -320,17 +1172,21 @@ func (svc *LocalService) PutLogoPass(logoPass service.LogoPass) error {
 		return err
 	}
 	fmt.Println("Successfully saved to local storage")
-	err = svc.Api.UploadLogoPass(logoPass)
+	logoPass.Revision, err = svc.Api.UploadLogoPass(logoPass)
 	if err != nil {
-		return err
+		return svc.keepPending(err)
 	}
 	fmt.Println("Successfully saved to remote")
-	return nil
+	// the revision the remote has stored the secret at is the one the next change is made to
+	logoPass.Overwrite = true
+	return svc.storage.UpdateLogoPass(logoPass)
 }
 
 // showTexts prints all available secret texts in a cute table and asks for further instructions
 // the options are:
 //   - update certain data
+//   - see the history of certain data and restore a previous version of it
+//   - share certain data with another user
 //   - exit back to the choice of available actions
 func (svc *LocalService) showTexts() error {
 updateText:
@@ -353,10 +1209,45 @@ updateText:
 	}
 	table.Render()
 
-	choice := svc.getAnswer("If you want to update any text enter it's ID\notherwise type exit")
+	svc.showSharedWithUser(storage.ItemText)
+
+	choice := svc.getAnswer("If you want to update any text enter it's ID\nto see the history of a text type history\n" +
+		"to share a text type share\notherwise type exit")
 	switch choice {
 	case "exit":
 		return nil
+	case "history":
+		id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+		if err != nil {
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto updateText
+		}
+		err = svc.showHistory(storage.ItemText, uint(id))
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto updateText
+	case "share":
+		id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+		if err != nil {
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto updateText
+		}
+		key := ""
+		for _, text := range listTexts {
+			if text.ID == uint(id) {
+				key = text.Description
+			}
+		}
+		if key == "" {
+			fmt.Println("There is no such ID, try again")
+			goto updateText
+		}
+		err = svc.shareEntry(storage.ItemText, key)
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto updateText
 	default:
 		var updText service.TextData
 		updText.Overwrite = true
@@ -370,6 +1261,7 @@ updateText:
 		for _, text := range listTexts {
 			if updText.ID == text.ID {
 				updText.Description = text.Description
+				updText.Revision = text.Revision
 			}
 		}
 		if updText.Description == "" {
@@ -405,17 +1297,21 @@ func (svc *LocalService) PutText(text service.TextData) error {
 		return err
 	}
 	fmt.Println("Successfully saved to local storage")
-	err = svc.Api.UploadText(text)
+	text.Revision, err = svc.Api.UploadText(text)
 	if err != nil {
-		return err
+		return svc.keepPending(err)
 	}
 	fmt.Println("Successfully saved to remote")
-	return nil
+	// the revision the remote has stored the secret at is the one the next change is made to
+	text.Overwrite = true
+	return svc.storage.UpdateText(text)
 }
 
 // showCreditCards prints all available credit cards in a cute table and asks for further instructions
 // the options are:
 //   - update certain data
+//   - see the history of certain data and restore a previous version of it
+//   - share certain data with another user
 //   - exit back to the choice of available actions
 func (svc *LocalService) showCreditCards() error {
 updateCard:
@@ -448,10 +1344,45 @@ updateCard:
 	}
 	table.Render()
 
-	choice := svc.getAnswer("If you want to update any credit card info enter it's ID\notherwise type exit")
+	svc.showSharedWithUser(storage.ItemCreditCard)
+
+	choice := svc.getAnswer("If you want to update any credit card info enter it's ID\nto see the history of a credit card type history\n" +
+		"to share a credit card type share\notherwise type exit")
 	switch choice {
 	case "exit":
 		return nil
+	case "history":
+		id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+		if err != nil {
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto updateCard
+		}
+		err = svc.showHistory(storage.ItemCreditCard, uint(id))
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto updateCard
+	case "share":
+		id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+		if err != nil {
+			fmt.Println("I feel you bro, but i just dont get it, please try again")
+			goto updateCard
+		}
+		key := ""
+		for _, card := range listCreditCards {
+			if card.ID == uint(id) {
+				key = card.Number
+			}
+		}
+		if key == "" {
+			fmt.Println("There is no such ID, try again")
+			goto updateCard
+		}
+		err = svc.shareEntry(storage.ItemCreditCard, key)
+		if err != nil {
+			fmt.Println(err)
+		}
+		goto updateCard
 	default:
 		var updCreditCard service.CreditCard
 		updCreditCard.Overwrite = true
@@ -465,6 +1396,7 @@ updateCard:
 		for _, card := range listCreditCards {
 			if updCreditCard.ID == card.ID {
 				updCreditCard.Number = card.Number
+				updCreditCard.Revision = card.Revision
 			}
 		}
 		if updCreditCard.Number == "" {
@@ -510,12 +1442,14 @@ func (svc *LocalService) PutCreditCard(creditCard service.CreditCard) error {
 		return err
 	}
 	fmt.Println("Successfully saved to local storage")
-	err = svc.Api.UploadCreditCard(creditCard)
+	creditCard.Revision, err = svc.Api.UploadCreditCard(creditCard)
 	if err != nil {
-		return err
+		return svc.keepPending(err)
 	}
 	fmt.Println("Successfully saved to remote")
-	return nil
+	// the revision the remote has stored the secret at is the one the next change is made to
+	creditCard.Overwrite = true
+	return svc.storage.UpdateCreditCard(creditCard)
 }
 
 // showCreditCards prints all available binaries in a cute table and asks for further instructions
@@ -554,6 +1488,7 @@ updateBinary:
 		for _, binary := range binaryList {
 			if updBinary.ID == binary.ID {
 				updBinary.Description = binary.Description
+				updBinary.Revision = binary.Revision
 			}
 		}
 		if updBinary.Description == "" {
@@ -593,26 +1528,78 @@ func (svc *LocalService) putBinary(binary service.BinaryData) error {
 	}
 	path := svc.getAnswer("Please, enter a path to upload your binary data")
 
-	content, err := os.ReadFile(path)
+	return svc.UploadBinaryFile(binary, path)
+}
+
+// UploadBinaryFile uploads a file to the remote in chunks, each of them encrypted separately,
+// so that the file is never read into memory as a whole.
+// An upload interrupted by a disconnect is resumed from the parts already received, even after a restart of the cli.
+func (svc *LocalService) UploadBinaryFile(binary service.BinaryData, path string) error {
+	file, err := os.Open(path)
 	if err != nil {
 		fmt.Println("Error reading file: ", err)
 		return err
 	}
-	binary.Binary = string(content)
+	defer file.Close()
 
-	binary.Binary, err = tools.EncryptString(binary.Binary, svc.key)
+	info, err := file.Stat()
 	if err != nil {
 		return err
 	}
-	binary.UpdatedAt = time.Now()
 
-	err = svc.Api.UploadBinary(binary)
+	upload, err := svc.getUpload(binary, path, info)
+	if err != nil {
+		return err
+	}
+
+	received := make(map[int]bool)
+	for _, part := range upload.Session.Received {
+		received[part] = true
+	}
+
+	buffer := make([]byte, binaryChunkSize)
+	for part := 0; part < upload.Session.ChunkCount; part++ {
+		if received[part] {
+			continue
+		}
+
+		n, err := file.ReadAt(buffer, int64(part)*binaryChunkSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		chunk, err := tools.EncryptBytes(buffer[:n], svc.key)
+		if err != nil {
+			return err
+		}
+
+		err = retry(func() error {
+			return svc.Api.UploadBinaryChunk(upload.Session, part, chunk)
+		})
+		if err != nil {
+			return fmt.Errorf("upload part %d, try again later to resume: %w", part+1, err)
+		}
+		fmt.Printf("Uploaded %d of %d parts\n", part+1, upload.Session.ChunkCount)
+	}
+
+	var revision int64
+	err = retry(func() error {
+		revision, err = svc.Api.CompleteBinaryUpload(upload.Session)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 	fmt.Println("Successfully uploaded to remote")
 
+	err = svc.storage.RemoveUpload(binary.Description)
+	if err != nil {
+		return err
+	}
+
 	binary.Binary = ""
+	binary.ChunkCount = upload.Session.ChunkCount
+	binary.UpdatedAt = upload.Session.UpdatedAt
+	binary.Revision = revision
 	err = svc.storage.UpdateBinaryList(binary)
 	if err != nil {
 		return err
@@ -621,6 +1608,46 @@ func (svc *LocalService) putBinary(binary service.BinaryData) error {
 	return nil
 }
 
+// getUpload resumes an unfinished upload of the same file if there is one, otherwise starts a new one
+func (svc *LocalService) getUpload(binary service.BinaryData, path string, info os.FileInfo) (PendingUpload, error) {
+	upload, err := svc.storage.GetUpload(binary.Description)
+	if err == nil && upload.Path == path && upload.ModTime.Equal(info.ModTime()) {
+		upload.Session, err = svc.Api.GetBinaryUpload(upload.Session)
+		if err == nil {
+			fmt.Printf("Resuming the upload, %d of %d parts are already there\n",
+				len(upload.Session.Received), upload.Session.ChunkCount)
+			return upload, nil
+		}
+	}
+	if err != nil && !errors.Is(err, ErrEmpty) {
+		return upload, err
+	}
+
+	chunkCount := int((info.Size() + binaryChunkSize - 1) / binaryChunkSize)
+	if chunkCount == 0 {
+		// an empty file is still stored as a single encrypted chunk
+		chunkCount = 1
+	}
+
+	var session service.UploadSession
+	session.Description = binary.Description
+	session.ChunkCount = chunkCount
+	session.Overwrite = binary.Overwrite
+	session.Revision = binary.Revision
+	session.UpdatedAt = time.Now()
+	session, err = svc.Api.StartBinaryUpload(session)
+	if err != nil {
+		return upload, err
+	}
+
+	upload = PendingUpload{Session: session, Path: path, ModTime: info.ModTime()}
+	err = svc.storage.StoreUpload(upload)
+	if err != nil {
+		return upload, err
+	}
+	return upload, nil
+}
+
 // downloadBinary asks for path where to save a file which is to be downloaded from remote and calls for api service
 func (svc *LocalService) downloadBinary(binary service.BinaryData) error {
 	path := svc.getAnswer("Please enter a path to folder where you want to save a binary")
@@ -632,6 +1659,12 @@ func (svc *LocalService) downloadBinary(binary service.BinaryData) error {
 
 	name := svc.getAnswer("Please enter a name for a file")
 
+	err = svc.DownloadBinaryFile(binary, path+"/"+name)
+	if !errors.Is(err, ErrEmpty) {
+		return err
+	}
+
+	// binaries uploaded before chunked transfer was introduced are stored as a whole
 	binary, err = svc.Api.GetBinary(binary)
 	if err != nil {
 		return err
@@ -648,3 +1681,359 @@ func (svc *LocalService) downloadBinary(binary service.BinaryData) error {
 	}
 	return nil
 }
+
+// DownloadBinaryFile downloads a chunked binary to a file decrypting it chunk by chunk,
+// so that the binary is never held in memory as a whole.
+// A download interrupted by a disconnect is resumed from the last complete chunk.
+func (svc *LocalService) DownloadBinaryFile(binary service.BinaryData, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var offset int64
+	err = retry(func() error {
+		consumed, err := svc.downloadFrom(binary, offset, file)
+		offset += consumed
+		return err
+	})
+	if errors.Is(err, ErrEmpty) {
+		// nothing has been written, so there is no need for an empty file
+		file.Close()
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// downloadFrom downloads a chunked binary starting at offset, writes decrypted chunks to the file
+// and returns the number of bytes of encrypted content consumed
+func (svc *LocalService) downloadFrom(binary service.BinaryData, offset int64, file io.Writer) (int64, error) {
+	binary, body, err := svc.Api.DownloadBinary(binary, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	buffer := make([]byte, binary.ChunkSize)
+	var consumed int64
+	for offset+consumed < binary.Size {
+		size := binary.Size - offset - consumed
+		if size > binary.ChunkSize {
+			size = binary.ChunkSize
+		}
+
+		_, err = io.ReadFull(body, buffer[:size])
+		if err != nil {
+			return consumed, err
+		}
+		data, err := tools.DecryptBytes(buffer[:size], svc.key)
+		if err != nil {
+			return consumed, fmt.Errorf("%w: %s", ErrCorruptedData, err)
+		}
+		_, err = file.Write(data)
+		if err != nil {
+			return consumed, err
+		}
+		consumed += size
+	}
+	return consumed, nil
+}
+
+// retry calls fn until it succeeds giving up after binaryRetries attempts.
+// Errors that won't go away by themselves are returned right away.
+func retry(fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= binaryRetries; attempt++ {
+		err = fn()
+		var pathErr *os.PathError
+		if err == nil || errors.Is(err, ErrEmpty) || errors.Is(err, ErrAlreadyExists) ||
+			errors.Is(err, ErrCorruptedData) || errors.Is(err, ErrQuotaExceeded) || errors.As(err, &pathErr) {
+			return err
+		}
+		if attempt < binaryRetries {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	return err
+}
+
+// deleteEntry asks user what kind of entry is to be deleted and which one exactly
+func (svc *LocalService) deleteEntry() error {
+	choice := svc.getAnswer("What kind of entry are we deleting?\n" +
+		"Login password pair: type 1\n" +
+		"Text:                type 2\n" +
+		"Credit card:         type 3\n" +
+		"Binary:              type 4\n" +
+		"otherwise:           type exit")
+
+	switch choice {
+	case "1":
+		var logoPass service.LogoPass
+		logoPass.Description = svc.getAnswer("Please, enter description of the pair")
+		return svc.DeleteLogoPass(logoPass)
+	case "2":
+		var text service.TextData
+		text.Description = svc.getAnswer("Please, enter description of the text")
+		return svc.DeleteText(text)
+	case "3":
+		var creditCard service.CreditCard
+		creditCard.Number = svc.getAnswer("Please, enter card number")
+		return svc.DeleteCreditCard(creditCard)
+	case "4":
+		var binary service.BinaryData
+		binary.Description = svc.getAnswer("Please, enter description of the binary")
+		return svc.DeleteBinary(binary)
+	default:
+		return nil
+	}
+}
+
+// DeleteLogoPass turns a logo-pass pair into a tombstone locally and removes it from the remote.
+// If the remote is unavailable, the tombstone is sent later on by UpdateAll.
+func (svc *LocalService) DeleteLogoPass(logoPass service.LogoPass) error {
+	listLogoPasses, err := svc.storage.GetLogoPasses()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, existingLogoPass := range listLogoPasses {
+		if existingLogoPass.Description == logoPass.Description {
+			logoPass = existingLogoPass
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrEmpty
+	}
+
+	logoPass.SecretLogin = ""
+	logoPass.SecretPass = ""
+	logoPass.UpdatedAt = time.Now()
+	logoPass.DeletedAt = gorm.DeletedAt{Time: logoPass.UpdatedAt, Valid: true}
+	logoPass.Overwrite = true
+
+	err = svc.storage.UpdateLogoPass(logoPass)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Successfully deleted from local storage")
+	revision, err := svc.Api.DeleteLogoPass(logoPass)
+	if errors.Is(err, ErrEmpty) {
+		return nil
+	}
+	if err != nil {
+		return svc.keepPending(err)
+	}
+	fmt.Println("Successfully deleted from remote")
+	logoPass.Revision = revision
+	return svc.storage.UpdateLogoPass(logoPass)
+}
+
+// DeleteText turns a secret text into a tombstone locally and removes it from the remote.
+// If the remote is unavailable, the tombstone is sent later on by UpdateAll.
+func (svc *LocalService) DeleteText(text service.TextData) error {
+	listTexts, err := svc.storage.GetTexts()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, existingText := range listTexts {
+		if existingText.Description == text.Description {
+			text = existingText
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrEmpty
+	}
+
+	text.Text = ""
+	text.UpdatedAt = time.Now()
+	text.DeletedAt = gorm.DeletedAt{Time: text.UpdatedAt, Valid: true}
+	text.Overwrite = true
+
+	err = svc.storage.UpdateText(text)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Successfully deleted from local storage")
+	revision, err := svc.Api.DeleteText(text)
+	if errors.Is(err, ErrEmpty) {
+		return nil
+	}
+	if err != nil {
+		return svc.keepPending(err)
+	}
+	fmt.Println("Successfully deleted from remote")
+	text.Revision = revision
+	return svc.storage.UpdateText(text)
+}
+
+// DeleteCreditCard turns a credit card into a tombstone locally and removes it from the remote.
+// If the remote is unavailable, the tombstone is sent later on by UpdateAll.
+func (svc *LocalService) DeleteCreditCard(creditCard service.CreditCard) error {
+	listCreditCards, err := svc.storage.GetCreditCards()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, existingCard := range listCreditCards {
+		if existingCard.Number == creditCard.Number {
+			creditCard = existingCard
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrEmpty
+	}
+
+	creditCard.Holder = ""
+	creditCard.DueDate = ""
+	creditCard.CVV = ""
+	creditCard.Description = ""
+	creditCard.UpdatedAt = time.Now()
+	creditCard.DeletedAt = gorm.DeletedAt{Time: creditCard.UpdatedAt, Valid: true}
+	creditCard.Overwrite = true
+
+	err = svc.storage.UpdateCreditCard(creditCard)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Successfully deleted from local storage")
+	revision, err := svc.Api.DeleteCreditCard(creditCard)
+	if errors.Is(err, ErrEmpty) {
+		return nil
+	}
+	if err != nil {
+		return svc.keepPending(err)
+	}
+	fmt.Println("Successfully deleted from remote")
+	creditCard.Revision = revision
+	return svc.storage.UpdateCreditCard(creditCard)
+}
+
+// DeleteBinary turns a binary list entry into a tombstone locally and removes the binary from the remote.
+// If the remote is unavailable, the tombstone is sent later on by UpdateAll.
+func (svc *LocalService) DeleteBinary(binary service.BinaryData) error {
+	binaryList, err := svc.storage.GetBinaryList()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, existingBinary := range binaryList {
+		if existingBinary.Description == binary.Description {
+			binary = existingBinary
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrEmpty
+	}
+
+	binary.UpdatedAt = time.Now()
+	binary.DeletedAt = gorm.DeletedAt{Time: binary.UpdatedAt, Valid: true}
+	binary.Overwrite = true
+
+	err = svc.storage.UpdateBinaryList(binary)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Successfully deleted from local storage")
+	revision, err := svc.Api.DeleteBinary(binary)
+	if errors.Is(err, ErrEmpty) {
+		return nil
+	}
+	if err != nil {
+		return svc.keepPending(err)
+	}
+	fmt.Println("Successfully deleted from remote")
+	binary.Revision = revision
+	return svc.storage.UpdateBinaryList(binary)
+}
+
+// Watching reports whether the changes on the remote are being watched, so there is no need to poll for them
+func (svc *LocalService) Watching() bool {
+	return svc.watching.Load()
+}
+
+// WatchEvents syncs user's info every time the remote announces a change of it, until ctx is done.
+// The stream of events is reopened every eventsRetryDelay once it breaks, while the user is signed in.
+func (svc *LocalService) WatchEvents(ctx context.Context) {
+	for {
+		if svc.key != "" {
+			err := svc.watchEvents(ctx)
+			if err != nil {
+				log.Printf("watch events: %s", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryDelay):
+		}
+	}
+}
+
+// watchEvents opens the stream of events and syncs on each of them until the stream ends
+func (svc *LocalService) watchEvents(ctx context.Context) error {
+	events, err := svc.Api.Events()
+	if err != nil {
+		return fmt.Errorf("open events: %w", err)
+	}
+	svc.mu.Lock()
+	svc.events = events
+	svc.mu.Unlock()
+	defer svc.closeEvents()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			events.Close()
+		case <-done:
+		}
+	}()
+
+	svc.watching.Store(true)
+	defer svc.watching.Store(false)
+
+	// the changes made while the stream was closed are caught up with at once
+	err = svc.UpdateAll()
+	if err != nil {
+		log.Printf("update data from server: %s", err)
+	}
+	for {
+		event, err := events.Next()
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("next event: %w", err)
+		}
+		if event.CollectionID != 0 {
+			// the secrets of the collections are not kept locally, there is nothing to update
+			continue
+		}
+		log.Printf("Updating data from server, %s changed", event.Item)
+		err = svc.UpdateAll()
+		if err != nil {
+			log.Printf("update data from server: %s", err)
+		}
+	}
+}
+
+// closeEvents closes the stream of events being watched, if any
+func (svc *LocalService) closeEvents() {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.events != nil {
+		svc.events.Close()
+		svc.events = nil
+	}
+}
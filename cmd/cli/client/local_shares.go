@@ -0,0 +1,505 @@
+package client
+
+// Here is the sharing of the secrets with other users. Every user has a key pair, the private key is kept
+// on the remote encrypted with the key derived from the password. A secret is shared sealed with the public key
+// of the recipient, so the remote never learns it. The changes of the recipients of read-write shares are sealed
+// with the public key of the owner, and it is the client of the owner that takes them in during UpdateAll.
+
+import (
+	"errors"
+	"fmt"
+	"github.com/olekukonko/tablewriter"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"os"
+	"strconv"
+)
+
+// keyPair returns the key pair of the user with the private key decrypted. A new pair is made and stored
+// on the remote if the user has none yet.
+func (svc *LocalService) keyPair() (service.KeyPair, error) {
+	keys, err := svc.Api.GetKeyPair("")
+	if errors.Is(err, ErrEmpty) {
+		keys.PublicKey, keys.PrivateKey, err = tools.GenerateKeyPair()
+		if err != nil {
+			return keys, err
+		}
+		stored := keys
+		stored.PrivateKey, err = tools.EncryptString(keys.PrivateKey, svc.key)
+		if err != nil {
+			return keys, err
+		}
+		return keys, svc.Api.PutKeyPair(stored)
+	}
+	if err != nil {
+		return keys, fmt.Errorf("get key pair: %w", err)
+	}
+	keys.PrivateKey, err = tools.DecryptString(keys.PrivateKey, svc.key)
+	if err != nil {
+		return keys, fmt.Errorf("%w: %s", ErrCorruptedData, err)
+	}
+	return keys, nil
+}
+
+// convertShare replaces every secret field of the secret the share holds with the result of convert,
+// the description and the card number are not secret ones
+func convertShare(share *service.Share, convert func(string) (string, error)) error {
+	var err error
+	switch {
+	case share.LogoPass != nil:
+		logoPass := *share.LogoPass
+		logoPass.SecretLogin, err = convert(logoPass.SecretLogin)
+		if err != nil {
+			return err
+		}
+		logoPass.SecretPass, err = convert(logoPass.SecretPass)
+		share.LogoPass = &logoPass
+	case share.Text != nil:
+		text := *share.Text
+		text.Text, err = convert(text.Text)
+		share.Text = &text
+	case share.CreditCard != nil:
+		card := *share.CreditCard
+		card.Holder, err = convert(card.Holder)
+		if err != nil {
+			return err
+		}
+		card.DueDate, err = convert(card.DueDate)
+		if err != nil {
+			return err
+		}
+		card.CVV, err = convert(card.CVV)
+		share.CreditCard = &card
+	}
+	return err
+}
+
+// sealFor returns the conversion of the secret fields encrypted with the key of the user
+// to the ones sealed with the public key
+func (svc *LocalService) sealFor(publicKey string) func(string) (string, error) {
+	return func(secret string) (string, error) {
+		data, err := tools.DecryptString(secret, svc.key)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrCorruptedData, err)
+		}
+		return tools.SealString(data, publicKey)
+	}
+}
+
+// openWith returns the conversion of the secret fields sealed with the public key of the pair to the plain ones
+func openWith(keys service.KeyPair) func(string) (string, error) {
+	return func(sealed string) (string, error) {
+		data, err := tools.OpenString(sealed, keys.PublicKey, keys.PrivateKey)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrCorruptedData, err)
+		}
+		return data, nil
+	}
+}
+
+// remoteSecrets holds the secrets of the user taken from the remote, every kind is asked for once at most
+type remoteSecrets struct {
+	api         Api
+	logoPasses  []service.LogoPass
+	texts       []service.TextData
+	creditCards []service.CreditCard
+	fetched     map[string]bool
+}
+
+// fetch asks the remote for the secrets of the kind unless they have been asked for already
+func (secrets *remoteSecrets) fetch(item string) error {
+	if secrets.fetched[item] {
+		return nil
+	}
+	var err error
+	switch item {
+	case storage.ItemLogoPass:
+		secrets.logoPasses, err = secrets.api.GetLogoPasses()
+	case storage.ItemText:
+		secrets.texts, err = secrets.api.GetTexts()
+	case storage.ItemCreditCard:
+		secrets.creditCards, err = secrets.api.GetCreditCards()
+	default:
+		return fmt.Errorf("%s can't be shared", item)
+	}
+	if err != nil && !errors.Is(err, ErrEmpty) {
+		return err
+	}
+	if secrets.fetched == nil {
+		secrets.fetched = make(map[string]bool)
+	}
+	secrets.fetched[item] = true
+	return nil
+}
+
+// find returns the share of the user's secret of the kind that has the key: the description of a logo-pass pair
+// or a text, the number of a credit card. The share is found by the ID of the secret if the key is empty.
+// ErrEmpty is returned if there is no such secret on the remote.
+func (secrets *remoteSecrets) find(item string, id uint, key string) (service.Share, error) {
+	share := service.Share{Item: item}
+	err := secrets.fetch(item)
+	if err != nil {
+		return share, err
+	}
+	matches := func(entryID uint, entryKey string) bool {
+		return (key == "" && entryID == id) || (key != "" && entryKey == key)
+	}
+	switch item {
+	case storage.ItemLogoPass:
+		for i, logoPass := range secrets.logoPasses {
+			if matches(logoPass.ID, logoPass.Description) {
+				share.ItemID, share.Revision, share.LogoPass = logoPass.ID, logoPass.Revision, &secrets.logoPasses[i]
+				return share, nil
+			}
+		}
+	case storage.ItemText:
+		for i, text := range secrets.texts {
+			if matches(text.ID, text.Description) {
+				share.ItemID, share.Revision, share.Text = text.ID, text.Revision, &secrets.texts[i]
+				return share, nil
+			}
+		}
+	case storage.ItemCreditCard:
+		for i, card := range secrets.creditCards {
+			if matches(card.ID, card.Number) {
+				share.ItemID, share.Revision, share.CreditCard = card.ID, card.Revision, &secrets.creditCards[i]
+				return share, nil
+			}
+		}
+	}
+	return share, ErrEmpty
+}
+
+// ShareSecret shares the user's secret of the kind that has the key with the recipient: the description
+// of a logo-pass pair or a text, the number of a credit card. The secret is taken from the remote,
+// so the local changes are sent first. ErrNoKeyPair is returned if the recipient has never signed in to get keys.
+func (svc *LocalService) ShareSecret(item string, key string, recipient string, access string) (service.Share, error) {
+	err := svc.UpdateAll()
+	if err != nil {
+		return service.Share{}, err
+	}
+	// the owner needs keys as well to take the changes of the recipient in
+	_, err = svc.keyPair()
+	if err != nil {
+		return service.Share{}, err
+	}
+	recipientKeys, err := svc.Api.GetKeyPair(recipient)
+	if errors.Is(err, ErrEmpty) {
+		return service.Share{}, ErrNoKeyPair
+	}
+	if err != nil {
+		return service.Share{}, err
+	}
+
+	secrets := remoteSecrets{api: svc.Api}
+	share, err := secrets.find(item, 0, key)
+	if err != nil {
+		return share, err
+	}
+	share.Recipient, share.Access = recipient, access
+	err = convertShare(&share, svc.sealFor(recipientKeys.PublicKey))
+	if err != nil {
+		return share, err
+	}
+	return svc.Api.PutShare(share)
+}
+
+// ChangeSharedSecret sends the change of the secret shared with the user for writing sealed with the public key
+// of the owner. The secret fields of the share are the plain ones entered by the user.
+// ErrReadOnly is returned if the share is read-only.
+func (svc *LocalService) ChangeSharedSecret(share service.Share) error {
+	ownerKeys, err := svc.Api.GetKeyPair(share.Owner)
+	if err != nil {
+		return err
+	}
+	err = convertShare(&share, func(data string) (string, error) {
+		return tools.SealString(data, ownerKeys.PublicKey)
+	})
+	if err != nil {
+		return err
+	}
+	return svc.Api.ChangeShare(share)
+}
+
+// sharedWithUser returns the accepted shares of the secrets of the kind made for the user, the secrets opened
+func (svc *LocalService) sharedWithUser(item string) ([]service.Share, error) {
+	shares, err := svc.Api.GetShares()
+	if errors.Is(err, ErrEmpty) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys, err := svc.keyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []service.Share
+	for _, share := range shares {
+		if share.Recipient != svc.login || !share.Accepted || share.Item != item {
+			continue
+		}
+		err = convertShare(&share, openWith(keys))
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", share.ID, err)
+		}
+		shared = append(shared, share)
+	}
+	return shared, nil
+}
+
+// showSharedWithUser prints the secrets of the kind shared with the user in a cute table, if there are any
+func (svc *LocalService) showSharedWithUser(item string) {
+	shares, err := svc.sharedWithUser(item)
+	if err != nil {
+		fmt.Println("Failed to get the secrets shared with you:", err)
+		return
+	}
+	if len(shares) == 0 {
+		return
+	}
+
+	fmt.Println("Shared with you:")
+	table := tablewriter.NewWriter(os.Stdout)
+	switch item {
+	case storage.ItemLogoPass:
+		table.SetHeader([]string{"Share", "Owner", "login", "Password", "Description", "Access"})
+	case storage.ItemText:
+		table.SetHeader([]string{"Share", "Owner", "Description", "Text", "Access"})
+	case storage.ItemCreditCard:
+		table.SetHeader([]string{"Share", "Owner", "Number", "Holder", "Due date", "CVV", "Description", "Access"})
+	}
+	for _, share := range shares {
+		row := []string{strconv.FormatUint(uint64(share.ID), 10), share.Owner}
+		switch {
+		case share.LogoPass != nil:
+			row = append(row, share.LogoPass.SecretLogin, share.LogoPass.SecretPass, share.LogoPass.Description)
+		case share.Text != nil:
+			row = append(row, share.Text.Description, share.Text.Text)
+		case share.CreditCard != nil:
+			row = append(row, share.CreditCard.Number, share.CreditCard.Holder, share.CreditCard.DueDate,
+				share.CreditCard.CVV, share.CreditCard.Description)
+		}
+		table.Append(append(row, share.Access))
+	}
+	table.Render()
+}
+
+// shareEntry asks whom and how to share the secret of the kind that has the key with and shares it
+func (svc *LocalService) shareEntry(item string, key string) error {
+	recipient := svc.getAnswer("Please, enter the login of the user to share it with")
+	access := service.AccessRead
+	if svc.getAnswer("Should the user be able to change it? yes/no") == "yes" {
+		access = service.AccessWrite
+	}
+	_, err := svc.ShareSecret(item, key, recipient, access)
+	if errors.Is(err, ErrNoKeyPair) {
+		fmt.Println("The user has to sign in once before anything can be shared with them")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Shared with %s, it is visible to them once they accept it\n", recipient)
+	return nil
+}
+
+// showShares prints the shares made by the user and the ones made for the user in a cute table
+// and asks for further instructions, the options are:
+//   - accept certain share made for the user
+//   - change the secret of certain share made for the user for writing
+//   - revoke certain share made by the user or decline the one made for the user
+//   - exit back to the choice of available actions
+func (svc *LocalService) showShares() error {
+showShares:
+	shares, err := svc.Api.GetShares()
+	if errors.Is(err, ErrEmpty) {
+		fmt.Println("Nothing is shared by you or with you")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Owner", "Recipient", "Kind", "Access", "Status", "Shared"})
+	for _, share := range shares {
+		state := "waiting for acceptance"
+		switch {
+		case share.Pending:
+			state = "changed by recipient"
+		case share.Accepted:
+			state = "accepted"
+		}
+		row := []string{strconv.FormatUint(uint64(share.ID), 10), share.Owner, share.Recipient, share.Item,
+			share.Access, state, share.CreatedAt.Local().Format(dateTimeLayout)}
+		table.Append(row)
+	}
+	table.Render()
+
+	choice := svc.getAnswer("To accept a share type accept\nto change a secret shared with you type change\n" +
+		"to revoke or decline a share type delete\notherwise type exit")
+	if choice == "exit" {
+		return nil
+	}
+	if choice != "accept" && choice != "change" && choice != "delete" {
+		fmt.Println("I feel you bro, but i just dont get it, please try again")
+		goto showShares
+	}
+	id, err := strconv.ParseUint(svc.getAnswer("Please, enter the ID"), 10, 32)
+	if err != nil {
+		fmt.Println("I feel you bro, but i just dont get it, please try again")
+		goto showShares
+	}
+	var chosen service.Share
+	for _, share := range shares {
+		if share.ID == uint(id) {
+			chosen = share
+		}
+	}
+	if chosen.ID == 0 {
+		fmt.Println("There is no such ID, try again")
+		goto showShares
+	}
+
+	switch choice {
+	case "accept":
+		err = svc.Api.AcceptShare(chosen.ID)
+	case "change":
+		err = svc.changeShare(chosen)
+	case "delete":
+		err = svc.Api.DeleteShare(chosen.ID)
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+	goto showShares
+}
+
+// changeShare asks for the new secret fields of the secret shared with the user and sends the change to the owner
+func (svc *LocalService) changeShare(share service.Share) error {
+	if share.Recipient != svc.login {
+		return fmt.Errorf("share %d is made by you, change the secret itself instead", share.ID)
+	}
+	if share.Access != service.AccessWrite {
+		return ErrReadOnly
+	}
+	switch {
+	case share.LogoPass != nil:
+		share.LogoPass.SecretLogin = svc.getAnswer("Please, enter login")
+		share.LogoPass.SecretPass = svc.getAnswer("Please, enter password")
+	case share.Text != nil:
+		share.Text.Text = svc.getAnswer("Please, enter text")
+	case share.CreditCard != nil:
+		share.CreditCard.Holder = svc.getAnswer("Please, enter card holder name")
+		share.CreditCard.DueDate = svc.getAnswer("Please, enter card due date")
+		share.CreditCard.CVV = svc.getAnswer("Please, enter card CVV")
+	}
+	err := svc.ChangeSharedSecret(share)
+	if err != nil {
+		return err
+	}
+	fmt.Println("The change has been sent, the owner takes it in on the next sync")
+	return nil
+}
+
+// updateShares keeps the shares made by the user up to the user's secrets: the changes of the recipients
+// are stored over the secrets, and the secrets changed since they were shared are shared once again.
+// A change made by a recipient to a revision of the secret changed by the user meanwhile is dropped,
+// the change of the owner wins.
+func (svc *LocalService) updateShares() error {
+	keys, err := svc.keyPair()
+	if err != nil {
+		return err
+	}
+	shares, err := svc.Api.GetShares()
+	if errors.Is(err, ErrEmpty) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get shares: %w", err)
+	}
+
+	secrets := remoteSecrets{api: svc.Api}
+	for _, share := range shares {
+		if share.Owner != svc.login {
+			continue
+		}
+		current, err := secrets.find(share.Item, share.ItemID, "")
+		if errors.Is(err, ErrEmpty) {
+			// the remote drops the shares of the deleted secrets by itself
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if share.Pending && current.Revision == share.Revision {
+			current, err = svc.takeChange(share, current, keys)
+			if errors.Is(err, ErrOldData) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if !share.Pending && current.Revision == share.Revision {
+			continue
+		}
+
+		recipientKeys, err := svc.Api.GetKeyPair(share.Recipient)
+		if err != nil {
+			return fmt.Errorf("share %d: %w", share.ID, err)
+		}
+		current.Recipient, current.Access = share.Recipient, share.Access
+		err = convertShare(&current, svc.sealFor(recipientKeys.PublicKey))
+		if err != nil {
+			return err
+		}
+		_, err = svc.Api.PutShare(current)
+		if err != nil {
+			return fmt.Errorf("share %d: %w", share.ID, err)
+		}
+	}
+	return nil
+}
+
+// takeChange stores the change of the recipient over the secret of the user and returns the share
+// of the secret stored. The change is taken from the secret fields only.
+func (svc *LocalService) takeChange(change service.Share, current service.Share, keys service.KeyPair) (service.Share, error) {
+	err := convertShare(&change, func(sealed string) (string, error) {
+		data, err := openWith(keys)(sealed)
+		if err != nil {
+			return "", err
+		}
+		return tools.EncryptString(data, svc.key)
+	})
+	if err != nil {
+		return current, fmt.Errorf("share %d: %w", change.ID, err)
+	}
+
+	switch {
+	case current.LogoPass != nil && change.LogoPass != nil:
+		logoPass := *current.LogoPass
+		logoPass.SecretLogin, logoPass.SecretPass = change.LogoPass.SecretLogin, change.LogoPass.SecretPass
+		logoPass.Overwrite = true
+		logoPass.Revision, err = svc.Api.UploadLogoPass(logoPass)
+		current.LogoPass, current.Revision = &logoPass, logoPass.Revision
+	case current.Text != nil && change.Text != nil:
+		text := *current.Text
+		text.Text = change.Text.Text
+		text.Overwrite = true
+		text.Revision, err = svc.Api.UploadText(text)
+		current.Text, current.Revision = &text, text.Revision
+	case current.CreditCard != nil && change.CreditCard != nil:
+		card := *current.CreditCard
+		card.Holder, card.DueDate, card.CVV = change.CreditCard.Holder, change.CreditCard.DueDate, change.CreditCard.CVV
+		card.Overwrite = true
+		card.Revision, err = svc.Api.UploadCreditCard(card)
+		current.CreditCard, current.Revision = &card, card.Revision
+	default:
+		return current, fmt.Errorf("share %d: %w", change.ID, ErrCorruptedData)
+	}
+	return current, err
+}
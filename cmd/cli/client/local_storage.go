@@ -6,15 +6,16 @@ import (
 	"io"
 	"os"
 	"sync"
+	"time"
 )
 
 // Storage is an interface of all storage interactions needed for Gophkeeper
 type Storage interface {
 	UpdatePath(path string) error
-	StoreLogoPasses(listLogoPasses []service.LogoPass) ([]service.LogoPass, error)
-	StoreTexts(listTexts []service.TextData) ([]service.TextData, error)
-	StoreCreditCards(listCreditCards []service.CreditCard) ([]service.CreditCard, error)
-	StoreBinaries(binaryList []service.BinaryData) error
+	StoreLogoPasses(listLogoPasses []service.LogoPass, complete bool) ([]service.LogoPass, error)
+	StoreTexts(listTexts []service.TextData, complete bool) ([]service.TextData, error)
+	StoreCreditCards(listCreditCards []service.CreditCard, complete bool) ([]service.CreditCard, error)
+	StoreBinaries(binaryList []service.BinaryData) ([]service.BinaryData, error)
 	UpdateLogoPass(logoPass service.LogoPass) error
 	UpdateText(Text service.TextData) error
 	UpdateCreditCard(CreditCard service.CreditCard) error
@@ -23,6 +24,20 @@ type Storage interface {
 	GetTexts() ([]service.TextData, error)
 	GetCreditCards() ([]service.CreditCard, error)
 	GetBinaryList() ([]service.BinaryData, error)
+	StoreUpload(upload PendingUpload) error
+	GetUpload(description string) (PendingUpload, error)
+	RemoveUpload(description string) error
+	GetCursor() (string, error)
+	StoreCursor(cursor string) error
+	ClearAll() error
+}
+
+// PendingUpload binds an unfinished upload session to the local file being uploaded, so that it could be resumed
+// after a disconnect or even a restart of the cli
+type PendingUpload struct {
+	Session service.UploadSession `json:"session"`
+	Path    string                `json:"path"`
+	ModTime time.Time             `json:"mod_time"`
 }
 
 // FileStorage holds a path for local storage to save files
@@ -52,7 +67,11 @@ func (storage *FileStorage) UpdatePath(path string) error {
 // StoreLogoPasses accepts []service.LogoPass acquired from another storage and sorts out which of logo-pass pairs
 // have to be updated locally and which of them are newer in this storage. It gathers the ones that are newer here
 // and sends []service.LogoPass list for further updating of the remote.
-func (storage *FileStorage) StoreLogoPasses(serverLogoPasses []service.LogoPass) ([]service.LogoPass, error) {
+// Entries are merged on the revisions assigned by the remote: the one stored at a later revision wins,
+// the one stored here at the same revision is newer here only if it has been changed since.
+// The list is complete if it holds every entry of another storage, the entries missing from it are considered
+// new in this storage then. Otherwise it's a delta holding only the entries changed since the last sync.
+func (storage *FileStorage) StoreLogoPasses(serverLogoPasses []service.LogoPass, complete bool) ([]service.LogoPass, error) {
 	var updLogoPasses []service.LogoPass
 
 	file, err := os.OpenFile(storage.outputPath+logoPassFile, os.O_RDWR|os.O_CREATE, 0644)
@@ -76,13 +95,13 @@ func (storage *FileStorage) StoreLogoPasses(serverLogoPasses []service.LogoPass)
 			return updLogoPasses, err
 		}
 	}
-	for i, serverLogoPass := range serverLogoPasses {
+	for _, serverLogoPass := range serverLogoPasses {
 		newEntry := true
-		for _, storedLogoPass := range storedLogoPasses {
+		for i, storedLogoPass := range storedLogoPasses {
 			if serverLogoPass.Description == storedLogoPass.Description {
-				if serverLogoPass.UpdatedAt.After(storedLogoPass.UpdatedAt) {
+				if serverLogoPass.Revision > storedLogoPass.Revision {
 					storedLogoPasses[i] = serverLogoPass
-				} else if serverLogoPass.UpdatedAt.Before(storedLogoPass.UpdatedAt) {
+				} else if serverLogoPass.Revision == storedLogoPass.Revision && !sameLogoPass(serverLogoPass, storedLogoPass) {
 					storedLogoPass.Overwrite = true
 					updLogoPasses = append(updLogoPasses, storedLogoPass)
 				}
@@ -94,16 +113,18 @@ func (storage *FileStorage) StoreLogoPasses(serverLogoPasses []service.LogoPass)
 			storedLogoPasses = append(storedLogoPasses, serverLogoPass)
 		}
 	}
-	for _, storedLogoPass := range storedLogoPasses {
-		newEntry := true
-		for _, serverLogoPass := range serverLogoPasses {
-			if serverLogoPass.Description == storedLogoPass.Description {
-				newEntry = false
-				break
+	if complete {
+		for _, storedLogoPass := range storedLogoPasses {
+			newEntry := true
+			for _, serverLogoPass := range serverLogoPasses {
+				if serverLogoPass.Description == storedLogoPass.Description {
+					newEntry = false
+					break
+				}
+			}
+			if newEntry && !storedLogoPass.DeletedAt.Valid {
+				updLogoPasses = append(updLogoPasses, storedLogoPass)
 			}
-		}
-		if newEntry {
-			updLogoPasses = append(updLogoPasses, storedLogoPass)
 		}
 	}
 
@@ -125,7 +146,11 @@ func (storage *FileStorage) StoreLogoPasses(serverLogoPasses []service.LogoPass)
 // StoreTexts accepts []service.TextData acquired from another storage and sorts out which of text data entries
 // have to be updated locally and which of them are newer in this storage. It gathers the ones that are newer here
 // and sends []service.TextData list for further updating of the remote.
-func (storage *FileStorage) StoreTexts(serverTexts []service.TextData) ([]service.TextData, error) {
+// Entries are merged on the revisions assigned by the remote: the one stored at a later revision wins,
+// the one stored here at the same revision is newer here only if it has been changed since.
+// The list is complete if it holds every entry of another storage, the entries missing from it are considered
+// new in this storage then. Otherwise it's a delta holding only the entries changed since the last sync.
+func (storage *FileStorage) StoreTexts(serverTexts []service.TextData, complete bool) ([]service.TextData, error) {
 	var updTexts []service.TextData
 
 	file, err := os.OpenFile(storage.outputPath+textFile, os.O_RDWR|os.O_CREATE, 0644)
@@ -153,9 +178,9 @@ func (storage *FileStorage) StoreTexts(serverTexts []service.TextData) ([]servic
 		newEntry := true
 		for i, storedText := range storedTexts {
 			if serverText.Description == storedText.Description {
-				if serverText.UpdatedAt.After(storedText.UpdatedAt) {
+				if serverText.Revision > storedText.Revision {
 					storedTexts[i] = serverText
-				} else if serverText.UpdatedAt.Before(storedText.UpdatedAt) {
+				} else if serverText.Revision == storedText.Revision && !sameText(serverText, storedText) {
 					storedText.Overwrite = true
 					updTexts = append(updTexts, storedText)
 				}
@@ -167,16 +192,18 @@ func (storage *FileStorage) StoreTexts(serverTexts []service.TextData) ([]servic
 			storedTexts = append(storedTexts, serverText)
 		}
 	}
-	for _, storedText := range storedTexts {
-		newEntry := true
-		for _, serverText := range serverTexts {
-			if serverText.Description == storedText.Description {
-				newEntry = false
-				break
+	if complete {
+		for _, storedText := range storedTexts {
+			newEntry := true
+			for _, serverText := range serverTexts {
+				if serverText.Description == storedText.Description {
+					newEntry = false
+					break
+				}
+			}
+			if newEntry && !storedText.DeletedAt.Valid {
+				updTexts = append(updTexts, storedText)
 			}
-		}
-		if newEntry {
-			updTexts = append(updTexts, storedText)
 		}
 	}
 
@@ -198,7 +225,11 @@ func (storage *FileStorage) StoreTexts(serverTexts []service.TextData) ([]servic
 // StoreCreditCards accepts []service.CreditCard acquired from another storage and sorts out which of credit cards entries
 // have to be updated locally and which of them are newer in this storage. It gathers the ones that are newer here
 // and sends []service.CreditCard list for further updating of the remote.
-func (storage *FileStorage) StoreCreditCards(serverCreditCards []service.CreditCard) ([]service.CreditCard, error) {
+// Entries are merged on the revisions assigned by the remote: the one stored at a later revision wins,
+// the one stored here at the same revision is newer here only if it has been changed since.
+// The list is complete if it holds every entry of another storage, the entries missing from it are considered
+// new in this storage then. Otherwise it's a delta holding only the entries changed since the last sync.
+func (storage *FileStorage) StoreCreditCards(serverCreditCards []service.CreditCard, complete bool) ([]service.CreditCard, error) {
 	var updCreditCards []service.CreditCard
 
 	file, err := os.OpenFile(storage.outputPath+creditCardFile, os.O_RDWR|os.O_CREATE, 0644)
@@ -222,13 +253,13 @@ func (storage *FileStorage) StoreCreditCards(serverCreditCards []service.CreditC
 			return updCreditCards, err
 		}
 	}
-	for i, serverCard := range serverCreditCards {
+	for _, serverCard := range serverCreditCards {
 		newEntry := true
-		for _, storedCard := range storedCreditCards {
+		for i, storedCard := range storedCreditCards {
 			if serverCard.Number == storedCard.Number {
-				if serverCard.UpdatedAt.After(storedCard.UpdatedAt) {
+				if serverCard.Revision > storedCard.Revision {
 					storedCreditCards[i] = serverCard
-				} else if serverCard.UpdatedAt.Before(storedCard.UpdatedAt) {
+				} else if serverCard.Revision == storedCard.Revision && !sameCreditCard(serverCard, storedCard) {
 					storedCard.Overwrite = true
 					updCreditCards = append(updCreditCards, storedCard)
 				}
@@ -240,16 +271,18 @@ func (storage *FileStorage) StoreCreditCards(serverCreditCards []service.CreditC
 			storedCreditCards = append(storedCreditCards, serverCard)
 		}
 	}
-	for _, storedCard := range storedCreditCards {
-		newEntry := true
-		for _, serverCard := range serverCreditCards {
-			if serverCard.Number == storedCard.Number {
-				newEntry = false
-				break
+	if complete {
+		for _, storedCard := range storedCreditCards {
+			newEntry := true
+			for _, serverCard := range serverCreditCards {
+				if serverCard.Number == storedCard.Number {
+					newEntry = false
+					break
+				}
+			}
+			if newEntry && !storedCard.DeletedAt.Valid {
+				updCreditCards = append(updCreditCards, storedCard)
 			}
-		}
-		if newEntry {
-			updCreditCards = append(updCreditCards, storedCard)
 		}
 	}
 
@@ -269,15 +302,18 @@ func (storage *FileStorage) StoreCreditCards(serverCreditCards []service.CreditC
 
 }
 
-// StoreBinaries accepts []service.BinaryData acquired from another storage and sorts out which of text data entries
-// have to be updated locally and which of them are newer in this storage. It gathers the ones that are newer here
-// and sends []service.BinaryData list for further updating of the remote.
+// StoreBinaries accepts []service.BinaryData acquired from another storage and sorts out which of binary entries
+// have to be updated locally, the one stored at a later revision wins. Binaries are always uploaded right away,
+// so the only thing that can be newer in this storage is a tombstone of a binary deleted offline. Those are sent as []service.BinaryData list
+// for further updating of the remote.
 //
 // **NOTE** that it only stores the descriptions of binaries, not binaries themselves. Those are downloaded by request.
-func (storage *FileStorage) StoreBinaries(serverBinaries []service.BinaryData) error {
+func (storage *FileStorage) StoreBinaries(serverBinaries []service.BinaryData) ([]service.BinaryData, error) {
+	var updBinaries []service.BinaryData
+
 	file, err := os.OpenFile(storage.outputPath+binaryListFile, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		return err
+		return updBinaries, err
 	}
 	defer file.Close()
 
@@ -286,25 +322,28 @@ func (storage *FileStorage) StoreBinaries(serverBinaries []service.BinaryData) e
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return err
+		return updBinaries, err
 	}
 
 	var storedBinaries []service.BinaryData
 	if len(data) != 0 {
 		err = json.Unmarshal(data, &storedBinaries)
 		if err != nil {
-			return err
+			return updBinaries, err
 		}
 	}
-	for i, serverBinary := range serverBinaries {
+	for _, serverBinary := range serverBinaries {
 		newEntry := true
-		for _, storedBinary := range storedBinaries {
+		for i, storedBinary := range storedBinaries {
 			if serverBinary.Description == storedBinary.Description {
 				newEntry = false
-				if serverBinary.UpdatedAt.After(storedBinary.UpdatedAt) {
+				if serverBinary.Revision > storedBinary.Revision {
 					storedBinaries[i] = serverBinary
-					break
+				} else if serverBinary.Revision == storedBinary.Revision &&
+					storedBinary.DeletedAt.Valid && !serverBinary.DeletedAt.Valid {
+					updBinaries = append(updBinaries, storedBinary)
 				}
+				break
 			}
 		}
 		if newEntry {
@@ -315,16 +354,33 @@ func (storage *FileStorage) StoreBinaries(serverBinaries []service.BinaryData) e
 	if len(storedBinaries) != 0 {
 		jsonBytes, err := json.Marshal(storedBinaries)
 		if err != nil {
-			return err
+			return updBinaries, err
 		}
 		err = os.WriteFile(storage.outputPath+binaryListFile, jsonBytes, 0644)
 		if err != nil {
-			return err
+			return updBinaries, err
 		}
 	}
 	mutex.Unlock()
 
-	return nil
+	return updBinaries, nil
+}
+
+// sameLogoPass reports whether the logo-pass pairs hold the same secret, the ones stored at the same revision
+// differ only if one of them has been changed locally since
+func sameLogoPass(a service.LogoPass, b service.LogoPass) bool {
+	return a.SecretLogin == b.SecretLogin && a.SecretPass == b.SecretPass && a.DeletedAt.Valid == b.DeletedAt.Valid
+}
+
+// sameText reports whether the texts hold the same secret
+func sameText(a service.TextData, b service.TextData) bool {
+	return a.Text == b.Text && a.DeletedAt.Valid == b.DeletedAt.Valid
+}
+
+// sameCreditCard reports whether the credit cards hold the same secret
+func sameCreditCard(a service.CreditCard, b service.CreditCard) bool {
+	return a.Holder == b.Holder && a.DueDate == b.DueDate && a.CVV == b.CVV && a.Description == b.Description &&
+		a.DeletedAt.Valid == b.DeletedAt.Valid
 }
 
 // UpdateLogoPass accepts service.LogoPass and writes it to local storage if it's new.
@@ -356,7 +412,7 @@ func (storage *FileStorage) UpdateLogoPass(logoPass service.LogoPass) error {
 		for i, existingLogoPass := range listLogoPasses {
 			if existingLogoPass.Description == logoPass.Description {
 				newEntry = false
-				if logoPass.Overwrite {
+				if logoPass.Overwrite || existingLogoPass.DeletedAt.Valid {
 					listLogoPasses[i] = logoPass
 					break
 				} else {
@@ -416,7 +472,7 @@ func (storage *FileStorage) UpdateText(text service.TextData) error {
 		for i, existingText := range listTexts {
 			if existingText.Description == text.Description {
 				newEntry = false
-				if text.Overwrite {
+				if text.Overwrite || existingText.DeletedAt.Valid {
 					listTexts[i] = text
 					break
 				} else {
@@ -476,7 +532,7 @@ func (storage *FileStorage) UpdateCreditCard(creditCard service.CreditCard) erro
 		for i, existingCard := range listCreditCards {
 			if existingCard.Number == creditCard.Number {
 				newEntry = false
-				if creditCard.Overwrite {
+				if creditCard.Overwrite || existingCard.DeletedAt.Valid {
 					listCreditCards[i] = creditCard
 					break
 				} else {
@@ -536,7 +592,7 @@ func (storage *FileStorage) UpdateBinaryList(binary service.BinaryData) error {
 		for i, existingBinary := range binaryList {
 			if existingBinary.Description == binary.Description {
 				newEntry = false
-				if binary.Overwrite {
+				if binary.Overwrite || existingBinary.DeletedAt.Valid {
 					binaryList[i] = binary
 					break
 				} else {
@@ -567,7 +623,7 @@ func (storage *FileStorage) UpdateBinaryList(binary service.BinaryData) error {
 	return nil
 }
 
-// GetLogoPasses returns the list of all service.LogoPass entries available locally
+// GetLogoPasses returns the list of all service.LogoPass entries available locally, tombstones excluded
 func (storage *FileStorage) GetLogoPasses() ([]service.LogoPass, error) {
 	var listLogoPasses []service.LogoPass
 
@@ -587,8 +643,17 @@ func (storage *FileStorage) GetLogoPasses() ([]service.LogoPass, error) {
 			return listLogoPasses, err
 		}
 	}
-	return listLogoPasses, nil
+
+	var availableLogoPasses []service.LogoPass
+	for _, logoPass := range listLogoPasses {
+		if !logoPass.DeletedAt.Valid {
+			availableLogoPasses = append(availableLogoPasses, logoPass)
+		}
+	}
+	return availableLogoPasses, nil
 }
+
+// GetTexts returns the list of all service.TextData entries available locally, tombstones excluded
 func (storage *FileStorage) GetTexts() ([]service.TextData, error) {
 	var listTexts []service.TextData
 
@@ -608,10 +673,17 @@ func (storage *FileStorage) GetTexts() ([]service.TextData, error) {
 			return listTexts, err
 		}
 	}
-	return listTexts, nil
+
+	var availableTexts []service.TextData
+	for _, text := range listTexts {
+		if !text.DeletedAt.Valid {
+			availableTexts = append(availableTexts, text)
+		}
+	}
+	return availableTexts, nil
 }
 
-// GetCreditCards returns the list of all service.CreditCard entries available locally
+// GetCreditCards returns the list of all service.CreditCard entries available locally, tombstones excluded
 func (storage *FileStorage) GetCreditCards() ([]service.CreditCard, error) {
 	var listCreditCards []service.CreditCard
 
@@ -632,10 +704,17 @@ func (storage *FileStorage) GetCreditCards() ([]service.CreditCard, error) {
 			return listCreditCards, err
 		}
 	}
-	return listCreditCards, nil
+
+	var availableCreditCards []service.CreditCard
+	for _, card := range listCreditCards {
+		if !card.DeletedAt.Valid {
+			availableCreditCards = append(availableCreditCards, card)
+		}
+	}
+	return availableCreditCards, nil
 }
 
-// GetBinaryList returns the list of all service.BinaryData entries available locally
+// GetBinaryList returns the list of all service.BinaryData entries available locally, tombstones excluded
 func (storage *FileStorage) GetBinaryList() ([]service.BinaryData, error) {
 	var BinaryList []service.BinaryData
 
@@ -655,7 +734,122 @@ func (storage *FileStorage) GetBinaryList() ([]service.BinaryData, error) {
 			return BinaryList, err
 		}
 	}
-	return BinaryList, nil
+
+	var availableBinaries []service.BinaryData
+	for _, binary := range BinaryList {
+		if !binary.DeletedAt.Valid {
+			availableBinaries = append(availableBinaries, binary)
+		}
+	}
+	return availableBinaries, nil
+}
+
+// StoreUpload saves an unfinished upload replacing any other upload of a binary with the same description
+func (storage *FileStorage) StoreUpload(upload PendingUpload) error {
+	var mutex sync.Mutex
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	uploads, err := storage.readUploads()
+	if err != nil {
+		return err
+	}
+
+	newEntry := true
+	for i, existingUpload := range uploads {
+		if existingUpload.Session.Description == upload.Session.Description {
+			uploads[i] = upload
+			newEntry = false
+			break
+		}
+	}
+	if newEntry {
+		uploads = append(uploads, upload)
+	}
+
+	return storage.writeUploads(uploads)
+}
+
+// GetUpload returns an unfinished upload of a binary with the description given
+func (storage *FileStorage) GetUpload(description string) (PendingUpload, error) {
+	uploads, err := storage.readUploads()
+	if err != nil {
+		return PendingUpload{}, err
+	}
+
+	for _, upload := range uploads {
+		if upload.Session.Description == description {
+			return upload, nil
+		}
+	}
+	return PendingUpload{}, ErrEmpty
+}
+
+// RemoveUpload forgets about an upload of a binary with the description given, once it's finished
+func (storage *FileStorage) RemoveUpload(description string) error {
+	var mutex sync.Mutex
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	uploads, err := storage.readUploads()
+	if err != nil {
+		return err
+	}
+
+	var leftUploads []PendingUpload
+	for _, upload := range uploads {
+		if upload.Session.Description != description {
+			leftUploads = append(leftUploads, upload)
+		}
+	}
+
+	return storage.writeUploads(leftUploads)
+}
+
+// readUploads returns all unfinished uploads stored locally
+func (storage *FileStorage) readUploads() ([]PendingUpload, error) {
+	var uploads []PendingUpload
+
+	data, err := os.ReadFile(storage.outputPath + uploadsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return uploads, nil
+		}
+		return uploads, err
+	}
+	if len(data) != 0 {
+		err = json.Unmarshal(data, &uploads)
+		if err != nil {
+			return uploads, err
+		}
+	}
+	return uploads, nil
+}
+
+// writeUploads replaces all unfinished uploads stored locally
+func (storage *FileStorage) writeUploads(uploads []PendingUpload) error {
+	jsonBytes, err := json.Marshal(uploads)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(storage.outputPath+uploadsFile, jsonBytes, 0644)
+}
+
+// GetCursor returns the cursor of the last sync with the remote, it is empty if there was none
+func (storage *FileStorage) GetCursor() (string, error) {
+	data, err := os.ReadFile(storage.outputPath + cursorFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+// StoreCursor saves the cursor of the last sync with the remote, empty cursor makes the next sync a full one
+func (storage *FileStorage) StoreCursor(cursor string) error {
+	return os.WriteFile(storage.outputPath+cursorFile, []byte(cursor), 0644)
 }
 
 // ClearAll destroys local storage
@@ -0,0 +1,9 @@
+package openapi
+
+// The client is generated from the specification served by the App, the schemas of which are mapped
+// to the types of service package by x-go-type extension. templates/typedef.tmpl turns them into aliases,
+// so that the client accepts and returns the service types as they are.
+//
+// oapi-codegen v1.8.2 is needed: go install github.com/deepmap/oapi-codegen/cmd/oapi-codegen@v1.8.2
+
+//go:generate oapi-codegen -generate types,client -templates templates -package openapi -o openapi.gen.go ../../../../internal/app/openapi.json
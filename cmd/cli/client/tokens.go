@@ -0,0 +1,94 @@
+package client
+
+import (
+	"errors"
+	"gophkeeper/internal/service"
+	"sync"
+	"time"
+)
+
+// refreshMargin is how long before the expiry the access token is refreshed, so that it won't expire on the way.
+// Short-lived tokens are refreshed a quarter of their lifetime before the expiry instead.
+const refreshMargin = 10 * time.Second
+
+// tokenHolder holds the tokens of the authorized user shared by all the requests of an Api.
+// Refresh tokens can be used only once, so the refresh is done by a single request at a time.
+// The challenge token is kept between the steps of the login with two-factor authentication.
+type tokenHolder struct {
+	mu        sync.Mutex
+	refreshMu sync.Mutex
+	tokens    service.Tokens
+	refreshAt time.Time
+	challenge string
+}
+
+// set replaces the tokens with the ones just issued by the remote
+func (holder *tokenHolder) set(tokens service.Tokens) {
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	lifetime := time.Duration(tokens.ExpiresIn) * time.Second
+	margin := refreshMargin
+	if margin > lifetime/4 {
+		margin = lifetime / 4
+	}
+	holder.tokens = tokens
+	holder.refreshAt = time.Now().Add(lifetime - margin)
+	holder.challenge = ""
+}
+
+// setChallenge keeps the challenge token sent by the remote until the code is sent along with it
+func (holder *tokenHolder) setChallenge(challenge string) {
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	holder.challenge = challenge
+}
+
+// challengeToken returns the challenge token of the login waiting for the code
+func (holder *tokenHolder) challengeToken() string {
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.challenge
+}
+
+// accessToken returns the current access token and whether it is about to expire
+func (holder *tokenHolder) accessToken() (string, bool) {
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	expiring := holder.tokens.RefreshToken != "" && time.Now().After(holder.refreshAt)
+	return holder.tokens.AccessToken, expiring
+}
+
+// canRefresh tells if there is a refresh token to get new tokens with
+func (holder *tokenHolder) canRefresh() bool {
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.tokens.RefreshToken != ""
+}
+
+// refresh exchanges the refresh token for new tokens with the exchange function, unless the access token
+// has already been replaced since it was taken by the caller. Returns the access token to be used.
+// The tokens are dropped if the remote refuses to refresh them, the user has to log in again then.
+func (holder *tokenHolder) refresh(stale string, exchange func(refreshToken string) (service.Tokens, error)) (string, error) {
+	holder.refreshMu.Lock()
+	defer holder.refreshMu.Unlock()
+
+	holder.mu.Lock()
+	current := holder.tokens
+	holder.mu.Unlock()
+	if current.AccessToken != stale {
+		return current.AccessToken, nil
+	}
+	if current.RefreshToken == "" {
+		return "", ErrSessionExpired
+	}
+
+	tokens, err := exchange(current.RefreshToken)
+	if err != nil {
+		if errors.Is(err, ErrSessionExpired) {
+			holder.set(service.Tokens{})
+		}
+		return "", err
+	}
+	holder.set(tokens)
+	return tokens.AccessToken, nil
+}
@@ -2,14 +2,19 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/caarlos0/env/v6"
-	"github.com/gorilla/sessions"
+	"golang.org/x/exp/slog"
 	"gophkeeper/internal/app"
 	"gophkeeper/internal/config"
+	"gophkeeper/internal/logging"
 	"gophkeeper/internal/storage"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 var (
@@ -23,8 +28,6 @@ var (
 	buildCommit  = "N/A"
 )
 
-var secretKey = "watch?v=Qw4w9WgXcQ"
-
 func printBuildData() {
 	fmt.Println("Build version:", buildVersion)
 	fmt.Println("Build date:", buildDate)
@@ -40,13 +43,42 @@ func main() {
 	}
 	flag.StringVar(&cfg.DatabaseDSN, "d", cfg.DatabaseDSN, "File Storage Path")
 	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "Server address")
+	flag.StringVar(&cfg.GRPCAddress, "g", cfg.GRPCAddress, "gRPC server address, empty to disable")
 	flag.StringVar(&cfg.DownloadFolder, "f", cfg.DownloadFolder, "Folder for binaries to download")
+	flag.StringVar(&cfg.TLSCertFile, "c", cfg.TLSCertFile, "TLS certificate file")
+	flag.StringVar(&cfg.TLSKeyFile, "k", cfg.TLSKeyFile, "TLS key file")
+	flag.BoolVar(&cfg.TLSSelfSigned, "s", cfg.TLSSelfSigned, "Generate a self-signed TLS certificate for development")
+	flag.StringVar(&cfg.TLSClientCAFile, "ca", cfg.TLSClientCAFile, "CA of the client certificates to require")
+	flag.DurationVar(&cfg.ShutdownTimeout, "t", cfg.ShutdownTimeout, "Time to finish the requests in flight on shutdown")
+	flag.StringVar(&cfg.LogLevel, "l", cfg.LogLevel, "Log level: debug, info, warn or error")
 	flag.Parse()
 
-	fmt.Println(cfg.DatabaseDSN)
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logging.New(os.Stdout, level))
+
+	userStorage := storage.NewUserStorage(cfg.DatabaseDSN, storage.Quotas{MaxBytes: cfg.QuotaBytes,
+		MaxBinarySize: cfg.QuotaBinarySize, MaxItems: cfg.QuotaItems})
+	var application = app.NewApp(cfg, userStorage, userStorage)
 
-	userStorage := storage.NewUserStorage(cfg.DatabaseDSN)
-	cookieStorage := sessions.NewCookieStore([]byte(secretKey))
-	var application = app.NewApp(cfg, userStorage, *cookieStorage)
-	application.Run()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	err := application.Start(ctx)
+	if err != nil {
+		log.Printf("server failed: %s", err)
+	}
+	stop()
+
+	log.Printf("shutting down, waiting %s for the requests in flight", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err = application.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown err: %s", err)
+	}
+	if err = userStorage.Close(); err != nil {
+		log.Printf("close database err: %s", err)
+	}
+	log.Printf("server stopped")
 }
@@ -2,54 +2,293 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"github.com/gorilla/mux"
-	"github.com/gorilla/sessions"
+	"google.golang.org/grpc"
 	"gophkeeper/internal/config"
 	"gophkeeper/internal/storage"
 	"gophkeeper/internal/tools"
 	"log"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // App is a struct holding structures crucial for the working of the service
 type App struct {
-	config        config.Config
-	UserStorage   storage.UserStorage
-	cookieStorage sessions.CookieStore
+	config         config.Config
+	UserStorage    storage.UserStorage
+	AuditStorage   storage.AuditStorage
+	tokenSecret    []byte
+	ipLimiter      *limiter
+	accountLimiter *limiter
+	metrics        *metrics
+	events         *eventBroker
+	shuttingDown   atomic.Bool
+	mu             sync.Mutex
+	server         *http.Server
+	grpcServer     *grpc.Server
 }
 
 // This holds all the routes available in App
 const (
-	RegisterEndpoint       = "/api/user/register"
-	LoginEndpoint          = "/api/user/login"
-	PutLogoPassEndpoint    = "/api/user/upload/logopass"
-	PutTextEndpoint        = "/api/user/upload/text"
-	PutCreditCardEndpoint  = "/api/user/upload/credit-card"
-	PutBinaryEndpoint      = "/api/user/upload/binary"
-	GetLogoPassesEndpoint  = "/api/user/download/logopasses"
-	GetTextsEndpoint       = "/api/user/download/texts"
-	GetCreditCardsEndpoint = "/api/user/download/credit-cards"
-	GetBinaryListEndpoint  = "/api/user/download/binary-list"
-	GetBinaryEndpoint      = "/api/user/download/binary"
-	GetWindows             = "/download/windows"
-	GetMac                 = "/download/mac"
-	GetLinux               = "/download/linux"
+	RegisterEndpoint             = "/api/user/register"
+	LoginEndpoint                = "/api/user/login"
+	LoginTwoFactorEndpoint       = "/api/user/login/2fa"
+	RefreshTokenEndpoint         = "/api/user/token/refresh"
+	LogoutEndpoint               = "/api/user/logout"
+	PasswordEndpoint             = "/api/user/password"
+	ExportEndpoint               = "/api/user/export"
+	DeleteAccountEndpoint        = "/api/user/delete"
+	SessionsEndpoint             = "/api/user/sessions"
+	TwoFactorEndpoint            = "/api/user/2fa"
+	EnrollTwoFactorEndpoint      = "/api/user/2fa/enroll"
+	VerifyTwoFactorEndpoint      = "/api/user/2fa/verify"
+	PutLogoPassEndpoint          = "/api/user/upload/logopass"
+	PutTextEndpoint              = "/api/user/upload/text"
+	PutCreditCardEndpoint        = "/api/user/upload/credit-card"
+	PutBinaryEndpoint            = "/api/user/upload/binary"
+	GetLogoPassesEndpoint        = "/api/user/download/logopasses"
+	GetTextsEndpoint             = "/api/user/download/texts"
+	GetCreditCardsEndpoint       = "/api/user/download/credit-cards"
+	GetBinaryListEndpoint        = "/api/user/download/binary-list"
+	GetBinaryEndpoint            = "/api/user/download/binary"
+	DeleteLogoPassEndpoint       = "/api/user/delete/logopass"
+	DeleteTextEndpoint           = "/api/user/delete/text"
+	DeleteCreditCardEndpoint     = "/api/user/delete/credit-card"
+	DeleteBinaryEndpoint         = "/api/user/delete/binary"
+	StartBinaryUploadEndpoint    = "/api/user/upload/binary/start"
+	GetBinaryUploadEndpoint      = "/api/user/upload/binary/status"
+	PutBinaryChunkEndpoint       = "/api/user/upload/binary/chunk"
+	CompleteBinaryUploadEndpoint = "/api/user/upload/binary/complete"
+	StreamBinaryEndpoint         = "/api/user/download/binary/stream"
+	SyncEndpoint                 = "/api/user/sync"
+	HistoryEndpoint              = "/api/user/history"
+	RestoreVersionEndpoint       = "/api/user/history/restore"
+	EventsEndpoint               = "/api/user/events"
+	UsageEndpoint                = "/api/user/usage"
+	AuditEndpoint                = "/api/user/audit"
+	KeysEndpoint                 = "/api/user/keys"
+	SharesEndpoint               = "/api/user/shares"
+	AcceptShareEndpoint          = "/api/user/shares/accept"
+	ChangeShareEndpoint          = "/api/user/shares/change"
+	OrgsEndpoint                 = "/api/user/orgs"
+	OrgMembersEndpoint           = "/api/user/orgs/members"
+	CollectionsEndpoint          = "/api/user/orgs/collections"
+	CollectionMembersEndpoint    = "/api/user/orgs/collections/members"
+	SendEndpoint                 = "/api/send"
+	AdminUsersEndpoint           = "/api/admin/users"
+	AdminDisableUserEndpoint     = "/api/admin/users/disable"
+	AdminEnableUserEndpoint      = "/api/admin/users/enable"
+	AdminLogoutUserEndpoint      = "/api/admin/users/logout"
+	MetricsEndpoint              = "/metrics"
+	HealthEndpoint               = "/healthz"
+	ReadyEndpoint                = "/readyz"
+	OpenAPIEndpoint              = "/api/openapi.json"
+	GetWindows                   = "/download/windows"
+	GetMac                       = "/download/mac"
+	GetLinux                     = "/download/linux"
 )
 
-// NewApp constructor for app
-func NewApp(cfg config.Config, userStorage storage.UserStorage, cookieStorage sessions.CookieStore) *App {
-	return &App{config: cfg, UserStorage: userStorage, cookieStorage: cookieStorage}
+// Headers used by chunked binary upload and download
+const (
+	ChecksumHeader   = "X-Chunk-Checksum"
+	ChunkSizeHeader  = "X-Chunk-Size"
+	BinarySizeHeader = "X-Binary-Size"
+)
+
+const (
+	// requestTimeout limits the time of handling any request except the streaming ones
+	requestTimeout = 2 * time.Second
+	// deletionTimeout limits the time of deleting an account instead of requestTimeout, the password is checked
+	// with bcrypt and every secret of the user is deleted in one transaction, that may well take longer
+	deletionTimeout = 30 * time.Second
+	// maxChunkSize is the largest part of a binary that can be uploaded at once
+	maxChunkSize = 16 << 20
+)
+
+// streamingEndpoints are the routes moving large amounts of data or lasting for long, so they are not limited
+// by requestTimeout. The change of the password replaces every secret of the user at once,
+// the export sends all of them.
+var streamingEndpoints = map[string]bool{
+	PutBinaryChunkEndpoint: true,
+	StreamBinaryEndpoint:   true,
+	EventsEndpoint:         true,
+	PasswordEndpoint:       true,
+	ExportEndpoint:         true,
+}
+
+// deletionEndpoints are the routes deleting the accounts along with the methods they are deleted by,
+// they are limited by deletionTimeout, the other methods of the same routes are limited by requestTimeout
+var deletionEndpoints = map[string]string{
+	DeleteAccountEndpoint: http.MethodPost,
+	AdminUsersEndpoint:    http.MethodDelete,
+}
+
+// NewApp constructor for app. Access tokens are signed with config.TokenSecret or with a random secret if it's empty.
+// The changes of the secrets stored through App.UserStorage are announced to the clients listening to the events,
+// every access to them is recorded to the audit log kept by auditStorage.
+func NewApp(cfg config.Config, userStorage storage.UserStorage, auditStorage storage.AuditStorage) *App {
+	tokenSecret := []byte(cfg.TokenSecret)
+	if len(tokenSecret) == 0 {
+		secret, err := tools.GenerateRandomString(32)
+		if err != nil {
+			log.Fatalf("generate token secret: %s", err)
+		}
+		log.Printf("token secret is not set, access tokens will become invalid on restart")
+		tokenSecret = []byte(secret)
+	}
+	events := newEventBroker()
+	return &App{config: cfg, UserStorage: auditingStorage{UserStorage: notifyingStorage{UserStorage: userStorage,
+		events: events}, audit: auditStorage},
+		AuditStorage:   auditStorage,
+		tokenSecret:    tokenSecret,
+		ipLimiter:      newLimiter(freeAttempts*ipFailureFactor, cfg.LoginMaxFailures*ipFailureFactor, cfg.LoginLockout),
+		accountLimiter: newLimiter(freeAttempts, cfg.LoginMaxFailures, cfg.LoginLockout),
+		metrics:        newMetrics(userStorage),
+		events:         events}
+}
+
+// Start starts the REST server and the gRPC server alongside if config.GRPCAddress is set, both of them use TLS
+// if it is configured. It blocks until the context is done or one of the servers fails, the servers keep working
+// after that until Shutdown is called.
+// The admin role is given to the registered users listed in config.AdminLogins before the servers start,
+// the admins who are no longer listed are demoted, see promoteAdmins.
+func (app *App) Start(ctx context.Context) error {
+	tlsConfig, err := app.tlsConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", app.config.ServerAddress)
+	if err != nil {
+		return err
+	}
+	var grpcListener net.Listener
+	if app.config.GRPCAddress != "" {
+		grpcListener, err = net.Listen("tcp", app.config.GRPCAddress)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+	}
+
+	app.mu.Lock()
+	if app.server != nil {
+		app.mu.Unlock()
+		listener.Close()
+		if grpcListener != nil {
+			grpcListener.Close()
+		}
+		return errors.New("app is already started")
+	}
+	app.promoteAdmins(ctx)
+	app.server = &http.Server{Handler: app.router(), TLSConfig: tlsConfig}
+	if grpcListener != nil {
+		app.grpcServer = app.newGRPCServer(tlsConfig)
+	}
+	server, grpcServer := app.server, app.grpcServer
+	app.mu.Unlock()
+
+	serveErr := make(chan error, 2)
+	go func() {
+		if tlsConfig != nil {
+			serveErr <- server.ServeTLS(listener, "", "")
+			return
+		}
+		serveErr <- server.Serve(listener)
+	}()
+	if grpcServer != nil {
+		go func() {
+			serveErr <- grpcServer.Serve(grpcListener)
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err = <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) || errors.Is(err, grpc.ErrServerStopped) {
+			return nil
+		}
+		return err
+	}
 }
 
-// Run creates routing and holds all the handlers.
-func (app *App) Run() {
+// Shutdown reports the App not ready at once and keeps serving for config.ShutdownDelay, so that the orchestrator
+// could stop sending the requests. Then it stops the servers from accepting new requests and waits for the ones
+// in flight, uploads and downloads included, until the context is done. The requests left by then are dropped.
+// The streams of events are ended right away.
+func (app *App) Shutdown(ctx context.Context) error {
+	app.shuttingDown.Store(true)
+	app.mu.Lock()
+	server, grpcServer := app.server, app.grpcServer
+	app.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+
+	if app.config.ShutdownDelay > 0 {
+		select {
+		case <-time.After(app.config.ShutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	// the streams of events last until the clients disconnect, so they are ended before waiting for the requests
+	app.events.close()
+
+	grpcStopped := make(chan struct{})
+	if grpcServer != nil {
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+	}
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		server.Close()
+	}
+	if grpcServer == nil {
+		return err
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		<-grpcStopped
+		err = ctx.Err()
+	}
+	return err
+}
+
+// router creates routing and holds all the handlers
+func (app *App) router() http.Handler {
 	router := mux.NewRouter()
-	router.Use(tools.GzipMiddleware, app.addContext)
+	router.Use(app.logRequests, app.measureRequests, tools.GzipMiddleware, app.addContext)
+	router.Handle(MetricsEndpoint, app.metrics.handler()).Methods(http.MethodGet)
+	router.HandleFunc(HealthEndpoint, app.healthz).Methods(http.MethodGet)
+	router.HandleFunc(ReadyEndpoint, app.readyz).Methods(http.MethodGet)
+	router.HandleFunc(OpenAPIEndpoint, app.openAPI).Methods(http.MethodGet)
 	router.HandleFunc(GetWindows, app.handleDownload).Methods(http.MethodGet)
 	router.HandleFunc(GetLinux, app.handleDownload).Methods(http.MethodGet)
 	router.HandleFunc(GetMac, app.handleDownload).Methods(http.MethodGet)
-	router.HandleFunc(RegisterEndpoint, app.register).Methods(http.MethodPost)
-	router.HandleFunc(LoginEndpoint, app.login).Methods(http.MethodPost)
+	router.HandleFunc(RegisterEndpoint, app.limitAttempts(app.register)).Methods(http.MethodPost)
+	router.HandleFunc(LoginEndpoint, app.limitAttempts(app.login)).Methods(http.MethodPost)
+	router.HandleFunc(LoginTwoFactorEndpoint, app.limitAttempts(app.loginTwoFactor)).Methods(http.MethodPost)
+	router.HandleFunc(RefreshTokenEndpoint, app.refreshToken).Methods(http.MethodPost)
+	router.HandleFunc(LogoutEndpoint, app.isAuthorized(app.logout)).Methods(http.MethodPost)
+	router.HandleFunc(PasswordEndpoint, app.isAuthorized(app.changePassword)).Methods(http.MethodPost)
+	router.HandleFunc(ExportEndpoint, app.isAuthorized(app.exportVault)).Methods(http.MethodGet)
+	router.HandleFunc(DeleteAccountEndpoint, app.isAuthorized(app.deleteAccount)).Methods(http.MethodPost)
+	router.HandleFunc(SessionsEndpoint, app.isAuthorized(app.listSessions)).Methods(http.MethodGet)
+	router.HandleFunc(SessionsEndpoint, app.isAuthorized(app.revokeSession)).Methods(http.MethodDelete)
+	router.HandleFunc(EnrollTwoFactorEndpoint, app.isAuthorized(app.enrollTwoFactor)).Methods(http.MethodPost)
+	router.HandleFunc(VerifyTwoFactorEndpoint, app.isAuthorized(app.verifyTwoFactor)).Methods(http.MethodPost)
+	router.HandleFunc(TwoFactorEndpoint, app.isAuthorized(app.disableTwoFactor)).Methods(http.MethodDelete)
 	router.HandleFunc(PutLogoPassEndpoint, app.isAuthorized(app.uploadLogoPass)).Methods(http.MethodPost)
 	router.HandleFunc(PutTextEndpoint, app.isAuthorized(app.uploadText)).Methods(http.MethodPost)
 	router.HandleFunc(PutCreditCardEndpoint, app.isAuthorized(app.uploadCreditCard)).Methods(http.MethodPost)
@@ -59,7 +298,45 @@ func (app *App) Run() {
 	router.HandleFunc(GetCreditCardsEndpoint, app.isAuthorized(app.batchDownloadCreditCards)).Methods(http.MethodGet)
 	router.HandleFunc(GetBinaryListEndpoint, app.isAuthorized(app.downloadBinaryList)).Methods(http.MethodGet)
 	router.HandleFunc(GetBinaryEndpoint, app.isAuthorized(app.downloadBinary)).Methods(http.MethodPost)
-	router.NotFoundHandler = http.HandlerFunc(app.handleDefault)
-
-	log.Fatal(http.ListenAndServe(app.config.ServerAddress, router))
+	router.HandleFunc(DeleteLogoPassEndpoint, app.isAuthorized(app.deleteLogoPass)).Methods(http.MethodDelete)
+	router.HandleFunc(DeleteTextEndpoint, app.isAuthorized(app.deleteText)).Methods(http.MethodDelete)
+	router.HandleFunc(DeleteCreditCardEndpoint, app.isAuthorized(app.deleteCreditCard)).Methods(http.MethodDelete)
+	router.HandleFunc(DeleteBinaryEndpoint, app.isAuthorized(app.deleteBinary)).Methods(http.MethodDelete)
+	router.HandleFunc(StartBinaryUploadEndpoint, app.isAuthorized(app.startBinaryUpload)).Methods(http.MethodPost)
+	router.HandleFunc(GetBinaryUploadEndpoint, app.isAuthorized(app.getBinaryUpload)).Methods(http.MethodGet)
+	router.HandleFunc(PutBinaryChunkEndpoint, app.isAuthorized(app.uploadBinaryChunk)).Methods(http.MethodPut)
+	router.HandleFunc(CompleteBinaryUploadEndpoint, app.isAuthorized(app.completeBinaryUpload)).Methods(http.MethodPost)
+	router.HandleFunc(StreamBinaryEndpoint, app.isAuthorized(app.streamBinary)).Methods(http.MethodGet)
+	router.HandleFunc(SyncEndpoint, app.isAuthorized(app.sync)).Methods(http.MethodGet)
+	router.HandleFunc(HistoryEndpoint, app.isAuthorized(app.history)).Methods(http.MethodGet)
+	router.HandleFunc(RestoreVersionEndpoint, app.isAuthorized(app.restoreVersion)).Methods(http.MethodPost)
+	router.HandleFunc(EventsEndpoint, app.isAuthorized(app.streamEvents)).Methods(http.MethodGet)
+	router.HandleFunc(UsageEndpoint, app.isAuthorized(app.usage)).Methods(http.MethodGet)
+	router.HandleFunc(AuditEndpoint, app.isAuthorized(app.auditLog)).Methods(http.MethodGet)
+	router.HandleFunc(KeysEndpoint, app.isAuthorized(app.getKeyPair)).Methods(http.MethodGet)
+	router.HandleFunc(KeysEndpoint, app.isAuthorized(app.putKeyPair)).Methods(http.MethodPut)
+	router.HandleFunc(SharesEndpoint, app.isAuthorized(app.listShares)).Methods(http.MethodGet)
+	router.HandleFunc(SharesEndpoint, app.isAuthorized(app.putShare)).Methods(http.MethodPost)
+	router.HandleFunc(SharesEndpoint, app.isAuthorized(app.deleteShare)).Methods(http.MethodDelete)
+	router.HandleFunc(AcceptShareEndpoint, app.isAuthorized(app.acceptShare)).Methods(http.MethodPost)
+	router.HandleFunc(ChangeShareEndpoint, app.isAuthorized(app.changeShare)).Methods(http.MethodPost)
+	router.HandleFunc(OrgsEndpoint, app.isAuthorized(app.listOrgs)).Methods(http.MethodGet)
+	router.HandleFunc(OrgsEndpoint, app.isAuthorized(app.createOrg)).Methods(http.MethodPost)
+	router.HandleFunc(OrgsEndpoint, app.isAuthorized(app.deleteOrg)).Methods(http.MethodDelete)
+	router.HandleFunc(OrgMembersEndpoint, app.isAuthorized(app.putMember)).Methods(http.MethodPost)
+	router.HandleFunc(OrgMembersEndpoint, app.isAuthorized(app.deleteMember)).Methods(http.MethodDelete)
+	router.HandleFunc(CollectionsEndpoint, app.isAuthorized(app.putCollection)).Methods(http.MethodPost)
+	router.HandleFunc(CollectionsEndpoint, app.isAuthorized(app.deleteCollection)).Methods(http.MethodDelete)
+	router.HandleFunc(CollectionMembersEndpoint, app.isAuthorized(app.putCollectionMember)).Methods(http.MethodPost)
+	router.HandleFunc(CollectionMembersEndpoint, app.isAuthorized(app.deleteCollectionMember)).Methods(http.MethodDelete)
+	router.HandleFunc(SendEndpoint, app.isAuthorized(app.createSend)).Methods(http.MethodPost)
+	router.HandleFunc(SendEndpoint, app.limitAttempts(app.getSend)).Methods(http.MethodGet)
+	router.HandleFunc(AdminUsersEndpoint, app.isAdmin(app.listUsers)).Methods(http.MethodGet)
+	router.HandleFunc(AdminUsersEndpoint, app.isAdmin(app.deleteUser)).Methods(http.MethodDelete)
+	router.HandleFunc(AdminDisableUserEndpoint, app.isAdmin(app.disableUser)).Methods(http.MethodPost)
+	router.HandleFunc(AdminEnableUserEndpoint, app.isAdmin(app.enableUser)).Methods(http.MethodPost)
+	router.HandleFunc(AdminLogoutUserEndpoint, app.isAdmin(app.logoutUser)).Methods(http.MethodPost)
+	// the middlewares are applied to the matched routes only, the rest are counted under unmatchedRoute
+	router.NotFoundHandler = app.measureRequests(http.HandlerFunc(app.handleDefault))
+	return router
 }
@@ -1,19 +1,45 @@
 package app
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/caarlos0/env/v6"
 	"github.com/go-resty/resty/v2"
-	"github.com/gorilla/sessions"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"gophkeeper/internal/config"
+	"gophkeeper/internal/logging"
+	pb "gophkeeper/internal/proto"
 	"gophkeeper/internal/service"
 	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
 	"gorm.io/gorm"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -30,30 +56,54 @@ func TestApp(t *testing.T) {
 	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "Server address")
 	flag.Parse()
 
-	var secretKey = "watch?v=Qw4w9WgXcQ"
-	userStorage := storage.NewUserStorage(cfg.DatabaseDSN)
-	cookieStorage := sessions.NewCookieStore([]byte(secretKey))
-	var app = NewApp(cfg, userStorage, *cookieStorage)
-	go app.Run()
+	userStorage := storage.NewUserStorage(cfg.DatabaseDSN, storage.Quotas{MaxBytes: cfg.QuotaBytes,
+		MaxBinarySize: cfg.QuotaBinarySize, MaxItems: cfg.QuotaItems})
+	var app = NewApp(cfg, userStorage, userStorage)
+	go app.Start(context.Background())
 
 	app.UserStorage.DeleteAll()
 
-	cookies := AuthTest(t, app)
-	PutLogoPassTest(t, app, cookies)
-	PutTextTest(t, app, cookies)
-	PutCreditCardTest(t, app, cookies)
-	PutBinaryTest(t, app, cookies)
-	GetLogoPassesTest(t, app, cookies)
-	GetTextsTest(t, app, cookies)
-	GetCreditCardsTest(t, app, cookies)
-	GetBinaryListTest(t, app, cookies)
-	GetBinaryTest(t, app, cookies)
+	tokens := AuthTest(t, app)
+	PutLogoPassTest(t, app, tokens)
+	PutTextTest(t, app, tokens)
+	PutCreditCardTest(t, app, tokens)
+	PutBinaryTest(t, app, tokens)
+	GetLogoPassesTest(t, app, tokens)
+	GetTextsTest(t, app, tokens)
+	GetCreditCardsTest(t, app, tokens)
+	GetBinaryListTest(t, app, tokens)
+	GetBinaryTest(t, app, tokens)
+	ChunkedBinaryTest(t, app, tokens)
+	DeleteTest(t, app, tokens)
+	SyncTest(t, app, tokens)
+	EventsTest(t, app, tokens)
+	UsageTest(t, app, tokens)
+	HistoryTest(t, app, tokens)
+	ShareTest(t, app, tokens)
+	OrgTest(t, app, tokens)
+	SendTest(t, app, tokens)
+	AuditTest(t, app, tokens)
+	RefreshTokenTest(t, app, tokens)
+	SessionsTest(t, app)
+	AdminTest(t, app, tokens)
+	PasswordTest(t, app)
+	AccountTest(t, app, tokens)
+	TwoFactorTest(t, app)
+	LockoutTest(t, app)
+	MetricsTest(t, app)
+	LoggingTest(t, app)
+	OpenAPITest(t, app)
+	GRPCTest(t, app)
+	TLSTest(t, app)
+	HealthTest(t, app)
+	ShutdownTest(t, app)
 
 	app.UserStorage.DeleteAll()
+	require.NoError(t, app.UserStorage.Close())
 }
 
-func AuthTest(t *testing.T, app *App) []http.Cookie {
-	var cookies []http.Cookie
+func AuthTest(t *testing.T, app *App) []service.Tokens {
+	var tokens []service.Tokens
 	type want struct {
 		statusCode  int
 		contentType string
@@ -72,7 +122,8 @@ func AuthTest(t *testing.T, app *App) []http.Cookie {
 				Password: "giveyouup",
 			},
 			want: want{
-				statusCode: http.StatusOK,
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
 			},
 		},
 		{
@@ -83,7 +134,8 @@ func AuthTest(t *testing.T, app *App) []http.Cookie {
 				Password: "and so do I",
 			},
 			want: want{
-				statusCode: http.StatusOK,
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
 			},
 		},
 		{
@@ -130,7 +182,8 @@ func AuthTest(t *testing.T, app *App) []http.Cookie {
 				Password: "giveyouup",
 			},
 			want: want{
-				statusCode: http.StatusOK,
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
 			},
 		},
 		{
@@ -141,7 +194,8 @@ func AuthTest(t *testing.T, app *App) []http.Cookie {
 				Password: "and so do I",
 			},
 			want: want{
-				statusCode: http.StatusOK,
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
 			},
 		},
 	}
@@ -158,16 +212,19 @@ func AuthTest(t *testing.T, app *App) []http.Cookie {
 			assert.Equal(t, tt.want.contentType, result.Header().Get("Content-Type"))
 			if tt.addr == LoginEndpoint {
 				if result.StatusCode() == http.StatusOK {
-					s := result.Cookies()
-					cookies = append(cookies, *s[0])
+					var resp service.Tokens
+					err = json.Unmarshal(result.Body(), &resp)
+					require.NoError(t, err)
+					assert.Equal(t, "Bearer", resp.TokenType)
+					tokens = append(tokens, resp)
 				}
 			}
 		})
 	}
-	return cookies
+	return tokens
 }
 
-func PutLogoPassTest(t *testing.T, app *App, cookies []http.Cookie) {
+func PutLogoPassTest(t *testing.T, app *App, tokens []service.Tokens) {
 
 	type want struct {
 		statusCode  int
@@ -177,7 +234,9 @@ func PutLogoPassTest(t *testing.T, app *App, cookies []http.Cookie) {
 		name string
 		addr string
 		data service.LogoPass
-		want want
+		// ifMatch is the ETag sent in If-Match header: the "current" one or the "stale" one, none if empty
+		ifMatch string
+		want    want
 	}{
 		{
 			name: "logoPass upload ok",
@@ -205,8 +264,9 @@ func PutLogoPassTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "logoPass update ok",
-			addr: PutLogoPassEndpoint,
+			name:    "logoPass update ok",
+			addr:    PutLogoPassEndpoint,
+			ifMatch: "current",
 			data: service.LogoPass{
 				SecretLogin: "aaa",
 				SecretPass:  "aaa",
@@ -221,7 +281,25 @@ func PutLogoPassTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "logoPass update conflict: old data",
+			name:    "logoPass update conflict: stale revision",
+			addr:    PutLogoPassEndpoint,
+			ifMatch: "stale",
+			data: service.LogoPass{
+				SecretLogin: "aaa",
+				SecretPass:  "aaa",
+				Description: "aaa",
+				Overwrite:   true,
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * -10),
+				},
+			},
+			want: want{
+				statusCode:  http.StatusPreconditionFailed,
+				contentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "logoPass update conflict: no revision",
 			addr: PutLogoPassEndpoint,
 			data: service.LogoPass{
 				SecretLogin: "aaa",
@@ -233,26 +311,38 @@ func PutLogoPassTest(t *testing.T, app *App, cookies []http.Cookie) {
 				},
 			},
 			want: want{
-				statusCode:  http.StatusConflict,
+				statusCode:  http.StatusPreconditionRequired,
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
 	}
+	etags := make(map[string]string)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := resty.New().R().SetHeader("Content-Type", "application/json").
-				SetBody(tt.data).SetCookie(&cookies[0])
+				SetBody(tt.data).SetAuthToken(tokens[0].AccessToken)
+			if tt.ifMatch != "" {
+				request.SetHeader("If-Match", etags[tt.ifMatch])
+			}
 
 			result, err := request.Post("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
 
 			assert.Equal(t, tt.want.statusCode, result.StatusCode())
 			assert.Equal(t, tt.want.contentType, result.Header().Get("Content-Type"))
+			switch result.StatusCode() {
+			case http.StatusCreated:
+				require.NotEmpty(t, result.Header().Get("ETag"))
+				etags["stale"], etags["current"] = etags["current"], result.Header().Get("ETag")
+			case http.StatusPreconditionFailed:
+				// the entry is sent back as it is stored
+				assert.Equal(t, etags["current"], result.Header().Get("ETag"))
+			}
 		})
 	}
 }
 
-func PutTextTest(t *testing.T, app *App, cookies []http.Cookie) {
+func PutTextTest(t *testing.T, app *App, tokens []service.Tokens) {
 
 	type want struct {
 		statusCode  int
@@ -262,7 +352,9 @@ func PutTextTest(t *testing.T, app *App, cookies []http.Cookie) {
 		name string
 		addr string
 		data service.TextData
-		want want
+		// ifMatch is the ETag sent in If-Match header: the "current" one or the "stale" one, none if empty
+		ifMatch string
+		want    want
 	}{
 		{
 			name: "text upload ok",
@@ -288,8 +380,9 @@ func PutTextTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "text update ok",
-			addr: PutTextEndpoint,
+			name:    "text update ok",
+			addr:    PutTextEndpoint,
+			ifMatch: "current",
 			data: service.TextData{
 				Text:        "never gonna run around, desert you",
 				Description: "aaa",
@@ -303,7 +396,24 @@ func PutTextTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "text update conflict: old data",
+			name:    "text update conflict: stale revision",
+			addr:    PutTextEndpoint,
+			ifMatch: "stale",
+			data: service.TextData{
+				Text:        "never gonna run around, desert you",
+				Description: "aaa",
+				Overwrite:   true,
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * -10),
+				},
+			},
+			want: want{
+				statusCode:  http.StatusPreconditionFailed,
+				contentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "text update conflict: no revision",
 			addr: PutTextEndpoint,
 			data: service.TextData{
 				Text:        "never gonna run around, desert you",
@@ -314,26 +424,38 @@ func PutTextTest(t *testing.T, app *App, cookies []http.Cookie) {
 				},
 			},
 			want: want{
-				statusCode:  http.StatusConflict,
+				statusCode:  http.StatusPreconditionRequired,
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
 	}
+	etags := make(map[string]string)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := resty.New().R().SetHeader("Content-Type", "application/json").
-				SetBody(tt.data).SetCookie(&cookies[0])
+				SetBody(tt.data).SetAuthToken(tokens[0].AccessToken)
+			if tt.ifMatch != "" {
+				request.SetHeader("If-Match", etags[tt.ifMatch])
+			}
 
 			result, err := request.Post("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
 
 			assert.Equal(t, tt.want.statusCode, result.StatusCode())
 			assert.Equal(t, tt.want.contentType, result.Header().Get("Content-Type"))
+			switch result.StatusCode() {
+			case http.StatusCreated:
+				require.NotEmpty(t, result.Header().Get("ETag"))
+				etags["stale"], etags["current"] = etags["current"], result.Header().Get("ETag")
+			case http.StatusPreconditionFailed:
+				// the entry is sent back as it is stored
+				assert.Equal(t, etags["current"], result.Header().Get("ETag"))
+			}
 		})
 	}
 }
 
-func PutCreditCardTest(t *testing.T, app *App, cookies []http.Cookie) {
+func PutCreditCardTest(t *testing.T, app *App, tokens []service.Tokens) {
 
 	type want struct {
 		statusCode  int
@@ -343,7 +465,9 @@ func PutCreditCardTest(t *testing.T, app *App, cookies []http.Cookie) {
 		name string
 		addr string
 		data service.CreditCard
-		want want
+		// ifMatch is the ETag sent in If-Match header: the "current" one or the "stale" one, none if empty
+		ifMatch string
+		want    want
 	}{
 		{
 			name: "Credit card upload ok",
@@ -375,8 +499,9 @@ func PutCreditCardTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "Credit card update ok",
-			addr: PutCreditCardEndpoint,
+			name:    "Credit card update ok",
+			addr:    PutCreditCardEndpoint,
+			ifMatch: "current",
 			data: service.CreditCard{
 				Number:      "1111",
 				Holder:      "Rick Astley",
@@ -393,7 +518,27 @@ func PutCreditCardTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "Credit card update conflict: old data",
+			name:    "Credit card update conflict: stale revision",
+			addr:    PutCreditCardEndpoint,
+			ifMatch: "stale",
+			data: service.CreditCard{
+				Number:      "1111",
+				Holder:      "Rick Astley",
+				DueDate:     "12/2025",
+				CVV:         "123",
+				Description: "aaa",
+				Overwrite:   true,
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * -10),
+				},
+			},
+			want: want{
+				statusCode:  http.StatusPreconditionFailed,
+				contentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "Credit card update conflict: no revision",
 			addr: PutCreditCardEndpoint,
 			data: service.CreditCard{
 				Number:      "1111",
@@ -407,26 +552,38 @@ func PutCreditCardTest(t *testing.T, app *App, cookies []http.Cookie) {
 				},
 			},
 			want: want{
-				statusCode:  http.StatusConflict,
+				statusCode:  http.StatusPreconditionRequired,
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
 	}
+	etags := make(map[string]string)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := resty.New().R().SetHeader("Content-Type", "application/json").
-				SetBody(tt.data).SetCookie(&cookies[0])
+				SetBody(tt.data).SetAuthToken(tokens[0].AccessToken)
+			if tt.ifMatch != "" {
+				request.SetHeader("If-Match", etags[tt.ifMatch])
+			}
 
 			result, err := request.Post("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
 
 			assert.Equal(t, tt.want.statusCode, result.StatusCode())
 			assert.Equal(t, tt.want.contentType, result.Header().Get("Content-Type"))
+			switch result.StatusCode() {
+			case http.StatusCreated:
+				require.NotEmpty(t, result.Header().Get("ETag"))
+				etags["stale"], etags["current"] = etags["current"], result.Header().Get("ETag")
+			case http.StatusPreconditionFailed:
+				// the entry is sent back as it is stored
+				assert.Equal(t, etags["current"], result.Header().Get("ETag"))
+			}
 		})
 	}
 }
 
-func PutBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
+func PutBinaryTest(t *testing.T, app *App, tokens []service.Tokens) {
 
 	type want struct {
 		statusCode  int
@@ -436,7 +593,9 @@ func PutBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 		name string
 		addr string
 		data service.BinaryData
-		want want
+		// ifMatch is the ETag sent in If-Match header: the "current" one or the "stale" one, none if empty
+		ifMatch string
+		want    want
 	}{
 		{
 			name: "binary upload ok",
@@ -463,8 +622,9 @@ func PutBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "binary update ok",
-			addr: PutBinaryEndpoint,
+			name:    "binary update ok",
+			addr:    PutBinaryEndpoint,
+			ifMatch: "current",
 			data: service.BinaryData{
 				Login:       "nevergonna",
 				Binary:      "mЕYђ8+012gЎZQСBБ00516МЖЪQ”dм™±cgѕџфДлИдЏ'уЮ",
@@ -479,7 +639,25 @@ func PutBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name: "binary update conflict: old data",
+			name:    "binary update conflict: stale revision",
+			addr:    PutBinaryEndpoint,
+			ifMatch: "stale",
+			data: service.BinaryData{
+				Login:       "nevergonna",
+				Binary:      "mЕYђ8+012gЎZQСBБ00516МЖЪQ”dм™±cgѕџфДлИдЏ'уЮ",
+				Description: "aaa",
+				Overwrite:   true,
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * -10),
+				},
+			},
+			want: want{
+				statusCode:  http.StatusPreconditionFailed,
+				contentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "binary update conflict: no revision",
 			addr: PutBinaryEndpoint,
 			data: service.BinaryData{
 				Login:       "nevergonna",
@@ -491,42 +669,54 @@ func PutBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 				},
 			},
 			want: want{
-				statusCode:  http.StatusConflict,
+				statusCode:  http.StatusPreconditionRequired,
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
 	}
+	etags := make(map[string]string)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := resty.New().R().SetHeader("Content-Type", "application/json").
-				SetBody(tt.data).SetCookie(&cookies[0])
+				SetBody(tt.data).SetAuthToken(tokens[0].AccessToken)
+			if tt.ifMatch != "" {
+				request.SetHeader("If-Match", etags[tt.ifMatch])
+			}
 
 			result, err := request.Post("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
 
 			assert.Equal(t, tt.want.statusCode, result.StatusCode())
 			assert.Equal(t, tt.want.contentType, result.Header().Get("Content-Type"))
+			switch result.StatusCode() {
+			case http.StatusCreated:
+				require.NotEmpty(t, result.Header().Get("ETag"))
+				etags["stale"], etags["current"] = etags["current"], result.Header().Get("ETag")
+			case http.StatusPreconditionFailed:
+				// the entry is sent back as it is stored
+				assert.Equal(t, etags["current"], result.Header().Get("ETag"))
+			}
 		})
 	}
 }
 
-func GetLogoPassesTest(t *testing.T, app *App, cookies []http.Cookie) {
+func GetLogoPassesTest(t *testing.T, app *App, tokens []service.Tokens) {
 	type want struct {
 		statusCode  int
 		contentType string
 		bodyLen     int
 	}
 	tests := []struct {
-		name      string
-		addr      string
-		cookieNum int
-		data      []service.LogoPass
-		want      want
+		name     string
+		addr     string
+		tokenNum int
+		data     []service.LogoPass
+		want     want
 	}{
 		{
-			name:      "logopass list download ok",
-			addr:      GetLogoPassesEndpoint,
-			cookieNum: 0,
+			name:     "logopass list download ok",
+			addr:     GetLogoPassesEndpoint,
+			tokenNum: 0,
 			data: []service.LogoPass{
 				{
 					SecretLogin: "aaa",
@@ -540,9 +730,9 @@ func GetLogoPassesTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name:      "logopass list download fail: no content",
-			addr:      GetLogoPassesEndpoint,
-			cookieNum: 1,
+			name:     "logopass list download fail: no content",
+			addr:     GetLogoPassesEndpoint,
+			tokenNum: 1,
 			want: want{
 				statusCode: http.StatusNotFound,
 				bodyLen:    0,
@@ -551,7 +741,7 @@ func GetLogoPassesTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			request := resty.New().R().SetHeader("Content-Type", "application/json").SetCookie(&cookies[tt.cookieNum])
+			request := resty.New().R().SetHeader("Content-Type", "application/json").SetAuthToken(tokens[tt.tokenNum].AccessToken)
 
 			result, err := request.Get("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
@@ -572,23 +762,23 @@ func GetLogoPassesTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 }
 
-func GetTextsTest(t *testing.T, app *App, cookies []http.Cookie) {
+func GetTextsTest(t *testing.T, app *App, tokens []service.Tokens) {
 	type want struct {
 		statusCode  int
 		contentType string
 		bodyLen     int
 	}
 	tests := []struct {
-		name      string
-		addr      string
-		cookieNum int
-		data      []service.TextData
-		want      want
+		name     string
+		addr     string
+		tokenNum int
+		data     []service.TextData
+		want     want
 	}{
 		{
-			name:      "text list download ok",
-			addr:      GetTextsEndpoint,
-			cookieNum: 0,
+			name:     "text list download ok",
+			addr:     GetTextsEndpoint,
+			tokenNum: 0,
 			data: []service.TextData{
 				{
 					Text:        "never gonna run around, desert you",
@@ -601,9 +791,9 @@ func GetTextsTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name:      "text list download fail: no content",
-			addr:      GetTextsEndpoint,
-			cookieNum: 1,
+			name:     "text list download fail: no content",
+			addr:     GetTextsEndpoint,
+			tokenNum: 1,
 			want: want{
 				statusCode: http.StatusNotFound,
 				bodyLen:    0,
@@ -612,7 +802,7 @@ func GetTextsTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			request := resty.New().R().SetHeader("Content-Type", "application/json").SetCookie(&cookies[tt.cookieNum])
+			request := resty.New().R().SetHeader("Content-Type", "application/json").SetAuthToken(tokens[tt.tokenNum].AccessToken)
 
 			result, err := request.Get("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
@@ -633,23 +823,23 @@ func GetTextsTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 }
 
-func GetCreditCardsTest(t *testing.T, app *App, cookies []http.Cookie) {
+func GetCreditCardsTest(t *testing.T, app *App, tokens []service.Tokens) {
 	type want struct {
 		statusCode  int
 		contentType string
 		bodyLen     int
 	}
 	tests := []struct {
-		name      string
-		addr      string
-		cookieNum int
-		data      []service.CreditCard
-		want      want
+		name     string
+		addr     string
+		tokenNum int
+		data     []service.CreditCard
+		want     want
 	}{
 		{
-			name:      "credit cards download ok",
-			addr:      GetCreditCardsEndpoint,
-			cookieNum: 0,
+			name:     "credit cards download ok",
+			addr:     GetCreditCardsEndpoint,
+			tokenNum: 0,
 			data: []service.CreditCard{
 				{
 					Number:      "1111",
@@ -665,9 +855,9 @@ func GetCreditCardsTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name:      "credit cards download fail: no content",
-			addr:      GetCreditCardsEndpoint,
-			cookieNum: 1,
+			name:     "credit cards download fail: no content",
+			addr:     GetCreditCardsEndpoint,
+			tokenNum: 1,
 			want: want{
 				statusCode: http.StatusNotFound,
 				bodyLen:    0,
@@ -677,7 +867,7 @@ func GetCreditCardsTest(t *testing.T, app *App, cookies []http.Cookie) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := resty.New().R().SetHeader("Content-Type", "application/json").
-				SetCookie(&cookies[tt.cookieNum])
+				SetAuthToken(tokens[tt.tokenNum].AccessToken)
 
 			result, err := request.Get("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
@@ -698,23 +888,23 @@ func GetCreditCardsTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 }
 
-func GetBinaryListTest(t *testing.T, app *App, cookies []http.Cookie) {
+func GetBinaryListTest(t *testing.T, app *App, tokens []service.Tokens) {
 	type want struct {
 		statusCode  int
 		contentType string
 		bodyLen     int
 	}
 	tests := []struct {
-		name      string
-		addr      string
-		cookieNum int
-		data      []service.BinaryData
-		want      want
+		name     string
+		addr     string
+		tokenNum int
+		data     []service.BinaryData
+		want     want
 	}{
 		{
-			name:      "binary list download ok",
-			addr:      GetBinaryListEndpoint,
-			cookieNum: 0,
+			name:     "binary list download ok",
+			addr:     GetBinaryListEndpoint,
+			tokenNum: 0,
 			data: []service.BinaryData{
 				{
 					Description: "aaa",
@@ -726,9 +916,9 @@ func GetBinaryListTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name:      "binary list download fail: no content",
-			addr:      GetBinaryListEndpoint,
-			cookieNum: 1,
+			name:     "binary list download fail: no content",
+			addr:     GetBinaryListEndpoint,
+			tokenNum: 1,
 			want: want{
 				statusCode: http.StatusNotFound,
 				bodyLen:    0,
@@ -737,7 +927,7 @@ func GetBinaryListTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			request := resty.New().R().SetHeader("Content-Type", "application/json").SetCookie(&cookies[tt.cookieNum])
+			request := resty.New().R().SetHeader("Content-Type", "application/json").SetAuthToken(tokens[tt.tokenNum].AccessToken)
 
 			result, err := request.Get("http://" + app.config.ServerAddress + tt.addr)
 			require.NoError(t, err)
@@ -758,24 +948,24 @@ func GetBinaryListTest(t *testing.T, app *App, cookies []http.Cookie) {
 	}
 }
 
-func GetBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
+func GetBinaryTest(t *testing.T, app *App, tokens []service.Tokens) {
 	type want struct {
 		statusCode  int
 		contentType string
 		bodyLen     int
 	}
 	tests := []struct {
-		name      string
-		addr      string
-		cookieNum int
-		reqData   service.BinaryData
-		data      service.BinaryData
-		want      want
+		name     string
+		addr     string
+		tokenNum int
+		reqData  service.BinaryData
+		data     service.BinaryData
+		want     want
 	}{
 		{
-			name:      "binary download ok",
-			addr:      GetBinaryEndpoint,
-			cookieNum: 0,
+			name:     "binary download ok",
+			addr:     GetBinaryEndpoint,
+			tokenNum: 0,
 			reqData: service.BinaryData{
 				Description: "aaa",
 			},
@@ -789,9 +979,9 @@ func GetBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 			},
 		},
 		{
-			name:      "binary download fail: no content",
-			addr:      GetBinaryEndpoint,
-			cookieNum: 1,
+			name:     "binary download fail: no content",
+			addr:     GetBinaryEndpoint,
+			tokenNum: 1,
 			want: want{
 				statusCode: http.StatusNotFound,
 				bodyLen:    0,
@@ -802,7 +992,7 @@ func GetBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 		t.Run(tt.name, func(t *testing.T) {
 			body, _ := json.Marshal(tt.reqData)
 			request := resty.New().R().SetHeader("Content-Type", "application/json").
-				SetBody(body).SetCookie(&cookies[tt.cookieNum])
+				SetBody(body).SetAuthToken(tokens[tt.tokenNum].AccessToken)
 
 			result, err := request.Post("http://" + app.config.ServerAddress + tt.addr)
 
@@ -823,3 +1013,2664 @@ func GetBinaryTest(t *testing.T, app *App, cookies []http.Cookie) {
 		})
 	}
 }
+
+func DeleteTest(t *testing.T, app *App, tokens []service.Tokens) {
+	// the deletions are made to the revisions the entries are stored at
+	result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).
+		Get("http://" + app.config.ServerAddress + SyncEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, result.StatusCode())
+	var stored service.SyncData
+	require.NoError(t, json.Unmarshal(result.Body(), &stored))
+	revisions := make(map[string]int64)
+	for _, logoPass := range stored.LogoPasses {
+		revisions[storage.ItemLogoPass+logoPass.Description] = logoPass.Revision
+	}
+	for _, text := range stored.Texts {
+		revisions[storage.ItemText+text.Description] = text.Revision
+	}
+	for _, card := range stored.CreditCards {
+		revisions[storage.ItemCreditCard+card.Number] = card.Revision
+	}
+	for _, binary := range stored.Binaries {
+		revisions[storage.ItemBinary+binary.Description] = binary.Revision
+	}
+
+	type want struct {
+		statusCode  int
+		contentType string
+	}
+	tests := []struct {
+		name     string
+		addr     string
+		tokenNum int
+		data     interface{}
+		// ifMatch is the revision sent in If-Match header, none if 0
+		ifMatch int64
+		want    want
+	}{
+		{
+			name:     "logoPass delete conflict: stale revision",
+			addr:     DeleteLogoPassEndpoint,
+			tokenNum: 0,
+			data: service.LogoPass{
+				Description: "aaa",
+			},
+			ifMatch: revisions[storage.ItemLogoPass+"aaa"] - 1,
+			want: want{
+				statusCode:  http.StatusPreconditionFailed,
+				contentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name:     "logoPass delete conflict: no revision",
+			addr:     DeleteLogoPassEndpoint,
+			tokenNum: 0,
+			data: service.LogoPass{
+				Description: "aaa",
+			},
+			want: want{
+				statusCode:  http.StatusPreconditionRequired,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name:     "logoPass delete ok",
+			addr:     DeleteLogoPassEndpoint,
+			tokenNum: 0,
+			ifMatch:  revisions[storage.ItemLogoPass+"aaa"],
+			data: service.LogoPass{
+				Description: "aaa",
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * 10),
+				},
+			},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:     "logoPass delete fail: already deleted",
+			addr:     DeleteLogoPassEndpoint,
+			tokenNum: 0,
+			data: service.LogoPass{
+				Description: "aaa",
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * 10),
+				},
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "text delete ok",
+			addr:     DeleteTextEndpoint,
+			tokenNum: 0,
+			ifMatch:  revisions[storage.ItemText+"aaa"],
+			data: service.TextData{
+				Description: "aaa",
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * 10),
+				},
+			},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:     "credit card delete ok",
+			addr:     DeleteCreditCardEndpoint,
+			tokenNum: 0,
+			ifMatch:  revisions[storage.ItemCreditCard+"1111"],
+			data: service.CreditCard{
+				Number: "1111",
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * 10),
+				},
+			},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:     "binary delete ok",
+			addr:     DeleteBinaryEndpoint,
+			tokenNum: 0,
+			ifMatch:  revisions[storage.ItemBinary+"aaa"],
+			data: service.BinaryData{
+				Description: "aaa",
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * 10),
+				},
+			},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:     "binary delete fail: no content",
+			addr:     DeleteBinaryEndpoint,
+			tokenNum: 1,
+			data: service.BinaryData{
+				Description: "aaa",
+				Model: gorm.Model{
+					UpdatedAt: time.Now().Add(time.Minute * 10),
+				},
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(tt.data).SetAuthToken(tokens[tt.tokenNum].AccessToken)
+			if tt.ifMatch != 0 {
+				request.SetHeader("If-Match", strconv.Quote(strconv.FormatInt(tt.ifMatch, 10)))
+			}
+
+			result, err := request.Delete("http://" + app.config.ServerAddress + tt.addr)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+			assert.Equal(t, tt.want.contentType, result.Header().Get("Content-Type"))
+			if result.StatusCode() == http.StatusOK {
+				assert.NotEmpty(t, result.Header().Get("ETag"))
+			}
+		})
+	}
+
+	t.Run("logoPass tombstone is listed", func(t *testing.T) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").SetAuthToken(tokens[0].AccessToken)
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+
+		var resp []service.LogoPass
+		err = json.Unmarshal(result.Body(), &resp)
+		require.NoError(t, err)
+		require.Len(t, resp, 1)
+		assert.True(t, resp[0].DeletedAt.Valid)
+		assert.Empty(t, resp[0].SecretPass)
+	})
+}
+
+func SyncTest(t *testing.T, app *App, tokens []service.Tokens) {
+	sync := func(t *testing.T, cursor string) service.SyncData {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken)
+		if cursor != "" {
+			request.SetQueryParam("since", cursor)
+		}
+		result, err := request.Get("http://" + app.config.ServerAddress + SyncEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		var resp service.SyncData
+		err = json.Unmarshal(result.Body(), &resp)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Cursor)
+		return resp
+	}
+
+	var cursor string
+	t.Run("full sync ok", func(t *testing.T) {
+		resp := sync(t, "")
+		assert.True(t, resp.Complete)
+		require.Len(t, resp.LogoPasses, 1)
+		assert.True(t, resp.LogoPasses[0].DeletedAt.Valid)
+		assert.NotEmpty(t, resp.Texts)
+		assert.NotEmpty(t, resp.CreditCards)
+		assert.NotEmpty(t, resp.Binaries)
+		cursor = resp.Cursor
+	})
+
+	t.Run("delta sync ok: no changes", func(t *testing.T) {
+		resp := sync(t, cursor)
+		assert.False(t, resp.Complete)
+		assert.Empty(t, resp.LogoPasses)
+		assert.Empty(t, resp.Texts)
+		assert.Empty(t, resp.CreditCards)
+		assert.Empty(t, resp.Binaries)
+		assert.Equal(t, cursor, resp.Cursor)
+	})
+
+	t.Run("delta sync ok: changes since the cursor", func(t *testing.T) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.TextData{Text: "never gonna", Description: "sync", Model: gorm.Model{UpdatedAt: time.Now()}}).
+			SetAuthToken(tokens[0].AccessToken)
+		result, err := request.Post("http://" + app.config.ServerAddress + PutTextEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, result.StatusCode())
+
+		resp := sync(t, cursor)
+		assert.False(t, resp.Complete)
+		assert.Empty(t, resp.LogoPasses)
+		require.Len(t, resp.Texts, 1)
+		assert.Equal(t, "sync", resp.Texts[0].Description)
+		assert.NotEqual(t, cursor, resp.Cursor)
+	})
+
+	t.Run("sync ok: unknown cursor", func(t *testing.T) {
+		resp := sync(t, "1000000")
+		assert.True(t, resp.Complete)
+		assert.Len(t, resp.LogoPasses, 1)
+	})
+
+	t.Run("sync fail: invalid cursor", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("since", "never")
+		result, err := request.Get("http://" + app.config.ServerAddress + SyncEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode())
+	})
+}
+
+func EventsTest(t *testing.T, app *App, tokens []service.Tokens) {
+	t.Run("events fail: no access token", func(t *testing.T) {
+		result, err := resty.New().R().Get("http://" + app.config.ServerAddress + EventsEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("events ok: change announced", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		result, err := resty.New().R().SetContext(ctx).SetDoNotParseResponse(true).
+			SetHeader("Accept-Encoding", "identity").SetAuthToken(tokens[0].AccessToken).
+			Get("http://" + app.config.ServerAddress + EventsEndpoint)
+		require.NoError(t, err)
+		defer result.RawBody().Close()
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		assert.Equal(t, "text/event-stream", result.Header().Get("Content-Type"))
+
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.TextData{Text: "never gonna", Description: "events", Model: gorm.Model{UpdatedAt: time.Now()}}).
+			SetAuthToken(tokens[0].AccessToken)
+		put, err := request.Post("http://" + app.config.ServerAddress + PutTextEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, put.StatusCode())
+
+		scanner := bufio.NewScanner(result.RawBody())
+		require.True(t, scanner.Scan())
+		assert.Equal(t, "event: "+EventChange, scanner.Text())
+		require.True(t, scanner.Scan())
+		assert.Equal(t, `data: {"item":"text"}`, scanner.Text())
+	})
+
+	// signIn starts a new session of the user, stream opens the stream of events of the session
+	signIn := func(t *testing.T) service.Tokens {
+		login, err := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.User{Login: "nevergonna", Password: "giveyouup", Device: "events"}).
+			Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, login.StatusCode())
+		var session service.Tokens
+		require.NoError(t, json.Unmarshal(login.Body(), &session))
+		return session
+	}
+	stream := func(t *testing.T, ctx context.Context, accessToken string) io.ReadCloser {
+		result, err := resty.New().R().SetContext(ctx).SetDoNotParseResponse(true).
+			SetHeader("Accept-Encoding", "identity").SetAuthToken(accessToken).
+			Get("http://" + app.config.ServerAddress + EventsEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		return result.RawBody()
+	}
+
+	t.Run("events ok: stream ends once its session is revoked", func(t *testing.T) {
+		session := signIn(t)
+		list, err := resty.New().R().SetAuthToken(session.AccessToken).
+			Get("http://" + app.config.ServerAddress + SessionsEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, list.StatusCode())
+		var sessions []service.Session
+		require.NoError(t, json.Unmarshal(list.Body(), &sessions))
+		var current string
+		for _, s := range sessions {
+			if s.Current {
+				current = s.ID
+			}
+		}
+		require.NotEmpty(t, current)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		body := stream(t, ctx, session.AccessToken)
+		defer body.Close()
+
+		revoke, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("session_id", current).
+			Delete("http://" + app.config.ServerAddress + SessionsEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, revoke.StatusCode())
+
+		// the stream is closed by the App long before the heartbeat or the timeout
+		_, err = io.ReadAll(body)
+		assert.NoError(t, err)
+		assert.NoError(t, ctx.Err())
+	})
+
+	t.Run("events ok: stream ends once its refresh token is reused", func(t *testing.T) {
+		session := signIn(t)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		body := stream(t, ctx, session.AccessToken)
+		defer body.Close()
+
+		for _, statusCode := range []int{http.StatusOK, http.StatusUnauthorized} {
+			result, err := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(service.RefreshRequest{RefreshToken: session.RefreshToken}).
+				Post("http://" + app.config.ServerAddress + RefreshTokenEndpoint)
+			require.NoError(t, err)
+			require.Equal(t, statusCode, result.StatusCode())
+		}
+
+		_, err := io.ReadAll(body)
+		assert.NoError(t, err)
+		assert.NoError(t, ctx.Err())
+	})
+}
+
+func UsageTest(t *testing.T, app *App, tokens []service.Tokens) {
+	t.Run("usage ok", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).
+			Get("http://" + app.config.ServerAddress + UsageEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		var usage service.Usage
+		err = json.Unmarshal(result.Body(), &usage)
+		require.NoError(t, err)
+		assert.Positive(t, usage.Bytes)
+		assert.Positive(t, usage.Items[storage.ItemText])
+		assert.Equal(t, app.config.QuotaBytes, usage.MaxBytes)
+		assert.Equal(t, app.config.QuotaItems, usage.MaxItems)
+	})
+
+	// the quotas of the App are too large to be reached, so the storage with the tight ones is used
+	limited := storage.NewUserStorage(app.config.DatabaseDSN, storage.Quotas{MaxBytes: 1 << 20, MaxBinarySize: 8, MaxItems: 1})
+	defer limited.Close()
+
+	quotaTests := []struct {
+		name       string
+		put        func() error
+		wantErr    error
+		statusCode int
+	}{
+		{
+			name: "put text fail: too many items",
+			put: func() error {
+				_, err := limited.PutText(service.TextData{Login: "nevergonna", Text: "never gonna", Description: "quota",
+					Model: gorm.Model{UpdatedAt: time.Now()}}, context.Background())
+				return err
+			},
+			wantErr:    storage.ErrQuotaExceeded,
+			statusCode: http.StatusInsufficientStorage,
+		},
+		{
+			name: "put binary fail: too large",
+			put: func() error {
+				_, err := limited.PutBinary(service.BinaryData{Login: "nevergonna", Binary: "never gonna give",
+					Description: "quota", Model: gorm.Model{UpdatedAt: time.Now()}}, context.Background())
+				return err
+			},
+			wantErr:    storage.ErrBinaryTooLarge,
+			statusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range quotaTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.put()
+			require.ErrorIs(t, err, tt.wantErr)
+
+			recorder := httptest.NewRecorder()
+			require.True(t, quotaExceeded(recorder, err))
+			assert.Equal(t, tt.statusCode, recorder.Code)
+		})
+	}
+}
+
+func HistoryTest(t *testing.T, app *App, tokens []service.Tokens) {
+	var etag string
+	putText := func(t *testing.T, text string, updatedAt time.Time) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.TextData{Text: text, Description: "history", Overwrite: true,
+				Model: gorm.Model{UpdatedAt: updatedAt}})
+		if etag != "" {
+			request.SetHeader("If-Match", etag)
+		}
+		result, err := request.SetAuthToken(tokens[0].AccessToken).
+			Post("http://" + app.config.ServerAddress + PutTextEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, result.StatusCode())
+		etag = result.Header().Get("ETag")
+	}
+	getText := func(t *testing.T) service.TextData {
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).
+			Get("http://" + app.config.ServerAddress + GetTextsEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var texts []service.TextData
+		require.NoError(t, json.Unmarshal(result.Body(), &texts))
+		for _, text := range texts {
+			if text.Description == "history" {
+				return text
+			}
+		}
+		require.FailNow(t, "no text to keep the history of")
+		return service.TextData{}
+	}
+	getHistory := func(t *testing.T, token string, query map[string]string) []service.SecretVersion {
+		result, err := resty.New().R().SetAuthToken(token).SetQueryParams(query).
+			Get("http://" + app.config.ServerAddress + HistoryEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var versions []service.SecretVersion
+		require.NoError(t, json.Unmarshal(result.Body(), &versions))
+		return versions
+	}
+	restore := func(t *testing.T, token string, version service.SecretVersion, ifMatch string) int {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(version)
+		if ifMatch != "" {
+			request.SetHeader("If-Match", ifMatch)
+		}
+		result, err := request.SetAuthToken(token).Post("http://" + app.config.ServerAddress + RestoreVersionEndpoint)
+		require.NoError(t, err)
+		return result.StatusCode()
+	}
+
+	putText(t, "never gonna give you up", time.Now())
+	stale := etag
+	putText(t, "never gonna let you down", time.Now().Add(time.Minute))
+	text := getText(t)
+	query := map[string]string{"item": storage.ItemText, "item_id": strconv.FormatUint(uint64(text.ID), 10)}
+
+	var previous service.SecretVersion
+	t.Run("history ok", func(t *testing.T) {
+		versions := getHistory(t, tokens[0].AccessToken, query)
+		require.Len(t, versions, 1)
+		require.NotNil(t, versions[0].Text)
+		assert.Equal(t, storage.ItemText, versions[0].Item)
+		assert.Equal(t, text.ID, versions[0].ItemID)
+		assert.Equal(t, "never gonna give you up", versions[0].Text.Text)
+		previous = versions[0]
+	})
+
+	t.Run("history ok: someone else's is not shown", func(t *testing.T) {
+		assert.Empty(t, getHistory(t, tokens[1].AccessToken, query))
+	})
+
+	t.Run("history fail: invalid item", func(t *testing.T) {
+		for _, query := range []map[string]string{{"item": storage.ItemBinary}, {"item_id": "1"},
+			{"item": storage.ItemText, "item_id": "never"}} {
+			result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParams(query).
+				Get("http://" + app.config.ServerAddress + HistoryEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode(), query)
+		}
+	})
+
+	t.Run("restore fail: someone else's version", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, restore(t, tokens[1].AccessToken, previous, etag))
+	})
+
+	t.Run("restore fail: no If-Match", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionRequired, restore(t, tokens[0].AccessToken, previous, ""))
+	})
+
+	t.Run("restore fail: text changed since", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionFailed, restore(t, tokens[0].AccessToken, previous, stale))
+		assert.Equal(t, "never gonna let you down", getText(t).Text)
+	})
+
+	t.Run("restore ok", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, restore(t, tokens[0].AccessToken, previous, etag))
+		assert.Equal(t, "never gonna give you up", getText(t).Text)
+
+		versions := getHistory(t, tokens[0].AccessToken, query)
+		require.Len(t, versions, 2)
+		assert.Equal(t, "never gonna let you down", versions[0].Text.Text)
+	})
+
+	t.Run("restore ok: deleted secret is brought back", func(t *testing.T) {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetHeader("If-Match", strconv.FormatInt(getText(t).Revision, 10)).
+			SetBody(service.TextData{Description: "history", Model: gorm.Model{UpdatedAt: time.Now().Add(time.Hour)}}).
+			SetAuthToken(tokens[0].AccessToken).Delete("http://" + app.config.ServerAddress + DeleteTextEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		// there is nothing to overwrite, the tombstone is replaced without If-Match
+		require.Equal(t, http.StatusOK, restore(t, tokens[0].AccessToken, previous, ""))
+		assert.Equal(t, "never gonna give you up", getText(t).Text)
+	})
+
+	t.Run("restore fail: unknown version", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, restore(t, tokens[0].AccessToken, service.SecretVersion{ID: 1 << 30}, ""))
+	})
+}
+
+func ShareTest(t *testing.T, app *App, tokens []service.Tokens) {
+	putKeys := func(t *testing.T, token string, keys service.KeyPair) int {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(keys).
+			SetAuthToken(token).Put("http://" + app.config.ServerAddress + KeysEndpoint)
+		require.NoError(t, err)
+		return result.StatusCode()
+	}
+	getShares := func(t *testing.T, token string) []service.Share {
+		result, err := resty.New().R().SetAuthToken(token).Get("http://" + app.config.ServerAddress + SharesEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var shares []service.Share
+		require.NoError(t, json.Unmarshal(result.Body(), &shares))
+		return shares
+	}
+	putShare := func(t *testing.T, share service.Share) (int, service.Share) {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(share).
+			SetAuthToken(tokens[0].AccessToken).Post("http://" + app.config.ServerAddress + SharesEndpoint)
+		require.NoError(t, err)
+		var stored service.Share
+		if result.StatusCode() == http.StatusCreated {
+			require.NoError(t, json.Unmarshal(result.Body(), &stored))
+		}
+		return result.StatusCode(), stored
+	}
+	shareRequest := func(t *testing.T, method string, endpoint string, token string, id uint, body interface{}) int {
+		request := resty.New().R().SetAuthToken(token).SetQueryParam("share_id", strconv.FormatUint(uint64(id), 10))
+		if body != nil {
+			request.SetHeader("Content-Type", "application/json").SetBody(body)
+		}
+		result, err := request.Execute(method, "http://"+app.config.ServerAddress+endpoint)
+		require.NoError(t, err)
+		return result.StatusCode()
+	}
+
+	result, err := resty.New().R().SetHeader("Content-Type", "application/json").
+		SetBody(service.TextData{Text: "sealed for you know the rules", Description: "shared"}).
+		SetAuthToken(tokens[0].AccessToken).Post("http://" + app.config.ServerAddress + PutTextEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, result.StatusCode())
+	result, err = resty.New().R().SetAuthToken(tokens[0].AccessToken).
+		Get("http://" + app.config.ServerAddress + GetTextsEndpoint)
+	require.NoError(t, err)
+	var texts []service.TextData
+	require.NoError(t, json.Unmarshal(result.Body(), &texts))
+	var textID uint
+	for _, text := range texts {
+		if text.Description == "shared" {
+			textID = text.ID
+		}
+	}
+	require.NotZero(t, textID)
+
+	t.Run("keys fail: no private key", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, putKeys(t, tokens[0].AccessToken, service.KeyPair{PublicKey: "public"}))
+	})
+
+	t.Run("keys ok", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, putKeys(t, tokens[0].AccessToken,
+			service.KeyPair{PublicKey: "rick public", PrivateKey: "rick private"}))
+		require.Equal(t, http.StatusOK, putKeys(t, tokens[1].AccessToken,
+			service.KeyPair{PublicKey: "rules public", PrivateKey: "rules private"}))
+
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).
+			Get("http://" + app.config.ServerAddress + KeysEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var keys service.KeyPair
+		require.NoError(t, json.Unmarshal(result.Body(), &keys))
+		assert.Equal(t, service.KeyPair{PublicKey: "rick public", PrivateKey: "rick private"},
+			service.KeyPair{PublicKey: keys.PublicKey, PrivateKey: keys.PrivateKey})
+	})
+
+	t.Run("keys ok: only the public key of someone else", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("login", "you know the rules").
+			Get("http://" + app.config.ServerAddress + KeysEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var keys service.KeyPair
+		require.NoError(t, json.Unmarshal(result.Body(), &keys))
+		assert.Equal(t, "rules public", keys.PublicKey)
+		assert.Empty(t, keys.PrivateKey)
+	})
+
+	t.Run("keys fail: no keys", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("login", "nobody").
+			Get("http://" + app.config.ServerAddress + KeysEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, result.StatusCode())
+	})
+
+	share := service.Share{Recipient: "you know the rules", Item: storage.ItemText, ItemID: textID,
+		Access: service.AccessRead, Text: &service.TextData{Text: "sealed", Description: "shared"}}
+	tests := []struct {
+		name       string
+		share      func() service.Share
+		statusCode int
+	}{
+		{
+			name:       "share fail: with oneself",
+			share:      func() service.Share { s := share; s.Recipient = "nevergonna"; return s },
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "share fail: unknown access",
+			share:      func() service.Share { s := share; s.Access = "admin"; return s },
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "share fail: binaries can't be shared",
+			share:      func() service.Share { s := share; s.Item = storage.ItemBinary; return s },
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "share fail: no such secret",
+			share:      func() service.Share { s := share; s.ItemID = 1 << 30; return s },
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "share fail: recipient has no keys",
+			share:      func() service.Share { s := share; s.Recipient = "nobody"; return s },
+			statusCode: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusCode, _ := putShare(t, tt.share())
+			assert.Equal(t, tt.statusCode, statusCode)
+		})
+	}
+
+	var shareID uint
+	t.Run("share ok", func(t *testing.T) {
+		statusCode, stored := putShare(t, share)
+		require.Equal(t, http.StatusCreated, statusCode)
+		require.NotZero(t, stored.ID)
+		assert.False(t, stored.Accepted)
+		shareID = stored.ID
+
+		shares := getShares(t, tokens[1].AccessToken)
+		require.Len(t, shares, 1)
+		assert.Equal(t, "nevergonna", shares[0].Owner)
+		require.NotNil(t, shares[0].Text)
+		assert.Equal(t, "sealed", shares[0].Text.Text)
+
+		shares = getShares(t, tokens[0].AccessToken)
+		require.Len(t, shares, 1)
+		assert.False(t, shares[0].Pending)
+		assert.Nil(t, shares[0].Text)
+	})
+
+	t.Run("accept fail: made by the user", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, shareRequest(t, http.MethodPost, AcceptShareEndpoint,
+			tokens[0].AccessToken, shareID, nil))
+	})
+
+	t.Run("accept ok", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, shareRequest(t, http.MethodPost, AcceptShareEndpoint,
+			tokens[1].AccessToken, shareID, nil))
+		assert.True(t, getShares(t, tokens[1].AccessToken)[0].Accepted)
+	})
+
+	change := service.Share{ID: shareID, Text: &service.TextData{Text: "changed", Description: "shared"}}
+	t.Run("change fail: read-only", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, shareRequest(t, http.MethodPost, ChangeShareEndpoint,
+			tokens[1].AccessToken, shareID, change))
+	})
+
+	t.Run("change ok", func(t *testing.T) {
+		writable := share
+		writable.Access = service.AccessWrite
+		statusCode, stored := putShare(t, writable)
+		require.Equal(t, http.StatusCreated, statusCode)
+		assert.Equal(t, shareID, stored.ID)
+		assert.True(t, stored.Accepted)
+
+		require.Equal(t, http.StatusOK, shareRequest(t, http.MethodPost, ChangeShareEndpoint,
+			tokens[1].AccessToken, shareID, change))
+		shares := getShares(t, tokens[0].AccessToken)
+		require.Len(t, shares, 1)
+		assert.True(t, shares[0].Pending)
+		require.NotNil(t, shares[0].Text)
+		assert.Equal(t, "changed", shares[0].Text.Text)
+	})
+
+	t.Run("delete fail: no such share", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, shareRequest(t, http.MethodDelete, SharesEndpoint,
+			tokens[1].AccessToken, 1<<30, nil))
+	})
+
+	t.Run("delete ok: declined by the recipient", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, shareRequest(t, http.MethodDelete, SharesEndpoint,
+			tokens[1].AccessToken, shareID, nil))
+		assert.Empty(t, getShares(t, tokens[0].AccessToken))
+		assert.Empty(t, getShares(t, tokens[1].AccessToken))
+	})
+}
+
+func OrgTest(t *testing.T, app *App, tokens []service.Tokens) {
+	orgRequest := func(t *testing.T, method string, endpoint string, token string, query map[string]string,
+		body interface{}) (int, []byte) {
+		request := resty.New().R().SetAuthToken(token).SetQueryParams(query)
+		if body != nil {
+			request.SetHeader("Content-Type", "application/json").SetBody(body)
+		}
+		result, err := request.Execute(method, "http://"+app.config.ServerAddress+endpoint)
+		require.NoError(t, err)
+		return result.StatusCode(), result.Body()
+	}
+	getOrgs := func(t *testing.T, token string) []service.Organization {
+		statusCode, body := orgRequest(t, http.MethodGet, OrgsEndpoint, token, nil, nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		var orgs []service.Organization
+		require.NoError(t, json.Unmarshal(body, &orgs))
+		return orgs
+	}
+	id := func(id uint) string { return strconv.FormatUint(uint64(id), 10) }
+
+	t.Run("create fail: no key", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodPost, OrgsEndpoint, tokens[0].AccessToken, nil,
+			service.Organization{Name: "rickroll"})
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+	})
+
+	var org service.Organization
+	t.Run("create ok", func(t *testing.T) {
+		statusCode, body := orgRequest(t, http.MethodPost, OrgsEndpoint, tokens[0].AccessToken, nil,
+			service.Organization{Name: "rickroll", OrgKey: "sealed for rick"})
+		require.Equal(t, http.StatusCreated, statusCode)
+		require.NoError(t, json.Unmarshal(body, &org))
+		require.NotZero(t, org.ID)
+		assert.Equal(t, service.OrgRoleOwner, org.Role)
+	})
+
+	t.Run("create fail: name taken", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodPost, OrgsEndpoint, tokens[1].AccessToken, nil,
+			service.Organization{Name: "rickroll", OrgKey: "sealed for rules"})
+		assert.Equal(t, http.StatusConflict, statusCode)
+	})
+
+	member := service.OrgMember{OrgID: org.ID, Login: "you know the rules", Role: service.OrgRoleReadOnly,
+		OrgKey: "sealed for rules"}
+	tests := []struct {
+		name       string
+		token      string
+		member     func() service.OrgMember
+		statusCode int
+	}{
+		{
+			name:       "member fail: unknown role",
+			token:      tokens[0].AccessToken,
+			member:     func() service.OrgMember { m := member; m.Role = "janitor"; return m },
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "member fail: no keys",
+			token:      tokens[0].AccessToken,
+			member:     func() service.OrgMember { m := member; m.Login = "nobody"; return m },
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:       "member fail: not a member of the organization",
+			token:      tokens[1].AccessToken,
+			member:     func() service.OrgMember { return member },
+			statusCode: http.StatusNotFound,
+		},
+		{
+			name:       "member ok",
+			token:      tokens[0].AccessToken,
+			member:     func() service.OrgMember { return member },
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "member fail: read-only members can't manage",
+			token:      tokens[1].AccessToken,
+			member:     func() service.OrgMember { m := member; m.Role = service.OrgRoleAdmin; return m },
+			statusCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusCode, _ := orgRequest(t, http.MethodPost, OrgMembersEndpoint, tt.token, nil, tt.member())
+			assert.Equal(t, tt.statusCode, statusCode)
+		})
+	}
+
+	var collection service.Collection
+	t.Run("collection fail: not a manager", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodPost, CollectionsEndpoint, tokens[1].AccessToken, nil,
+			service.Collection{OrgID: org.ID, Name: "never gonna give you up"})
+		assert.Equal(t, http.StatusForbidden, statusCode)
+	})
+
+	t.Run("collection ok", func(t *testing.T) {
+		statusCode, body := orgRequest(t, http.MethodPost, CollectionsEndpoint, tokens[0].AccessToken, nil,
+			service.Collection{OrgID: org.ID, Name: "never gonna give you up"})
+		require.Equal(t, http.StatusCreated, statusCode)
+		require.NoError(t, json.Unmarshal(body, &collection))
+		require.NotZero(t, collection.ID)
+	})
+
+	secret := service.TextData{CollectionID: collection.ID, Text: "encrypted with the org key", Description: "team"}
+	collectionQuery := map[string]string{"collection_id": id(collection.ID)}
+	t.Run("collection secret ok", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodPost, PutTextEndpoint, tokens[0].AccessToken, nil, secret)
+		require.Equal(t, http.StatusCreated, statusCode)
+
+		statusCode, body := orgRequest(t, http.MethodGet, GetTextsEndpoint, tokens[0].AccessToken, nil, nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		assert.NotContains(t, string(body), secret.Text)
+	})
+
+	t.Run("collection secret fail: not added to the collection", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodGet, GetTextsEndpoint, tokens[1].AccessToken, collectionQuery, nil)
+		assert.Equal(t, http.StatusForbidden, statusCode)
+	})
+
+	t.Run("collection member ok", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodPost, CollectionMembersEndpoint, tokens[0].AccessToken, nil,
+			service.CollectionMember{CollectionID: collection.ID, Login: "you know the rules"})
+		require.Equal(t, http.StatusOK, statusCode)
+
+		statusCode, body := orgRequest(t, http.MethodGet, GetTextsEndpoint, tokens[1].AccessToken, collectionQuery, nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		var texts []service.TextData
+		require.NoError(t, json.Unmarshal(body, &texts))
+		require.Len(t, texts, 1)
+		assert.Equal(t, secret.Text, texts[0].Text)
+	})
+
+	t.Run("collection secret fail: read-only", func(t *testing.T) {
+		change := secret
+		change.Description = "rules"
+		statusCode, _ := orgRequest(t, http.MethodPost, PutTextEndpoint, tokens[1].AccessToken, nil, change)
+		assert.Equal(t, http.StatusForbidden, statusCode)
+	})
+
+	t.Run("orgs ok", func(t *testing.T) {
+		orgs := getOrgs(t, tokens[1].AccessToken)
+		require.Len(t, orgs, 1)
+		assert.Equal(t, service.OrgRoleReadOnly, orgs[0].Role)
+		assert.Equal(t, "sealed for rules", orgs[0].OrgKey)
+		assert.Len(t, orgs[0].Members, 2)
+		require.Len(t, orgs[0].Collections, 1)
+		assert.Equal(t, []string{"you know the rules"}, orgs[0].Collections[0].Members)
+	})
+
+	t.Run("leave fail: last owner", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodDelete, OrgMembersEndpoint, tokens[0].AccessToken,
+			map[string]string{"org_id": id(org.ID)}, nil)
+		assert.Equal(t, http.StatusConflict, statusCode)
+	})
+
+	t.Run("delete fail: not an owner", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodDelete, OrgsEndpoint, tokens[1].AccessToken,
+			map[string]string{"org_id": id(org.ID)}, nil)
+		assert.Equal(t, http.StatusForbidden, statusCode)
+	})
+
+	t.Run("delete ok", func(t *testing.T) {
+		statusCode, _ := orgRequest(t, http.MethodDelete, OrgsEndpoint, tokens[0].AccessToken,
+			map[string]string{"org_id": id(org.ID)}, nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		assert.Empty(t, getOrgs(t, tokens[1].AccessToken))
+
+		statusCode, _ = orgRequest(t, http.MethodGet, GetTextsEndpoint, tokens[0].AccessToken, collectionQuery, nil)
+		assert.Equal(t, http.StatusForbidden, statusCode)
+	})
+}
+
+func SendTest(t *testing.T, app *App, tokens []service.Tokens) {
+	createSend := func(t *testing.T, token string, send service.Send) (int, service.Send) {
+		result, err := resty.New().R().SetAuthToken(token).SetHeader("Content-Type", "application/json").
+			SetBody(send).Post("http://" + app.config.ServerAddress + SendEndpoint)
+		require.NoError(t, err)
+		var created service.Send
+		if result.StatusCode() == http.StatusCreated {
+			require.NoError(t, json.Unmarshal(result.Body(), &created))
+		}
+		return result.StatusCode(), created
+	}
+	getSend := func(t *testing.T, id string) (int, service.Send) {
+		result, err := resty.New().R().SetQueryParam("send_id", id).
+			Get("http://" + app.config.ServerAddress + SendEndpoint)
+		require.NoError(t, err)
+		var send service.Send
+		if result.StatusCode() == http.StatusOK {
+			require.NoError(t, json.Unmarshal(result.Body(), &send))
+		}
+		return result.StatusCode(), send
+	}
+	data := []byte("encrypted never gonna give you up")
+
+	tests := []struct {
+		name       string
+		token      string
+		send       service.Send
+		statusCode int
+	}{
+		{
+			name:       "create fail: unauthorized",
+			send:       service.Send{Item: storage.ItemText, Data: data},
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "create fail: unknown item",
+			token:      tokens[1].AccessToken,
+			send:       service.Send{Item: storage.ItemCreditCard, Data: data},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "create fail: no data",
+			token:      tokens[1].AccessToken,
+			send:       service.Send{Item: storage.ItemText},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "create fail: too many views",
+			token:      tokens[1].AccessToken,
+			send:       service.Send{Item: storage.ItemText, Data: data, MaxViews: maxSendViews + 1},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "create fail: lives too long",
+			token:      tokens[1].AccessToken,
+			send:       service.Send{Item: storage.ItemText, Data: data, ExpiresIn: int64(maxSendTTL.Seconds()) + 1},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "create fail: too large",
+			token:      tokens[1].AccessToken,
+			send:       service.Send{Item: storage.ItemBinary, Data: make([]byte, maxSendSize+1)},
+			statusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusCode, _ := createSend(t, tt.token, tt.send)
+			assert.Equal(t, tt.statusCode, statusCode)
+		})
+	}
+
+	var send service.Send
+	t.Run("create ok", func(t *testing.T) {
+		var statusCode int
+		statusCode, send = createSend(t, tokens[1].AccessToken, service.Send{Item: storage.ItemBinary,
+			Name: "encrypted rickroll.mp4", Data: data, MaxViews: 2})
+		require.Equal(t, http.StatusCreated, statusCode)
+		require.NotEmpty(t, send.ID)
+		assert.Empty(t, send.Data)
+		assert.Equal(t, int64(defaultSendTTL.Seconds()), send.ExpiresIn)
+		assert.WithinDuration(t, time.Now().Add(defaultSendTTL), send.ExpiresAt, time.Minute)
+	})
+
+	t.Run("view ok", func(t *testing.T) {
+		for views := 1; views <= 2; views++ {
+			statusCode, viewed := getSend(t, send.ID)
+			require.Equal(t, http.StatusOK, statusCode)
+			assert.Equal(t, data, viewed.Data)
+			assert.Equal(t, "encrypted rickroll.mp4", viewed.Name)
+			assert.Equal(t, views, viewed.Views)
+		}
+	})
+
+	t.Run("view fail: no views left", func(t *testing.T) {
+		statusCode, _ := getSend(t, send.ID)
+		assert.Equal(t, http.StatusNotFound, statusCode)
+	})
+
+	t.Run("view fail: unknown send", func(t *testing.T) {
+		statusCode, _ := getSend(t, "rickroll")
+		assert.Equal(t, http.StatusNotFound, statusCode)
+	})
+
+	t.Run("view fail: expired", func(t *testing.T) {
+		statusCode, expiring := createSend(t, tokens[1].AccessToken, service.Send{Item: storage.ItemText,
+			Data: data, ExpiresIn: 1})
+		require.Equal(t, http.StatusCreated, statusCode)
+		time.Sleep(1100 * time.Millisecond)
+		statusCode, _ = getSend(t, expiring.ID)
+		assert.Equal(t, http.StatusNotFound, statusCode)
+	})
+
+	t.Run("audit ok: views are recorded for the sender", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(tokens[1].AccessToken).
+			Get("http://" + app.config.ServerAddress + AuditEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var page service.AuditPage
+		require.NoError(t, json.Unmarshal(result.Body(), &page))
+		var views int
+		for _, event := range page.Events {
+			if event.Item == storage.ItemSend && event.Action == service.AuditFetch {
+				views++
+			}
+		}
+		assert.Equal(t, 2, views)
+	})
+	forgetFailures(app)
+}
+
+func AuditTest(t *testing.T, app *App, tokens []service.Tokens) {
+	getPage := func(t *testing.T, query map[string]string) service.AuditPage {
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParams(query).
+			Get("http://" + app.config.ServerAddress + AuditEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var page service.AuditPage
+		require.NoError(t, json.Unmarshal(result.Body(), &page))
+		return page
+	}
+
+	t.Run("audit log ok", func(t *testing.T) {
+		result, err := resty.New().R().SetBody(service.Authentication{Login: "nevergonna", Password: "letyoudown"}).
+			SetHeader("User-Agent", "rickroll/1.0").Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, result.StatusCode())
+
+		page := getPage(t, map[string]string{"limit": "500"})
+		require.NotEmpty(t, page.Events)
+		assert.Equal(t, service.AuditLoginFailed, page.Events[0].Action)
+		assert.Equal(t, "rickroll/1.0", page.Events[0].UserAgent)
+		assert.NotEmpty(t, page.Events[0].IP)
+
+		actions := map[string]bool{}
+		for i, event := range page.Events {
+			actions[event.Action] = true
+			if i > 0 {
+				assert.Less(t, event.ID, page.Events[i-1].ID)
+			}
+			if event.Action == service.AuditFetch {
+				assert.Equal(t, storage.ItemBinary, event.Item)
+				assert.NotZero(t, event.ItemID)
+				assert.NotEmpty(t, event.Description)
+			}
+		}
+		for _, action := range []string{service.AuditLogin, service.AuditUpload, service.AuditOverwrite,
+			service.AuditDelete, service.AuditDownload, service.AuditFetch, service.AuditRestore} {
+			assert.True(t, actions[action], action)
+		}
+	})
+
+	t.Run("audit log ok: paging", func(t *testing.T) {
+		first := getPage(t, map[string]string{"limit": "1"})
+		require.Len(t, first.Events, 1)
+		require.NotEmpty(t, first.Next)
+
+		second := getPage(t, map[string]string{"limit": "1", "before": first.Next})
+		require.Len(t, second.Events, 1)
+		assert.Less(t, second.Events[0].ID, first.Events[0].ID)
+	})
+
+	t.Run("audit log ok: someone else's is not shown", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(tokens[1].AccessToken).
+			Get("http://" + app.config.ServerAddress + AuditEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		var page service.AuditPage
+		require.NoError(t, json.Unmarshal(result.Body(), &page))
+		for _, event := range page.Events {
+			assert.NotEqual(t, service.AuditLoginFailed, event.Action)
+		}
+	})
+
+	for _, query := range []map[string]string{{"limit": "-1"}, {"limit": "lots"}, {"before": "0"}, {"before": "never"}} {
+		t.Run(fmt.Sprintf("audit log fail: %v", query), func(t *testing.T) {
+			result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParams(query).
+				Get("http://" + app.config.ServerAddress + AuditEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode())
+		})
+	}
+}
+
+func ChunkedBinaryTest(t *testing.T, app *App, tokens []service.Tokens) {
+	chunks := [][]byte{[]byte("never gonna "), []byte("give you up ")}
+	chunks = append(chunks, []byte("never"))
+	var session service.UploadSession
+
+	t.Run("start upload ok", func(t *testing.T) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").SetAuthToken(tokens[0].AccessToken).
+			SetBody(service.UploadSession{Description: "chunked", ChunkCount: len(chunks), UpdatedAt: time.Now()})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + StartBinaryUploadEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, result.StatusCode())
+
+		err = json.Unmarshal(result.Body(), &session)
+		require.NoError(t, err)
+		assert.NotEmpty(t, session.ID)
+	})
+
+	t.Run("upload chunk fail: checksum mismatch", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetBody(chunks[0]).
+			SetHeader(ChecksumHeader, tools.ChecksumBytes(chunks[1])).
+			SetQueryParams(map[string]string{"upload_id": session.ID, "part": "0"})
+
+		result, err := request.Put("http://" + app.config.ServerAddress + PutBinaryChunkEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode())
+	})
+
+	t.Run("complete upload fail: missing chunks", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("upload_id", session.ID)
+
+		result, err := request.Post("http://" + app.config.ServerAddress + CompleteBinaryUploadEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode())
+	})
+
+	for i, chunk := range chunks {
+		t.Run("upload chunk ok", func(t *testing.T) {
+			request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetBody(chunk).
+				SetHeader(ChecksumHeader, tools.ChecksumBytes(chunk)).
+				SetQueryParams(map[string]string{"upload_id": session.ID, "part": strconv.Itoa(i)})
+
+			result, err := request.Put("http://" + app.config.ServerAddress + PutBinaryChunkEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, result.StatusCode())
+		})
+	}
+
+	t.Run("upload status ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("upload_id", session.ID)
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetBinaryUploadEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+
+		var resp service.UploadSession
+		err = json.Unmarshal(result.Body(), &resp)
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 1, 2}, resp.Received)
+	})
+
+	t.Run("complete upload ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("upload_id", session.ID)
+
+		result, err := request.Post("http://" + app.config.ServerAddress + CompleteBinaryUploadEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, result.StatusCode())
+	})
+
+	t.Run("stream binary ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("description", "chunked")
+
+		result, err := request.Get("http://" + app.config.ServerAddress + StreamBinaryEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+		assert.Equal(t, "never gonna give you up never", string(result.Body()))
+		assert.Equal(t, "12", result.Header().Get(ChunkSizeHeader))
+	})
+
+	t.Run("stream binary range ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetQueryParam("description", "chunked").
+			SetHeader("Range", "bytes=18-").SetHeader("Accept-Encoding", "identity")
+
+		result, err := request.Get("http://" + app.config.ServerAddress + StreamBinaryEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusPartialContent, result.StatusCode())
+		assert.Equal(t, "you up never", string(result.Body()))
+	})
+
+	t.Run("stream binary fail: no content", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[1].AccessToken).SetQueryParam("description", "chunked")
+
+		result, err := request.Get("http://" + app.config.ServerAddress + StreamBinaryEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, result.StatusCode())
+	})
+}
+
+func OpenAPITest(t *testing.T, app *App) {
+	result, err := resty.New().R().Get("http://" + app.config.ServerAddress + OpenAPIEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, result.StatusCode())
+	assert.Equal(t, "application/json", result.Header().Get("Content-Type"))
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string                     `json:"operationId"`
+			Responses   map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+		Components map[string]map[string]json.RawMessage `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(result.Body(), &spec))
+	assert.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+
+	t.Run("every route is described", func(t *testing.T) {
+		var routes []string
+		err := app.router().(*mux.Router).Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			template, err := route.GetPathTemplate()
+			if err != nil {
+				return err
+			}
+			methods, err := route.GetMethods()
+			if err != nil {
+				return err
+			}
+			for _, method := range methods {
+				routes = append(routes, method+" "+template)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		var described []string
+		for path, operations := range spec.Paths {
+			for method := range operations {
+				described = append(described, strings.ToUpper(method)+" "+path)
+			}
+		}
+		assert.ElementsMatch(t, routes, described)
+	})
+
+	t.Run("every operation has id and responses", func(t *testing.T) {
+		operationIDs := make(map[string]bool)
+		for path, operations := range spec.Paths {
+			for method, operation := range operations {
+				assert.NotEmpty(t, operation.OperationID, method+" "+path)
+				assert.False(t, operationIDs[operation.OperationID], "duplicate "+operation.OperationID)
+				operationIDs[operation.OperationID] = true
+				assert.NotEmpty(t, operation.Responses, method+" "+path)
+			}
+		}
+	})
+
+	t.Run("every reference is resolved", func(t *testing.T) {
+		refs := regexp.MustCompile(`"\$ref": "#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(result.Body()), -1)
+		require.NotEmpty(t, refs)
+		for _, ref := range refs {
+			assert.Contains(t, spec.Components[ref[1]], ref[2], ref[0])
+		}
+	})
+}
+
+func GRPCTest(t *testing.T, app *App) {
+	conn, err := grpc.Dial(app.config.GRPCAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	keeper := pb.NewKeeperClient(conn)
+	var accessToken string
+
+	authTests := []struct {
+		name     string
+		user     *pb.AuthRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "login fail: wrong password",
+			user:     &pb.AuthRequest{Login: "nevergonna", Password: "letyoudown"},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "login ok",
+			user:     &pb.AuthRequest{Login: "nevergonna", Password: "giveyouup"},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range authTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := keeper.Login(context.Background(), tt.user)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				require.NotEmpty(t, resp.GetTokens().GetAccessToken())
+				accessToken = resp.GetTokens().GetAccessToken()
+			}
+		})
+	}
+
+	t.Run("put logoPass fail: no access token", func(t *testing.T) {
+		_, err := keeper.PutLogoPass(context.Background(), &pb.LogoPass{Description: "grpc"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthorizationMetadata, "Bearer "+accessToken)
+	putTests := []struct {
+		name     string
+		logoPass service.LogoPass
+		wantCode codes.Code
+	}{
+		{
+			name: "put logoPass ok",
+			logoPass: service.LogoPass{SecretLogin: "rick", SecretPass: "astley", Description: "grpc",
+				Model: gorm.Model{UpdatedAt: time.Now()}},
+			wantCode: codes.OK,
+		},
+		{
+			name: "put logoPass conflict: already exists",
+			logoPass: service.LogoPass{SecretLogin: "rick", SecretPass: "roll", Description: "grpc",
+				Model: gorm.Model{UpdatedAt: time.Now()}},
+			wantCode: codes.AlreadyExists,
+		},
+	}
+	var revision int64
+	for _, tt := range putTests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header metadata.MD
+			_, err := keeper.PutLogoPass(ctx, pb.FromLogoPass(tt.logoPass), grpc.Header(&header))
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				require.Len(t, header.Get(pb.ETagMetadata), 1)
+				revision, err = strconv.ParseInt(header.Get(pb.ETagMetadata)[0], 10, 64)
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("put logoPass conflict: no revision", func(t *testing.T) {
+		_, err := keeper.PutLogoPass(ctx, pb.FromLogoPass(service.LogoPass{SecretLogin: "rick", SecretPass: "roll",
+			Description: "grpc", Overwrite: true}))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("put logoPass conflict: revision mismatch", func(t *testing.T) {
+		_, err := keeper.PutLogoPass(ctx, pb.FromLogoPass(service.LogoPass{SecretLogin: "rick", SecretPass: "roll",
+			Description: "grpc", Overwrite: true, Revision: revision + 1}))
+		require.Equal(t, codes.Aborted, status.Code(err))
+
+		// the pair is sent back as it is stored
+		details := status.Convert(err).Details()
+		require.Len(t, details, 1)
+		current, ok := details[0].(*pb.LogoPass)
+		require.True(t, ok)
+		assert.Equal(t, "astley", current.GetSecret())
+		assert.Equal(t, revision, current.GetMeta().GetRevision())
+	})
+
+	t.Run("get logoPasses ok", func(t *testing.T) {
+		resp, err := keeper.GetLogoPasses(ctx, &emptypb.Empty{})
+		require.NoError(t, err)
+
+		var found bool
+		for _, logoPass := range resp.GetLogoPasses() {
+			if logoPass.GetDescription() == "grpc" {
+				found = true
+				assert.Equal(t, "astley", logoPass.GetSecret())
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("sync ok", func(t *testing.T) {
+		full, err := keeper.Sync(ctx, &pb.SyncRequest{})
+		require.NoError(t, err)
+		assert.True(t, full.GetComplete())
+
+		_, err = keeper.PutLogoPass(ctx, pb.FromLogoPass(service.LogoPass{SecretLogin: "rick", SecretPass: "roll",
+			Description: "grpc", Overwrite: true, Revision: revision, Model: gorm.Model{UpdatedAt: time.Now()}}))
+		require.NoError(t, err)
+
+		delta, err := keeper.Sync(ctx, &pb.SyncRequest{Since: full.GetCursor()})
+		require.NoError(t, err)
+		assert.False(t, delta.GetComplete())
+		require.Len(t, delta.GetLogoPasses(), 1)
+		assert.Equal(t, "roll", delta.GetLogoPasses()[0].GetSecret())
+	})
+
+	t.Run("sync fail: invalid cursor", func(t *testing.T) {
+		_, err := keeper.Sync(ctx, &pb.SyncRequest{Since: "never"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("events ok", func(t *testing.T) {
+		eventsCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		stream, err := keeper.Events(eventsCtx, &emptypb.Empty{})
+		require.NoError(t, err)
+		_, err = stream.Header()
+		require.NoError(t, err)
+
+		_, err = keeper.PutText(ctx, pb.FromTextData(service.TextData{Text: "never gonna",
+			Description: "grpc events", Model: gorm.Model{UpdatedAt: time.Now()}}))
+		require.NoError(t, err)
+
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, storage.ItemText, event.GetItem())
+	})
+
+	t.Run("get usage ok", func(t *testing.T) {
+		usage, err := keeper.GetUsage(ctx, &emptypb.Empty{})
+		require.NoError(t, err)
+		assert.Positive(t, usage.GetBytes())
+		assert.Positive(t, usage.GetItems()[storage.ItemLogoPass])
+	})
+
+	t.Run("get audit log ok", func(t *testing.T) {
+		page, err := keeper.GetAuditLog(ctx, &pb.AuditRequest{Limit: 500})
+		require.NoError(t, err)
+		actions := map[string]bool{}
+		for _, event := range page.GetEvents() {
+			if strings.HasPrefix(event.GetUserAgent(), "grpc-go") {
+				actions[event.GetAction()] = true
+			}
+		}
+		assert.True(t, actions[service.AuditLogin])
+		assert.True(t, actions[service.AuditLoginFailed])
+		assert.True(t, actions[service.AuditDownload])
+	})
+
+	t.Run("get audit log fail: invalid cursor", func(t *testing.T) {
+		_, err := keeper.GetAuditLog(ctx, &pb.AuditRequest{Before: "never"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("get history ok", func(t *testing.T) {
+		resp, err := keeper.GetHistory(ctx, &pb.HistoryRequest{Item: storage.ItemText})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.GetVersions())
+		assert.NotNil(t, resp.GetVersions()[0].GetText())
+	})
+
+	t.Run("get history fail: invalid item", func(t *testing.T) {
+		_, err := keeper.GetHistory(ctx, &pb.HistoryRequest{Item: storage.ItemBinary})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("restore version fail: unknown version", func(t *testing.T) {
+		_, err := keeper.RestoreVersion(ctx, &pb.SecretVersion{VersionId: 1 << 30})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("export ok", func(t *testing.T) {
+		stream, err := keeper.ExportVault(ctx, &emptypb.Empty{})
+		require.NoError(t, err)
+		var archive []byte
+		for {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			archive = append(archive, msg.GetData()...)
+		}
+		checkExport(t, archive)
+	})
+
+	t.Run("delete account fail: wrong password", func(t *testing.T) {
+		_, err := keeper.DeleteAccount(ctx, &pb.AuthRequest{Password: "letyoudown"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("change password fail: wrong password", func(t *testing.T) {
+		_, err := keeper.ChangePassword(ctx, &pb.PasswordChange{OldPassword: "letyoudown", NewPassword: "desertyou"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("list users fail: not an admin", func(t *testing.T) {
+		_, err := keeper.ListUsers(ctx, &emptypb.Empty{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("list users ok", func(t *testing.T) {
+		resp, err := keeper.Login(context.Background(), &pb.AuthRequest{Login: "rickastley", Password: "giveyouup"})
+		require.NoError(t, err)
+		adminCtx := metadata.AppendToOutgoingContext(context.Background(), pb.AuthorizationMetadata,
+			"Bearer "+resp.GetTokens().GetAccessToken())
+
+		users, err := keeper.ListUsers(adminCtx, &emptypb.Empty{})
+		require.NoError(t, err)
+		assert.NotEmpty(t, users.GetUsers())
+
+		_, err = keeper.DeleteUser(adminCtx, &pb.UserRequest{Login: "rickastley"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("download binary ok", func(t *testing.T) {
+		stream, err := keeper.DownloadBinary(ctx, &pb.DownloadRequest{Description: "chunked", Offset: 12})
+		require.NoError(t, err)
+
+		first, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, int64(29), first.GetSize())
+
+		var content []byte
+		for {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			content = append(content, msg.GetData()...)
+		}
+		assert.Equal(t, "give you up never", string(content))
+	})
+
+	t.Run("download binary fail: no content", func(t *testing.T) {
+		stream, err := keeper.DownloadBinary(ctx, &pb.DownloadRequest{Description: "rickroll"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func RefreshTokenTest(t *testing.T, app *App, tokens []service.Tokens) {
+	refreshed := tokens[0]
+	type want struct {
+		statusCode int
+	}
+	tests := []struct {
+		name         string
+		refreshToken func() string
+		want         want
+	}{
+		{
+			name:         "refresh ok",
+			refreshToken: func() string { return tokens[0].RefreshToken },
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:         "refresh fail: unknown token",
+			refreshToken: func() string { return "rickroll" },
+			want: want{
+				statusCode: http.StatusUnauthorized,
+			},
+		},
+		{
+			name:         "refresh fail: token reused",
+			refreshToken: func() string { return tokens[0].RefreshToken },
+			want: want{
+				statusCode: http.StatusUnauthorized,
+			},
+		},
+		{
+			name:         "refresh fail: family revoked after reuse",
+			refreshToken: func() string { return refreshed.RefreshToken },
+			want: want{
+				statusCode: http.StatusUnauthorized,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(service.RefreshRequest{RefreshToken: tt.refreshToken()})
+
+			result, err := request.Post("http://" + app.config.ServerAddress + RefreshTokenEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+
+			if result.StatusCode() == http.StatusOK {
+				err = json.Unmarshal(result.Body(), &refreshed)
+				require.NoError(t, err)
+				assert.NotEqual(t, tokens[0].RefreshToken, refreshed.RefreshToken)
+
+				result, err = resty.New().R().SetAuthToken(refreshed.AccessToken).
+					Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, result.StatusCode())
+			}
+		})
+	}
+
+	t.Run("download fail: session revoked after reuse", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(refreshed.AccessToken)
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("download fail: no access token", func(t *testing.T) {
+		result, err := resty.New().R().Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("download fail: forged access token", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken + "x")
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+}
+
+func SessionsTest(t *testing.T, app *App) {
+	devices := map[string]service.Tokens{}
+	for _, device := range []string{"laptop", "phone"} {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.User{Login: "nevergonna", Password: "giveyouup", Device: device})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		var tokens service.Tokens
+		err = json.Unmarshal(result.Body(), &tokens)
+		require.NoError(t, err)
+		devices[device] = tokens
+	}
+
+	var sessions []service.Session
+	t.Run("list sessions ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(devices["laptop"].AccessToken)
+
+		result, err := request.Get("http://" + app.config.ServerAddress + SessionsEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+		assert.Equal(t, "application/json", result.Header().Get("Content-Type"))
+
+		err = json.Unmarshal(result.Body(), &sessions)
+		require.NoError(t, err)
+		current := map[string]bool{}
+		for _, session := range sessions {
+			current[session.Device] = session.Current
+		}
+		assert.Contains(t, current, "phone")
+		assert.True(t, current["laptop"])
+		assert.False(t, current["phone"])
+	})
+
+	var phone string
+	for _, session := range sessions {
+		if session.Device == "phone" {
+			phone = session.ID
+		}
+	}
+	type want struct {
+		statusCode int
+	}
+	tests := []struct {
+		name      string
+		sessionID string
+		want      want
+	}{
+		{
+			name:      "revoke ok",
+			sessionID: phone,
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name:      "revoke fail: already revoked",
+			sessionID: phone,
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:      "revoke fail: no such session",
+			sessionID: "rickroll",
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetAuthToken(devices["laptop"].AccessToken).
+				SetQueryParam("session_id", tt.sessionID)
+
+			result, err := request.Delete("http://" + app.config.ServerAddress + SessionsEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+		})
+	}
+
+	t.Run("download fail: session revoked", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(devices["phone"].AccessToken)
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("logout ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(devices["laptop"].AccessToken)
+
+		result, err := request.Post("http://" + app.config.ServerAddress + LogoutEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+
+	t.Run("download fail: logged out", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(devices["laptop"].AccessToken)
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("refresh fail: logged out", func(t *testing.T) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.RefreshRequest{RefreshToken: devices["laptop"].RefreshToken})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + RefreshTokenEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+}
+
+func PasswordTest(t *testing.T, app *App) {
+	devices := map[string]service.Tokens{}
+	for _, device := range []string{"laptop", "phone"} {
+		addr := LoginEndpoint
+		if device == "laptop" {
+			addr = RegisterEndpoint
+		}
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.User{Login: "runaround", Password: "letyoudown", Device: device})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + addr)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		var tokens service.Tokens
+		err = json.Unmarshal(result.Body(), &tokens)
+		require.NoError(t, err)
+		devices[device] = tokens
+	}
+
+	request := resty.New().R().SetHeader("Content-Type", "application/json").
+		SetBody(service.TextData{Text: "never gonna", Description: "password", Model: gorm.Model{UpdatedAt: time.Now()}}).
+		SetAuthToken(devices["laptop"].AccessToken)
+	result, err := request.Post("http://" + app.config.ServerAddress + PutTextEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, result.StatusCode())
+
+	result, err = resty.New().R().SetAuthToken(devices["laptop"].AccessToken).
+		Get("http://" + app.config.ServerAddress + SyncEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, result.StatusCode())
+	var vault service.SyncData
+	err = json.Unmarshal(result.Body(), &vault)
+	require.NoError(t, err)
+	require.Len(t, vault.Texts, 1)
+
+	reencrypted := vault.Texts[0]
+	reencrypted.Text = "run around"
+	reencrypted.UpdatedAt = time.Now()
+	type want struct {
+		statusCode int
+	}
+	tests := []struct {
+		name   string
+		change service.PasswordChange
+		want   want
+	}{
+		{
+			name: "change password fail: wrong password",
+			change: service.PasswordChange{OldPassword: "giveyouup", NewPassword: "desertyou",
+				Cursor: vault.Cursor, Texts: []service.TextData{reencrypted}},
+			want: want{
+				statusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name: "change password fail: empty password",
+			change: service.PasswordChange{OldPassword: "letyoudown", Cursor: vault.Cursor,
+				Texts: []service.TextData{reencrypted}},
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:   "change password conflict: secret missing",
+			change: service.PasswordChange{OldPassword: "letyoudown", NewPassword: "desertyou", Cursor: vault.Cursor},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name: "change password conflict: stale cursor",
+			change: service.PasswordChange{OldPassword: "letyoudown", NewPassword: "desertyou", Cursor: "1",
+				Texts: []service.TextData{reencrypted}},
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name: "change password ok",
+			change: service.PasswordChange{OldPassword: "letyoudown", NewPassword: "desertyou",
+				Cursor: vault.Cursor, Texts: []service.TextData{reencrypted}},
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(tt.change).SetAuthToken(devices["laptop"].AccessToken)
+
+			result, err := request.Post("http://" + app.config.ServerAddress + PasswordEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+		})
+	}
+
+	t.Run("download ok: secret re-encrypted", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(devices["laptop"].AccessToken).
+			Get("http://" + app.config.ServerAddress + GetTextsEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		var texts []service.TextData
+		err = json.Unmarshal(result.Body(), &texts)
+		require.NoError(t, err)
+		require.Len(t, texts, 1)
+		assert.Equal(t, "run around", texts[0].Text)
+	})
+
+	t.Run("download fail: other session revoked", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(devices["phone"].AccessToken).
+			Get("http://" + app.config.ServerAddress + GetTextsEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	for _, tt := range []struct {
+		name       string
+		password   string
+		statusCode int
+	}{
+		{name: "login fail: old password", password: "letyoudown", statusCode: http.StatusUnauthorized},
+		{name: "login ok: new password", password: "desertyou", statusCode: http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(service.User{Login: "runaround", Password: tt.password})
+
+			result, err := request.Post("http://" + app.config.ServerAddress + LoginEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.statusCode, result.StatusCode())
+		})
+	}
+	forgetFailures(app)
+}
+
+func AccountTest(t *testing.T, app *App, tokens []service.Tokens) {
+	t.Run("export ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens[0].AccessToken).SetHeader("Accept-Encoding", "identity")
+
+		result, err := request.Get("http://" + app.config.ServerAddress + ExportEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		assert.Equal(t, "application/zip", result.Header().Get("Content-Type"))
+		checkExport(t, result.Body())
+	})
+
+	request := resty.New().R().SetHeader("Content-Type", "application/json").
+		SetBody(service.User{Login: "saygoodbye", Password: "tellalie"})
+	result, err := request.Post("http://" + app.config.ServerAddress + RegisterEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, result.StatusCode())
+	var account service.Tokens
+	err = json.Unmarshal(result.Body(), &account)
+	require.NoError(t, err)
+
+	request = resty.New().R().SetHeader("Content-Type", "application/json").
+		SetBody(service.TextData{Text: "never gonna", Description: "goodbye", Model: gorm.Model{UpdatedAt: time.Now()}}).
+		SetAuthToken(account.AccessToken)
+	result, err = request.Post("http://" + app.config.ServerAddress + PutTextEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, result.StatusCode())
+
+	type want struct {
+		statusCode int
+	}
+	tests := []struct {
+		name     string
+		password string
+		want     want
+	}{
+		{
+			name:     "delete account fail: wrong password",
+			password: "giveyouup",
+			want: want{
+				statusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name:     "delete account ok",
+			password: "tellalie",
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(service.Authentication{Password: tt.password}).SetAuthToken(account.AccessToken)
+
+			result, err := request.Post("http://" + app.config.ServerAddress + DeleteAccountEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+		})
+	}
+
+	t.Run("download fail: account deleted", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(account.AccessToken).
+			Get("http://" + app.config.ServerAddress + GetTextsEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("login fail: account deleted", func(t *testing.T) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.User{Login: "saygoodbye", Password: "tellalie"})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("usage ok: nothing left", func(t *testing.T) {
+		usage, err := app.UserStorage.GetUsage("saygoodbye", context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, usage.Bytes)
+		assert.Zero(t, usage.Items[storage.ItemText])
+	})
+	forgetFailures(app)
+}
+
+// forgetFailures forgets the failed attempts made from the local addresses, so that LockoutTest counts its own only
+func forgetFailures(app *App) {
+	for _, ip := range []string{"127.0.0.1", "::1"} {
+		app.ipLimiter.reset(ipKey(ip))
+	}
+}
+
+// checkExport checks the archive holds the secrets without the tombstones and the content of every chunked binary
+func checkExport(t *testing.T, archive []byte) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+	for _, name := range []string{exportLogoPasses, exportTexts, exportCreditCards, exportBinaries} {
+		require.Contains(t, files, name)
+	}
+
+	var texts []service.TextData
+	file, err := files[exportTexts].Open()
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(file).Decode(&texts))
+	require.NotEmpty(t, texts)
+	for _, text := range texts {
+		assert.False(t, text.DeletedAt.Valid)
+		assert.NotEmpty(t, text.Text)
+	}
+
+	var binaries []service.BinaryData
+	file, err = files[exportBinaries].Open()
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(file).Decode(&binaries))
+	for _, binary := range binaries {
+		if binary.ChunkCount == 0 {
+			assert.NotEmpty(t, binary.Binary)
+			continue
+		}
+		content, ok := files[fmt.Sprintf(exportChunks, binary.ID)]
+		require.True(t, ok)
+		assert.Equal(t, uint64(binary.Size), content.UncompressedSize64)
+	}
+}
+
+func AdminTest(t *testing.T, app *App, tokens []service.Tokens) {
+	app.config.AdminLogins = []string{"rickastley"}
+	defer func() {
+		app.config.AdminLogins = nil
+	}()
+
+	accounts := map[string]service.Tokens{}
+	for _, login := range []string{"rickastley", "rickroll"} {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.User{Login: login, Password: "giveyouup"})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + RegisterEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+
+		var tokens service.Tokens
+		err = json.Unmarshal(result.Body(), &tokens)
+		require.NoError(t, err)
+		accounts[login] = tokens
+	}
+	admin := accounts["rickastley"].AccessToken
+
+	t.Run("register fail: listed login is not an admin until start", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(admin).
+			Get("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, result.StatusCode())
+	})
+	app.promoteAdmins(context.Background())
+
+	t.Run("list users fail: not an admin", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(tokens[0].AccessToken).
+			Get("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, result.StatusCode())
+	})
+
+	t.Run("list users ok", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(admin).
+			Get("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		// the secrets are never sent to the admins
+		assert.NotContains(t, result.String(), "never gonna")
+
+		var users []service.UserSummary
+		err = json.Unmarshal(result.Body(), &users)
+		require.NoError(t, err)
+		summaries := map[string]service.UserSummary{}
+		for _, user := range users {
+			summaries[user.Login] = user
+		}
+		require.Contains(t, summaries, "nevergonna")
+		assert.Equal(t, service.RoleAdmin, summaries["rickastley"].Role)
+		assert.Equal(t, service.RoleUser, summaries["nevergonna"].Role)
+		assert.Positive(t, summaries["nevergonna"].Usage.Bytes)
+		assert.Positive(t, summaries["nevergonna"].Usage.Items[storage.ItemText])
+	})
+
+	type want struct {
+		statusCode int
+	}
+	tests := []struct {
+		name     string
+		method   string
+		endpoint string
+		login    string
+		want     want
+	}{
+		{
+			name:     "disable fail: own account",
+			method:   http.MethodPost,
+			endpoint: AdminDisableUserEndpoint,
+			login:    "rickastley",
+			want: want{
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name:     "disable fail: no such user",
+			method:   http.MethodPost,
+			endpoint: AdminDisableUserEndpoint,
+			login:    "nobody",
+			want: want{
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name:     "disable ok",
+			method:   http.MethodPost,
+			endpoint: AdminDisableUserEndpoint,
+			login:    "rickroll",
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resty.New().R().SetAuthToken(admin).SetQueryParam("login", tt.login).
+				Execute(tt.method, "http://"+app.config.ServerAddress+tt.endpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+		})
+	}
+
+	login := func() *resty.Response {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.Authentication{Login: "rickroll", Password: "giveyouup"}).
+			Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("download fail: account disabled", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(accounts["rickroll"].AccessToken).
+			Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("login fail: account disabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, login().StatusCode())
+	})
+
+	t.Run("enable ok", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(admin).SetQueryParam("login", "rickroll").
+			Post("http://" + app.config.ServerAddress + AdminEnableUserEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+
+	var victim service.Tokens
+	t.Run("login ok: account enabled", func(t *testing.T) {
+		result := login()
+		require.Equal(t, http.StatusOK, result.StatusCode())
+		require.NoError(t, json.Unmarshal(result.Body(), &victim))
+	})
+
+	t.Run("logout user ok", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(admin).SetQueryParam("login", "rickroll").
+			Post("http://" + app.config.ServerAddress + AdminLogoutUserEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+
+	t.Run("download fail: logged out by admin", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(victim.AccessToken).
+			Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("delete user fail: own account", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(admin).SetQueryParam("login", "rickastley").
+			Delete("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, result.StatusCode())
+	})
+
+	t.Run("delete user ok", func(t *testing.T) {
+		result, err := resty.New().R().SetAuthToken(admin).SetQueryParam("login", "rickroll").
+			Delete("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+
+		_, err = app.UserStorage.GetUser("rickroll", context.Background())
+		assert.ErrorIs(t, err, storage.ErrEmpty)
+		assert.Equal(t, http.StatusUnauthorized, login().StatusCode())
+	})
+
+	t.Run("list users fail: admin no longer listed is demoted on start", func(t *testing.T) {
+		app.config.AdminLogins = nil
+		app.promoteAdmins(context.Background())
+
+		result, err := resty.New().R().SetAuthToken(admin).
+			Get("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, result.StatusCode())
+
+		// listed again for the gRPC tests
+		app.config.AdminLogins = []string{"rickastley"}
+		app.promoteAdmins(context.Background())
+		result, err = resty.New().R().SetAuthToken(admin).
+			Get("http://" + app.config.ServerAddress + AdminUsersEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+}
+
+func TwoFactorTest(t *testing.T, app *App) {
+	user := service.User{Login: "whenever", Password: "you need somebody"}
+	result, err := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(user).
+		Post("http://" + app.config.ServerAddress + RegisterEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, result.StatusCode())
+	var tokens service.Tokens
+	require.NoError(t, json.Unmarshal(result.Body(), &tokens))
+
+	var enrollment service.TOTPEnrollment
+	t.Run("enroll ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens.AccessToken)
+
+		result, err := request.Post("http://" + app.config.ServerAddress + EnrollTwoFactorEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+		require.NoError(t, json.Unmarshal(result.Body(), &enrollment))
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/")
+	})
+
+	step := tools.TOTPStep(time.Now())
+	code := func(step int64) string {
+		code, err := tools.TOTPCode(enrollment.Secret, step)
+		require.NoError(t, err)
+		return code
+	}
+	var recoveryCodes service.RecoveryCodes
+	verifyTests := []struct {
+		name       string
+		addr       string
+		method     string
+		code       func() string
+		statusCode int
+	}{
+		{
+			name:       "verify fail: wrong code",
+			addr:       VerifyTwoFactorEndpoint,
+			method:     http.MethodPost,
+			code:       func() string { return "rickroll" },
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "verify ok",
+			addr:       VerifyTwoFactorEndpoint,
+			method:     http.MethodPost,
+			code:       func() string { return code(step) },
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "verify fail: already enabled",
+			addr:       VerifyTwoFactorEndpoint,
+			method:     http.MethodPost,
+			code:       func() string { return code(step) },
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:       "enroll fail: already enabled",
+			addr:       EnrollTwoFactorEndpoint,
+			method:     http.MethodPost,
+			code:       func() string { return "" },
+			statusCode: http.StatusConflict,
+		},
+	}
+	for _, tt := range verifyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetAuthToken(tokens.AccessToken).
+				SetHeader("Content-Type", "application/json").SetBody(service.TwoFactorCode{Code: tt.code()})
+
+			result, err := request.Execute(tt.method, "http://"+app.config.ServerAddress+tt.addr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.statusCode, result.StatusCode())
+			if tt.addr == VerifyTwoFactorEndpoint && result.StatusCode() == http.StatusOK {
+				require.NoError(t, json.Unmarshal(result.Body(), &recoveryCodes))
+				assert.Len(t, recoveryCodes.RecoveryCodes, recoveryCodeCount)
+			}
+		})
+	}
+	require.Len(t, recoveryCodes.RecoveryCodes, recoveryCodeCount)
+
+	challenge := func(t *testing.T) string {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(user).
+			Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusAccepted, result.StatusCode())
+
+		var challenge service.TwoFactorChallenge
+		require.NoError(t, json.Unmarshal(result.Body(), &challenge))
+		assert.Equal(t, "2fa_required", challenge.Status)
+		return challenge.ChallengeToken
+	}
+	loginTests := []struct {
+		name       string
+		challenge  func(t *testing.T) string
+		code       string
+		statusCode int
+	}{
+		{
+			name:       "login 2fa fail: wrong code",
+			challenge:  challenge,
+			code:       "123",
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "login 2fa fail: code already used",
+			challenge:  challenge,
+			code:       code(step),
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "login 2fa ok",
+			challenge:  challenge,
+			code:       code(step + 1),
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "login 2fa ok: recovery code",
+			challenge:  challenge,
+			code:       recoveryCodes.RecoveryCodes[0],
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "login 2fa fail: recovery code already used",
+			challenge:  challenge,
+			code:       recoveryCodes.RecoveryCodes[0],
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "login 2fa fail: access token is not a challenge",
+			challenge:  func(t *testing.T) string { return tokens.AccessToken },
+			code:       recoveryCodes.RecoveryCodes[1],
+			statusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "login 2fa fail: account disabled after the password",
+			challenge: func(t *testing.T) string {
+				token := challenge(t)
+				require.NoError(t, app.UserStorage.SetDisabled(user.Login, true, context.Background()))
+				return token
+			},
+			code:       recoveryCodes.RecoveryCodes[2],
+			statusCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range loginTests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(service.TwoFactorLogin{ChallengeToken: tt.challenge(t), Code: tt.code})
+
+			result, err := request.Post("http://" + app.config.ServerAddress + LoginTwoFactorEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.statusCode, result.StatusCode())
+			if result.StatusCode() == http.StatusOK {
+				var resp service.Tokens
+				require.NoError(t, json.Unmarshal(result.Body(), &resp))
+				assert.NotEmpty(t, resp.AccessToken)
+			}
+		})
+	}
+	require.NoError(t, app.UserStorage.SetDisabled(user.Login, false, context.Background()))
+
+	t.Run("download fail: challenge is not an access token", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(challenge(t))
+
+		result, err := request.Get("http://" + app.config.ServerAddress + GetLogoPassesEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+	})
+
+	t.Run("disable ok", func(t *testing.T) {
+		request := resty.New().R().SetAuthToken(tokens.AccessToken).
+			SetHeader("Content-Type", "application/json").
+			SetBody(service.TwoFactorCode{Code: recoveryCodes.RecoveryCodes[1]})
+
+		result, err := request.Delete("http://" + app.config.ServerAddress + TwoFactorEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+
+	t.Run("login ok: 2fa disabled", func(t *testing.T) {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(user).
+			Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+}
+
+func LockoutTest(t *testing.T, app *App) {
+	type want struct {
+		statusCode int
+		retryAfter bool
+	}
+	type attempt struct {
+		name     string
+		password string
+		want     want
+	}
+	var tests []attempt
+	for i := 1; i <= freeAttempts+1; i++ {
+		tests = append(tests, attempt{
+			name:     "login fail: wrong pass " + strconv.Itoa(i),
+			password: "tellalie",
+			want: want{
+				statusCode: http.StatusUnauthorized,
+			},
+		})
+	}
+	tests = append(tests,
+		attempt{
+			name:     "login fail: backoff",
+			password: "tellalie",
+			want: want{
+				statusCode: http.StatusTooManyRequests,
+				retryAfter: true,
+			},
+		},
+		attempt{
+			name:     "login fail: backoff applies to right pass",
+			password: "giveyouup",
+			want: want{
+				statusCode: http.StatusTooManyRequests,
+				retryAfter: true,
+			},
+		},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := resty.New().R().SetHeader("Content-Type", "application/json").
+				SetBody(service.Authentication{Login: "nevergonna", Password: tt.password})
+
+			result, err := request.Post("http://" + app.config.ServerAddress + LoginEndpoint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.statusCode, result.StatusCode())
+			if tt.want.retryAfter {
+				retryAfter, err := strconv.Atoi(result.Header().Get("Retry-After"))
+				require.NoError(t, err)
+				assert.Positive(t, retryAfter)
+			}
+		})
+	}
+
+	t.Run("login ok: backoff over", func(t *testing.T) {
+		app.accountLimiter.reset(passwordKey("nevergonna"))
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.Authentication{Login: "nevergonna", Password: "giveyouup"})
+
+		result, err := request.Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+}
+
+func TLSTest(t *testing.T, app *App) {
+	dir := t.TempDir()
+	caPEM, caKeyPEM, err := tools.GenerateCertificate(nil, nil)
+	require.NoError(t, err)
+	ca, err := tls.X509KeyPair(caPEM, caKeyPEM)
+	require.NoError(t, err)
+	clientPEM, clientKeyPEM, err := tools.GenerateCertificate([]string{"nevergonna"}, &ca)
+	require.NoError(t, err)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), caPEM, 0600))
+
+	cfg := app.config
+	cfg.ServerAddress = "localhost:8443"
+	cfg.GRPCAddress = "localhost:3443"
+	cfg.TLSSelfSigned = true
+	cfg.TLSCertFile = filepath.Join(dir, "server.crt")
+	cfg.TLSKeyFile = filepath.Join(dir, "server.key")
+	cfg.TLSClientCAFile = filepath.Join(dir, "ca.crt")
+	tlsApp := NewApp(cfg, app.UserStorage, app.AuditStorage)
+	go tlsApp.Start(context.Background())
+	defer tlsApp.Shutdown(context.Background())
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", cfg.ServerAddress)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	roots, err := tools.LoadCertPool(cfg.TLSCertFile)
+	require.NoError(t, err)
+	login := service.Authentication{Login: "nevergonna", Password: "giveyouup"}
+
+	t.Run("login fail: plain http", func(t *testing.T) {
+		result, err := resty.New().R().SetBody(login).Post("http://" + cfg.ServerAddress + LoginEndpoint)
+		if err == nil {
+			assert.Equal(t, http.StatusBadRequest, result.StatusCode())
+		}
+	})
+
+	t.Run("login fail: no client certificate", func(t *testing.T) {
+		_, err := resty.New().SetTLSClientConfig(&tls.Config{RootCAs: roots}).R().SetBody(login).
+			Post("https://" + cfg.ServerAddress + LoginEndpoint)
+		assert.Error(t, err)
+	})
+
+	clientTLS := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}}
+	t.Run("login ok: client certificate", func(t *testing.T) {
+		result, err := resty.New().SetTLSClientConfig(clientTLS).R().SetBody(login).
+			Post("https://" + cfg.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+	})
+
+	t.Run("grpc login ok: client certificate", func(t *testing.T) {
+		conn, err := grpc.Dial(cfg.GRPCAddress, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+		require.NoError(t, err)
+		defer conn.Close()
+		resp, err := pb.NewKeeperClient(conn).Login(context.Background(),
+			&pb.AuthRequest{Login: login.Login, Password: login.Password})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.GetTokens().GetAccessToken())
+	})
+}
+
+func ShutdownTest(t *testing.T, app *App) {
+	conn, err := grpc.Dial(app.config.GRPCAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	keeper := pb.NewKeeperClient(conn)
+	_, err = keeper.Login(context.Background(), &pb.AuthRequest{Login: "nevergonna", Password: "giveyouup"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Shutdown(ctx))
+
+	t.Run("login fail: server is shut down", func(t *testing.T) {
+		request := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetBody(service.Authentication{Login: "nevergonna", Password: "giveyouup"})
+		_, err := request.Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		assert.Error(t, err)
+	})
+
+	t.Run("grpc login fail: server is shut down", func(t *testing.T) {
+		_, err := keeper.Login(context.Background(), &pb.AuthRequest{Login: "nevergonna", Password: "giveyouup"})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func MetricsTest(t *testing.T, app *App) {
+	unknown := "/api/never/gonna/run/around"
+	noRedirect := resty.RedirectPolicyFunc(func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	})
+	result, err := resty.New().SetRedirectPolicy(noRedirect).R().Get("http://" + app.config.ServerAddress + unknown)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTemporaryRedirect, result.StatusCode())
+
+	result, err = resty.New().R().Get("http://" + app.config.ServerAddress + MetricsEndpoint)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, result.StatusCode())
+
+	body := string(result.Body())
+	for _, metric := range []string{
+		`gophkeeper_http_requests_total{code="200",method="POST",route="` + LoginEndpoint + `"}`,
+		`gophkeeper_http_requests_total{code="401",method="POST",route="` + LoginEndpoint + `"}`,
+		`gophkeeper_http_request_duration_seconds_bucket{method="POST",route="` + PutLogoPassEndpoint + `"`,
+		`gophkeeper_logins_total{result="success",step="password"}`,
+		`gophkeeper_logins_total{result="failure",step="password"}`,
+		`gophkeeper_logins_total{result="lockout",step="password"}`,
+		`gophkeeper_stored_items{type="logopass"}`,
+		`gophkeeper_stored_items{type="binary"}`,
+		`gophkeeper_db_query_duration_seconds_bucket{operation="query",table="users"`,
+		`gophkeeper_http_requests_total{code="307",method="GET",route="` + unmatchedRoute + `"}`,
+	} {
+		assert.Contains(t, body, metric)
+	}
+	assert.NotContains(t, body, unknown)
+}
+
+func LoggingTest(t *testing.T, app *App) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, slog.LevelDebug))
+	defer func() {
+		slog.SetDefault(previous)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	t.Run("login fail: request id is sent back", func(t *testing.T) {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetHeader(logging.RequestIDHeader, "rickroll-42").
+			SetBody(service.Authentication{Login: "nevergonna", Password: "runaround"}).
+			Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode())
+		assert.Equal(t, "rickroll-42", result.Header().Get(logging.RequestIDHeader))
+	})
+
+	t.Run("login fail: unsafe request id is replaced", func(t *testing.T) {
+		result, err := resty.New().R().SetHeader("Content-Type", "application/json").
+			SetHeader(logging.RequestIDHeader, "desert you\"}").
+			SetBody(service.Authentication{Login: "nevergonna", Password: "runaround"}).
+			Post("http://" + app.config.ServerAddress + LoginEndpoint)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Header().Get(logging.RequestIDHeader))
+		assert.NotEqual(t, "desert you\"}", result.Header().Get(logging.RequestIDHeader))
+	})
+
+	t.Run("log lines are tied to the request", func(t *testing.T) {
+		var tied int
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(line, &entry))
+			if entry["request_id"] == "rickroll-42" {
+				tied++
+			}
+		}
+		assert.Equal(t, 2, tied, "error and access log lines")
+		assert.NotContains(t, buf.String(), "runaround")
+	})
+
+	t.Run("secrets are redacted", func(t *testing.T) {
+		buf.Reset()
+		logging.FromContext(context.Background()).Info("redaction",
+			"user", service.Authentication{Login: "nevergonna", Password: "runaround"},
+			"cards", []service.CreditCard{{Number: "4242424242424242", CVV: "123", Holder: "RICK ASTLEY"}})
+		assert.NotContains(t, buf.String(), "runaround")
+		assert.NotContains(t, buf.String(), "4242424242424242")
+		assert.Contains(t, buf.String(), `"CVV":"[REDACTED]"`)
+		assert.Contains(t, buf.String(), "nevergonna")
+		assert.NotContains(t, buf.String(), "RICK ASTLEY")
+	})
+}
+
+func HealthTest(t *testing.T, app *App) {
+	t.Run("healthz ok", func(t *testing.T) {
+		var health service.Readiness
+		result, err := resty.New().R().SetResult(&health).Get("http://" + app.config.ServerAddress + HealthEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode())
+		assert.Equal(t, "ok", health.Status)
+	})
+
+	t.Run("readyz reports every check", func(t *testing.T) {
+		var readiness service.Readiness
+		result, err := resty.New().R().SetResult(&readiness).SetError(&readiness).
+			Get("http://" + app.config.ServerAddress + ReadyEndpoint)
+		require.NoError(t, err)
+		require.Contains(t, readiness.Checks, "database")
+		require.Contains(t, readiness.Checks, "downloads")
+		assert.Equal(t, "ok", readiness.Checks["database"].Status)
+		if readiness.Checks["downloads"].Status == "ok" {
+			assert.Equal(t, http.StatusOK, result.StatusCode())
+			assert.Equal(t, "ok", readiness.Status)
+		} else {
+			assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode())
+			assert.Equal(t, "fail", readiness.Status)
+			assert.NotEmpty(t, readiness.Checks["downloads"].Error)
+		}
+	})
+
+	t.Run("readyz fail: shutting down", func(t *testing.T) {
+		app.shuttingDown.Store(true)
+		defer app.shuttingDown.Store(false)
+
+		var readiness service.Readiness
+		result, err := resty.New().R().SetError(&readiness).Get("http://" + app.config.ServerAddress + ReadyEndpoint)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode())
+		assert.Equal(t, "shutting_down", readiness.Status)
+	})
+}
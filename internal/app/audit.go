@@ -0,0 +1,267 @@
+package app
+
+// Here is the audit log of the users: the logins, the failed ones and every access to the secrets are recorded
+// along with the address and the user agent of the client. The content of the secrets is never recorded.
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"time"
+)
+
+// clientKey is the key of the context value holding the client that made the request
+type clientKey struct{}
+
+// client holds the address and the user agent of the client that made the request
+type client struct {
+	ip        string
+	userAgent string
+}
+
+// withClient returns the context holding the address and the user agent of the client
+func withClient(ctx context.Context, ip string, userAgent string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client{ip: ip, userAgent: userAgent})
+}
+
+// clientFromContext returns the address and the user agent of the client put to the context by withClient,
+// the ones of the gRPC peer if there are none
+func clientFromContext(ctx context.Context) (string, string) {
+	if c, ok := ctx.Value(clientKey{}).(client); ok {
+		return c.ip, c.userAgent
+	}
+	return grpcPeerIP(ctx), grpcUserAgent(ctx)
+}
+
+// recordAudit records the event along with the client taken from the context. The event is recorded
+// even if the request has been cancelled by then, as the secrets may have been sent already.
+// A failure to record is logged only, the request is served anyway.
+func recordAudit(auditStorage storage.AuditStorage, event service.AuditEvent, ctx context.Context) {
+	event.IP, event.UserAgent = clientFromContext(ctx)
+	event.CreatedAt = time.Now()
+	auditCtx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	err := auditStorage.PutAuditEvent(event, auditCtx)
+	if err != nil {
+		logging.FromContext(ctx).Error("record audit event", "err", err, "login", event.Login, "action", event.Action)
+	}
+}
+
+// auditLogin records the login of the user
+func (app *App) auditLogin(login string, ctx context.Context) {
+	recordAudit(app.AuditStorage, service.AuditEvent{Login: login, Action: service.AuditLogin}, ctx)
+}
+
+// auditLoginFailed records a failed login of the user. Nothing is recorded for the logins that don't exist,
+// so that no one could fill the audit log of a user before the user registers.
+func (app *App) auditLoginFailed(login string, ctx context.Context) {
+	_, err := app.UserStorage.GetUser(login, ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEmpty) {
+			logging.FromContext(ctx).Error("record audit event", "err", err, "login", login)
+		}
+		return
+	}
+	recordAudit(app.AuditStorage, service.AuditEvent{Login: login, Action: service.AuditLoginFailed}, ctx)
+}
+
+// auditingStorage records an audit event for every access to the user's secrets made through it,
+// so that both REST and gRPC requests are recorded
+type auditingStorage struct {
+	storage.UserStorage
+	audit storage.AuditStorage
+}
+
+// record records the event if the access has succeeded
+func (s auditingStorage) record(event service.AuditEvent, err error, ctx context.Context) error {
+	if err == nil {
+		recordAudit(s.audit, event, ctx)
+	}
+	return err
+}
+
+// uploadAction tells whether an upload is meant to store a new secret or to overwrite the stored one
+func uploadAction(overwrite bool) string {
+	if overwrite {
+		return service.AuditOverwrite
+	}
+	return service.AuditUpload
+}
+
+func (s auditingStorage) PutLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutLogoPass(logoPass, ctx)
+	return revision, s.record(service.AuditEvent{Login: logoPass.Login, Action: uploadAction(logoPass.Overwrite),
+		Item: storage.ItemLogoPass, CollectionID: logoPass.CollectionID, Description: logoPass.Description}, err, ctx)
+}
+
+func (s auditingStorage) PutText(secret service.TextData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutText(secret, ctx)
+	return revision, s.record(service.AuditEvent{Login: secret.Login, Action: uploadAction(secret.Overwrite),
+		Item: storage.ItemText, CollectionID: secret.CollectionID, Description: secret.Description}, err, ctx)
+}
+
+func (s auditingStorage) PutCreditCard(card service.CreditCard, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutCreditCard(card, ctx)
+	return revision, s.record(service.AuditEvent{Login: card.Login, Action: uploadAction(card.Overwrite),
+		Item: storage.ItemCreditCard, CollectionID: card.CollectionID, Description: card.Description}, err, ctx)
+}
+
+func (s auditingStorage) PutBinary(binary service.BinaryData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutBinary(binary, ctx)
+	return revision, s.record(service.AuditEvent{Login: binary.Login, Action: uploadAction(binary.Overwrite),
+		Item: storage.ItemBinary, CollectionID: binary.CollectionID, Description: binary.Description}, err, ctx)
+}
+
+// CompleteUpload looks the upload up first, as only the upload knows what binary it is meant to store
+func (s auditingStorage) CompleteUpload(session service.UploadSession, ctx context.Context) (int64, error) {
+	upload, err := s.UserStorage.GetUploadSession(session, ctx)
+	if err != nil {
+		return 0, err
+	}
+	revision, err := s.UserStorage.CompleteUpload(session, ctx)
+	return revision, s.record(service.AuditEvent{Login: session.Login, Action: uploadAction(upload.Overwrite),
+		Item: storage.ItemBinary, CollectionID: upload.CollectionID, Description: upload.Description}, err, ctx)
+}
+
+func (s auditingStorage) DeleteLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteLogoPass(logoPass, ctx)
+	return revision, s.record(service.AuditEvent{Login: logoPass.Login, Action: service.AuditDelete,
+		Item: storage.ItemLogoPass, CollectionID: logoPass.CollectionID, Description: logoPass.Description}, err, ctx)
+}
+
+func (s auditingStorage) DeleteText(secret service.TextData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteText(secret, ctx)
+	return revision, s.record(service.AuditEvent{Login: secret.Login, Action: service.AuditDelete,
+		Item: storage.ItemText, CollectionID: secret.CollectionID, Description: secret.Description}, err, ctx)
+}
+
+func (s auditingStorage) DeleteCreditCard(card service.CreditCard, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteCreditCard(card, ctx)
+	return revision, s.record(service.AuditEvent{Login: card.Login, Action: service.AuditDelete,
+		Item: storage.ItemCreditCard, CollectionID: card.CollectionID, Description: card.Description}, err, ctx)
+}
+
+func (s auditingStorage) DeleteBinary(binary service.BinaryData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteBinary(binary, ctx)
+	return revision, s.record(service.AuditEvent{Login: binary.Login, Action: service.AuditDelete,
+		Item: storage.ItemBinary, CollectionID: binary.CollectionID, Description: binary.Description}, err, ctx)
+}
+
+func (s auditingStorage) BatchGetLogoPasses(login string, collection uint, ctx context.Context) ([]service.LogoPass, error) {
+	logoPasses, err := s.UserStorage.BatchGetLogoPasses(login, collection, ctx)
+	return logoPasses, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload,
+		Item: storage.ItemLogoPass, CollectionID: collection}, err, ctx)
+}
+
+func (s auditingStorage) BatchGetTexts(login string, collection uint, ctx context.Context) ([]service.TextData, error) {
+	texts, err := s.UserStorage.BatchGetTexts(login, collection, ctx)
+	return texts, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload,
+		Item: storage.ItemText, CollectionID: collection}, err, ctx)
+}
+
+func (s auditingStorage) BatchGetCreditCards(login string, collection uint, ctx context.Context) ([]service.CreditCard, error) {
+	cards, err := s.UserStorage.BatchGetCreditCards(login, collection, ctx)
+	return cards, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload,
+		Item: storage.ItemCreditCard, CollectionID: collection}, err, ctx)
+}
+
+func (s auditingStorage) GetBinaryList(login string, collection uint, ctx context.Context) ([]service.BinaryData, error) {
+	binaries, err := s.UserStorage.GetBinaryList(login, collection, ctx)
+	return binaries, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload,
+		Item: storage.ItemBinary, CollectionID: collection}, err, ctx)
+}
+
+func (s auditingStorage) GetBinary(binary service.BinaryData, ctx context.Context) (service.BinaryData, error) {
+	binary, err := s.UserStorage.GetBinary(binary, ctx)
+	return binary, s.record(service.AuditEvent{Login: binary.Login, Action: service.AuditFetch,
+		Item: storage.ItemBinary, ItemID: binary.ID, CollectionID: binary.CollectionID,
+		Description: binary.Description}, err, ctx)
+}
+
+// GetBinaryChunkList records the fetch of a chunked binary, the chunks themselves are not recorded one by one
+func (s auditingStorage) GetBinaryChunkList(binary service.BinaryData, ctx context.Context) (service.BinaryData, []service.BinaryChunk, error) {
+	binary, chunks, err := s.UserStorage.GetBinaryChunkList(binary, ctx)
+	return binary, chunks, s.record(service.AuditEvent{Login: binary.Login, Action: service.AuditFetch,
+		Item: storage.ItemBinary, ItemID: binary.ID, CollectionID: binary.CollectionID,
+		Description: binary.Description}, err, ctx)
+}
+
+// GetChanges records a download of the secrets of every kind, if there are any changes to be sent
+func (s auditingStorage) GetChanges(login string, collection uint, since int64, ctx context.Context) (service.SyncData, int64, error) {
+	changes, revision, err := s.UserStorage.GetChanges(login, collection, since, ctx)
+	if len(changes.LogoPasses)+len(changes.Texts)+len(changes.CreditCards)+len(changes.Binaries) == 0 {
+		return changes, revision, err
+	}
+	return changes, revision, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload,
+		CollectionID: collection}, err, ctx)
+}
+
+// GetVersions records a download of the previous versions of the secrets
+func (s auditingStorage) GetVersions(login string, collection uint, item string, id uint, ctx context.Context) ([]service.SecretVersion, error) {
+	versions, err := s.UserStorage.GetVersions(login, collection, item, id, ctx)
+	return versions, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload, Item: item,
+		ItemID: id, CollectionID: collection}, err, ctx)
+}
+
+func (s auditingStorage) RestoreVersion(version service.SecretVersion, ctx context.Context) (service.SecretVersion, error) {
+	version, err := s.UserStorage.RestoreVersion(version, ctx)
+	event := service.AuditEvent{Login: version.Login, Action: service.AuditRestore, Item: version.Item,
+		ItemID: version.ItemID, CollectionID: version.CollectionID}
+	switch {
+	case version.LogoPass != nil:
+		event.Description = version.LogoPass.Description
+	case version.Text != nil:
+		event.Description = version.Text.Description
+	case version.CreditCard != nil:
+		event.Description = version.CreditCard.Description
+	}
+	return version, s.record(event, err, ctx)
+}
+
+// PutShare records the share of the owner's secret, the secret itself is never recorded
+func (s auditingStorage) PutShare(share service.Share, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.PutShare(share, ctx)
+	return share, s.record(service.AuditEvent{Login: share.Owner, Action: service.AuditShare, Item: share.Item,
+		ItemID: share.ItemID}, err, ctx)
+}
+
+// GetShares records a download of the shared secrets, if any of the shares holds one
+func (s auditingStorage) GetShares(login string, ctx context.Context) ([]service.Share, error) {
+	shares, err := s.UserStorage.GetShares(login, ctx)
+	for _, share := range shares {
+		if share.Recipient == login || share.Pending {
+			return shares, s.record(service.AuditEvent{Login: login, Action: service.AuditDownload,
+				Item: storage.ItemShare}, err, ctx)
+		}
+	}
+	return shares, err
+}
+
+// ChangeShare records the change of the shared secret made by the recipient
+func (s auditingStorage) ChangeShare(share service.Share, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.ChangeShare(share, ctx)
+	return share, s.record(service.AuditEvent{Login: share.Recipient, Action: service.AuditOverwrite,
+		Item: share.Item, ItemID: share.ItemID}, err, ctx)
+}
+
+func (s auditingStorage) DeleteShare(login string, id uint, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.DeleteShare(login, id, ctx)
+	return share, s.record(service.AuditEvent{Login: login, Action: service.AuditUnshare, Item: share.Item,
+		ItemID: share.ItemID}, err, ctx)
+}
+
+// PutSend records the send made by the user, the content is never recorded
+func (s auditingStorage) PutSend(send service.Send, ctx context.Context) (service.Send, error) {
+	send, err := s.UserStorage.PutSend(send, ctx)
+	return send, s.record(service.AuditEvent{Login: send.Login, Action: service.AuditShare,
+		Item: storage.ItemSend}, err, ctx)
+}
+
+// GetSend records every view of the send to the audit log of the sender, along with the client of the viewer
+func (s auditingStorage) GetSend(id string, ctx context.Context) (service.Send, error) {
+	send, err := s.UserStorage.GetSend(id, ctx)
+	return send, s.record(service.AuditEvent{Login: send.Login, Action: service.AuditFetch,
+		Item: storage.ItemSend}, err, ctx)
+}
@@ -0,0 +1,344 @@
+package app
+
+// Here is the push channel of the App: every change of the user's secrets is announced to the clients of the user
+// listening to the events, so that they could sync at once instead of waiting for the next poll.
+// The events are kept in memory, so they reach only the clients connected to the same instance of the App.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// eventsHeartbeat is how often a comment is sent to the idle streams of events, so that dead connections are noticed
+	eventsHeartbeat = 30 * time.Second
+	// eventsBuffer is how many events wait for a slow subscriber before the new ones are dropped
+	eventsBuffer = 16
+)
+
+// EventChange is the name of Server-Sent Events of EventsEndpoint announcing a change of the user's secrets
+const EventChange = "change"
+
+// eventBroker sends the events of every user to all the subscribers of the user,
+// every subscriber is kept along with the ID of the session it is subscribed by
+type eventBroker struct {
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[string]map[chan service.ChangeEvent]string
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[string]map[chan service.ChangeEvent]string)}
+}
+
+// subscribe returns the channel the events of the user are sent to and the function to stop sending them.
+// The channel is closed once unsubscribed, the session is revoked or the broker is closed.
+func (broker *eventBroker) subscribe(login string, session string) (<-chan service.ChangeEvent, func()) {
+	events := make(chan service.ChangeEvent, eventsBuffer)
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if broker.closed {
+		close(events)
+		return events, func() {}
+	}
+	if broker.subscribers[login] == nil {
+		broker.subscribers[login] = make(map[chan service.ChangeEvent]string)
+	}
+	broker.subscribers[login][events] = session
+
+	return events, func() {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+		if _, ok := broker.subscribers[login][events]; !ok {
+			return
+		}
+		delete(broker.subscribers[login], events)
+		if len(broker.subscribers[login]) == 0 {
+			delete(broker.subscribers, login)
+		}
+		close(events)
+	}
+}
+
+// publish sends the event to every subscriber of the user. The event is dropped for the subscribers that are
+// behind by eventsBuffer events, as the events they are yet to read make them sync anyway.
+func (broker *eventBroker) publish(login string, event service.ChangeEvent) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	for events := range broker.subscribers[login] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// revoke ends the subscriptions of the user made by the sessions revoked reports true for,
+// so that a revoked session stops receiving the events at once
+func (broker *eventBroker) revoke(login string, revoked func(session string) bool) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	for events, session := range broker.subscribers[login] {
+		if revoked(session) {
+			delete(broker.subscribers[login], events)
+			close(events)
+		}
+	}
+	if len(broker.subscribers[login]) == 0 {
+		delete(broker.subscribers, login)
+	}
+}
+
+// allSessions is the revoke filter of the changes signing the user out everywhere
+func allSessions(string) bool {
+	return true
+}
+
+// close ends all the subscriptions and refuses the new ones, so that the streams of events let the App shut down
+func (broker *eventBroker) close() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	broker.closed = true
+	for _, subscribers := range broker.subscribers {
+		for events := range subscribers {
+			close(events)
+		}
+	}
+	broker.subscribers = make(map[string]map[chan service.ChangeEvent]string)
+}
+
+// notifyingStorage publishes an event for every change of the user's secrets made through it,
+// so that both REST and gRPC changes are announced
+type notifyingStorage struct {
+	storage.UserStorage
+	events *eventBroker
+}
+
+// notify publishes the change of the item if it is stored
+func (s notifyingStorage) notify(login string, item string, err error) error {
+	if err == nil {
+		s.events.publish(login, service.ChangeEvent{Item: item})
+	}
+	return err
+}
+
+func (s notifyingStorage) PutLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutLogoPass(logoPass, ctx)
+	return revision, s.notifyVault(logoPass.Login, logoPass.CollectionID, storage.ItemLogoPass, err, ctx)
+}
+
+func (s notifyingStorage) PutText(secret service.TextData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutText(secret, ctx)
+	return revision, s.notifyVault(secret.Login, secret.CollectionID, storage.ItemText, err, ctx)
+}
+
+func (s notifyingStorage) PutCreditCard(card service.CreditCard, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutCreditCard(card, ctx)
+	return revision, s.notifyVault(card.Login, card.CollectionID, storage.ItemCreditCard, err, ctx)
+}
+
+func (s notifyingStorage) PutBinary(binary service.BinaryData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.PutBinary(binary, ctx)
+	return revision, s.notifyVault(binary.Login, binary.CollectionID, storage.ItemBinary, err, ctx)
+}
+
+// CompleteUpload looks the upload up first, as only the upload knows the vault the binary is stored in
+func (s notifyingStorage) CompleteUpload(session service.UploadSession, ctx context.Context) (int64, error) {
+	upload, err := s.UserStorage.GetUploadSession(session, ctx)
+	if err != nil {
+		return 0, err
+	}
+	revision, err := s.UserStorage.CompleteUpload(session, ctx)
+	return revision, s.notifyVault(session.Login, upload.CollectionID, storage.ItemBinary, err, ctx)
+}
+
+func (s notifyingStorage) DeleteLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteLogoPass(logoPass, ctx)
+	return revision, s.notifyVault(logoPass.Login, logoPass.CollectionID, storage.ItemLogoPass, err, ctx)
+}
+
+func (s notifyingStorage) DeleteText(secret service.TextData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteText(secret, ctx)
+	return revision, s.notifyVault(secret.Login, secret.CollectionID, storage.ItemText, err, ctx)
+}
+
+func (s notifyingStorage) DeleteCreditCard(card service.CreditCard, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteCreditCard(card, ctx)
+	return revision, s.notifyVault(card.Login, card.CollectionID, storage.ItemCreditCard, err, ctx)
+}
+
+func (s notifyingStorage) DeleteBinary(binary service.BinaryData, ctx context.Context) (int64, error) {
+	revision, err := s.UserStorage.DeleteBinary(binary, ctx)
+	return revision, s.notifyVault(binary.Login, binary.CollectionID, storage.ItemBinary, err, ctx)
+}
+
+func (s notifyingStorage) RestoreVersion(version service.SecretVersion, ctx context.Context) (service.SecretVersion, error) {
+	version, err := s.UserStorage.RestoreVersion(version, ctx)
+	return version, s.notifyVault(version.Login, version.CollectionID, version.Item, err, ctx)
+}
+
+// notifyVault publishes the change of the item stored in the vault: to the user for the personal vault,
+// to everyone who can see the collection otherwise. The change is stored by then, so a failure
+// to learn who can see the collection is logged only.
+func (s notifyingStorage) notifyVault(login string, collection uint, item string, err error, ctx context.Context) error {
+	if err != nil || collection == 0 {
+		return s.notify(login, item, err)
+	}
+	logins, lookupErr := s.UserStorage.GetCollectionLogins(collection, ctx)
+	if lookupErr != nil {
+		logging.FromContext(ctx).Error("notify collection members", "err", lookupErr, "collection", collection)
+		return nil
+	}
+	for _, member := range logins {
+		s.events.publish(member, service.ChangeEvent{Item: item, CollectionID: collection})
+	}
+	return nil
+}
+
+// PutShare announces the share to the recipient
+func (s notifyingStorage) PutShare(share service.Share, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.PutShare(share, ctx)
+	return share, s.notify(share.Recipient, storage.ItemShare, err)
+}
+
+func (s notifyingStorage) AcceptShare(login string, id uint, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.AcceptShare(login, id, ctx)
+	return share, s.notify(login, storage.ItemShare, err)
+}
+
+// ChangeShare announces the change of the shared secret to the owner, whose client is to take it in
+func (s notifyingStorage) ChangeShare(share service.Share, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.ChangeShare(share, ctx)
+	return share, s.notify(share.Owner, storage.ItemShare, err)
+}
+
+// DeleteShare announces the removal of the share to both the owner and the recipient
+func (s notifyingStorage) DeleteShare(login string, id uint, ctx context.Context) (service.Share, error) {
+	share, err := s.UserStorage.DeleteShare(login, id, ctx)
+	err = s.notify(share.Owner, storage.ItemShare, err)
+	return share, s.notify(share.Recipient, storage.ItemShare, err)
+}
+
+// ChangePassword announces the change of every kind of secrets, as all of them are re-encrypted.
+// The streams of the other sessions of the user are ended, as the sessions are revoked.
+func (s notifyingStorage) ChangePassword(change service.PasswordChange, ctx context.Context) error {
+	err := s.UserStorage.ChangePassword(change, ctx)
+	if err != nil {
+		return err
+	}
+	s.events.revoke(change.Login, func(session string) bool { return session != change.Session })
+	for _, item := range []string{storage.ItemLogoPass, storage.ItemText, storage.ItemCreditCard, storage.ItemBinary} {
+		err = s.notify(change.Login, item, err)
+	}
+	return err
+}
+
+// RotateRefreshToken ends the streams of the session revoked for the reuse of its refresh token,
+// as the token must have been stolen
+func (s notifyingStorage) RotateRefreshToken(old service.RefreshToken, next service.RefreshToken,
+	ctx context.Context) (service.RefreshToken, error) {
+	next, err := s.UserStorage.RotateRefreshToken(old, next, ctx)
+	if errors.Is(err, storage.ErrTokenReused) {
+		s.events.revoke(next.Login, func(session string) bool { return session == next.Family })
+	}
+	return next, err
+}
+
+// DeleteSession ends the streams of the session revoked
+func (s notifyingStorage) DeleteSession(session service.Session, ctx context.Context) error {
+	err := s.UserStorage.DeleteSession(session, ctx)
+	if err == nil {
+		s.events.revoke(session.Login, func(id string) bool { return id == session.ID })
+	}
+	return err
+}
+
+// DeleteSessions ends all the streams of the user
+func (s notifyingStorage) DeleteSessions(login string, ctx context.Context) error {
+	err := s.UserStorage.DeleteSessions(login, ctx)
+	if err == nil {
+		s.events.revoke(login, allSessions)
+	}
+	return err
+}
+
+// SetDisabled ends all the streams of the user disabled, as the sessions are revoked
+func (s notifyingStorage) SetDisabled(login string, disabled bool, ctx context.Context) error {
+	err := s.UserStorage.SetDisabled(login, disabled, ctx)
+	if err == nil && disabled {
+		s.events.revoke(login, allSessions)
+	}
+	return err
+}
+
+// DeleteUser ends all the streams of the user removed
+func (s notifyingStorage) DeleteUser(login string, ctx context.Context) error {
+	err := s.UserStorage.DeleteUser(login, ctx)
+	if err == nil {
+		s.events.revoke(login, allSessions)
+	}
+	return err
+}
+
+// streamEvents handles streaming the changes of the user's secrets via http.Get request as Server-Sent Events.
+// Every change is sent as `change` event with json.Marshalled service.ChangeEvent as data, a comment is sent
+// every eventsHeartbeat while there are none. The stream lasts until the client disconnects, the session
+// of the access token is revoked or the App shuts down.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `500` if the response can't be streamed
+//   - `200` and the stream of events
+func (app *App) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	login := app.getLogin(r)
+	events, unsubscribe := app.events.subscribe(login, sessionFromContext(r.Context()))
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, marshalErr := json.Marshal(event)
+			if marshalErr != nil {
+				logging.FromContext(r.Context()).Error("marshal event", "err", marshalErr, "login", login)
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", EventChange, data)
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("send event", "err", err, "login", login)
+			return
+		}
+		flusher.Flush()
+	}
+}
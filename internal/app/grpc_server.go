@@ -0,0 +1,1265 @@
+package app
+
+// Here is the gRPC server of the App, it provides the same operations as the REST handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"gophkeeper/internal/logging"
+	pb "gophkeeper/internal/proto"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// grpcMaxMessageSize is the largest message the gRPC server accepts, a chunk with some room for the rest
+	grpcMaxMessageSize = maxChunkSize + 1<<20
+	// grpcStreamPieceSize is the size of data sent in a single message of DownloadBinary
+	grpcStreamPieceSize = 1 << 20
+)
+
+// grpcPublicMethods are the methods available without authorization
+var grpcPublicMethods = map[string]bool{
+	pb.Keeper_Register_FullMethodName:       true,
+	pb.Keeper_Login_FullMethodName:          true,
+	pb.Keeper_RefreshToken_FullMethodName:   true,
+	pb.Keeper_LoginTwoFactor_FullMethodName: true,
+	pb.Keeper_GetSend_FullMethodName:        true,
+}
+
+// grpcLimitedMethods are the methods checking the credentials or guessable links, they are refused
+// to the addresses blocked for too many failed attempts just like limitAttempts does
+var grpcLimitedMethods = map[string]bool{
+	pb.Keeper_Register_FullMethodName:       true,
+	pb.Keeper_Login_FullMethodName:          true,
+	pb.Keeper_LoginTwoFactor_FullMethodName: true,
+	pb.Keeper_GetSend_FullMethodName:        true,
+}
+
+// grpcAdminMethods are the methods available to the admins only, they are checked just like isAdmin does
+var grpcAdminMethods = map[string]bool{
+	pb.Keeper_ListUsers_FullMethodName:   true,
+	pb.Keeper_DisableUser_FullMethodName: true,
+	pb.Keeper_EnableUser_FullMethodName:  true,
+	pb.Keeper_LogoutUser_FullMethodName:  true,
+	pb.Keeper_DeleteUser_FullMethodName:  true,
+}
+
+// grpcStreamingMethods are the methods moving large amounts of data or lasting for long, so they are not limited
+// by requestTimeout just like streamingEndpoints
+var grpcStreamingMethods = map[string]bool{
+	pb.Keeper_UploadBinaryChunk_FullMethodName: true,
+	pb.Keeper_DownloadBinary_FullMethodName:    true,
+	pb.Keeper_ChangePassword_FullMethodName:    true,
+	pb.Keeper_ExportVault_FullMethodName:       true,
+}
+
+// grpcDeletionMethods are the methods deleting the accounts, they are limited by deletionTimeout
+// just like deletionEndpoints
+var grpcDeletionMethods = map[string]bool{
+	pb.Keeper_DeleteAccount_FullMethodName: true,
+	pb.Keeper_DeleteUser_FullMethodName:    true,
+}
+
+// grpcServer implements pb.KeeperServer on top of the App
+type grpcServer struct {
+	pb.UnimplementedKeeperServer
+	app *App
+}
+
+// newGRPCServer creates the gRPC server of the App, it uses TLS unless tlsConfig is nil
+func (app *App) newGRPCServer(tlsConfig *tls.Config) *grpc.Server {
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(grpcMaxMessageSize),
+		grpc.UnaryInterceptor(app.grpcUnaryInterceptor),
+		grpc.StreamInterceptor(app.grpcStreamInterceptor),
+		// the idle connections are pinged, so that the dead streams of events are noticed
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: eventsHeartbeat}),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(options...)
+	pb.RegisterKeeperServer(server, &grpcServer{app: app})
+	return server
+}
+
+// grpcUnaryInterceptor checks the access token of the user just like isAuthorized does, the role of the user
+// for grpcAdminMethods just like isAdmin does, and adds requestTimeout to all the calls but the streaming ones,
+// grpcDeletionMethods get deletionTimeout instead.
+// The calls are logged just like logRequests does.
+func (app *App) grpcUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (resp interface{}, err error) {
+	ctx = grpcLogContext(ctx)
+	start := time.Now()
+	defer func() {
+		logCall(ctx, info.FullMethod, start, err)
+	}()
+
+	if !grpcStreamingMethods[info.FullMethod] {
+		timeout := requestTimeout
+		if grpcDeletionMethods[info.FullMethod] {
+			timeout = deletionTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if grpcLimitedMethods[info.FullMethod] {
+		err = app.ipLimiter.check(ipKey(grpcPeerIP(ctx)))
+		if err != nil {
+			return nil, grpcLockout(ctx, err)
+		}
+	}
+	if grpcPublicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	ctx, err = app.grpcAuthorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if grpcAdminMethods[info.FullMethod] {
+		err = app.checkAdmin(loginFromContext(ctx), ctx)
+		if err != nil {
+			if !errors.Is(err, errNotAdmin) {
+				logging.FromContext(ctx).Error("grpc check admin", "err", err, "login", loginFromContext(ctx))
+			}
+			return nil, grpcError(err)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// grpcStreamInterceptor checks the access token of the user just like isAuthorized does
+// and logs the calls just like logRequests does
+func (app *App) grpcStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) (err error) {
+	ctx := grpcLogContext(stream.Context())
+	start := time.Now()
+	defer func() {
+		logCall(ctx, info.FullMethod, start, err)
+	}()
+
+	ctx, err = app.grpcAuthorize(ctx)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorizedStream is grpc.ServerStream with the login of the user and the logger in its context
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream holding the login of the user
+func (stream *authorizedStream) Context() context.Context {
+	return stream.ctx
+}
+
+// grpcAuthorize validates the access token from pb.AuthorizationMetadata just like isAuthorized does
+// and puts the login and the session of the user to the context
+func (app *App) grpcAuthorize(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(pb.AuthorizationMetadata)
+	if len(values) == 0 {
+		return ctx, status.Error(codes.Unauthenticated, "no access token")
+	}
+	accessToken, ok := bearerToken(values[0])
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, errInvalidAccessToken.Error())
+	}
+
+	ctx, err := app.authorize(ctx, accessToken, grpcPeerIP(ctx))
+	if err != nil {
+		if errors.Is(err, errInvalidAccessToken) {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc authorize", "err", err)
+		return ctx, status.Error(codes.Internal, err.Error())
+	}
+	return ctx, nil
+}
+
+// grpcPeerIP returns the IP address of the client that made the call
+func grpcPeerIP(ctx context.Context) string {
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return remoteIP(client.Addr.String())
+}
+
+// grpcUserAgent returns the user agent of the client that made the call
+func grpcUserAgent(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("user-agent")
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// grpcLockout converts lockoutError to ResourceExhausted status and sends the time to retry after
+// in pb.RetryAfterMetadata header just like writeLockout does. Other errors are converted by grpcError.
+func grpcLockout(ctx context.Context, err error) error {
+	var lockout *lockoutError
+	if !errors.As(err, &lockout) {
+		return grpcError(err)
+	}
+	if headerErr := grpc.SetHeader(ctx, metadata.Pairs(pb.RetryAfterMetadata, retryAfterSeconds(lockout))); headerErr != nil {
+		logging.FromContext(ctx).Error("grpc set retry-after header", "err", headerErr)
+	}
+	return status.Error(codes.ResourceExhausted, lockout.Error())
+}
+
+// grpcETag sends the revision the entry is stored at in pb.ETagMetadata header just like setETag does
+func grpcETag(ctx context.Context, revision int64) {
+	err := grpc.SetHeader(ctx, metadata.Pairs(pb.ETagMetadata, strconv.FormatInt(revision, 10)))
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc set etag header", "err", err)
+	}
+}
+
+// grpcRevisionError converts RevisionError to Aborted status with the entry as it is stored in the details,
+// just like revisionMismatch sends it along with `412`
+func grpcRevisionError(revisionErr *storage.RevisionError) error {
+	var current protoiface.MessageV1
+	switch entry := revisionErr.Current.(type) {
+	case *service.LogoPass:
+		current = pb.FromLogoPass(*entry)
+	case *service.TextData:
+		current = pb.FromTextData(*entry)
+	case *service.CreditCard:
+		current = pb.FromCreditCard(*entry)
+	case *service.BinaryData:
+		current = pb.FromBinaryData(*entry)
+	}
+	st := status.New(codes.Aborted, revisionErr.Error())
+	if current != nil {
+		if detailed, err := st.WithDetails(current); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// grpcError maps storage errors to gRPC status codes
+func grpcError(err error) error {
+	var revisionErr *storage.RevisionError
+	switch {
+	case errors.As(err, &revisionErr):
+		return grpcRevisionError(revisionErr)
+	case errors.Is(err, storage.ErrRevisionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, storage.ErrRevisionRequired):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrEmpty):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrAlreadyExists), errors.Is(err, storage.ErrUserExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrOldData), errors.Is(err, errOwnAccount):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrInvalidCredentials), errors.Is(err, storage.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, storage.ErrInvalidChunk), errors.Is(err, storage.ErrIncompleteUpload):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrInvalidCode), errors.Is(err, storage.ErrAccountDisabled), errors.Is(err, errNotAdmin),
+		errors.Is(err, storage.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, storage.ErrLastOwner):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// authorize checks the credentials and issues a new pair of tokens.
+// The users with two-factor authentication enabled get a challenge instead if twoFactor is set.
+func (server *grpcServer) authorize(ctx context.Context, authDetails service.Authentication,
+	twoFactor bool) (*pb.LoginResponse, error) {
+	err := server.app.checkCredentials(authDetails, grpcPeerIP(ctx), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc auth err", "err", err, "login", authDetails.Login)
+		return nil, grpcLockout(ctx, err)
+	}
+
+	device := deviceName(authDetails.Device, grpcUserAgent(ctx))
+	if twoFactor {
+		required, err := server.app.twoFactorEnabled(authDetails.Login, ctx)
+		if err != nil {
+			logging.FromContext(ctx).Error("grpc auth then check 2fa err", "err", err, "login", authDetails.Login)
+			return nil, grpcError(err)
+		}
+		if required {
+			challenge, err := server.app.signChallenge(authDetails.Login, device)
+			if err != nil {
+				logging.FromContext(ctx).Error("grpc auth then issue challenge err", "err", err, "login", authDetails.Login)
+				return nil, grpcError(err)
+			}
+			return &pb.LoginResponse{Challenge: &pb.TwoFactorChallenge{ChallengeToken: challenge.ChallengeToken,
+				ExpiresIn: challenge.ExpiresIn}}, nil
+		}
+	}
+
+	tokens, err := server.app.startSession(service.Session{Login: authDetails.Login, Device: device,
+		IP: grpcPeerIP(ctx)}, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc auth then issue tokens err", "err", err, "login", authDetails.Login)
+		return nil, grpcError(err)
+	}
+	return &pb.LoginResponse{Tokens: pb.FromTokens(tokens)}, nil
+}
+
+// Register creates a new user and authorizes it right away
+func (server *grpcServer) Register(ctx context.Context, req *pb.AuthRequest) (*pb.Tokens, error) {
+	user := service.User{Login: req.GetLogin(), Password: req.GetPassword()}
+	err := server.app.UserStorage.RegisterUser(user, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc register err", "err", err, "login", user.Login)
+		if errors.Is(err, storage.ErrUserExists) {
+			server.app.ipLimiter.fail(ipKey(grpcPeerIP(ctx)))
+		}
+		return nil, grpcError(err)
+	}
+	resp, err := server.authorize(ctx, service.Authentication{Login: user.Login, Password: user.Password,
+		Device: req.GetDevice()}, false)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetTokens(), nil
+}
+
+// Login authorizes the user, the users with two-factor authentication enabled get a challenge instead of the tokens
+func (server *grpcServer) Login(ctx context.Context, req *pb.AuthRequest) (*pb.LoginResponse, error) {
+	return server.authorize(ctx, service.Authentication{Login: req.GetLogin(), Password: req.GetPassword(),
+		Device: req.GetDevice()}, true)
+}
+
+// LoginTwoFactor finishes the login with the challenge and the code in the same way loginTwoFactor does
+func (server *grpcServer) LoginTwoFactor(ctx context.Context, req *pb.TwoFactorLogin) (*pb.Tokens, error) {
+	tokens, err := server.app.finishLogin(service.TwoFactorLogin{ChallengeToken: req.GetChallengeToken(),
+		Code: req.GetCode()}, grpcPeerIP(ctx), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc auth 2fa err", "err", err)
+		if errors.Is(err, errInvalidChallenge) || errors.Is(err, storage.ErrInvalidCode) ||
+			errors.Is(err, storage.ErrEmpty) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, grpcLockout(ctx, err)
+	}
+	return pb.FromTokens(tokens), nil
+}
+
+// RefreshToken exchanges a refresh token for a new pair of tokens in the same way refreshToken does
+func (server *grpcServer) RefreshToken(ctx context.Context, req *pb.RefreshRequest) (*pb.Tokens, error) {
+	tokens, err := server.app.rotateTokens(req.GetRefreshToken(), ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrInvalidToken) {
+			logging.FromContext(ctx).Error("grpc refresh token err", "err", err)
+		}
+		return nil, grpcError(err)
+	}
+	return pb.FromTokens(tokens), nil
+}
+
+// Logout revokes the session of the access token in the same way logout does
+func (server *grpcServer) Logout(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	session := service.Session{ID: sessionFromContext(ctx), Login: loginFromContext(ctx)}
+	err := server.app.UserStorage.DeleteSession(session, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc logout", "err", err, "login", session.Login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ChangePassword replaces the password along with the re-encrypted secrets in the same way changePassword does
+func (server *grpcServer) ChangePassword(ctx context.Context, req *pb.PasswordChange) (*emptypb.Empty, error) {
+	change := pb.ToPasswordChange(req)
+	change.Login = loginFromContext(ctx)
+	change.Session = sessionFromContext(ctx)
+	err := server.app.updatePassword(change, grpcPeerIP(ctx), ctx)
+	if err != nil {
+		if errors.Is(err, errEmptyPassword) || errors.Is(err, errInvalidCursor) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		// Unauthenticated stands for the access token refused, just like `401` does
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc change password", "err", err, "login", change.Login)
+		return nil, grpcLockout(ctx, err)
+	}
+	logging.FromContext(ctx).Info("grpc password changed", "login", change.Login)
+	return &emptypb.Empty{}, nil
+}
+
+// ExportVault streams every secret of the user as a zip archive, just like exportVault does
+func (server *grpcServer) ExportVault(_ *emptypb.Empty, stream pb.Keeper_ExportVaultServer) error {
+	ctx := stream.Context()
+	login := loginFromContext(ctx)
+
+	secrets, err := server.app.loadVault(login, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc export", "err", err, "login", login)
+		return grpcError(err)
+	}
+	err = server.app.writeVault(login, secrets, &exportWriter{stream: stream}, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc export: write archive", "err", err, "login", login)
+		return grpcError(err)
+	}
+	logging.FromContext(ctx).Info("grpc export", "login", login)
+	return nil
+}
+
+// exportWriter sends everything written to it over the stream of ExportVault in pieces of grpcStreamPieceSize
+type exportWriter struct {
+	stream pb.Keeper_ExportVaultServer
+}
+
+// Write sends the data, the message is marshalled right away, so the data can be reused once it returns
+func (writer *exportWriter) Write(data []byte) (int, error) {
+	var sent int
+	for sent < len(data) {
+		piece := data[sent:]
+		if len(piece) > grpcStreamPieceSize {
+			piece = piece[:grpcStreamPieceSize]
+		}
+		err := writer.stream.Send(&pb.BinaryChunk{Data: piece})
+		if err != nil {
+			return sent, err
+		}
+		sent += len(piece)
+	}
+	return sent, nil
+}
+
+// DeleteAccount deletes the user along with all the data once the password is checked, just like deleteAccount does
+func (server *grpcServer) DeleteAccount(ctx context.Context, req *pb.AuthRequest) (*emptypb.Empty, error) {
+	authDetails := service.Authentication{Login: loginFromContext(ctx), Password: req.GetPassword()}
+	err := server.app.removeAccount(authDetails, grpcPeerIP(ctx), ctx)
+	if err != nil {
+		// Unauthenticated stands for the access token refused, just like `401` does
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if !errors.Is(err, storage.ErrLastOwner) {
+			logging.FromContext(ctx).Error("grpc delete account", "err", err, "login", authDetails.Login)
+		}
+		return nil, grpcLockout(ctx, err)
+	}
+	logging.FromContext(ctx).Info("grpc account deleted", "login", authDetails.Login)
+	return &emptypb.Empty{}, nil
+}
+
+// GetSessions returns all the devices signed in by the user, the session that made the call is marked as current
+func (server *grpcServer) GetSessions(ctx context.Context, _ *emptypb.Empty) (*pb.SessionList, error) {
+	login := loginFromContext(ctx)
+	sessions, err := server.app.UserStorage.GetSessions(login, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get sessions", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.SessionList
+	current := sessionFromContext(ctx)
+	for _, session := range sessions {
+		session.Current = session.ID == current
+		resp.Sessions = append(resp.Sessions, pb.FromSession(session))
+	}
+	return &resp, nil
+}
+
+// RevokeSession revokes any session of the user in the same way revokeSession does
+func (server *grpcServer) RevokeSession(ctx context.Context, req *pb.Session) (*emptypb.Empty, error) {
+	session := service.Session{ID: req.GetSessionId(), Login: loginFromContext(ctx)}
+	err := server.app.UserStorage.DeleteSession(session, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc revoke session", "err", err, "login", session.Login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// EnrollTwoFactor generates a new TOTP secret in the same way enrollTwoFactor does
+func (server *grpcServer) EnrollTwoFactor(ctx context.Context, _ *emptypb.Empty) (*pb.TOTPEnrollment, error) {
+	login := loginFromContext(ctx)
+	enrollment, err := server.app.enrollTOTP(login, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc enroll 2fa", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &pb.TOTPEnrollment{Secret: enrollment.Secret, Uri: enrollment.URI}, nil
+}
+
+// VerifyTwoFactor enables two-factor authentication in the same way verifyTwoFactor does
+func (server *grpcServer) VerifyTwoFactor(ctx context.Context, req *pb.TwoFactorCode) (*pb.RecoveryCodes, error) {
+	login := loginFromContext(ctx)
+	recoveryCodes, err := server.app.verifyTOTP(login, req.GetCode(), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc verify 2fa", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &pb.RecoveryCodes{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off in the same way disableTwoFactor does
+func (server *grpcServer) DisableTwoFactor(ctx context.Context, req *pb.TwoFactorCode) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	err := server.app.disableTOTP(login, req.GetCode(), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc disable 2fa", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// PutLogoPass stores a logo-pass pair in the same way uploadLogoPass does
+func (server *grpcServer) PutLogoPass(ctx context.Context, req *pb.LogoPass) (*emptypb.Empty, error) {
+	logoPass := pb.ToLogoPass(req)
+	logoPass.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.PutLogoPass(logoPass, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put logopass pair", "err", err, "login", logoPass.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// GetLogoPasses returns all user's personal logo-pass pairs including tombstones, the list is empty if there are none
+func (server *grpcServer) GetLogoPasses(ctx context.Context, _ *emptypb.Empty) (*pb.LogoPassList, error) {
+	login := loginFromContext(ctx)
+	listLogoPasses, err := server.app.UserStorage.BatchGetLogoPasses(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get logopass pairs", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.LogoPassList
+	for _, logoPass := range listLogoPasses {
+		resp.LogoPasses = append(resp.LogoPasses, pb.FromLogoPass(logoPass))
+	}
+	return &resp, nil
+}
+
+// DeleteLogoPass turns a logo-pass pair into a tombstone in the same way deleteLogoPass does
+func (server *grpcServer) DeleteLogoPass(ctx context.Context, req *pb.LogoPass) (*emptypb.Empty, error) {
+	logoPass := pb.ToLogoPass(req)
+	logoPass.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.DeleteLogoPass(logoPass, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete logopass pair", "err", err, "login", logoPass.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// PutText stores a secret text in the same way uploadText does
+func (server *grpcServer) PutText(ctx context.Context, req *pb.TextData) (*emptypb.Empty, error) {
+	text := pb.ToTextData(req)
+	text.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.PutText(text, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put secret text", "err", err, "login", text.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// GetTexts returns all user's personal secret texts including tombstones, the list is empty if there are none
+func (server *grpcServer) GetTexts(ctx context.Context, _ *emptypb.Empty) (*pb.TextDataList, error) {
+	login := loginFromContext(ctx)
+	listTexts, err := server.app.UserStorage.BatchGetTexts(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get secrets", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.TextDataList
+	for _, text := range listTexts {
+		resp.Texts = append(resp.Texts, pb.FromTextData(text))
+	}
+	return &resp, nil
+}
+
+// DeleteText turns a secret text into a tombstone in the same way deleteText does
+func (server *grpcServer) DeleteText(ctx context.Context, req *pb.TextData) (*emptypb.Empty, error) {
+	text := pb.ToTextData(req)
+	text.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.DeleteText(text, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete secret text", "err", err, "login", text.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// PutCreditCard stores a credit card in the same way uploadCreditCard does
+func (server *grpcServer) PutCreditCard(ctx context.Context, req *pb.CreditCard) (*emptypb.Empty, error) {
+	card := pb.ToCreditCard(req)
+	card.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.PutCreditCard(card, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put credit card", "err", err, "login", card.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// GetCreditCards returns all user's personal credit cards including tombstones, the list is empty if there are none
+func (server *grpcServer) GetCreditCards(ctx context.Context, _ *emptypb.Empty) (*pb.CreditCardList, error) {
+	login := loginFromContext(ctx)
+	listCreditCards, err := server.app.UserStorage.BatchGetCreditCards(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get credit cards", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.CreditCardList
+	for _, card := range listCreditCards {
+		resp.CreditCards = append(resp.CreditCards, pb.FromCreditCard(card))
+	}
+	return &resp, nil
+}
+
+// DeleteCreditCard turns a credit card into a tombstone in the same way deleteCreditCard does
+func (server *grpcServer) DeleteCreditCard(ctx context.Context, req *pb.CreditCard) (*emptypb.Empty, error) {
+	card := pb.ToCreditCard(req)
+	card.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.DeleteCreditCard(card, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete credit card", "err", err, "login", card.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// PutBinary stores the whole binary at once in the same way uploadBinary does
+func (server *grpcServer) PutBinary(ctx context.Context, req *pb.BinaryData) (*emptypb.Empty, error) {
+	binary := pb.ToBinaryData(req)
+	binary.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.PutBinary(binary, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put binary", "err", err, "login", binary.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// GetBinaryList returns all user's personal binaries without their content, the list is empty if there are none
+func (server *grpcServer) GetBinaryList(ctx context.Context, _ *emptypb.Empty) (*pb.BinaryDataList, error) {
+	login := loginFromContext(ctx)
+	binaryList, err := server.app.UserStorage.GetBinaryList(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get binary list", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.BinaryDataList
+	for _, binary := range binaryList {
+		resp.Binaries = append(resp.Binaries, pb.FromBinaryData(binary))
+	}
+	return &resp, nil
+}
+
+// GetBinary returns the whole binary stored by PutBinary
+func (server *grpcServer) GetBinary(ctx context.Context, req *pb.BinaryData) (*pb.BinaryData, error) {
+	binary := pb.ToBinaryData(req)
+	binary.Login = loginFromContext(ctx)
+	binary, err := server.app.UserStorage.GetBinary(binary, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc get binary", "err", err, "login", binary.Login)
+		return nil, grpcError(err)
+	}
+	return pb.FromBinaryData(binary), nil
+}
+
+// DeleteBinary turns a binary into a tombstone in the same way deleteBinary does
+func (server *grpcServer) DeleteBinary(ctx context.Context, req *pb.BinaryData) (*emptypb.Empty, error) {
+	binary := pb.ToBinaryData(req)
+	binary.Login = loginFromContext(ctx)
+	revision, err := server.app.UserStorage.DeleteBinary(binary, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete binary", "err", err, "login", binary.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// StartBinaryUpload starts a chunked upload in the same way startBinaryUpload does
+func (server *grpcServer) StartBinaryUpload(ctx context.Context, req *pb.UploadSession) (*pb.UploadSession, error) {
+	session := pb.ToUploadSession(req)
+	session.Login = loginFromContext(ctx)
+	session, err := server.app.UserStorage.CreateUploadSession(session, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc start binary upload", "err", err, "login", session.Login)
+		return nil, grpcError(err)
+	}
+	return pb.FromUploadSession(session), nil
+}
+
+// GetBinaryUpload returns the state of a chunked upload with the parts already received
+func (server *grpcServer) GetBinaryUpload(ctx context.Context, req *pb.UploadSession) (*pb.UploadSession, error) {
+	session := pb.ToUploadSession(req)
+	session.Login = loginFromContext(ctx)
+	session, err := server.app.UserStorage.GetUploadSession(session, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc get binary upload", "err", err, "login", session.Login)
+		return nil, grpcError(err)
+	}
+	return pb.FromUploadSession(session), nil
+}
+
+// UploadBinaryChunk stores a single part of a chunked upload in the same way uploadBinaryChunk does
+func (server *grpcServer) UploadBinaryChunk(ctx context.Context, req *pb.BinaryChunk) (*emptypb.Empty, error) {
+	session := service.UploadSession{ID: req.GetUploadId(), Login: loginFromContext(ctx)}
+	chunk := service.BinaryChunk{Part: int(req.GetPart()), Data: req.GetData()}
+
+	if len(chunk.Data) > maxChunkSize {
+		return nil, status.Error(codes.InvalidArgument, "chunk is too large")
+	}
+	chunk.Checksum = tools.ChecksumBytes(chunk.Data)
+	if !strings.EqualFold(chunk.Checksum, req.GetChecksum()) {
+		return nil, status.Error(codes.InvalidArgument, "checksum mismatch")
+	}
+
+	err := server.app.UserStorage.PutBinaryChunk(session, chunk, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc upload binary chunk", "err", err, "login", session.Login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// CompleteBinaryUpload turns all the parts of an upload into a binary in the same way completeBinaryUpload does
+func (server *grpcServer) CompleteBinaryUpload(ctx context.Context, req *pb.UploadSession) (*emptypb.Empty, error) {
+	session := service.UploadSession{ID: req.GetUploadId(), Login: loginFromContext(ctx)}
+	revision, err := server.app.UserStorage.CompleteUpload(session, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc complete binary upload", "err", err, "login", session.Login)
+		return nil, grpcError(err)
+	}
+	grpcETag(ctx, revision)
+	return &emptypb.Empty{}, nil
+}
+
+// DownloadBinary streams the encrypted content of a chunked binary starting at the offset,
+// one chunk at a time split into pieces of grpcStreamPieceSize.
+func (server *grpcServer) DownloadBinary(req *pb.DownloadRequest, stream pb.Keeper_DownloadBinaryServer) error {
+	ctx := stream.Context()
+	binary := service.BinaryData{Login: loginFromContext(ctx), Description: req.GetDescription(),
+		CollectionID: uint(req.GetCollectionId())}
+
+	binary, chunks, err := server.app.UserStorage.GetBinaryChunkList(binary, ctx)
+	if err != nil {
+		logging.FromContext(stream.Context()).Error("grpc download binary", "err", err, "login", binary.Login)
+		return grpcError(err)
+	}
+	offset := req.GetOffset()
+	if offset < 0 || (offset > 0 && offset >= binary.Size) {
+		return status.Error(codes.OutOfRange, "invalid offset")
+	}
+
+	// the first message carries the sizes only, so that the client could prepare for the content
+	err = stream.Send(&pb.BinaryChunk{Size: binary.Size, ChunkSize: binary.ChunkSize})
+	if err != nil {
+		return err
+	}
+
+	var position int64
+	for _, chunk := range chunks {
+		if position+chunk.Size <= offset {
+			position += chunk.Size
+			continue
+		}
+
+		chunk, err = server.app.UserStorage.GetBinaryChunk(chunk, ctx)
+		if err != nil {
+			logging.FromContext(stream.Context()).Error("grpc download binary: get chunk", "err", err, "login", binary.Login)
+			return grpcError(err)
+		}
+		data := chunk.Data
+		if offset > position {
+			data = data[offset-position:]
+		}
+		for len(data) > 0 {
+			piece := data
+			if len(piece) > grpcStreamPieceSize {
+				piece = piece[:grpcStreamPieceSize]
+			}
+			err = stream.Send(&pb.BinaryChunk{Part: int32(chunk.Part), Data: piece})
+			if err != nil {
+				logging.FromContext(stream.Context()).Error("grpc download binary: send chunk", "err", err, "login", binary.Login)
+				return err
+			}
+			data = data[len(piece):]
+		}
+		position += chunk.Size
+	}
+	return nil
+}
+
+// Sync returns all user's secrets changed since the cursor in the same way sync does
+func (server *grpcServer) Sync(ctx context.Context, req *pb.SyncRequest) (*pb.SyncData, error) {
+	login := loginFromContext(ctx)
+	since, err := parseCursor(req.GetSince())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	changes, revision, err := server.app.UserStorage.GetChanges(login, uint(req.GetCollectionId()), since, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc get changes", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	changes.Cursor = formatCursor(revision)
+	return pb.FromSyncData(changes), nil
+}
+
+// GetUsage returns how much space the user takes and the quotas limiting it in the same way usage does
+func (server *grpcServer) GetUsage(ctx context.Context, _ *emptypb.Empty) (*pb.Usage, error) {
+	login := loginFromContext(ctx)
+	usage, err := server.app.UserStorage.GetUsage(login, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc get usage", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return pb.FromUsage(usage), nil
+}
+
+// GetAuditLog returns a page of the user's audit log in the same way auditLog does
+func (server *grpcServer) GetAuditLog(ctx context.Context, req *pb.AuditRequest) (*pb.AuditPage, error) {
+	login := loginFromContext(ctx)
+	page, err := server.app.auditPage(login, req.GetBefore(), int(req.GetLimit()), ctx)
+	if err != nil {
+		if errors.Is(err, errInvalidAuditPage) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc get audit log", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return pb.FromAuditPage(page), nil
+}
+
+// GetHistory returns the previous versions of the user's secrets in the same way history does
+func (server *grpcServer) GetHistory(ctx context.Context, req *pb.HistoryRequest) (*pb.SecretVersionList, error) {
+	login := loginFromContext(ctx)
+	versions, err := server.app.versions(login, uint(req.GetCollectionId()), req.GetItem(), uint(req.GetItemId()), ctx)
+	if err != nil {
+		if errors.Is(err, errInvalidItem) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc get history", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.SecretVersionList
+	for _, version := range versions {
+		resp.Versions = append(resp.Versions, pb.FromSecretVersion(version))
+	}
+	return &resp, nil
+}
+
+// RestoreVersion makes the version the current one in the same way restoreVersion does
+func (server *grpcServer) RestoreVersion(ctx context.Context, req *pb.SecretVersion) (*emptypb.Empty, error) {
+	version := pb.ToSecretVersion(req)
+	version.Login = loginFromContext(ctx)
+	_, err := server.app.UserStorage.RestoreVersion(version, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc restore version", "err", err, "login", version.Login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetKeyPair returns the key pair of the user or the public key of another user in the same way getKeyPair does
+func (server *grpcServer) GetKeyPair(ctx context.Context, req *pb.UserRequest) (*pb.KeyPair, error) {
+	login := loginFromContext(ctx)
+	keys, err := server.app.keyPair(login, req.GetLogin(), ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoKeyPair) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc get key pair", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return pb.FromKeyPair(keys), nil
+}
+
+// PutKeyPair stores the key pair of the user in the same way putKeyPair does
+func (server *grpcServer) PutKeyPair(ctx context.Context, req *pb.KeyPair) (*emptypb.Empty, error) {
+	keys := pb.ToKeyPair(req)
+	if keys.PublicKey == "" || keys.PrivateKey == "" {
+		return nil, status.Error(codes.InvalidArgument, errInvalidKeyPair.Error())
+	}
+	keys.Login = loginFromContext(ctx)
+	err := server.app.UserStorage.PutKeyPair(keys, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put key pair", "err", err, "login", keys.Login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetShares returns the shares made by the user and the ones made for the user in the same way listShares does
+func (server *grpcServer) GetShares(ctx context.Context, _ *emptypb.Empty) (*pb.ShareList, error) {
+	login := loginFromContext(ctx)
+	shares, err := server.app.UserStorage.GetShares(login, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get shares", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.ShareList
+	for _, share := range shares {
+		resp.Shares = append(resp.Shares, pb.FromShare(share))
+	}
+	return &resp, nil
+}
+
+// PutShare shares the secret of the user in the same way putShare does
+func (server *grpcServer) PutShare(ctx context.Context, req *pb.Share) (*pb.Share, error) {
+	share := pb.ToShare(req)
+	share.Owner = loginFromContext(ctx)
+	err := checkShare(share.Owner, share)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	share, err = server.app.UserStorage.PutShare(share, ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUnknownItem):
+			return nil, status.Error(codes.InvalidArgument, errInvalidShare.Error())
+		case errors.Is(err, storage.ErrNoKeyPair):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc put share", "err", err, "login", share.Owner)
+		return nil, grpcError(err)
+	}
+	return pb.FromShare(share), nil
+}
+
+// AcceptShare accepts the share made for the user in the same way acceptShare does
+func (server *grpcServer) AcceptShare(ctx context.Context, req *pb.ShareRequest) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	_, err := server.app.UserStorage.AcceptShare(login, uint(req.GetShareId()), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc accept share", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ChangeShare keeps the change of the secret shared with the user in the same way changeShare does
+func (server *grpcServer) ChangeShare(ctx context.Context, req *pb.Share) (*emptypb.Empty, error) {
+	share := pb.ToShare(req)
+	share.Recipient = loginFromContext(ctx)
+	_, err := server.app.UserStorage.ChangeShare(share, ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUnknownItem):
+			return nil, status.Error(codes.InvalidArgument, errInvalidShare.Error())
+		case errors.Is(err, storage.ErrReadOnly):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc change share", "err", err, "login", share.Recipient)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteShare revokes or declines the share in the same way deleteShare does
+func (server *grpcServer) DeleteShare(ctx context.Context, req *pb.ShareRequest) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	_, err := server.app.UserStorage.DeleteShare(login, uint(req.GetShareId()), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete share", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetOrgs returns the organizations of the user in the same way listOrgs does
+func (server *grpcServer) GetOrgs(ctx context.Context, _ *emptypb.Empty) (*pb.OrgList, error) {
+	login := loginFromContext(ctx)
+	orgs, err := server.app.UserStorage.GetOrgs(login, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(ctx).Error("grpc get organizations", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+
+	var resp pb.OrgList
+	for _, org := range orgs {
+		resp.Orgs = append(resp.Orgs, pb.FromOrganization(org))
+	}
+	return &resp, nil
+}
+
+// CreateOrg creates an organization owned by the user in the same way createOrg does
+func (server *grpcServer) CreateOrg(ctx context.Context, req *pb.Organization) (*pb.Organization, error) {
+	login := loginFromContext(ctx)
+	org := pb.ToOrganization(req)
+	if org.Name == "" || org.OrgKey == "" {
+		return nil, status.Error(codes.InvalidArgument, errInvalidOrg.Error())
+	}
+	org, err := server.app.UserStorage.CreateOrg(org, service.OrgMember{Login: login, OrgKey: org.OrgKey}, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc create organization", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return pb.FromOrganization(org), nil
+}
+
+// DeleteOrg removes the organization in the same way deleteOrg does
+func (server *grpcServer) DeleteOrg(ctx context.Context, req *pb.OrgRequest) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	err := server.app.UserStorage.DeleteOrg(login, uint(req.GetOrgId()), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete organization", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// PutMember adds the member to the organization or changes its role in the same way putMember does
+func (server *grpcServer) PutMember(ctx context.Context, req *pb.OrgMember) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	member := pb.ToOrgMember(req)
+	if member.OrgID == 0 || member.Login == "" || !validRole(member.Role) {
+		return nil, status.Error(codes.InvalidArgument, errInvalidMember.Error())
+	}
+	err := server.app.UserStorage.PutMember(login, member, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoKeyPair) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		logging.FromContext(ctx).Error("grpc put member", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteMember removes the member from the organization in the same way deleteMember does
+func (server *grpcServer) DeleteMember(ctx context.Context, req *pb.OrgMember) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	member := pb.ToOrgMember(req)
+	if member.Login == "" {
+		member.Login = login
+	}
+	err := server.app.UserStorage.DeleteMember(login, member, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete member", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// PutCollection creates or renames a collection in the same way putCollection does
+func (server *grpcServer) PutCollection(ctx context.Context, req *pb.Collection) (*pb.Collection, error) {
+	login := loginFromContext(ctx)
+	collection := pb.ToCollection(req)
+	if collection.OrgID == 0 || collection.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, errInvalidOrg.Error())
+	}
+	collection, err := server.app.UserStorage.PutCollection(login, collection, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put collection", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return pb.FromCollection(collection), nil
+}
+
+// DeleteCollection removes the collection in the same way deleteCollection does
+func (server *grpcServer) DeleteCollection(ctx context.Context, req *pb.Collection) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	err := server.app.UserStorage.DeleteCollection(login, uint(req.GetCollectionId()), ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete collection", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// PutCollectionMember adds the member to the collection in the same way putCollectionMember does
+func (server *grpcServer) PutCollectionMember(ctx context.Context, req *pb.CollectionMember) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	member := service.CollectionMember{CollectionID: uint(req.GetCollectionId()), Login: req.GetLogin()}
+	if member.CollectionID == 0 || member.Login == "" {
+		return nil, status.Error(codes.InvalidArgument, errInvalidMember.Error())
+	}
+	err := server.app.UserStorage.PutCollectionMember(login, member, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc put collection member", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteCollectionMember removes the member from the collection in the same way deleteCollectionMember does
+func (server *grpcServer) DeleteCollectionMember(ctx context.Context, req *pb.CollectionMember) (*emptypb.Empty, error) {
+	login := loginFromContext(ctx)
+	member := service.CollectionMember{CollectionID: uint(req.GetCollectionId()), Login: req.GetLogin()}
+	err := server.app.UserStorage.DeleteCollectionMember(login, member, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc delete collection member", "err", err, "login", login)
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// CreateSend stores the send of the user in the same way createSend does
+func (server *grpcServer) CreateSend(ctx context.Context, req *pb.Send) (*pb.Send, error) {
+	send := pb.ToSend(req)
+	err := prepareSend(&send)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	send.Login = loginFromContext(ctx)
+	send, err = server.app.UserStorage.PutSend(send, ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc create send", "err", err, "login", send.Login)
+		return nil, grpcError(err)
+	}
+	send.Data, send.Name = nil, ""
+	return pb.FromSend(send), nil
+}
+
+// GetSend returns the send to anyone holding the link in the same way getSend does
+func (server *grpcServer) GetSend(ctx context.Context, req *pb.SendRequest) (*pb.Send, error) {
+	send, err := server.app.viewSend(req.GetSendId(), grpcPeerIP(ctx), ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEmpty) {
+			logging.FromContext(ctx).Error("grpc get send", "err", err)
+		}
+		return nil, grpcError(err)
+	}
+	return pb.FromSend(send), nil
+}
+
+// ListUsers returns all the accounts along with the space they take in the same way listUsers does
+func (server *grpcServer) ListUsers(ctx context.Context, _ *emptypb.Empty) (*pb.UserSummaryList, error) {
+	users, err := server.app.UserStorage.ListUsers(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("grpc list users", "err", err, "admin", loginFromContext(ctx))
+		return nil, grpcError(err)
+	}
+
+	var resp pb.UserSummaryList
+	for _, user := range users {
+		resp.Users = append(resp.Users, pb.FromUserSummary(user))
+	}
+	return &resp, nil
+}
+
+// DisableUser disables the account in the same way disableUser does
+func (server *grpcServer) DisableUser(ctx context.Context, req *pb.UserRequest) (*emptypb.Empty, error) {
+	return server.setDisabled(ctx, req.GetLogin(), true)
+}
+
+// EnableUser enables the account in the same way enableUser does
+func (server *grpcServer) EnableUser(ctx context.Context, req *pb.UserRequest) (*emptypb.Empty, error) {
+	return server.setDisabled(ctx, req.GetLogin(), false)
+}
+
+// setDisabled disables or enables the account in the same way setDisabled does
+func (server *grpcServer) setDisabled(ctx context.Context, login string, disabled bool) (*emptypb.Empty, error) {
+	if disabled && login == loginFromContext(ctx) {
+		return nil, grpcError(errOwnAccount)
+	}
+	err := server.app.UserStorage.SetDisabled(login, disabled, ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEmpty) {
+			logging.FromContext(ctx).Error("grpc set disabled", "err", err, "login", login, "admin", loginFromContext(ctx))
+		}
+		return nil, grpcError(err)
+	}
+	logging.FromContext(ctx).Info("grpc set disabled", "login", login, "disabled", disabled, "admin", loginFromContext(ctx))
+	return &emptypb.Empty{}, nil
+}
+
+// LogoutUser revokes all the sessions of the user in the same way logoutUser does
+func (server *grpcServer) LogoutUser(ctx context.Context, req *pb.UserRequest) (*emptypb.Empty, error) {
+	err := server.app.UserStorage.DeleteSessions(req.GetLogin(), ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEmpty) {
+			logging.FromContext(ctx).Error("grpc logout user", "err", err, "login", req.GetLogin(),
+				"admin", loginFromContext(ctx))
+		}
+		return nil, grpcError(err)
+	}
+	logging.FromContext(ctx).Info("grpc logout user", "login", req.GetLogin(), "admin", loginFromContext(ctx))
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteUser removes the account along with all its data in the same way deleteUser does
+func (server *grpcServer) DeleteUser(ctx context.Context, req *pb.UserRequest) (*emptypb.Empty, error) {
+	if req.GetLogin() == loginFromContext(ctx) {
+		return nil, grpcError(errOwnAccount)
+	}
+	err := server.app.UserStorage.DeleteUser(req.GetLogin(), ctx)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEmpty) && !errors.Is(err, storage.ErrLastOwner) {
+			logging.FromContext(ctx).Error("grpc delete user", "err", err, "login", req.GetLogin(),
+				"admin", loginFromContext(ctx))
+		}
+		return nil, grpcError(err)
+	}
+	logging.FromContext(ctx).Info("grpc delete user", "login", req.GetLogin(), "admin", loginFromContext(ctx))
+	return &emptypb.Empty{}, nil
+}
+
+// Events streams the changes of the user's secrets in the same way streamEvents does,
+// the dead connections are noticed by keepalive pings instead of heartbeats
+func (server *grpcServer) Events(_ *emptypb.Empty, stream pb.Keeper_EventsServer) error {
+	ctx := stream.Context()
+	events, unsubscribe := server.app.events.subscribe(loginFromContext(ctx), sessionFromContext(ctx))
+	defer unsubscribe()
+	// the headers let the client know it is subscribed, just like the headers of streamEvents do
+	err := stream.SendHeader(metadata.MD{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			err := stream.Send(pb.FromChangeEvent(event))
+			if err != nil {
+				logging.FromContext(ctx).Warn("grpc send event", "err", err, "login", loginFromContext(ctx))
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -9,37 +9,64 @@ import (
 	"fmt"
 	"github.com/go-chi/render"
 	"gophkeeper/internal/config"
+	"gophkeeper/internal/logging"
 	"gophkeeper/internal/service"
 	"gophkeeper/internal/storage"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path"
-	"time"
 )
 
 // isAuthorized is a middleware used to find out if the user authorized.
+// It validates the access token from `Authorization: Bearer` header, checks that its session has not been revoked
+// and puts the login and the session of the user to the context.
 //
 // Returns:
-//   - `401` upon unsuccessful cookie check.
+//   - `401` if the access token is missing, invalid or expired, or its session has been revoked
+//   - `500` if storage methods fail to comprehend the request
 func (app *App) isAuthorized(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		session, _ := app.cookieStorage.Get(r, "session.id")
-		authenticated := session.Values["authenticated"]
-		if authenticated != nil && authenticated != false {
-			handler.ServeHTTP(w, r)
-			return
+		accessToken, ok := bearerToken(r.Header.Get("Authorization"))
+		if ok {
+			ctx, err := app.authorize(r.Context(), accessToken, remoteIP(r.RemoteAddr))
+			if err == nil {
+				handler.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+			if !errors.Is(err, errInvalidAccessToken) {
+				logging.FromContext(r.Context()).Error("authorize", "err", err)
+				http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+				return
+			}
 		}
 
-		http.Redirect(w, r, "/login", http.StatusUnauthorized)
+		w.Header().Set("WWW-Authenticate", tokenType)
+		http.Error(w, errInvalidAccessToken.Error(), http.StatusUnauthorized)
 	}
 }
 
-// addContext is a middleware that adds context.Context to all the incoming requests.
+// getLogin returns the login of the authorized user that made the request
+func (app *App) getLogin(r *http.Request) string {
+	return loginFromContext(r.Context())
+}
+
+// addContext is a middleware that adds context.Context with requestTimeout to all the incoming requests,
+// the context holds the client that made the request for the audit log.
+// Streaming endpoints only get the context of the request itself, as large transfers can't fit in the timeout,
+// deletionEndpoints get deletionTimeout instead.
 func (app *App) addContext(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		r = r.WithContext(withClient(r.Context(), remoteIP(r.RemoteAddr), r.UserAgent()))
+		if streamingEndpoints[r.URL.Path] {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		timeout := requestTimeout
+		if deletionEndpoints[r.URL.Path] == r.Method {
+			timeout = deletionTimeout
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 		r = r.WithContext(ctx)
 		handler.ServeHTTP(w, r)
@@ -49,26 +76,30 @@ func (app *App) addContext(handler http.Handler) http.Handler {
 // register handles registration.
 //
 // Accepts json.Marshalled service.User struct with 'login' and 'password' fields obligatory.
+// Optional 'device' field names the session, User-Agent is used otherwise.
 // Also authorizes the user right away if the registration is successful.
 //
 // Returns:
 //   - `400` if json is corrupted
 //   - `409` if 'login' already exists in storage
+//   - `429` and Retry-After header if the address has made too many failed attempts
 //   - `500` if storage methods fail to comprehend the request
-//   - `200` and the access cookie in the header - if everything works out
+//   - `200` and json.Marshalled service.Tokens - if everything works out
 func (app *App) register(w http.ResponseWriter, r *http.Request) {
 	var user service.User
 	err := json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
-		log.Printf("register err: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("register err: json parse error", "err", err)
 		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
 		return
 	}
 
 	err = app.UserStorage.RegisterUser(user, r.Context())
 	if err != nil {
-		log.Printf("register err: %s for user: %s, password: %s", err, user.Login, user.Password)
+		logging.FromContext(r.Context()).Error("register err", "err", err, "login", user.Login)
 		if errors.Is(err, storage.ErrUserExists) {
+			// probing for the logins taken counts as a failed attempt
+			app.ipLimiter.fail(ipKey(remoteIP(r.RemoteAddr)))
 			http.Error(w, fmt.Sprintf("register error: %s", err), http.StatusConflict)
 			return
 		}
@@ -81,7 +112,7 @@ func (app *App) register(w http.ResponseWriter, r *http.Request) {
 	authDetails.Password = user.Password
 	err = app.UserStorage.CheckUserAuth(authDetails, r.Context())
 	if err != nil {
-		log.Printf("register then auth err: %s for user: %s, password: %s", err, authDetails.Login, authDetails.Password)
+		logging.FromContext(r.Context()).Error("register then auth err", "err", err, "login", authDetails.Login)
 		if errors.Is(err, storage.ErrInvalidCredentials) {
 			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusUnauthorized)
 			return
@@ -90,105 +121,202 @@ func (app *App) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	session.Values["authenticated"] = true
-	session.Values["login"] = user.Login
-	session.Save(r, w)
-	w.WriteHeader(http.StatusOK)
+	tokens, err := app.startSession(service.Session{Login: user.Login,
+		Device: deviceName(user.Device, r.UserAgent()), IP: remoteIP(r.RemoteAddr)}, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("register then issue tokens err", "err", err, "login", user.Login)
+		http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, tokens)
 }
 
 // login handles signing in.
 //
 // Accepts json.Marshalled service.Authentication struct with 'login' and 'password' fields obligatory.
+// Optional 'device' field names the session started, User-Agent is used otherwise.
+// The users with two-factor authentication enabled get a challenge token instead of the tokens,
+// it is sent to loginTwoFactor along with the code to finish signing in.
 //
 // Returns:
 //   - `400` if json is corrupted
-//   - `401` if user does not exist
+//   - `401` if user does not exist or the password is wrong
+//   - `403` if the account is disabled
+//   - `429` and Retry-After header if the address or the account has made too many failed attempts
 //   - `500` if storage methods fail to comprehend the request
-//   - `200` and the access cookie in the header - if everything works out
+//   - `202` and json.Marshalled service.TwoFactorChallenge - if two-factor authentication is required
+//   - `200` and json.Marshalled service.Tokens - if everything works out
 func (app *App) login(w http.ResponseWriter, r *http.Request) {
 	var authDetails service.Authentication
 	err := json.NewDecoder(r.Body).Decode(&authDetails)
 	if err != nil {
-		log.Printf("auth err: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("auth err: json parse error", "err", err)
 		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
 		return
 	}
 
-	err = app.UserStorage.CheckUserAuth(authDetails, r.Context())
+	err = app.checkCredentials(authDetails, remoteIP(r.RemoteAddr), r.Context())
 	if err != nil {
-		log.Printf("auth err: %s for user: %s, password: %s", err, authDetails.Login, authDetails.Password)
+		logging.FromContext(r.Context()).Error("auth err", "err", err, "login", authDetails.Login)
+		var lockout *lockoutError
+		if errors.As(err, &lockout) {
+			writeLockout(w, lockout)
+			return
+		}
 		if errors.Is(err, storage.ErrInvalidCredentials) {
 			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusUnauthorized)
 			return
 		}
+		if errors.Is(err, storage.ErrAccountDisabled) {
+			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusForbidden)
+			return
+		}
 		http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
 		return
 	}
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	session.Values["authenticated"] = true
-	session.Values["login"] = authDetails.Login
-	session.Save(r, w)
-	w.WriteHeader(http.StatusOK)
+	required, err := app.twoFactorEnabled(authDetails.Login, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("auth then check 2fa err", "err", err, "login", authDetails.Login)
+		http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	if required {
+		challenge, err := app.signChallenge(authDetails.Login, deviceName(authDetails.Device, r.UserAgent()))
+		if err != nil {
+			logging.FromContext(r.Context()).Error("auth then issue challenge err", "err", err, "login", authDetails.Login)
+			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
+			return
+		}
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, challenge)
+		return
+	}
+
+	tokens, err := app.startSession(service.Session{Login: authDetails.Login,
+		Device: deviceName(authDetails.Device, r.UserAgent()), IP: remoteIP(r.RemoteAddr)}, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("auth then issue tokens err", "err", err, "login", authDetails.Login)
+		http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, tokens)
+}
+
+// refreshToken handles exchanging a refresh token for a new pair of tokens.
+// The refresh token is rotated: it can't be used again, and using it again revokes all the tokens issued after it.
+//
+// Accepts json.Marshalled service.RefreshRequest struct with 'refresh_token' field obligatory.
+//
+// Returns:
+//   - `400` if json is corrupted
+//   - `401` if the refresh token is invalid, expired or has already been used
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and json.Marshalled service.Tokens - if everything works out
+func (app *App) refreshToken(w http.ResponseWriter, r *http.Request) {
+	var refreshRequest service.RefreshRequest
+	err := json.NewDecoder(r.Body).Decode(&refreshRequest)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("refresh token err: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := app.rotateTokens(refreshRequest.RefreshToken, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidToken) {
+			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusUnauthorized)
+			return
+		}
+		logging.FromContext(r.Context()).Error("refresh token err", "err", err)
+		http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, tokens)
 }
 
 // uploadLogoPass handles uploading logo-pass pairs via http.Post request.
 //
 // Accepts json.Marshalled service.LogoPass struct with 'description' field obligatory.
 // 'secret_login', 'secret' are optional but are recommended in the sake of common sense.
-// 'overwrite' field is obligatory if the request is meant to update existing information.
+// Optional 'collection_id' field stores the entry in the collection instead of the personal vault.
+// 'overwrite' field is obligatory if the request is meant to update existing information,
+// so is If-Match header with the revision of the stored entry the update is made to.
 //
 // Returns:
-//   - `400` if json is corrupted
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
 //   - `409` if this data already exist and 'overwrite' flag is false or omitted
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if the stored entry is overwritten without If-Match header
+//   - `507` if the user is out of quota
 //   - `500` if storage methods fail to comprehend the request
-//   - `201` if everything is OK
+//   - `201` and the revision the entry is stored at in ETag header if everything is OK
 func (app *App) uploadLogoPass(w http.ResponseWriter, r *http.Request) {
 	var logoPass service.LogoPass
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	logoPass.Login = session.Values["login"].(string)
+	logoPass.Login = app.getLogin(r)
 
 	err := json.NewDecoder(r.Body).Decode(&logoPass)
 	if err != nil {
-		log.Printf("upload logopass pair: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("upload logopass pair: json parse error", "err", err)
 		http.Error(w, "json parse error:", http.StatusBadRequest)
 		return
 	}
 
-	err = app.UserStorage.PutLogoPass(logoPass, r.Context())
+	logoPass.Revision, err = ifMatch(r)
 	if err != nil {
-		if errors.Is(err, storage.ErrOldData) || errors.Is(err, storage.ErrAlreadyExists) {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.PutLogoPass(logoPass, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
 			http.Error(w, fmt.Sprint(err), http.StatusConflict)
 			return
 		}
-		log.Printf("put logopass pair: save to db: %s for user: %s", err, logoPass.Login)
+		if revisionMismatch(w, r, err) || quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("put logopass pair: save to db", "err", err, "login", logoPass.Login)
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
 		return
-
 	}
+	setETag(w, revision)
 	w.WriteHeader(http.StatusCreated)
 }
 
 // batchDownloadLogoPasses handles sending the list of all user's logo-pass pairs via http.Get request.
 //
-// All it needs to run is a user login from http.Cookie.
+// Accepts optional 'collection_id' query parameter with the ID of the collection to list,
+// the personal vault of the user is listed if it is omitted.
 //
 // Returns:
+//   - `400` if the ID of the collection is invalid
+//   - `403` if the user can't see the collection
 //   - `404` if user has no such data in storage
 //   - `500` if storage methods fail to comprehend the request
 //   - json.Marshalled struct of []service.LogoPass type that contains all fields got from storage
-//     except 'overwrite' field by virtue of its needlessness
+//     except 'overwrite' field by virtue of its needlessness.
+//     Deleted entries are sent as tombstones with 'DeletedAt' set and secret fields wiped
 func (app *App) batchDownloadLogoPasses(w http.ResponseWriter, r *http.Request) {
 	var logoPass service.LogoPass
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	logoPass.Login = session.Values["login"].(string)
+	logoPass.Login = app.getLogin(r)
+
+	collection, err := collectionID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
 
-	listLogoPasses, err := app.UserStorage.BatchGetLogoPasses(logoPass.Login, r.Context())
+	listLogoPasses, err := app.UserStorage.BatchGetLogoPasses(logoPass.Login, collection, r.Context())
 	if err != nil {
-		log.Printf("get logpass pairs: %s for user: %s", err, logoPass.Login)
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get logpass pairs", "err", err, "login", logoPass.Login)
 		if errors.Is(err, storage.ErrEmpty) {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -204,57 +332,84 @@ func (app *App) batchDownloadLogoPasses(w http.ResponseWriter, r *http.Request)
 //
 // Accepts json.Marshalled service.TextData struct with 'description' field obligatory.
 // 'data' is optional but is recommended in the sake of common sense.
-// 'overwrite' field is obligatory if the request is meant to update existing information.
+// Optional 'collection_id' field stores the entry in the collection instead of the personal vault.
+// 'overwrite' field is obligatory if the request is meant to update existing information,
+// so is If-Match header with the revision of the stored entry the update is made to.
 //
 // Returns:
-//   - `400` if json is corrupted
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
 //   - `409` if this data already exist and 'overwrite' flag is false or omitted
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if the stored entry is overwritten without If-Match header
+//   - `507` if the user is out of quota
 //   - `500` if storage methods fail to comprehend the request
-//   - `201` if everything is OK
+//   - `201` and the revision the entry is stored at in ETag header if everything is OK
 func (app *App) uploadText(w http.ResponseWriter, r *http.Request) {
 	var text service.TextData
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	text.Login = session.Values["login"].(string)
+	text.Login = app.getLogin(r)
 
 	err := json.NewDecoder(r.Body).Decode(&text)
 	if err != nil {
-		log.Printf("upload secret text: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("upload secret text: json parse error", "err", err)
 		http.Error(w, "json parse error:", http.StatusBadRequest)
 		return
 	}
 
-	err = app.UserStorage.PutText(text, r.Context())
+	text.Revision, err = ifMatch(r)
 	if err != nil {
-		if errors.Is(err, storage.ErrOldData) || errors.Is(err, storage.ErrAlreadyExists) {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.PutText(text, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
 			http.Error(w, fmt.Sprint(err), http.StatusConflict)
 			return
 		}
-		log.Printf("put secret text: save to db: %s for user: %s", err, text.Login)
+		if revisionMismatch(w, r, err) || quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("put secret text: save to db", "err", err, "login", text.Login)
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
 		return
 	}
+	setETag(w, revision)
 	w.WriteHeader(http.StatusCreated)
 }
 
 // batchDownloadTexts handles sending the list of all user's secret strings via http.Get request.
 //
-// All it needs to run is a user login from http.Cookie.
+// Accepts optional 'collection_id' query parameter with the ID of the collection to list,
+// the personal vault of the user is listed if it is omitted.
 //
 // Returns:
+//   - `400` if the ID of the collection is invalid
+//   - `403` if the user can't see the collection
 //   - `404` if user has no such data in storage
 //   - `500` if storage methods fail to comprehend the request
 //   - json.Marshalled struct of []service.TextData type that contains all fields got from storage
-//     except 'overwrite' field by virtue of its needlessness
+//     except 'overwrite' field by virtue of its needlessness.
+//     Deleted entries are sent as tombstones with 'DeletedAt' set and secret fields wiped
 func (app *App) batchDownloadTexts(w http.ResponseWriter, r *http.Request) {
 	var text service.TextData
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	text.Login = session.Values["login"].(string)
+	text.Login = app.getLogin(r)
+
+	collection, err := collectionID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
 
-	listTexts, err := app.UserStorage.BatchGetTexts(text.Login, r.Context())
+	listTexts, err := app.UserStorage.BatchGetTexts(text.Login, collection, r.Context())
 	if err != nil {
-		log.Printf("get secrets: %s for user: %s", err, text.Login)
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get secrets", "err", err, "login", text.Login)
 		if errors.Is(err, storage.ErrEmpty) {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -270,57 +425,84 @@ func (app *App) batchDownloadTexts(w http.ResponseWriter, r *http.Request) {
 //
 // Accepts json.Marshalled service.CreditCard struct with 'number' field obligatory.
 // 'holder', 'due_date', 'cvv', 'description' are optional but are recommended in the sake of common sense.
-// 'overwrite' field is obligatory if the request is meant to update existing information.
+// Optional 'collection_id' field stores the entry in the collection instead of the personal vault.
+// 'overwrite' field is obligatory if the request is meant to update existing information,
+// so is If-Match header with the revision of the stored entry the update is made to.
 //
 // Returns:
-//   - `400` if json is corrupted
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
 //   - `409` if this data already exist and 'overwrite' flag is false or omitted
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if the stored entry is overwritten without If-Match header
+//   - `507` if the user is out of quota
 //   - `500` if storage methods fail to comprehend the request
-//   - `201` if everything is OK
+//   - `201` and the revision the entry is stored at in ETag header if everything is OK
 func (app *App) uploadCreditCard(w http.ResponseWriter, r *http.Request) {
 	var card service.CreditCard
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	card.Login = session.Values["login"].(string)
+	card.Login = app.getLogin(r)
 
 	err := json.NewDecoder(r.Body).Decode(&card)
 	if err != nil {
-		log.Printf("upload credit card: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("upload credit card: json parse error", "err", err)
 		http.Error(w, "json parse error:", http.StatusBadRequest)
 		return
 	}
 
-	err = app.UserStorage.PutCreditCard(card, r.Context())
+	card.Revision, err = ifMatch(r)
 	if err != nil {
-		if errors.Is(err, storage.ErrOldData) || errors.Is(err, storage.ErrAlreadyExists) {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.PutCreditCard(card, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
 			http.Error(w, fmt.Sprint(err), http.StatusConflict)
 			return
 		}
-		log.Printf("put credit card: save to db: %s for user: %s", err, card.Login)
+		if revisionMismatch(w, r, err) || quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("put credit card: save to db", "err", err, "login", card.Login)
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
 		return
 	}
+	setETag(w, revision)
 	w.WriteHeader(http.StatusCreated)
 }
 
 // batchDownloadCreditCards handles sending the list of all user's credit cards via http.Get request.
 //
-// All it needs to run is a user login from http.Cookie.
+// Accepts optional 'collection_id' query parameter with the ID of the collection to list,
+// the personal vault of the user is listed if it is omitted.
 //
 // Returns:
+//   - `400` if the ID of the collection is invalid
+//   - `403` if the user can't see the collection
 //   - `404` if user has no such data in storage
 //   - `500` if storage methods fail to comprehend the request
 //   - json.Marshalled struct of []service.CreditCard type that contains all fields got from storage
-//     except 'overwrite' field by virtue of its needlessness
+//     except 'overwrite' field by virtue of its needlessness.
+//     Deleted entries are sent as tombstones with 'DeletedAt' set and secret fields wiped
 func (app *App) batchDownloadCreditCards(w http.ResponseWriter, r *http.Request) {
 	var card service.CreditCard
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	card.Login = session.Values["login"].(string)
+	card.Login = app.getLogin(r)
+
+	collection, err := collectionID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
 
-	listCards, err := app.UserStorage.BatchGetCreditCards(card.Login, r.Context())
+	listCards, err := app.UserStorage.BatchGetCreditCards(card.Login, collection, r.Context())
 	if err != nil {
-		log.Printf("get logpass pairs: %s for user: %s", err, card.Login)
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get logpass pairs", "err", err, "login", card.Login)
 		if errors.Is(err, storage.ErrEmpty) {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -336,57 +518,85 @@ func (app *App) batchDownloadCreditCards(w http.ResponseWriter, r *http.Request)
 //
 // Accepts json.Marshalled service.BinaryData struct with 'description' field obligatory.
 // 'binary' is optional but is recommended in the sake of common sense.
-// 'overwrite' field is obligatory if the request is meant to update existing information.
+// Optional 'collection_id' field stores the entry in the collection instead of the personal vault.
+// 'overwrite' field is obligatory if the request is meant to update existing information,
+// so is If-Match header with the revision of the stored entry the update is made to.
 //
 // Returns:
-//   - `400` if json is corrupted
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
 //   - `409` if this data already exist and 'overwrite' flag is false or omitted
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if the stored entry is overwritten without If-Match header
+//   - `413` if the binary is larger than the quota allows
+//   - `507` if the user is out of quota
 //   - `500` if storage methods fail to comprehend the request
-//   - `201` if everything is OK
+//   - `201` and the revision the entry is stored at in ETag header if everything is OK
 func (app *App) uploadBinary(w http.ResponseWriter, r *http.Request) {
 	var binary service.BinaryData
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	binary.Login = session.Values["login"].(string)
+	binary.Login = app.getLogin(r)
 
 	err := json.NewDecoder(r.Body).Decode(&binary)
 	if err != nil {
-		log.Printf("upload credit card: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("upload credit card: json parse error", "err", err)
 		http.Error(w, "json parse error:", http.StatusBadRequest)
 		return
 	}
 
-	err = app.UserStorage.PutBinary(binary, r.Context())
+	binary.Revision, err = ifMatch(r)
 	if err != nil {
-		log.Printf("put credit card: save to db: %s for user: %s", err, binary.Login)
-		if errors.Is(err, storage.ErrOldData) || errors.Is(err, storage.ErrAlreadyExists) {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.PutBinary(binary, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("put credit card: save to db", "err", err, "login", binary.Login)
+		if errors.Is(err, storage.ErrAlreadyExists) {
 			http.Error(w, fmt.Sprint(err), http.StatusConflict)
 			return
 		}
+		if revisionMismatch(w, r, err) || quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
 		return
 	}
+	setETag(w, revision)
 	w.WriteHeader(http.StatusCreated)
 }
 
 // downloadBinaryList handles sending the list of all user's binaries via http.Get request.
 //
-// All it needs to run is a user login from http.Cookie.
+// Accepts optional 'collection_id' query parameter with the ID of the collection to list,
+// the personal vault of the user is listed if it is omitted.
 //
 // Returns:
+//   - `400` if the ID of the collection is invalid
+//   - `403` if the user can't see the collection
 //   - `404` if user has no such data in storage
 //   - `500` if storage methods fail to comprehend the request
 //   - json.Marshalled struct of []service.BinaryData type that contains only the 'description' field
-//     while all stored binary data itself can be too large to batch download all of them
+//     while all stored binary data itself can be too large to batch download all of them.
+//     Deleted entries are sent as tombstones with 'DeletedAt' set
 func (app *App) downloadBinaryList(w http.ResponseWriter, r *http.Request) {
 	var binary service.BinaryData
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	binary.Login = session.Values["login"].(string)
+	binary.Login = app.getLogin(r)
 
-	binaryList, err := app.UserStorage.GetBinaryList(binary.Login, r.Context())
+	collection, err := collectionID(r)
 	if err != nil {
-		log.Printf("get binary list: %s for user: %s", err, binary.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	binaryList, err := app.UserStorage.GetBinaryList(binary.Login, collection, r.Context())
+	if err != nil {
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get binary list", "err", err, "login", binary.Login)
 		if errors.Is(err, storage.ErrEmpty) {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -399,11 +609,13 @@ func (app *App) downloadBinaryList(w http.ResponseWriter, r *http.Request) {
 }
 
 // downloadBinary handles sending a particular user binary data via http.Post request.
-// Accepts json.Marshalled service.BinaryData struct with 'description' field obligatory.
+// Accepts json.Marshalled service.BinaryData struct with 'description' field obligatory,
+// 'collection_id' field is set for the binaries of a collection.
 // All other fields are optional but are recommended to be omitted in the sake of common sense.
 //
 // Returns:
 //   - `400` if json is corrupted
+//   - `403` if the user can't see the collection
 //   - `404` if user has no such data in storage
 //   - `500` if storage methods fail to comprehend the request
 //   - json.Marshalled struct of service.BinaryData type that contains all fields got from storage
@@ -411,19 +623,21 @@ func (app *App) downloadBinaryList(w http.ResponseWriter, r *http.Request) {
 func (app *App) downloadBinary(w http.ResponseWriter, r *http.Request) {
 	var binary service.BinaryData
 
-	session, _ := app.cookieStorage.Get(r, "session.id")
-	binary.Login = session.Values["login"].(string)
+	binary.Login = app.getLogin(r)
 
 	err := json.NewDecoder(r.Body).Decode(&binary)
 	if err != nil {
-		log.Printf("get binary: json parse error: %s", err)
+		logging.FromContext(r.Context()).Warn("get binary: json parse error", "err", err)
 		http.Error(w, "json parse error:", http.StatusBadRequest)
 		return
 	}
 
 	binary, err = app.UserStorage.GetBinary(binary, r.Context())
 	if err != nil {
-		log.Printf("get binary: %s for user: %s", err, binary.Login)
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get binary", "err", err, "login", binary.Login)
 		if errors.Is(err, storage.ErrEmpty) {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -435,6 +649,210 @@ func (app *App) downloadBinary(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, binary)
 }
 
+// deleteLogoPass handles removing logo-pass pairs via http.Delete request.
+//
+// Accepts json.Marshalled service.LogoPass struct with 'description' field obligatory,
+// 'collection_id' field is set for the entries of a collection.
+// If-Match header with the revision of the stored entry is obligatory as well, so that the stale data
+// would not remove the newer one.
+// The entry is wiped, but its tombstone stays in storage for other clients to learn about the removal.
+//
+// Returns:
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
+//   - `404` if user has no such data in storage
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if there is no If-Match header
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and the revision of the tombstone in ETag header if everything is OK
+func (app *App) deleteLogoPass(w http.ResponseWriter, r *http.Request) {
+	var logoPass service.LogoPass
+
+	logoPass.Login = app.getLogin(r)
+
+	err := json.NewDecoder(r.Body).Decode(&logoPass)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("delete logopass pair: json parse error", "err", err)
+		http.Error(w, "json parse error:", http.StatusBadRequest)
+		return
+	}
+
+	logoPass.Revision, err = ifMatch(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.DeleteLogoPass(logoPass, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if revisionMismatch(w, r, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete logopass pair", "err", err, "login", logoPass.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteText handles removing text data via http.Delete request.
+//
+// Accepts json.Marshalled service.TextData struct with 'description' field obligatory,
+// 'collection_id' field is set for the entries of a collection.
+// If-Match header with the revision of the stored entry is obligatory as well, so that the stale data
+// would not remove the newer one.
+// The entry is wiped, but its tombstone stays in storage for other clients to learn about the removal.
+//
+// Returns:
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
+//   - `404` if user has no such data in storage
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if there is no If-Match header
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and the revision of the tombstone in ETag header if everything is OK
+func (app *App) deleteText(w http.ResponseWriter, r *http.Request) {
+	var text service.TextData
+
+	text.Login = app.getLogin(r)
+
+	err := json.NewDecoder(r.Body).Decode(&text)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("delete secret text: json parse error", "err", err)
+		http.Error(w, "json parse error:", http.StatusBadRequest)
+		return
+	}
+
+	text.Revision, err = ifMatch(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.DeleteText(text, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if revisionMismatch(w, r, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete secret text", "err", err, "login", text.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteCreditCard handles removing credit card data via http.Delete request.
+//
+// Accepts json.Marshalled service.CreditCard struct with 'number' field obligatory,
+// 'collection_id' field is set for the entries of a collection.
+// If-Match header with the revision of the stored entry is obligatory as well, so that the stale data
+// would not remove the newer one.
+// The entry is wiped, but its tombstone stays in storage for other clients to learn about the removal.
+//
+// Returns:
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
+//   - `404` if user has no such data in storage
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if there is no If-Match header
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and the revision of the tombstone in ETag header if everything is OK
+func (app *App) deleteCreditCard(w http.ResponseWriter, r *http.Request) {
+	var card service.CreditCard
+
+	card.Login = app.getLogin(r)
+
+	err := json.NewDecoder(r.Body).Decode(&card)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("delete credit card: json parse error", "err", err)
+		http.Error(w, "json parse error:", http.StatusBadRequest)
+		return
+	}
+
+	card.Revision, err = ifMatch(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.DeleteCreditCard(card, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if revisionMismatch(w, r, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete credit card", "err", err, "login", card.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteBinary handles removing binary data via http.Delete request.
+//
+// Accepts json.Marshalled service.BinaryData struct with 'description' field obligatory,
+// 'collection_id' field is set for the entries of a collection.
+// If-Match header with the revision of the stored entry is obligatory as well, so that the stale data
+// would not remove the newer one.
+// The entry is wiped, but its tombstone stays in storage for other clients to learn about the removal.
+//
+// Returns:
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection
+//   - `404` if user has no such data in storage
+//   - `412` and the entry as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if there is no If-Match header
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and the revision of the tombstone in ETag header if everything is OK
+func (app *App) deleteBinary(w http.ResponseWriter, r *http.Request) {
+	var binary service.BinaryData
+
+	binary.Login = app.getLogin(r)
+
+	err := json.NewDecoder(r.Body).Decode(&binary)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("delete binary: json parse error", "err", err)
+		http.Error(w, "json parse error:", http.StatusBadRequest)
+		return
+	}
+
+	binary.Revision, err = ifMatch(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.UserStorage.DeleteBinary(binary, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if revisionMismatch(w, r, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete binary", "err", err, "login", binary.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusOK)
+}
+
 // handleDownload lets user download an .zip with executable client file for specified platform
 func (app *App) handleDownload(w http.ResponseWriter, r *http.Request) {
 	platform := path.Base(r.URL.Path)
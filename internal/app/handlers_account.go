@@ -0,0 +1,238 @@
+package app
+
+// Here are the handler functions for taking all the data of a user away and for deleting the account
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"io"
+	"net/http"
+)
+
+// The files of the archive made by exportVault
+const (
+	exportLogoPasses  = "logo_passes.json"
+	exportTexts       = "texts.json"
+	exportCreditCards = "credit_cards.json"
+	exportBinaries    = "binaries.json"
+	// exportChunks is the format of the name of the file holding the content of a chunked binary with the ID
+	exportChunks = "binaries/%d"
+)
+
+// vault holds every secret of a user to be exported. The content of the chunked binaries is not here,
+// it is read from the storage chunk by chunk while the archive is written.
+type vault struct {
+	logoPasses  []service.LogoPass
+	texts       []service.TextData
+	creditCards []service.CreditCard
+	binaries    []service.BinaryData
+}
+
+// loadVault gets all the secrets of the personal vault of the user but the deleted ones,
+// the binaries stored as a whole are taken along with their content. The collections belong to the organizations,
+// they are not exported.
+func (app *App) loadVault(login string, ctx context.Context) (vault, error) {
+	secrets := vault{logoPasses: []service.LogoPass{}, texts: []service.TextData{},
+		creditCards: []service.CreditCard{}, binaries: []service.BinaryData{}}
+
+	logoPasses, err := app.UserStorage.BatchGetLogoPasses(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		return secrets, err
+	}
+	for _, logoPass := range logoPasses {
+		if !logoPass.DeletedAt.Valid {
+			secrets.logoPasses = append(secrets.logoPasses, logoPass)
+		}
+	}
+	texts, err := app.UserStorage.BatchGetTexts(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		return secrets, err
+	}
+	for _, text := range texts {
+		if !text.DeletedAt.Valid {
+			secrets.texts = append(secrets.texts, text)
+		}
+	}
+	cards, err := app.UserStorage.BatchGetCreditCards(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		return secrets, err
+	}
+	for _, card := range cards {
+		if !card.DeletedAt.Valid {
+			secrets.creditCards = append(secrets.creditCards, card)
+		}
+	}
+
+	binaries, err := app.UserStorage.GetBinaryList(login, 0, ctx)
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		return secrets, err
+	}
+	for _, binary := range binaries {
+		if binary.DeletedAt.Valid {
+			continue
+		}
+		if binary.ChunkCount == 0 {
+			binary.Login = login
+			binary, err = app.UserStorage.GetBinary(binary, ctx)
+			if errors.Is(err, storage.ErrEmpty) {
+				// deleted meanwhile
+				continue
+			}
+			if err != nil {
+				return secrets, err
+			}
+		}
+		secrets.binaries = append(secrets.binaries, binary)
+	}
+	return secrets, nil
+}
+
+// writeVault writes the secrets of the user to w as a zip archive. The content of every chunked binary is stored
+// in a file of its own, its chunks following one another as they are, the secrets stay encrypted.
+func (app *App) writeVault(login string, secrets vault, w io.Writer, ctx context.Context) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{exportLogoPasses, secrets.logoPasses},
+		{exportTexts, secrets.texts},
+		{exportCreditCards, secrets.creditCards},
+		{exportBinaries, secrets.binaries},
+	}
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(file.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, binary := range secrets.binaries {
+		if binary.ChunkCount == 0 {
+			continue
+		}
+		binary.Login = login
+		_, chunks, err := app.UserStorage.GetBinaryChunkList(binary, ctx)
+		if err != nil {
+			return fmt.Errorf("binary %s: %w", binary.Description, err)
+		}
+		// encrypted data can't be compressed anyway
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf(exportChunks, binary.ID),
+			Method: zip.Store, Modified: binary.UpdatedAt})
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			chunk, err = app.UserStorage.GetBinaryChunk(chunk, ctx)
+			if err != nil {
+				return fmt.Errorf("binary %s: %w", binary.Description, err)
+			}
+			_, err = writer.Write(chunk.Data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return archive.Close()
+}
+
+// exportVault handles sending every secret of the user as a single zip archive via http.Get request.
+// The archive holds logo_passes.json, texts.json, credit_cards.json and binaries.json with the secrets
+// in the same form they are downloaded in, deleted ones left out. The binaries stored as a whole have their content
+// in 'binary', the content of a chunked one is in binaries/<ID> file, 'chunk_size' telling where every chunk ends.
+// The secrets stay encrypted, so the archive is of use only along with the password.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` with the archive as a body, it is cut short if the storage fails once the sending has begun
+func (app *App) exportVault(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	secrets, err := app.loadVault(login, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("export", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="gophkeeper-export.zip"`)
+	w.WriteHeader(http.StatusOK)
+	err = app.writeVault(login, secrets, w, r.Context())
+	if err != nil {
+		// headers are already sent, the client will notice the archive is broken
+		logging.FromContext(r.Context()).Error("export: write archive", "err", err, "login", login)
+		return
+	}
+	logging.FromContext(r.Context()).Info("export", "login", login)
+}
+
+// removeAccount checks the password just like the login does, failed attempts included,
+// and deletes the user along with all the data
+func (app *App) removeAccount(authDetails service.Authentication, ip string, ctx context.Context) error {
+	err := app.checkCredentials(authDetails, ip, ctx)
+	if err != nil {
+		return err
+	}
+	return app.UserStorage.DeleteUser(authDetails.Login, ctx)
+}
+
+// deleteAccount handles deleting the account of the user along with all the data via http.Post request.
+// It can't be undone, so the password is asked for once again.
+//
+// Accepts json.Marshalled service.Authentication struct with 'password' field obligatory,
+// the login is taken from the access token.
+//
+// Returns:
+//   - `400` if json is corrupted
+//   - `403` if the password is wrong, 401 is not used for it as it stands for the access token refused
+//   - `409` if the user is the last owner of an organization that has other members
+//   - `429` and Retry-After header if the address or the account has made too many failed attempts
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	var authDetails service.Authentication
+	err := json.NewDecoder(r.Body).Decode(&authDetails)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("delete account: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	authDetails.Login = app.getLogin(r)
+
+	err = app.removeAccount(authDetails, remoteIP(r.RemoteAddr), r.Context())
+	if err != nil {
+		var lockout *lockoutError
+		if errors.As(err, &lockout) {
+			writeLockout(w, lockout)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			http.Error(w, fmt.Sprintf("delete account: %s", err), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, storage.ErrLastOwner) {
+			http.Error(w, fmt.Sprintf("delete account: %s", err), http.StatusConflict)
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete account", "err", err, "login", authDetails.Login)
+		http.Error(w, fmt.Sprintf("delete account: %s", err), http.StatusInternalServerError)
+		return
+	}
+	logging.FromContext(r.Context()).Info("account deleted", "login", authDetails.Login)
+	w.WriteHeader(http.StatusOK)
+}
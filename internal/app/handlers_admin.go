@@ -0,0 +1,216 @@
+package app
+
+// Here are the handler functions for the admins managing the accounts of the users.
+// The admins see the accounts and how much space they take, but never the secrets.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+)
+
+var (
+	// errNotAdmin is returned if the user is not an admin
+	errNotAdmin = errors.New("admin role required")
+	// errOwnAccount is returned if an admin tries to disable or delete their own account
+	errOwnAccount = errors.New("admins can't disable or delete their own account")
+)
+
+// isAdmin is a middleware that lets only the admins through. The access token is checked just like
+// isAuthorized does, the role is checked in the storage, so that it is revoked at once.
+//
+// Returns:
+//   - `401` if the access token is missing, invalid or expired, or its session has been revoked
+//   - `403` if the user is not an admin
+//   - `500` if storage methods fail to comprehend the request
+func (app *App) isAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return app.isAuthorized(func(w http.ResponseWriter, r *http.Request) {
+		login := app.getLogin(r)
+		err := app.checkAdmin(login, r.Context())
+		if err != nil {
+			if errors.Is(err, errNotAdmin) {
+				logging.FromContext(r.Context()).Warn("admin access denied", "login", login)
+				http.Error(w, fmt.Sprint(err), http.StatusForbidden)
+				return
+			}
+			logging.FromContext(r.Context()).Error("check admin", "err", err, "login", login)
+			http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// checkAdmin returns errNotAdmin unless the user is an admin
+func (app *App) checkAdmin(login string, ctx context.Context) error {
+	user, err := app.UserStorage.GetUser(login, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			return errNotAdmin
+		}
+		return err
+	}
+	if user.Role != service.RoleAdmin {
+		return errNotAdmin
+	}
+	return nil
+}
+
+// promoteAdmins makes the admins of the users listed in config.AdminLogins and only of them.
+// The listed users who have already registered are given the admin role, the rest are promoted on a later start
+// once they have registered. The role is given to whoever has registered the login, so only the logins
+// of the accounts known to belong to the admins are to be listed. The admins who are no longer listed
+// are demoted to users.
+func (app *App) promoteAdmins(ctx context.Context) {
+	logger := logging.FromContext(ctx)
+	for _, login := range app.config.AdminLogins {
+		err := app.UserStorage.SetRole(login, service.RoleAdmin, ctx)
+		if err != nil {
+			if errors.Is(err, storage.ErrEmpty) {
+				logger.Warn("admin is not registered yet", "login", login)
+				continue
+			}
+			logger.Error("promote admin", "err", err, "login", login)
+		}
+	}
+	demoted, err := app.UserStorage.DemoteAdmins(app.config.AdminLogins, ctx)
+	if err != nil {
+		logger.Error("demote admins", "err", err)
+		return
+	}
+	for _, login := range demoted {
+		logger.Info("admin is no longer listed, demoted to user", "login", login)
+	}
+}
+
+// listUsers handles sending the list of all the accounts via http.Get request.
+//
+// All it needs to run is the access token of an admin.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of []service.UserSummary type ordered by login, every account along with
+//     the number of its secrets and the space they take
+func (app *App) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := app.UserStorage.ListUsers(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("list users", "err", err, "admin", app.getLogin(r))
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, users)
+}
+
+// disableUser handles disabling an account via http.Post request. The user is signed out everywhere at once
+// and can't sign in until the account is enabled again.
+//
+// Accepts 'login' query parameter.
+//
+// Returns:
+//   - `404` if there is no such user
+//   - `409` if the admin tries to disable their own account
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) disableUser(w http.ResponseWriter, r *http.Request) {
+	app.setDisabled(w, r, true)
+}
+
+// enableUser handles enabling a disabled account via http.Post request.
+//
+// Accepts 'login' query parameter.
+//
+// Returns:
+//   - `404` if there is no such user
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) enableUser(w http.ResponseWriter, r *http.Request) {
+	app.setDisabled(w, r, false)
+}
+
+// setDisabled disables or enables the account named by 'login' query parameter
+func (app *App) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	login := r.URL.Query().Get("login")
+	if disabled && login == app.getLogin(r) {
+		http.Error(w, errOwnAccount.Error(), http.StatusConflict)
+		return
+	}
+
+	err := app.UserStorage.SetDisabled(login, disabled, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("set disabled", "err", err, "login", login, "admin", app.getLogin(r))
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	logging.FromContext(r.Context()).Info("set disabled", "login", login, "disabled", disabled, "admin", app.getLogin(r))
+	w.WriteHeader(http.StatusOK)
+}
+
+// logoutUser handles signing a user out of all the sessions via http.Post request.
+// The account stays enabled, the user can sign in again.
+//
+// Accepts 'login' query parameter.
+//
+// Returns:
+//   - `404` if there is no such user
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) logoutUser(w http.ResponseWriter, r *http.Request) {
+	login := r.URL.Query().Get("login")
+
+	err := app.UserStorage.DeleteSessions(login, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("logout user", "err", err, "login", login, "admin", app.getLogin(r))
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	logging.FromContext(r.Context()).Info("logout user", "login", login, "admin", app.getLogin(r))
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteUser handles deleting an account along with all its data via http.Delete request. It can't be undone.
+//
+// Accepts 'login' query parameter.
+//
+// Returns:
+//   - `404` if there is no such user
+//   - `409` if the admin tries to delete their own account, or the user is the last owner of an organization
+//     that has other members
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteUser(w http.ResponseWriter, r *http.Request) {
+	login := r.URL.Query().Get("login")
+	if login == app.getLogin(r) {
+		http.Error(w, errOwnAccount.Error(), http.StatusConflict)
+		return
+	}
+
+	err := app.UserStorage.DeleteUser(login, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrLastOwner) {
+			http.Error(w, fmt.Sprint(err), http.StatusConflict)
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete user", "err", err, "login", login, "admin", app.getLogin(r))
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	logging.FromContext(r.Context()).Info("delete user", "login", login, "admin", app.getLogin(r))
+	w.WriteHeader(http.StatusOK)
+}
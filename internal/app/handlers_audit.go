@@ -0,0 +1,97 @@
+package app
+
+// Here is the handler function for the audit log of the user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+// Size of the pages of the audit log: the default one and the largest one a client can ask for
+const (
+	auditPageSize    = 50
+	maxAuditPageSize = 500
+)
+
+// errInvalidAuditPage is returned for the cursors not issued by the App and for negative page sizes
+var errInvalidAuditPage = errors.New("invalid audit log cursor or limit")
+
+// auditPage returns up to limit events of the user's audit log following the cursor, the most recent ones first.
+// Empty cursor stands for the first page, zero limit for auditPageSize.
+func (app *App) auditPage(login string, cursor string, limit int, ctx context.Context) (service.AuditPage, error) {
+	page := service.AuditPage{Events: []service.AuditEvent{}}
+	var before uint64
+	if cursor != "" {
+		var err error
+		before, err = strconv.ParseUint(cursor, 10, 64)
+		if err != nil || before == 0 {
+			return page, errInvalidAuditPage
+		}
+	}
+	switch {
+	case limit < 0:
+		return page, errInvalidAuditPage
+	case limit == 0:
+		limit = auditPageSize
+	case limit > maxAuditPageSize:
+		limit = maxAuditPageSize
+	}
+
+	// one more event tells whether there is the next page
+	events, err := app.AuditStorage.GetAuditEvents(login, uint(before), limit+1, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			return page, nil
+		}
+		return page, err
+	}
+	if len(events) > limit {
+		events = events[:limit]
+		page.Next = strconv.FormatUint(uint64(events[limit-1].ID), 10)
+	}
+	page.Events = events
+	return page, nil
+}
+
+// auditLog handles sending the audit log of the user page by page via http.Get request.
+// The logins, the failed ones and every access to the secrets are recorded, the secrets themselves never are.
+//
+// Accepts optional 'before' query parameter holding the cursor of the page got from the previous one
+// and optional 'limit' one, auditPageSize events are sent by default and maxAuditPageSize at most.
+//
+// Returns:
+//   - `400` if the cursor or the limit is invalid
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled service.AuditPage with the most recent events first and 'next' cursor if there are more
+func (app *App) auditLog(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	var limit int
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, errInvalidAuditPage.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := app.auditPage(login, r.URL.Query().Get("before"), limit, r.Context())
+	if err != nil {
+		if errors.Is(err, errInvalidAuditPage) {
+			http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+			return
+		}
+		logging.FromContext(r.Context()).Error("get audit log", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, page)
+}
@@ -0,0 +1,313 @@
+package app
+
+// Here are the handler functions for chunked binary upload and download
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// startBinaryUpload handles starting a chunked upload of a binary via http.Post request.
+//
+// Accepts json.Marshalled service.UploadSession struct with 'description' and 'chunk_count' fields obligatory.
+// Optional 'collection_id' field uploads the binary to the collection instead of the personal vault.
+// 'overwrite' field is obligatory if the upload is meant to update existing information,
+// so is If-Match header with the revision of the stored binary the update is made to, just like in uploadBinary.
+//
+// Returns:
+//   - `400` if json, If-Match header or 'chunk_count' is invalid
+//   - `403` if the user can't change the secrets of the collection
+//   - `409` if this data already exist and 'overwrite' flag is false or omitted
+//   - `412` if the binary has been changed since the revision in If-Match header
+//   - `428` if the stored binary is overwritten without If-Match header
+//   - `507` if the user has too many binaries already
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` and json.Marshalled service.UploadSession with 'upload_id' to be used for uploading chunks
+func (app *App) startBinaryUpload(w http.ResponseWriter, r *http.Request) {
+	var session service.UploadSession
+
+	err := json.NewDecoder(r.Body).Decode(&session)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("start binary upload: json parse error", "err", err)
+		http.Error(w, "json parse error:", http.StatusBadRequest)
+		return
+	}
+	session.Login = app.getLogin(r)
+	session.Revision, err = ifMatch(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	session, err = app.UserStorage.CreateUploadSession(session, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			http.Error(w, fmt.Sprint(err), http.StatusConflict)
+			return
+		}
+		if revisionMismatch(w, r, err) {
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidChunk) {
+			http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+			return
+		}
+		if quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("start binary upload", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, session)
+}
+
+// getBinaryUpload handles sending the state of a chunked upload via http.Get request.
+// Meant to be used for resuming the upload after a disconnect.
+//
+// Accepts 'upload_id' query parameter.
+//
+// Returns:
+//   - `404` if there is no such upload for the user
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled service.UploadSession with 'received' field listing the parts already stored
+func (app *App) getBinaryUpload(w http.ResponseWriter, r *http.Request) {
+	var session service.UploadSession
+	session.Login = app.getLogin(r)
+	session.ID = r.URL.Query().Get("upload_id")
+
+	session, err := app.UserStorage.GetUploadSession(session, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("get binary upload", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, session)
+}
+
+// uploadBinaryChunk handles uploading a single part of a binary via http.Put request.
+// Uploading the same part again replaces it, so any part can be safely retried.
+//
+// Accepts 'upload_id' and 'part' query parameters, raw chunk as a body
+// and its hex encoded sha256 checksum in ChecksumHeader.
+//
+// Returns:
+//   - `400` if parameters are invalid or the checksum does not match
+//   - `404` if there is no such upload for the user
+//   - `413` if the chunk is larger than maxChunkSize or the binary gets larger than the quota allows
+//   - `507` if the user is out of quota
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` if everything is OK
+func (app *App) uploadBinaryChunk(w http.ResponseWriter, r *http.Request) {
+	var session service.UploadSession
+	var chunk service.BinaryChunk
+	session.Login = app.getLogin(r)
+	session.ID = r.URL.Query().Get("upload_id")
+
+	var err error
+	chunk.Part, err = strconv.Atoi(r.URL.Query().Get("part"))
+	if err != nil {
+		http.Error(w, "invalid part number", http.StatusBadRequest)
+		return
+	}
+
+	chunk.Data, err = io.ReadAll(io.LimitReader(r.Body, maxChunkSize+1))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("upload binary chunk: read body", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+	if len(chunk.Data) > maxChunkSize {
+		http.Error(w, "chunk is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	chunk.Checksum = tools.ChecksumBytes(chunk.Data)
+	if !strings.EqualFold(chunk.Checksum, r.Header.Get(ChecksumHeader)) {
+		http.Error(w, "checksum mismatch", http.StatusBadRequest)
+		return
+	}
+
+	err = app.UserStorage.PutBinaryChunk(session, chunk, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidChunk) {
+			http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+			return
+		}
+		if quotaExceeded(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("upload binary chunk", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// completeBinaryUpload handles finishing a chunked upload via http.Post request.
+// All the parts are turned into a binary at once, so a half-uploaded binary never replaces the stored one.
+//
+// Accepts 'upload_id' query parameter.
+//
+// Returns:
+//   - `400` if some parts are missing or have wrong sizes
+//   - `403` if the user can't change the secrets of the collection anymore
+//   - `404` if there is no such upload for the user
+//   - `409` if this data already exist and 'overwrite' flag was false
+//   - `412` and the binary as it is stored if it has been changed since the upload has started
+//   - `413` if the binary is larger than the quota allows
+//   - `507` if the user has too many binaries already
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` and the revision the binary is stored at in ETag header if everything is OK
+func (app *App) completeBinaryUpload(w http.ResponseWriter, r *http.Request) {
+	var session service.UploadSession
+	session.Login = app.getLogin(r)
+	session.ID = r.URL.Query().Get("upload_id")
+
+	revision, err := app.UserStorage.CompleteUpload(session, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, storage.ErrIncompleteUpload) || errors.Is(err, storage.ErrInvalidChunk) {
+			http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			http.Error(w, fmt.Sprint(err), http.StatusConflict)
+			return
+		}
+		if revisionMismatch(w, r, err) || quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("complete binary upload", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	setETag(w, revision)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// streamBinary handles sending a chunked binary via http.Get request, one chunk at a time.
+// Supports `Range: bytes=<offset>-` header to resume an interrupted download.
+//
+// Accepts 'description' query parameter, and optional 'collection_id' one for the binaries of a collection.
+//
+// Returns:
+//   - `400` if the ID of the collection is invalid
+//   - `403` if the user can't see the collection
+//   - `404` if user has no such chunked binary in storage
+//   - `416` if the range is invalid
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` or `206` with the encrypted content of the binary as a body, its total size in BinarySizeHeader
+//     and the size of every chunk but the last one in ChunkSizeHeader
+func (app *App) streamBinary(w http.ResponseWriter, r *http.Request) {
+	var binary service.BinaryData
+	binary.Login = app.getLogin(r)
+	binary.Description = r.URL.Query().Get("description")
+	var err error
+	binary.CollectionID, err = collectionID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	binary, chunks, err := app.UserStorage.GetBinaryChunkList(binary, r.Context())
+	if err != nil {
+		if forbidden(w, err) {
+			return
+		}
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("stream binary", "err", err, "login", binary.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+
+	offset, err := parseRangeOffset(r.Header.Get("Range"), binary.Size)
+	if err != nil {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", binary.Size))
+		http.Error(w, fmt.Sprint(err), http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set(BinarySizeHeader, strconv.FormatInt(binary.Size, 10))
+	w.Header().Set(ChunkSizeHeader, strconv.FormatInt(binary.ChunkSize, 10))
+	if w.Header().Get("Content-Encoding") == "" {
+		w.Header().Set("Content-Length", strconv.FormatInt(binary.Size-offset, 10))
+	}
+	if offset > 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, binary.Size-1, binary.Size))
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	var position int64
+	for _, chunk := range chunks {
+		if position+chunk.Size <= offset {
+			position += chunk.Size
+			continue
+		}
+
+		chunk, err = app.UserStorage.GetBinaryChunk(chunk, r.Context())
+		if err != nil {
+			// headers are already sent, the client will notice the body is short
+			logging.FromContext(r.Context()).Error("stream binary: get chunk", "err", err, "login", binary.Login)
+			return
+		}
+		skip := int64(0)
+		if offset > position {
+			skip = offset - position
+		}
+		_, err = w.Write(chunk.Data[skip:])
+		if err != nil {
+			logging.FromContext(r.Context()).Error("stream binary: write chunk", "err", err, "login", binary.Login)
+			return
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		position += chunk.Size
+	}
+}
+
+// parseRangeOffset parses the only range format the App supports: `bytes=<offset>-`
+func parseRangeOffset(header string, size int64) (int64, error) {
+	if header == "" {
+		return 0, nil
+	}
+	if !strings.HasPrefix(header, "bytes=") || !strings.HasSuffix(header, "-") {
+		return 0, errors.New("unsupported range")
+	}
+	spec := strings.TrimSuffix(strings.TrimPrefix(header, "bytes="), "-")
+	offset, err := strconv.ParseInt(spec, 10, 64)
+	if err != nil || offset < 0 || offset >= size {
+		return 0, errors.New("invalid range")
+	}
+	return offset, nil
+}
@@ -0,0 +1,136 @@
+package app
+
+// Here are the handler functions for the history of the user's secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+// errInvalidItem is returned for the kinds of the secrets that have no history and for invalid IDs
+var errInvalidItem = errors.New("invalid item or item ID")
+
+// versions returns the previous versions of the secret of the kind and with the ID given kept in the personal vault
+// of the user or in the collection, the most recent ones first. Every version of the kind is returned if id is 0,
+// every version of the vault if item is empty as well.
+func (app *App) versions(login string, collection uint, item string, id uint, ctx context.Context) ([]service.SecretVersion, error) {
+	switch item {
+	case storage.ItemLogoPass, storage.ItemText, storage.ItemCreditCard:
+	case "":
+		if id != 0 {
+			return nil, errInvalidItem
+		}
+	default:
+		return nil, errInvalidItem
+	}
+
+	versions, err := app.UserStorage.GetVersions(login, collection, item, id, ctx)
+	if errors.Is(err, storage.ErrEmpty) {
+		return []service.SecretVersion{}, nil
+	}
+	return versions, err
+}
+
+// history handles sending the previous versions of a user's secret via http.Get request.
+// A version is kept every time a logo-pass pair, a text or a credit card is overwritten, deleted or restored,
+// binaries have no history.
+//
+// Accepts 'item' query parameter with the kind of the secret: logopass, text or credit_card,
+// and 'item_id' one with the ID of the secret. Every version of the kind is sent if 'item_id' is omitted,
+// every version of the vault if both of them are. Optional 'collection_id' query parameter names the collection
+// the secret belongs to, the personal vault of the user is meant if it is omitted.
+//
+// Returns:
+//   - `400` if the item, its ID or the ID of the collection is invalid
+//   - `403` if the user can't see the collection
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of []service.SecretVersion type, the most recent versions first,
+//     every version holds the secret as it was in 'logo_pass', 'text' or 'credit_card' field
+func (app *App) history(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	var id uint64
+	if value := r.URL.Query().Get("item_id"); value != "" {
+		var err error
+		id, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			http.Error(w, errInvalidItem.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	collection, err := collectionID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	versions, err := app.versions(login, collection, r.URL.Query().Get("item"), uint(id), r.Context())
+	if err != nil {
+		if errors.Is(err, errInvalidItem) {
+			http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+			return
+		}
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get history", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, versions)
+}
+
+// restoreVersion handles making a previous version of a user's secret the current one via http.Post request.
+// The secret is brought back if it has been deleted, the version it replaces is kept in the history in its turn.
+//
+// Accepts json.Marshalled service.SecretVersion struct with 'version_id' field obligatory,
+// If-Match header must hold the revision of the current secret unless it has been deleted.
+//
+// Returns:
+//   - `400` if json or If-Match header is corrupted
+//   - `403` if the user can't change the secrets of the collection the version belongs to
+//   - `404` if the user has no such version or the secret is gone
+//   - `412` and the secret as it is stored if it has been changed since the revision in If-Match header
+//   - `428` if the secret is replaced without If-Match header
+//   - `507` if the user is out of quota
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) restoreVersion(w http.ResponseWriter, r *http.Request) {
+	var version service.SecretVersion
+	err := json.NewDecoder(r.Body).Decode(&version)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("restore version: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	version.Login = app.getLogin(r)
+	version.SecretRevision, err = ifMatch(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = app.UserStorage.RestoreVersion(version, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if revisionMismatch(w, r, err) || quotaExceeded(w, err) || forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("restore version", "err", err, "login", version.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,357 @@
+package app
+
+// Here are the handler functions for the organizations and their collections. The secrets of a collection
+// are stored and read by the same handlers the personal ones are, with 'collection_id' given.
+// The key of an organization is generated and wrapped for every member by the clients, the App only keeps it.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// errInvalidOrg is returned for the organizations and the collections with no name or ID,
+	// and for the organizations created without the key
+	errInvalidOrg = errors.New("invalid organization or collection")
+	// errInvalidMember is returned for the members with no login or unknown role
+	errInvalidMember = errors.New("invalid member")
+)
+
+// validRole reports whether the role is known to the organizations
+func validRole(role string) bool {
+	switch role {
+	case service.OrgRoleOwner, service.OrgRoleAdmin, service.OrgRoleMember, service.OrgRoleReadOnly:
+		return true
+	}
+	return false
+}
+
+// queryID returns the ID taken from the query parameter with the name, errInvalidOrg is returned
+// if it is missing or invalid
+func queryID(r *http.Request, name string) (uint, error) {
+	id, err := strconv.ParseUint(r.URL.Query().Get(name), 10, 32)
+	if err != nil || id == 0 {
+		return 0, errInvalidOrg
+	}
+	return uint(id), nil
+}
+
+// collectionID returns the ID of the collection taken from optional 'collection_id' query parameter,
+// 0 standing for the personal vault of the user
+func collectionID(r *http.Request) (uint, error) {
+	if r.URL.Query().Get("collection_id") == "" {
+		return 0, nil
+	}
+	return queryID(r, "collection_id")
+}
+
+// forbidden writes the response for the error if the user's role in the organization doesn't allow the request,
+// reports whether it has been written
+func forbidden(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, storage.ErrForbidden) {
+		return false
+	}
+	http.Error(w, fmt.Sprint(err), http.StatusForbidden)
+	return true
+}
+
+// orgFailed writes the response for the error of the storage managing the organizations
+func orgFailed(w http.ResponseWriter, r *http.Request, err error, action string) {
+	switch {
+	case forbidden(w, err):
+	case errors.Is(err, storage.ErrEmpty):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, storage.ErrAlreadyExists), errors.Is(err, storage.ErrLastOwner),
+		errors.Is(err, storage.ErrNoKeyPair):
+		http.Error(w, fmt.Sprint(err), http.StatusConflict)
+	default:
+		logging.FromContext(r.Context()).Error(action, "err", err, "login", loginFromContext(r.Context()))
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+	}
+}
+
+// listOrgs handles sending the organizations of the user via http.Get request.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of []service.Organization type. Every organization holds the role of the user
+//     and the key of the organization wrapped for the user in 'org_key', its members and the collections
+//     the user can see
+func (app *App) listOrgs(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	orgs, err := app.UserStorage.GetOrgs(login, r.Context())
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(r.Context()).Error("get organizations", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	if orgs == nil {
+		orgs = []service.Organization{}
+	}
+	render.JSON(w, r, orgs)
+}
+
+// createOrg handles creating an organization owned by the user via http.Post request.
+//
+// Accepts json.Marshalled service.Organization struct with 'name' and 'org_key' fields obligatory,
+// 'org_key' being the key of the organization encrypted with the public key of the user.
+//
+// Returns:
+//   - `400` if json is corrupted or one of the fields is missing
+//   - `409` if the name is taken
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` and json.Marshalled struct of service.Organization type with 'org_id' of the organization
+func (app *App) createOrg(w http.ResponseWriter, r *http.Request) {
+	var org service.Organization
+	err := json.NewDecoder(r.Body).Decode(&org)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("create organization: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if org.Name == "" || org.OrgKey == "" {
+		http.Error(w, errInvalidOrg.Error(), http.StatusBadRequest)
+		return
+	}
+
+	org, err = app.UserStorage.CreateOrg(org, service.OrgMember{Login: app.getLogin(r), OrgKey: org.OrgKey},
+		r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "create organization")
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, org)
+}
+
+// deleteOrg handles removing an organization along with all of its collections via http.Delete request,
+// only the owners can.
+//
+// Accepts 'org_id' query parameter.
+//
+// Returns:
+//   - `400` if the ID is invalid
+//   - `403` if the user is not an owner of the organization
+//   - `404` if the user is not a member of such organization
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteOrg(w http.ResponseWriter, r *http.Request) {
+	id, err := queryID(r, "org_id")
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	err = app.UserStorage.DeleteOrg(app.getLogin(r), id, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "delete organization")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// putMember handles adding a user to an organization or changing the role of a member via http.Post request.
+// Owners and admins manage the members, only owners manage other owners and admins.
+//
+// Accepts json.Marshalled service.OrgMember struct with 'org_id', 'login' and 'role' fields obligatory,
+// 'role' being owner, admin, member or read-only. 'org_key' is the key of the organization encrypted
+// with the public key of the member, it is obligatory for the new members and replaces the stored one otherwise.
+//
+// Returns:
+//   - `400` if json is corrupted or the member is invalid
+//   - `403` if the role of the user doesn't allow the change
+//   - `404` if the user is not a member of such organization
+//   - `409` if the member has no key pair or no 'org_key', or the last owner is demoted
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) putMember(w http.ResponseWriter, r *http.Request) {
+	var member service.OrgMember
+	err := json.NewDecoder(r.Body).Decode(&member)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("put member: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if member.OrgID == 0 || member.Login == "" || !validRole(member.Role) {
+		http.Error(w, errInvalidMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = app.UserStorage.PutMember(app.getLogin(r), member, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "put member")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteMember handles removing a member from an organization and all of its collections via http.Delete request.
+// Owners and admins remove the members, only owners remove other owners and admins, every member can leave.
+//
+// Accepts 'org_id' query parameter and optional 'login' one with the login of the member,
+// the user leaves the organization if it is omitted.
+//
+// Returns:
+//   - `400` if the ID is invalid
+//   - `403` if the role of the user doesn't allow the removal
+//   - `404` if there is no such member
+//   - `409` if the member is the last owner
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteMember(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+	id, err := queryID(r, "org_id")
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+	member := service.OrgMember{OrgID: id, Login: r.URL.Query().Get("login")}
+	if member.Login == "" {
+		member.Login = login
+	}
+
+	err = app.UserStorage.DeleteMember(login, member, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "delete member")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// putCollection handles creating a collection in an organization or renaming it via http.Post request,
+// only owners and admins can.
+//
+// Accepts json.Marshalled service.Collection struct with 'org_id' and 'name' fields obligatory,
+// 'collection_id' is set to rename the collection.
+//
+// Returns:
+//   - `400` if json is corrupted or one of the fields is missing
+//   - `403` if the user is not an owner or an admin of the organization
+//   - `404` if there is no such organization of the user or collection to rename
+//   - `409` if the organization has a collection with the name
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` if the collection is created, `200` if it is renamed, and json.Marshalled struct
+//     of service.Collection type with 'collection_id' of the collection
+func (app *App) putCollection(w http.ResponseWriter, r *http.Request) {
+	var collection service.Collection
+	err := json.NewDecoder(r.Body).Decode(&collection)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("put collection: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if collection.OrgID == 0 || collection.Name == "" {
+		http.Error(w, errInvalidOrg.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created := collection.ID == 0
+	collection, err = app.UserStorage.PutCollection(app.getLogin(r), collection, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "put collection")
+		return
+	}
+	if created {
+		render.Status(r, http.StatusCreated)
+	}
+	render.JSON(w, r, collection)
+}
+
+// deleteCollection handles removing a collection along with all of its secrets via http.Delete request,
+// only owners and admins can.
+//
+// Accepts 'collection_id' query parameter.
+//
+// Returns:
+//   - `400` if the ID is invalid
+//   - `403` if the user is not an owner or an admin of the organization
+//   - `404` if there is no such collection in the organizations of the user
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := queryID(r, "collection_id")
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	err = app.UserStorage.DeleteCollection(app.getLogin(r), id, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "delete collection")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// putCollectionMember handles adding a member of an organization to its collection via http.Post request,
+// only owners and admins can.
+//
+// Accepts json.Marshalled service.CollectionMember struct with 'collection_id' and 'login' fields obligatory.
+//
+// Returns:
+//   - `400` if json is corrupted or one of the fields is missing
+//   - `403` if the user is not an owner or an admin of the organization
+//   - `404` if there is no such collection or member
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) putCollectionMember(w http.ResponseWriter, r *http.Request) {
+	var member service.CollectionMember
+	err := json.NewDecoder(r.Body).Decode(&member)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("put collection member: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if member.CollectionID == 0 || member.Login == "" {
+		http.Error(w, errInvalidMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = app.UserStorage.PutCollectionMember(app.getLogin(r), member, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "put collection member")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteCollectionMember handles removing a member from a collection via http.Delete request,
+// only owners and admins can.
+//
+// Accepts 'collection_id' and 'login' query parameters.
+//
+// Returns:
+//   - `400` if the ID or the login is invalid
+//   - `403` if the user is not an owner or an admin of the organization
+//   - `404` if there is no such collection or the member has not been added to it
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteCollectionMember(w http.ResponseWriter, r *http.Request) {
+	id, err := queryID(r, "collection_id")
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+	member := service.CollectionMember{CollectionID: id, Login: r.URL.Query().Get("login")}
+	if member.Login == "" {
+		http.Error(w, errInvalidMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = app.UserStorage.DeleteCollectionMember(app.getLogin(r), member, r.Context())
+	if err != nil {
+		orgFailed(w, r, err, "delete collection member")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,99 @@
+package app
+
+// Here is the handler function for changing the password. The secrets are encrypted on the client with the key
+// derived from the password, so the client re-encrypts all of them and sends them along with the new password.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+)
+
+// errEmptyPassword is returned if the new password is empty
+var errEmptyPassword = errors.New("new password is empty")
+
+// updatePassword checks the old password just like the login does, failed attempts included,
+// and replaces the password along with the re-encrypted secrets
+func (app *App) updatePassword(change service.PasswordChange, ip string, ctx context.Context) error {
+	if change.NewPassword == "" {
+		return errEmptyPassword
+	}
+	var err error
+	change.Revision, err = parseCursor(change.Cursor)
+	if err != nil {
+		return err
+	}
+
+	err = app.checkCredentials(service.Authentication{Login: change.Login, Password: change.OldPassword}, ip, ctx)
+	if err != nil {
+		return err
+	}
+	return app.UserStorage.ChangePassword(change, ctx)
+}
+
+// changePassword handles changing the password via http.Post request. The secrets are replaced with the ones
+// re-encrypted with the new key and the password is changed all at once, the other sessions of the user
+// are revoked, as the other devices are to sign in with the new password to get the new key.
+//
+// Accepts json.Marshalled service.PasswordChange struct with 'old_password', 'new_password' and 'cursor' fields
+// obligatory, the cursor being the one of the full sync the secrets were taken from.
+// Every secret of the user must be in it: the binaries stored as a whole among 'binaries', the chunked ones
+// uploaded beforehand with 'overwrite' set and the IDs of their uploads listed in 'uploads'.
+//
+// Returns:
+//   - `400` if json is corrupted, the new password is empty, the cursor is invalid or an upload is incomplete
+//   - `403` if the old password is wrong, 401 is not used for it as it stands for the access token refused
+//   - `409` if the secrets have changed since the cursor or some of them are missing, the client is to sync
+//     and try again
+//   - `413` if a binary is too large
+//   - `429` and Retry-After header if the address or the account has made too many failed attempts
+//   - `507` if the user is out of quota
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) changePassword(w http.ResponseWriter, r *http.Request) {
+	var change service.PasswordChange
+	err := json.NewDecoder(r.Body).Decode(&change)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("change password: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	change.Login = app.getLogin(r)
+	change.Session = sessionFromContext(r.Context())
+
+	err = app.updatePassword(change, remoteIP(r.RemoteAddr), r.Context())
+	if err != nil {
+		var lockout *lockoutError
+		if errors.As(err, &lockout) {
+			writeLockout(w, lockout)
+			return
+		}
+		if errors.Is(err, errEmptyPassword) || errors.Is(err, errInvalidCursor) ||
+			errors.Is(err, storage.ErrInvalidChunk) || errors.Is(err, storage.ErrIncompleteUpload) {
+			http.Error(w, fmt.Sprintf("change password: %s", err), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			http.Error(w, fmt.Sprintf("change password: %s", err), http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, storage.ErrOldData) || errors.Is(err, storage.ErrAlreadyExists) ||
+			errors.Is(err, storage.ErrEmpty) {
+			http.Error(w, fmt.Sprintf("change password: %s", err), http.StatusConflict)
+			return
+		}
+		if quotaExceeded(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("change password", "err", err, "login", change.Login)
+		http.Error(w, fmt.Sprintf("change password: %s", err), http.StatusInternalServerError)
+		return
+	}
+	logging.FromContext(r.Context()).Info("password changed", "login", change.Login)
+	w.WriteHeader(http.StatusOK)
+}
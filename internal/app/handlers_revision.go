@@ -0,0 +1,61 @@
+package app
+
+// Here are the revisions of the secrets on the wire: every secret is stored at a revision assigned by the server,
+// it is sent as an ETag and is expected back in If-Match header, so that no one could overwrite a change
+// one has never seen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errInvalidRevision is returned for If-Match header that holds no revision
+var errInvalidRevision = errors.New("invalid If-Match header")
+
+// ifMatch returns the revision of the secret the client has edited, taken from If-Match header.
+// The revision is 0 if there is no header.
+func ifMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, nil
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision <= 0 {
+		return 0, errInvalidRevision
+	}
+	return revision, nil
+}
+
+// setETag sends the revision the secret is stored at as ETag header
+func setETag(w http.ResponseWriter, revision int64) {
+	if revision != 0 {
+		w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(revision, 10)))
+	}
+}
+
+// revisionMismatch writes the response to the request refused for the revision edited
+// and reports whether it was one:
+//   - `412` along with the secret as it is stored and its ETag if the secret has been changed since the revision edited
+//   - `428` if the stored secret is overwritten or deleted without If-Match header
+func revisionMismatch(w http.ResponseWriter, r *http.Request, err error) bool {
+	var revisionErr *storage.RevisionError
+	switch {
+	case errors.As(err, &revisionErr) && revisionErr.Current != nil:
+		setETag(w, revisionErr.Revision)
+		render.Status(r, http.StatusPreconditionFailed)
+		render.JSON(w, r, revisionErr.Current)
+	case errors.Is(err, storage.ErrRevisionMismatch):
+		http.Error(w, fmt.Sprint(err), http.StatusPreconditionFailed)
+	case errors.Is(err, storage.ErrRevisionRequired):
+		http.Error(w, fmt.Sprint(err), http.StatusPreconditionRequired)
+	default:
+		return false
+	}
+	return true
+}
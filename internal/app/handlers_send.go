@@ -0,0 +1,141 @@
+package app
+
+// Here are the handler functions for the sends: a text or a small binary handed by a link to someone without
+// an account. The content comes encrypted with a key the App never sees, the App only keeps it till it expires
+// or runs out of views and hands it to anyone holding the link.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxSendSize is the largest encrypted content a send can hold, the larger files are to be stored in the vault
+	maxSendSize = 1 << 20
+	// maxSendViews is how many times a send can be viewed at most
+	maxSendViews = 100
+	// defaultSendTTL is how long a send lives if the lifetime is not asked for
+	defaultSendTTL = 24 * time.Hour
+	// maxSendTTL is how long a send can live at most
+	maxSendTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// errInvalidSend is returned for the sends of unknown kind, with no content or with the limits out of range
+	errInvalidSend = errors.New("invalid send")
+	// errSendTooLarge is returned for the sends holding more than maxSendSize
+	errSendTooLarge = fmt.Errorf("send is too large: %d bytes at most", maxSendSize)
+)
+
+// prepareSend checks the send made by the user and sets its expiry, a single view and defaultSendTTL
+// are taken if the limits are not asked for. Returns errInvalidSend or errSendTooLarge unless the send can be made.
+func prepareSend(send *service.Send) error {
+	if (send.Item != storage.ItemText && send.Item != storage.ItemBinary) || len(send.Data) == 0 {
+		return errInvalidSend
+	}
+	if len(send.Data) > maxSendSize {
+		return errSendTooLarge
+	}
+	if send.MaxViews == 0 {
+		send.MaxViews = 1
+	}
+	ttl := defaultSendTTL
+	if send.ExpiresIn != 0 {
+		ttl = time.Duration(send.ExpiresIn) * time.Second
+	}
+	if send.MaxViews < 0 || send.MaxViews > maxSendViews || ttl <= 0 || ttl > maxSendTTL {
+		return errInvalidSend
+	}
+	send.ExpiresAt = time.Now().Add(ttl)
+	send.ExpiresIn = int64(ttl.Seconds())
+	return nil
+}
+
+// viewSend returns the send with the id counting the view. Asking for a send that doesn't exist counts as
+// a failed attempt of the address, so that the links can't be guessed, storage.ErrEmpty is returned then.
+func (app *App) viewSend(id string, ip string, ctx context.Context) (service.Send, error) {
+	send, err := app.UserStorage.GetSend(id, ctx)
+	if errors.Is(err, storage.ErrEmpty) {
+		app.ipLimiter.fail(ipKey(ip))
+	}
+	return send, err
+}
+
+// createSend handles storing a send of the user via http.Post request. The link to the send is made by the client,
+// its fragment carrying the key the content is encrypted with.
+//
+// Accepts json.Marshalled service.Send struct with 'item' and 'data' fields obligatory, 'item' being either
+// text or binary and 'data' holding the encrypted content, 'name' is the encrypted file name of a binary.
+// Optional 'max_views' is 1 and 'expires_in' is a day in seconds by default, they are 100 and a week at most.
+//
+// Returns:
+//   - `400` if json is corrupted or the send is invalid
+//   - `413` if the content is larger than allowed
+//   - `507` if the user has too many sends alive
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` and json.Marshalled struct of service.Send type with 'send_id' and 'expires_at' of the send,
+//     the content is not sent back
+func (app *App) createSend(w http.ResponseWriter, r *http.Request) {
+	var send service.Send
+	err := json.NewDecoder(r.Body).Decode(&send)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("create send: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	err = prepareSend(&send)
+	if err != nil {
+		if errors.Is(err, errSendTooLarge) {
+			http.Error(w, fmt.Sprint(err), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+	send.Login = app.getLogin(r)
+
+	send, err = app.UserStorage.PutSend(send, r.Context())
+	if err != nil {
+		if quotaExceeded(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("create send", "err", err, "login", send.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	send.Data, send.Name = nil, ""
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, send)
+}
+
+// getSend handles sending a send to anyone holding the link via http.Get request, no authorization is needed.
+// Every request counts as a view, the send is gone after the last one.
+//
+// Accepts 'send_id' query parameter.
+//
+// Returns:
+//   - `404` if there is no such send, it has expired or has been viewed as many times as allowed
+//   - `429` if the address is blocked for asking for too many sends that don't exist
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of service.Send type with 'data' and 'name' encrypted, 'views' counting this one
+func (app *App) getSend(w http.ResponseWriter, r *http.Request) {
+	send, err := app.viewSend(r.URL.Query().Get("send_id"), remoteIP(r.RemoteAddr), r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("get send", "err", err)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, send)
+}
@@ -0,0 +1,82 @@
+package app
+
+// Here are the handler functions for managing the sessions of a user
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+)
+
+// logout handles signing out of the current session via http.Post request.
+// The session is revoked along with its refresh tokens, so the access token stops working right away.
+//
+// All it needs to run is the access token of the session.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) logout(w http.ResponseWriter, r *http.Request) {
+	session := service.Session{ID: sessionFromContext(r.Context()), Login: app.getLogin(r)}
+
+	err := app.UserStorage.DeleteSession(session, r.Context())
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(r.Context()).Error("logout", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// listSessions handles sending the list of all the devices signed in by the user via http.Get request.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of []service.Session type, the most recently seen sessions first,
+//     the session that made the request has 'current' field set
+func (app *App) listSessions(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	sessions, err := app.UserStorage.GetSessions(login, r.Context())
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(r.Context()).Error("list sessions", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+
+	current := sessionFromContext(r.Context())
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	render.JSON(w, r, sessions)
+}
+
+// revokeSession handles signing out any session of the user via http.Delete request.
+//
+// Accepts 'session_id' query parameter.
+//
+// Returns:
+//   - `404` if the user has no such session
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) revokeSession(w http.ResponseWriter, r *http.Request) {
+	session := service.Session{ID: r.URL.Query().Get("session_id"), Login: app.getLogin(r)}
+
+	err := app.UserStorage.DeleteSession(session, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("revoke session", "err", err, "login", session.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,290 @@
+package app
+
+// Here are the handler functions for sharing the secrets with other users. The secrets are shared encrypted
+// with the public key of the recipient by the client of the owner, the App only keeps and hands them over.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"strconv"
+)
+
+var (
+	// errInvalidShare is returned for the shares with unknown access, unknown kind of the secret or no secret,
+	// and for the shares made with oneself
+	errInvalidShare = errors.New("invalid share")
+	// errInvalidKeyPair is returned for the key pairs missing one of the keys
+	errInvalidKeyPair = errors.New("both public and private keys are required")
+)
+
+// keyPair returns the key pair of the user named by of, only the public key is returned for the keys of other users
+func (app *App) keyPair(login string, of string, ctx context.Context) (service.KeyPair, error) {
+	if of == "" {
+		of = login
+	}
+	keys, err := app.UserStorage.GetKeyPair(of, ctx)
+	if err != nil {
+		return keys, err
+	}
+	if of != login {
+		keys.PrivateKey = ""
+	}
+	return keys, nil
+}
+
+// checkShare returns errInvalidShare unless the share can be made by the user
+func checkShare(login string, share service.Share) error {
+	if share.Recipient == "" || share.Recipient == login {
+		return errInvalidShare
+	}
+	if share.Access != service.AccessRead && share.Access != service.AccessWrite {
+		return errInvalidShare
+	}
+	return nil
+}
+
+// shareID returns the ID of the share taken from 'share_id' query parameter
+func shareID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("share_id"), 10, 32)
+	if err != nil {
+		return 0, errInvalidShare
+	}
+	return uint(id), nil
+}
+
+// getKeyPair handles sending the key pair of the user or the public key of another user via http.Get request.
+//
+// Accepts optional 'login' query parameter with the login of the user whose public key is asked for,
+// the key pair of the user is sent if it is omitted.
+//
+// Returns:
+//   - `404` if the user has no key pair
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of service.KeyPair type, 'private_key' is sent only with the user's own key pair
+func (app *App) getKeyPair(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	keys, err := app.keyPair(login, r.URL.Query().Get("login"), r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrNoKeyPair) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("get key pair", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, keys)
+}
+
+// putKeyPair handles storing the key pair of the user via http.Put request. The private key is to be encrypted
+// with the key derived from the user's password. The shares made for the previous public key are dropped.
+//
+// Accepts json.Marshalled service.KeyPair struct with 'public_key' and 'private_key' fields obligatory.
+//
+// Returns:
+//   - `400` if json is corrupted or one of the keys is missing
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) putKeyPair(w http.ResponseWriter, r *http.Request) {
+	var keys service.KeyPair
+	err := json.NewDecoder(r.Body).Decode(&keys)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("put key pair: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	if keys.PublicKey == "" || keys.PrivateKey == "" {
+		http.Error(w, errInvalidKeyPair.Error(), http.StatusBadRequest)
+		return
+	}
+	keys.Login = app.getLogin(r)
+
+	err = app.UserStorage.PutKeyPair(keys, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("put key pair", "err", err, "login", keys.Login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// listShares handles sending the shares made by the user and the ones made for the user via http.Get request.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled struct of []service.Share type. The shares made for the user hold the secret
+//     encrypted with the user's public key in 'logo_pass', 'text' or 'credit_card' field, the ones made by the user
+//     hold the change of the recipient encrypted with the user's public key if 'pending' is set
+func (app *App) listShares(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	shares, err := app.UserStorage.GetShares(login, r.Context())
+	if err != nil && !errors.Is(err, storage.ErrEmpty) {
+		logging.FromContext(r.Context()).Error("get shares", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	if shares == nil {
+		shares = []service.Share{}
+	}
+	render.JSON(w, r, shares)
+}
+
+// putShare handles sharing a secret of the user via http.Post request. Sharing the same secret with the same
+// recipient once again replaces the secret of the share, the change made by the recipient is dropped then.
+//
+// Accepts json.Marshalled service.Share struct with 'recipient', 'item', 'item_id' and 'access' fields obligatory,
+// 'access' being either read or write. The secret is sent in the field of its kind: 'logo_pass', 'text'
+// or 'credit_card', its secret fields encrypted with the public key of the recipient.
+//
+// Returns:
+//   - `400` if json is corrupted or the share is invalid
+//   - `404` if user has no such secret
+//   - `409` if the recipient has no key pair to share with
+//   - `500` if storage methods fail to comprehend the request
+//   - `201` and json.Marshalled struct of service.Share type with 'share_id' of the share
+func (app *App) putShare(w http.ResponseWriter, r *http.Request) {
+	var share service.Share
+	err := json.NewDecoder(r.Body).Decode(&share)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("put share: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	share.Owner = app.getLogin(r)
+	err = checkShare(share.Owner, share)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	share, err = app.UserStorage.PutShare(share, r.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUnknownItem):
+			http.Error(w, errInvalidShare.Error(), http.StatusBadRequest)
+		case errors.Is(err, storage.ErrEmpty):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, storage.ErrNoKeyPair):
+			http.Error(w, fmt.Sprint(err), http.StatusConflict)
+		default:
+			logging.FromContext(r.Context()).Error("put share", "err", err, "login", share.Owner)
+			http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		}
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, share)
+}
+
+// acceptShare handles accepting a share made for the user via http.Post request,
+// the shared secret is listed along with the user's own ones then.
+//
+// Accepts 'share_id' query parameter.
+//
+// Returns:
+//   - `400` if the ID is invalid
+//   - `404` if there is no such share made for the user
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) acceptShare(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+	id, err := shareID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = app.UserStorage.AcceptShare(login, id, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("accept share", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// changeShare handles changing a secret shared with the user for reading and writing via http.Post request.
+// The change is kept for the owner, whose client takes it in and shares the secret changed once again.
+//
+// Accepts json.Marshalled service.Share struct with 'share_id' field obligatory and the secret changed
+// in the field of its kind, its secret fields encrypted with the public key of the owner.
+//
+// Returns:
+//   - `400` if json is corrupted or there is no secret of the kind shared
+//   - `403` if the share is read-only
+//   - `404` if there is no such share made for the user
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) changeShare(w http.ResponseWriter, r *http.Request) {
+	var share service.Share
+	err := json.NewDecoder(r.Body).Decode(&share)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("change share: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+	share.Recipient = app.getLogin(r)
+
+	_, err = app.UserStorage.ChangeShare(share, r.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUnknownItem):
+			http.Error(w, errInvalidShare.Error(), http.StatusBadRequest)
+		case errors.Is(err, storage.ErrReadOnly):
+			http.Error(w, fmt.Sprint(err), http.StatusForbidden)
+		case errors.Is(err, storage.ErrEmpty):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			logging.FromContext(r.Context()).Error("change share", "err", err, "login", share.Recipient)
+			http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteShare handles removing a share via http.Delete request: the owner revokes it
+// and the recipient declines it in the same way.
+//
+// Accepts 'share_id' query parameter.
+//
+// Returns:
+//   - `400` if the ID is invalid
+//   - `404` if there is no such share made by the user or for the user
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) deleteShare(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+	id, err := shareID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	_, err = app.UserStorage.DeleteShare(login, id, r.Context())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logging.FromContext(r.Context()).Error("delete share", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
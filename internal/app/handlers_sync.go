@@ -0,0 +1,76 @@
+package app
+
+// Here is the handler function for the delta sync of the user's secrets
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"net/http"
+	"strconv"
+)
+
+// errInvalidCursor is returned for the cursors not issued by the App
+var errInvalidCursor = errors.New("invalid sync cursor")
+
+// parseCursor turns the cursor sent by a client into the revision of the user's data.
+// Empty cursor stands for a full sync.
+func parseCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	revision, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, errInvalidCursor
+	}
+	return revision, nil
+}
+
+// formatCursor turns the revision of the user's data into the cursor sent to the client.
+// Clients should treat it as an opaque string.
+func formatCursor(revision int64) string {
+	return strconv.FormatInt(revision, 10)
+}
+
+// sync handles sending all the user's secrets changed since the cursor via http.Get request.
+//
+// Accepts optional 'since' query parameter holding the cursor got from the previous sync.
+// Every secret is sent if it is omitted, or if the cursor is unknown to the storage, 'complete' being set then.
+// Optional 'collection_id' query parameter names the collection to sync, every collection has cursors of its own.
+// The personal vault of the user is synced if it is omitted.
+//
+// Returns:
+//   - `400` if the cursor or the ID of the collection is invalid
+//   - `403` if the user can't see the collection
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled service.SyncData with the secrets created, updated or deleted since the cursor
+//     and the 'cursor' to be sent next time. Binaries come without data,
+//     deleted entries are sent as tombstones with 'DeletedAt' set and secret fields wiped
+func (app *App) sync(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	since, err := parseCursor(r.URL.Query().Get("since"))
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	collection, err := collectionID(r)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusBadRequest)
+		return
+	}
+
+	changes, revision, err := app.UserStorage.GetChanges(login, collection, since, r.Context())
+	if err != nil {
+		if forbidden(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("get changes", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	changes.Cursor = formatCursor(revision)
+	render.JSON(w, r, changes)
+}
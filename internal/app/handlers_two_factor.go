@@ -0,0 +1,160 @@
+package app
+
+// Here are the handler functions for TOTP two-factor authentication
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"net/http"
+)
+
+// loginTwoFactor handles the second step of signing in for the users with two-factor authentication enabled.
+//
+// Accepts json.Marshalled service.TwoFactorLogin struct with 'challenge_token' sent by login
+// and 'code' fields obligatory. The code is either TOTP code or one of the recovery codes, neither can be used twice.
+//
+// Returns:
+//   - `400` if json is corrupted
+//   - `401` if the challenge token is invalid or expired or the code is wrong
+//   - `403` if the account has been disabled
+//   - `429` and Retry-After header if the address or the account has made too many failed attempts
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and json.Marshalled service.Tokens - if everything works out
+func (app *App) loginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var login service.TwoFactorLogin
+	err := json.NewDecoder(r.Body).Decode(&login)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("auth 2fa err: json parse error", "err", err)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := app.finishLogin(login, remoteIP(r.RemoteAddr), r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("auth 2fa err", "err", err)
+		var lockout *lockoutError
+		if errors.As(err, &lockout) {
+			writeLockout(w, lockout)
+			return
+		}
+		if errors.Is(err, errInvalidChallenge) || errors.Is(err, storage.ErrInvalidCode) ||
+			errors.Is(err, storage.ErrEmpty) {
+			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, storage.ErrAccountDisabled) {
+			http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusForbidden)
+			return
+		}
+		http.Error(w, fmt.Sprintf("auth error: %s", err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, tokens)
+}
+
+// enrollTwoFactor handles generating a new TOTP secret for the user via http.Post request.
+// Two-factor authentication is not enabled until the secret is verified, enrolling again replaces it.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `409` if two-factor authentication is already enabled
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and json.Marshalled service.TOTPEnrollment with the secret and otpauth:// URI
+func (app *App) enrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	enrollment, err := app.enrollTOTP(login, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("enroll 2fa", "err", err, "login", login)
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			http.Error(w, fmt.Sprintf("enroll 2fa: %s", err), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("enroll 2fa: %s", err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, enrollment)
+}
+
+// verifyTwoFactor handles enabling two-factor authentication via http.Post request
+// once TOTP code proves the authenticator of the user is set up.
+//
+// Accepts json.Marshalled service.TwoFactorCode struct with 'code' field obligatory.
+//
+// Returns:
+//   - `400` if json is corrupted
+//   - `403` if the code is wrong
+//   - `404` if there is no secret enrolled
+//   - `409` if two-factor authentication is already enabled
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` and json.Marshalled service.RecoveryCodes, they are shown only once
+func (app *App) verifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+	var code service.TwoFactorCode
+	err := json.NewDecoder(r.Body).Decode(&code)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("verify 2fa: json parse error", "err", err, "login", login)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	recoveryCodes, err := app.verifyTOTP(login, code.Code, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("verify 2fa", "err", err, "login", login)
+		switch {
+		case errors.Is(err, storage.ErrInvalidCode):
+			http.Error(w, fmt.Sprintf("verify 2fa: %s", err), http.StatusForbidden)
+		case errors.Is(err, storage.ErrEmpty):
+			http.Error(w, fmt.Sprintf("verify 2fa: %s", err), http.StatusNotFound)
+		case errors.Is(err, storage.ErrAlreadyExists):
+			http.Error(w, fmt.Sprintf("verify 2fa: %s", err), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("verify 2fa: %s", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	render.JSON(w, r, service.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// disableTwoFactor handles turning two-factor authentication off via http.Delete request.
+//
+// Accepts json.Marshalled service.TwoFactorCode struct with 'code' field obligatory,
+// it is either TOTP code or one of the recovery codes.
+//
+// Returns:
+//   - `400` if json is corrupted
+//   - `403` if the code is wrong
+//   - `404` if two-factor authentication is not enabled
+//   - `500` if storage methods fail to comprehend the request
+//   - `200` if everything is OK
+func (app *App) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+	var code service.TwoFactorCode
+	err := json.NewDecoder(r.Body).Decode(&code)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("disable 2fa: json parse error", "err", err, "login", login)
+		http.Error(w, fmt.Sprintf("json parse error: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	err = app.disableTOTP(login, code.Code, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("disable 2fa", "err", err, "login", login)
+		switch {
+		case errors.Is(err, storage.ErrInvalidCode):
+			http.Error(w, fmt.Sprintf("disable 2fa: %s", err), http.StatusForbidden)
+		case errors.Is(err, storage.ErrEmpty):
+			http.Error(w, fmt.Sprintf("disable 2fa: %s", err), http.StatusNotFound)
+		default:
+			http.Error(w, fmt.Sprintf("disable 2fa: %s", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,47 @@
+package app
+
+// Here are the handler function for the usage of the storage and the responses to the requests exceeding the quotas
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/storage"
+	"net/http"
+)
+
+// quotaExceeded writes the response to the request refused by the quotas of the storage
+// and reports whether it was one:
+//   - `413` if a binary is larger than allowed
+//   - `507` if the user is out of space or has too many items of the kind
+func quotaExceeded(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, storage.ErrBinaryTooLarge):
+		http.Error(w, fmt.Sprint(err), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		http.Error(w, fmt.Sprint(err), http.StatusInsufficientStorage)
+	default:
+		return false
+	}
+	return true
+}
+
+// usage handles sending how much space the user takes and the quotas limiting it via http.Get request.
+//
+// All it needs to run is a user login from the access token.
+//
+// Returns:
+//   - `500` if storage methods fail to comprehend the request
+//   - json.Marshalled service.Usage, a zero limit means there is none
+func (app *App) usage(w http.ResponseWriter, r *http.Request) {
+	login := app.getLogin(r)
+
+	usage, err := app.UserStorage.GetUsage(login, r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("get usage", "err", err, "login", login)
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	render.JSON(w, r, usage)
+}
@@ -0,0 +1,86 @@
+package app
+
+// Here are the probes of the App for the orchestrator
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/render"
+	"gophkeeper/internal/config"
+	"gophkeeper/internal/service"
+	"net/http"
+	"os"
+	"time"
+)
+
+// statuses of the readiness and its checks
+const (
+	healthOK           = "ok"
+	healthFail         = "fail"
+	healthShuttingDown = "shutting_down"
+)
+
+// readinessTimeout limits the time of every readiness check
+const readinessTimeout = time.Second
+
+// healthz handles liveness probe, the App is alive as long as it can respond.
+//
+// Returns:
+//   - `200` and json.Marshalled service.Readiness with 'ok' status
+func (app *App) healthz(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, service.Readiness{Status: healthOK})
+}
+
+// readyz handles readiness probe. It pings the database and checks that the client zips can be downloaded.
+//
+// Returns:
+//   - `503` and json.Marshalled service.Readiness if any of the checks fails or the App is shutting down
+//   - `200` and json.Marshalled service.Readiness with the results of every check - if everything works out
+func (app *App) readyz(w http.ResponseWriter, r *http.Request) {
+	if app.shuttingDown.Load() {
+		render.Status(r, http.StatusServiceUnavailable)
+		render.JSON(w, r, service.Readiness{Status: healthShuttingDown})
+		return
+	}
+
+	readiness := service.Readiness{Status: healthOK, Checks: map[string]service.HealthCheck{
+		"database":  runCheck(r.Context(), app.UserStorage.Ping),
+		"downloads": runCheck(r.Context(), app.checkDownloads),
+	}}
+	for _, check := range readiness.Checks {
+		if check.Status != healthOK {
+			readiness.Status = healthFail
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+	}
+	render.JSON(w, r, readiness)
+}
+
+// runCheck runs the check within readinessTimeout and measures its latency
+func runCheck(ctx context.Context, check func(ctx context.Context) error) service.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := service.HealthCheck{Status: healthOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = healthFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkDownloads checks that config.DownloadFolder holds the client zips of all the platforms
+func (app *App) checkDownloads(_ context.Context) error {
+	for _, name := range []string{config.WinFileName, config.MacFileName, config.LinFileName} {
+		info, err := os.Stat(app.config.DownloadFolder + name)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", name)
+		}
+	}
+	return nil
+}
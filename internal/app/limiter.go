@@ -0,0 +1,196 @@
+package app
+
+// Here is the brute-force protection of the credentials: the failed attempts are counted
+// per address and per account, and the key is blocked for a while once there are too many of them
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// freeAttempts is the number of failed attempts of an account allowed before the backoff starts
+	freeAttempts = 3
+	// backoffBase is the delay after the first failed attempt beyond freeAttempts, it doubles with every next one
+	backoffBase = time.Second
+	// ipFailureFactor is how many times more failures an address is allowed than an account
+	// as many users may share one address
+	ipFailureFactor = 5
+	// limiterPruneInterval is how often the forgotten failures are dropped
+	limiterPruneInterval = time.Minute
+)
+
+// lockoutError is returned when the credentials are not checked because of too many failed attempts
+type lockoutError struct {
+	RetryAfter time.Duration
+}
+
+// Error implements error interface
+func (err *lockoutError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry after %s", err.RetryAfter.Round(time.Second))
+}
+
+// failures holds the failed attempts made with a key and the time the key is blocked until
+type failures struct {
+	count        int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// limiter counts the failed attempts made with the keys. After the free attempts every failure blocks the key
+// for an exponentially growing delay, and after maxFailures the key is locked out for the lockout duration.
+// The failures are forgotten once the key has not failed for the lockout duration.
+// The counters are kept in memory, so every instance of the App counts on its own.
+type limiter struct {
+	mu          sync.Mutex
+	free        int
+	maxFailures int
+	lockout     time.Duration
+	keys        map[string]*failures
+	lastPrune   time.Time
+}
+
+// newLimiter creates a limiter locking the keys out after maxFailures, it never does if maxFailures is not positive
+func newLimiter(free int, maxFailures int, lockout time.Duration) *limiter {
+	return &limiter{free: free, maxFailures: maxFailures, lockout: lockout, keys: make(map[string]*failures)}
+}
+
+// check returns lockoutError if any of the keys is blocked
+func (l *limiter) check(keys ...string) error {
+	if l.maxFailures <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var retryAfter time.Duration
+	now := time.Now()
+	for _, key := range keys {
+		entry, ok := l.keys[key]
+		if ok && entry.blockedUntil.Sub(now) > retryAfter {
+			retryAfter = entry.blockedUntil.Sub(now)
+		}
+	}
+	if retryAfter > 0 {
+		return &lockoutError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// fail counts a failed attempt for every key and blocks the ones that have failed too often
+func (l *limiter) fail(keys ...string) {
+	if l.maxFailures <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+	for _, key := range keys {
+		entry, ok := l.keys[key]
+		if !ok || now.Sub(entry.lastFailure) > l.lockout {
+			entry = &failures{}
+			l.keys[key] = entry
+		}
+		entry.count++
+		entry.lastFailure = now
+
+		switch {
+		case entry.count >= l.maxFailures:
+			entry.blockedUntil = now.Add(l.lockout)
+		case entry.count > l.free:
+			delay := backoffBase * time.Duration(math.Pow(2, float64(entry.count-l.free-1)))
+			if delay > l.lockout {
+				delay = l.lockout
+			}
+			entry.blockedUntil = now.Add(delay)
+		}
+	}
+}
+
+// reset forgets the failures of the key after a successful attempt
+func (l *limiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.keys, key)
+}
+
+// prune drops the keys that have not failed for the lockout duration, so that the counters don't pile up
+func (l *limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < limiterPruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for key, entry := range l.keys {
+		if now.Sub(entry.lastFailure) > l.lockout && now.After(entry.blockedUntil) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// Keys of the limiters, the failures of the passwords and of the second factor are counted apart,
+// so that knowing the password does not reset the failures of the codes
+func ipKey(ip string) string           { return "ip:" + ip }
+func passwordKey(login string) string  { return "password:" + login }
+func twoFactorKey(login string) string { return "2fa:" + login }
+
+// retryAfterSeconds returns the value of Retry-After header for the lockout rounded up to the whole seconds
+func retryAfterSeconds(lockout *lockoutError) string {
+	return strconv.Itoa(int(math.Ceil(lockout.RetryAfter.Seconds())))
+}
+
+// writeLockout responds with `429` and Retry-After header
+func writeLockout(w http.ResponseWriter, lockout *lockoutError) {
+	w.Header().Set("Retry-After", retryAfterSeconds(lockout))
+	http.Error(w, lockout.Error(), http.StatusTooManyRequests)
+}
+
+// limitAttempts is a middleware refusing the requests from the addresses blocked for too many failed attempts
+// before they get to check anything
+func (app *App) limitAttempts(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var lockout *lockoutError
+		if err := app.ipLimiter.check(ipKey(remoteIP(r.RemoteAddr))); errors.As(err, &lockout) {
+			writeLockout(w, lockout)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// checkCredentials checks the credentials of the user unless the address or the account is blocked
+// for too many failed attempts. Returns lockoutError if it is.
+// A wrong password is recorded to the audit log of the user as a failed login.
+func (app *App) checkCredentials(authDetails service.Authentication, ip string, ctx context.Context) error {
+	err := app.ipLimiter.check(ipKey(ip))
+	if err == nil {
+		err = app.accountLimiter.check(passwordKey(authDetails.Login))
+	}
+	if err != nil {
+		app.metrics.countLogin(loginStepPassword, loginLockout)
+		return err
+	}
+
+	err = app.UserStorage.CheckUserAuth(authDetails, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCredentials) {
+			app.ipLimiter.fail(ipKey(ip))
+			app.accountLimiter.fail(passwordKey(authDetails.Login))
+			app.metrics.countLogin(loginStepPassword, loginFailure)
+			app.auditLoginFailed(authDetails.Login, ctx)
+		}
+		return err
+	}
+	app.accountLimiter.reset(passwordKey(authDetails.Login))
+	app.metrics.countLogin(loginStepPassword, loginSuccess)
+	return nil
+}
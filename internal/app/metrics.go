@@ -0,0 +1,163 @@
+package app
+
+// Here are Prometheus metrics of the App, the ones of the database and gzip are registered globally
+// by storage and tools packages and are exposed along with these
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gophkeeper/internal/logging"
+	"gophkeeper/internal/storage"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// steps and results of the login attempts counted by metrics.logins
+const (
+	loginStepPassword  = "password"
+	loginStepTwoFactor = "2fa"
+	loginSuccess       = "success"
+	loginFailure       = "failure"
+	loginLockout       = "lockout"
+)
+
+// metrics holds the collectors of the App, they are registered in its own registry
+// so that several instances of the App could work in one process
+type metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	logins          *prometheus.CounterVec
+}
+
+// newMetrics creates the collectors of the App, the stored items are counted in the storage on every scrape
+func newMetrics(userStorage storage.UserStorage) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gophkeeper",
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of REST requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "gophkeeper",
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of REST requests by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "gophkeeper",
+			Name:      "logins_total",
+			Help:      "Login attempts by step, password or 2fa, and result.",
+		}, []string{"step", "result"}),
+	}
+	m.registry.MustRegister(m.requests, m.requestDuration, m.logins, &itemsCollector{userStorage: userStorage})
+	return m
+}
+
+// handler serves the metrics of the App along with the global ones, compression is left to tools.GzipMiddleware
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, m.registry},
+		promhttp.HandlerOpts{DisableCompression: true})
+}
+
+// countLogin counts a login attempt at the step with the result
+func (m *metrics) countLogin(step string, result string) {
+	m.logins.WithLabelValues(step, result).Inc()
+}
+
+// statusWriter remembers the status code written to the response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader remembers the status code and writes it
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the data, the status is 200 unless it has been written before
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush lets the streaming handlers flush through the writer
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Status returns the status code of the response, it is 200 unless another one has been written
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// unmatchedRoute is the route label of the requests matched to no route, the paths themselves
+// aren't used as labels, since anyone could make up as many of them as they like
+const unmatchedRoute = "unmatched"
+
+// routeTemplate returns the route constant the request has been matched to or unmatchedRoute if there is none
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return unmatchedRoute
+}
+
+// measureRequests is a middleware counting the requests and observing their latency by the route constants
+func (app *App) measureRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		writer := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(writer, r)
+
+		route := routeTemplate(r)
+		app.metrics.requests.WithLabelValues(route, r.Method, strconv.Itoa(writer.Status())).Inc()
+		app.metrics.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// itemsCollector reports the number of the stored secrets of every kind
+type itemsCollector struct {
+	userStorage storage.UserStorage
+}
+
+var storedItemsDesc = prometheus.NewDesc("gophkeeper_stored_items", "Number of stored secrets by type.",
+	[]string{"type"}, nil)
+
+// Describe implements prometheus.Collector
+func (c *itemsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- storedItemsDesc
+}
+
+// Collect implements prometheus.Collector, nothing is reported if the storage fails
+func (c *itemsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	counts, err := c.userStorage.CountItems(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("count stored items", "err", err)
+		return
+	}
+	for item, count := range counts {
+		ch <- prometheus.MustNewConstMetric(storedItemsDesc, prometheus.GaugeValue, float64(count), item)
+	}
+}
@@ -0,0 +1,25 @@
+package app
+
+// Here is the OpenAPI specification of the REST API. The client of cmd/cli is generated from it,
+// so every route, parameter, body and status code is to be described in openapi.json as well.
+
+import (
+	_ "embed"
+	"gophkeeper/internal/logging"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// openAPI handles sending the OpenAPI specification of the REST API.
+//
+// Returns:
+//   - `200` and the json document describing every route of the App
+func (app *App) openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(openAPISpec)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("send openapi spec", "err", err)
+	}
+}
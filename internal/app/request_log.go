@@ -0,0 +1,54 @@
+package app
+
+// Here is the access log of the App, every request gets an ID tying its log lines together
+
+import (
+	"context"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gophkeeper/internal/logging"
+	"net/http"
+	"time"
+)
+
+// logRequests is a middleware giving every request an ID, the one from X-Request-ID header if the client sends it.
+// The ID is sent back in the same header, the handlers log with it by logging.FromContext.
+// An access log line is written once the request is handled.
+func (app *App) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := logging.RequestID(r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, requestID)
+		logger := slog.Default().With("request_id", requestID)
+
+		start := time.Now()
+		writer := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(writer, r.WithContext(logging.NewContext(r.Context(), logger)))
+
+		logger.Info("request", "method", r.Method, "route", routeTemplate(r), "status", writer.Status(),
+			"duration", time.Since(start), "ip", remoteIP(r.RemoteAddr))
+	})
+}
+
+// grpcLogContext gives the call an ID just like logRequests does, the one from logging.RequestIDMetadata
+// if the client sends it. Returns the context holding the logger of the call.
+func grpcLogContext(ctx context.Context) context.Context {
+	var sent string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(logging.RequestIDMetadata); len(values) != 0 {
+		sent = values[0]
+	}
+	requestID := logging.RequestID(sent)
+	logger := slog.Default().With("request_id", requestID)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDMetadata, requestID)); err != nil {
+		logger.Warn("grpc set request id header", "err", err)
+	}
+	return logging.NewContext(ctx, logger)
+}
+
+// logCall writes the access log line of a gRPC call
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	logging.FromContext(ctx).Info("grpc request", "method", method, "code", status.Code(err).String(),
+		"duration", time.Since(start), "ip", grpcPeerIP(ctx))
+}
@@ -0,0 +1,70 @@
+package app
+
+// Here is TLS configuration shared by REST and gRPC servers
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/config"
+	"gophkeeper/internal/tools"
+	"log"
+	"net"
+)
+
+// tlsConfig builds TLS configuration of the servers from the config, it is nil if TLS is off.
+// The self-signed certificate is generated for the hosts of the servers along with localhost.
+func (app *App) tlsConfig() (*tls.Config, error) {
+	cfg := app.config
+	if cfg.TLSCertFile == "" && !cfg.TLSSelfSigned {
+		if cfg.TLSClientCAFile != "" {
+			return nil, errors.New("client certificates can't be required without TLS")
+		}
+		return nil, nil
+	}
+
+	certFile, keyFile := cfg.TLSCertFile, cfg.TLSKeyFile
+	if certFile == "" {
+		certFile = config.SelfSignedCertFile
+	}
+	if keyFile == "" {
+		keyFile = config.SelfSignedKeyFile
+	}
+	if cfg.TLSSelfSigned {
+		created, err := tools.EnsureSelfSignedCertificate(certFile, keyFile, serverHosts(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("generate self-signed certificate: %w", err)
+		}
+		if created {
+			log.Printf("self-signed certificate is written to %s, give it to the clients as the CA bundle", certFile)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if cfg.TLSClientCAFile != "" {
+		tlsConfig.ClientCAs, err = tools.LoadCertPool(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client CA: %w", err)
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// serverHosts returns the hosts the servers are listening on along with the local ones
+func serverHosts(cfg config.Config) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	for _, address := range []string{cfg.ServerAddress, cfg.GRPCAddress} {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil || host == "" || host == "localhost" || host == "127.0.0.1" || host == "::1" {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
@@ -0,0 +1,173 @@
+package app
+
+// Here are the access and refresh tokens used to authorize the users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"net"
+	"strings"
+	"time"
+)
+
+// tokenType is the only type of access tokens the App issues
+const tokenType = "Bearer"
+
+// errInvalidAccessToken is returned for access tokens that are malformed, forged or expired
+var errInvalidAccessToken = errors.New("invalid access token")
+
+// accessClaims are the claims of an access token, Family is the family of refresh tokens it was issued with
+type accessClaims struct {
+	jwt.RegisteredClaims
+	Family string `json:"sid"`
+}
+
+// loginKey and sessionKey are the context keys holding the login and the session of the authorized user
+type (
+	loginKey   struct{}
+	sessionKey struct{}
+)
+
+// loginFromContext returns the login of the authorized user put to the context by authorization
+func loginFromContext(ctx context.Context) string {
+	login, _ := ctx.Value(loginKey{}).(string)
+	return login
+}
+
+// sessionFromContext returns the ID of the session of the authorized user put to the context by authorization
+func sessionFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(sessionKey{}).(string)
+	return sessionID
+}
+
+// startSession registers a new session of the user on login and issues the first pair of tokens for it.
+// The ID of the session is the family of all the refresh tokens that are going to be issued to it.
+// The login is recorded to the audit log of the user.
+func (app *App) startSession(session service.Session, ctx context.Context) (service.Tokens, error) {
+	var err error
+	session.ID, err = tools.GenerateRandomString(16)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+	session.LastSeen = time.Now()
+	err = app.UserStorage.CreateSession(session, ctx)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+	app.auditLogin(session.Login, ctx)
+
+	refreshToken, err := tools.GenerateRandomString(32)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+	err = app.UserStorage.PutRefreshToken(service.RefreshToken{Login: session.Login, Family: session.ID,
+		TokenHash: tools.ChecksumBytes([]byte(refreshToken)), ExpiresAt: time.Now().Add(app.config.RefreshTokenTTL)}, ctx)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+	return app.signTokens(session.Login, session.ID, refreshToken)
+}
+
+// authorize validates the access token and checks that its session has not been revoked.
+// Returns the context holding the login and the session of the user.
+func (app *App) authorize(ctx context.Context, accessToken string, ip string) (context.Context, error) {
+	claims, err := app.parseAccessToken(accessToken)
+	if err != nil {
+		return ctx, err
+	}
+
+	err = app.UserStorage.CheckSession(service.Session{ID: claims.Family, Login: claims.Subject, IP: ip}, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			return ctx, errInvalidAccessToken
+		}
+		return ctx, err
+	}
+
+	ctx = context.WithValue(ctx, loginKey{}, claims.Subject)
+	return context.WithValue(ctx, sessionKey{}, claims.Family), nil
+}
+
+// rotateTokens exchanges a refresh token for a new pair of tokens, the refresh token can't be used again
+func (app *App) rotateTokens(refreshToken string, ctx context.Context) (service.Tokens, error) {
+	nextToken, err := tools.GenerateRandomString(32)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+
+	old := service.RefreshToken{TokenHash: tools.ChecksumBytes([]byte(refreshToken))}
+	next := service.RefreshToken{TokenHash: tools.ChecksumBytes([]byte(nextToken)),
+		ExpiresAt: time.Now().Add(app.config.RefreshTokenTTL)}
+	next, err = app.UserStorage.RotateRefreshToken(old, next, ctx)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+	return app.signTokens(next.Login, next.Family, nextToken)
+}
+
+// signTokens signs an access token for the user and pairs it with the refresh token
+func (app *App) signTokens(login string, family string, refreshToken string) (service.Tokens, error) {
+	now := time.Now()
+	claims := accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   login,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(app.config.AccessTokenTTL)),
+		},
+		Family: family,
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(app.tokenSecret)
+	if err != nil {
+		return service.Tokens{}, fmt.Errorf("sign access token: %w", err)
+	}
+
+	return service.Tokens{AccessToken: accessToken, RefreshToken: refreshToken, TokenType: tokenType,
+		ExpiresIn: int64(app.config.AccessTokenTTL.Seconds())}, nil
+}
+
+// parseAccessToken checks the signature and the expiry of an access token and returns its claims
+func (app *App) parseAccessToken(accessToken string) (accessClaims, error) {
+	var claims accessClaims
+	token, err := jwt.ParseWithClaims(accessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errInvalidAccessToken
+		}
+		return app.tokenSecret, nil
+	})
+	// challenge tokens are signed with the same secret, but they don't let anyone in
+	if err != nil || !token.Valid || claims.Subject == "" || len(claims.Audience) != 0 {
+		return claims, errInvalidAccessToken
+	}
+	return claims, nil
+}
+
+// bearerToken extracts the token from the value of `Authorization: Bearer <token>` header
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, tokenType) || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// remoteIP returns the IP address part of the remote address of a request
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// deviceName returns the device name sent by the user falling back to the user agent
+func deviceName(device string, userAgent string) string {
+	if device != "" {
+		return device
+	}
+	return userAgent
+}
@@ -0,0 +1,212 @@
+package app
+
+// Here is TOTP two-factor authentication shared by REST and gRPC APIs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/storage"
+	"gophkeeper/internal/tools"
+	"strings"
+	"time"
+)
+
+const (
+	// twoFactorIssuer names the App in the authenticator apps
+	twoFactorIssuer = "Gophkeeper"
+	// twoFactorRequired is the status of service.TwoFactorChallenge
+	twoFactorRequired = "2fa_required"
+	// challengeAudience tells the challenge tokens from the access tokens
+	challengeAudience = "2fa"
+	// challengeTTL is how long the user has to send the code after the password
+	challengeTTL = 5 * time.Minute
+	// recoveryCodeCount is the number of recovery codes issued at once
+	recoveryCodeCount = 10
+)
+
+// errInvalidChallenge is returned for challenge tokens that are malformed, forged or expired
+var errInvalidChallenge = errors.New("invalid challenge token")
+
+// challengeClaims are the claims of a challenge token, Device is the name of the session to be started
+type challengeClaims struct {
+	jwt.RegisteredClaims
+	Device string `json:"device,omitempty"`
+}
+
+// twoFactorEnabled tells if the user has to send a code after the password to sign in
+func (app *App) twoFactorEnabled(login string, ctx context.Context) (bool, error) {
+	twoFactor, err := app.UserStorage.GetTwoFactor(login, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrEmpty) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled, nil
+}
+
+// signChallenge signs a challenge token that lets the user whose password has been checked finish the login
+func (app *App) signChallenge(login string, device string) (service.TwoFactorChallenge, error) {
+	now := time.Now()
+	claims := challengeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   login,
+			Audience:  jwt.ClaimStrings{challengeAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(challengeTTL)),
+		},
+		Device: device,
+	}
+	challengeToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(app.tokenSecret)
+	if err != nil {
+		return service.TwoFactorChallenge{}, fmt.Errorf("sign challenge token: %w", err)
+	}
+	return service.TwoFactorChallenge{Status: twoFactorRequired, ChallengeToken: challengeToken,
+		ExpiresIn: int64(challengeTTL.Seconds())}, nil
+}
+
+// parseChallenge checks the signature and the expiry of a challenge token and returns its claims
+func (app *App) parseChallenge(challengeToken string) (challengeClaims, error) {
+	var claims challengeClaims
+	token, err := jwt.ParseWithClaims(challengeToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errInvalidChallenge
+		}
+		return app.tokenSecret, nil
+	})
+	if err != nil || !token.Valid || claims.Subject == "" || !claims.VerifyAudience(challengeAudience, true) {
+		return claims, errInvalidChallenge
+	}
+	return claims, nil
+}
+
+// finishLogin checks the code sent along with the challenge token and starts the session of the user.
+// The failed codes are limited just like the failed passwords, but they are counted apart.
+// The user is loaded again, storage.ErrAccountDisabled is returned if the account has been disabled since the password step.
+func (app *App) finishLogin(login service.TwoFactorLogin, ip string, ctx context.Context) (service.Tokens, error) {
+	err := app.ipLimiter.check(ipKey(ip))
+	if err != nil {
+		app.metrics.countLogin(loginStepTwoFactor, loginLockout)
+		return service.Tokens{}, err
+	}
+	claims, err := app.parseChallenge(login.ChallengeToken)
+	if err != nil {
+		app.ipLimiter.fail(ipKey(ip))
+		app.metrics.countLogin(loginStepTwoFactor, loginFailure)
+		return service.Tokens{}, err
+	}
+	err = app.accountLimiter.check(twoFactorKey(claims.Subject))
+	if err != nil {
+		app.metrics.countLogin(loginStepTwoFactor, loginLockout)
+		return service.Tokens{}, err
+	}
+
+	err = app.checkTwoFactorCode(claims.Subject, login.Code, ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidCode) || errors.Is(err, storage.ErrEmpty) {
+			app.ipLimiter.fail(ipKey(ip))
+			app.accountLimiter.fail(twoFactorKey(claims.Subject))
+			app.metrics.countLogin(loginStepTwoFactor, loginFailure)
+			app.auditLoginFailed(claims.Subject, ctx)
+		}
+		return service.Tokens{}, err
+	}
+	app.accountLimiter.reset(twoFactorKey(claims.Subject))
+
+	user, err := app.UserStorage.GetUser(claims.Subject, ctx)
+	if err != nil {
+		return service.Tokens{}, err
+	}
+	if user.Disabled {
+		app.metrics.countLogin(loginStepTwoFactor, loginFailure)
+		return service.Tokens{}, storage.ErrAccountDisabled
+	}
+	app.metrics.countLogin(loginStepTwoFactor, loginSuccess)
+	return app.startSession(service.Session{Login: claims.Subject, Device: claims.Device, IP: ip}, ctx)
+}
+
+// enrollTOTP generates a new TOTP secret for the user, it has to be verified to enable two-factor authentication.
+// Returns storage.ErrAlreadyExists if two-factor authentication is already enabled.
+func (app *App) enrollTOTP(login string, ctx context.Context) (service.TOTPEnrollment, error) {
+	secret, err := tools.GenerateTOTPSecret()
+	if err != nil {
+		return service.TOTPEnrollment{}, err
+	}
+	err = app.UserStorage.PutTwoFactor(service.TwoFactor{Login: login, Secret: secret}, ctx)
+	if err != nil {
+		return service.TOTPEnrollment{}, err
+	}
+	return service.TOTPEnrollment{Secret: secret, URI: tools.TOTPURI(twoFactorIssuer, login, secret)}, nil
+}
+
+// verifyTOTP enables two-factor authentication of the user once the code proves the authenticator is set up.
+// Returns the recovery codes, they are shown to the user only once.
+func (app *App) verifyTOTP(login string, code string, ctx context.Context) ([]string, error) {
+	twoFactor, err := app.UserStorage.GetTwoFactor(login, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, storage.ErrAlreadyExists
+	}
+	step, ok := tools.ValidateTOTP(twoFactor.Secret, normalizeCode(code), time.Now())
+	if !ok {
+		return nil, storage.ErrInvalidCode
+	}
+
+	var recoveryCodes []string
+	var codes []service.RecoveryCode
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := tools.GenerateRandomString(5)
+		if err != nil {
+			return nil, err
+		}
+		recoveryCodes = append(recoveryCodes, code[:5]+"-"+code[5:])
+		codes = append(codes, service.RecoveryCode{Login: login, CodeHash: tools.ChecksumBytes([]byte(code))})
+	}
+
+	twoFactor.LastStep = step
+	err = app.UserStorage.EnableTwoFactor(twoFactor, codes, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// disableTOTP turns two-factor authentication of the user off, a valid code is required to do so
+func (app *App) disableTOTP(login string, code string, ctx context.Context) error {
+	err := app.checkTwoFactorCode(login, code, ctx)
+	if err != nil {
+		return err
+	}
+	return app.UserStorage.DeleteTwoFactor(login, ctx)
+}
+
+// checkTwoFactorCode accepts either TOTP code or a recovery code of the user, neither can be used twice.
+// Returns storage.ErrEmpty if two-factor authentication is not enabled and storage.ErrInvalidCode if the code is wrong.
+func (app *App) checkTwoFactorCode(login string, code string, ctx context.Context) error {
+	twoFactor, err := app.UserStorage.GetTwoFactor(login, ctx)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled {
+		return storage.ErrEmpty
+	}
+
+	code = normalizeCode(code)
+	if step, ok := tools.ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		twoFactor.LastStep = step
+		return app.UserStorage.UseTOTPStep(twoFactor, ctx)
+	}
+	return app.UserStorage.UseRecoveryCode(service.RecoveryCode{Login: login,
+		CodeHash: tools.ChecksumBytes([]byte(code))}, ctx)
+}
+
+// normalizeCode drops the spaces and dashes users tend to type along with the codes
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
@@ -0,0 +1,163 @@
+// Package logging holds structured logging of Gophkeeper: JSON lines tied to the requests by their IDs.
+// The secrets are masked in the logged values by `log:"redact"` tags of their struct fields.
+package logging
+
+import (
+	"context"
+	"golang.org/x/exp/slog"
+	"gophkeeper/internal/tools"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Keys the request ID is passed in, the header of REST requests and the metadata of gRPC calls
+const (
+	RequestIDHeader   = "X-Request-ID"
+	RequestIDMetadata = "x-request-id"
+)
+
+const (
+	// redactTag is the struct tag marking the fields to be masked, its value is to be redactTagValue
+	redactTag      = "log"
+	redactTagValue = "redact"
+	// redacted is logged in place of the masked fields
+	redacted = "[REDACTED]"
+	// maxRequestIDLength limits the IDs of the requests accepted from the clients
+	maxRequestIDLength = 64
+)
+
+// contextKey is the key of the logger in the context
+type contextKey struct{}
+
+// New creates a logger writing JSON lines of the level and above, the logged values are redacted
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.HandlerOptions{Level: level, ReplaceAttr: ReplaceAttr}.NewJSONHandler(w))
+}
+
+// ReplaceAttr masks the fields tagged with `log:"redact"` in the values of the attributes
+func ReplaceAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindAny {
+		attr.Value = slog.AnyValue(Redact(attr.Value.Any()))
+	}
+	return attr
+}
+
+// NewContext returns a copy of the context holding the logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of the request the context belongs to or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID sent by the client if it is safe to be logged or a new random one otherwise
+func RequestID(sent string) string {
+	if sent != "" && len(sent) <= maxRequestIDLength {
+		safe := true
+		for _, c := range sent {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+				safe = false
+				break
+			}
+		}
+		if safe {
+			return sent
+		}
+	}
+	requestID, err := tools.GenerateRandomString(8)
+	if err != nil {
+		return "unknown"
+	}
+	return requestID
+}
+
+// Redact returns the value with the fields tagged with `log:"redact"` masked, structs holding such fields
+// are turned into maps of their exported fields. Values without the secrets are returned as is.
+func Redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if _, ok := value.(error); ok {
+		return value
+	}
+	v := reflect.ValueOf(value)
+	if !hasSecrets(v.Type()) {
+		return value
+	}
+	return redactValue(v)
+}
+
+// redactValue walks the value the type of which has secrets
+func redactValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = Redact(v.Index(i).Interface())
+		}
+		return items
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get(redactTag) == redactTagValue {
+				fields[field.Name] = redacted
+				continue
+			}
+			fields[field.Name] = Redact(v.Field(i).Interface())
+		}
+		return fields
+	}
+	return v.Interface()
+}
+
+// secretTypes caches whether the types hold the fields to be masked
+var secretTypes sync.Map
+
+// hasSecrets tells if the values of the type may hold the fields to be masked
+func hasSecrets(t reflect.Type) bool {
+	if cached, ok := secretTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	secret := walkSecrets(t, make(map[reflect.Type]bool))
+	secretTypes.Store(t, secret)
+	return secret
+}
+
+// walkSecrets looks for the fields to be masked in the type, visited types are skipped so that recursive types end
+func walkSecrets(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return walkSecrets(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.IsExported() && (field.Tag.Get(redactTag) == redactTagValue || walkSecrets(field.Type, visited)) {
+				return true
+			}
+		}
+	}
+	return false
+}
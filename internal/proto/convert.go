@@ -0,0 +1,477 @@
+// Package proto holds gRPC API of Gophkeeper secrets manager generated from gophkeeper.proto
+// along with the converters between its messages and the service types.
+package proto
+
+//go:generate buf generate
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+)
+
+const (
+	// AuthorizationMetadata is the metadata key holding the access token of an authorized user as "Bearer <token>"
+	AuthorizationMetadata = "authorization"
+	// RetryAfterMetadata is the header key holding the number of seconds to wait before retrying
+	// the call refused with ResourceExhausted for too many failed attempts
+	RetryAfterMetadata = "retry-after"
+	// ETagMetadata is the header key holding the revision an entry is stored at by the call storing or deleting it
+	ETagMetadata = "etag"
+)
+
+// fromModel converts gorm.Model of an entry to Meta
+func fromModel(model gorm.Model, revision int64, overwrite bool, collection uint) *Meta {
+	meta := &Meta{Id: uint64(model.ID), UpdatedAt: timestamppb.New(model.UpdatedAt), Overwrite: overwrite,
+		Revision: revision, CollectionId: uint64(collection)}
+	if model.DeletedAt.Valid {
+		meta.DeletedAt = timestamppb.New(model.DeletedAt.Time)
+	}
+	return meta
+}
+
+// toModel converts Meta to gorm.Model of an entry, the tombstones keep their DeletedAt
+func toModel(meta *Meta) gorm.Model {
+	var model gorm.Model
+	if meta == nil {
+		return model
+	}
+	model.ID = uint(meta.GetId())
+	if meta.GetUpdatedAt() != nil {
+		model.UpdatedAt = meta.GetUpdatedAt().AsTime().Local()
+	}
+	if meta.GetDeletedAt() != nil {
+		model.DeletedAt = gorm.DeletedAt{Time: meta.GetDeletedAt().AsTime().Local(), Valid: true}
+	}
+	return model
+}
+
+// FromLogoPass converts service.LogoPass to its message
+func FromLogoPass(logoPass service.LogoPass) *LogoPass {
+	return &LogoPass{Meta: fromModel(logoPass.Model, logoPass.Revision, logoPass.Overwrite, logoPass.CollectionID),
+		SecretLogin: logoPass.SecretLogin, Secret: logoPass.SecretPass, Description: logoPass.Description}
+}
+
+// ToLogoPass converts the message to service.LogoPass
+func ToLogoPass(logoPass *LogoPass) service.LogoPass {
+	return service.LogoPass{Model: toModel(logoPass.GetMeta()), SecretLogin: logoPass.GetSecretLogin(),
+		SecretPass: logoPass.GetSecret(), Description: logoPass.GetDescription(),
+		Overwrite: logoPass.GetMeta().GetOverwrite(), Revision: logoPass.GetMeta().GetRevision(),
+		CollectionID: uint(logoPass.GetMeta().GetCollectionId())}
+}
+
+// FromTextData converts service.TextData to its message
+func FromTextData(text service.TextData) *TextData {
+	return &TextData{Meta: fromModel(text.Model, text.Revision, text.Overwrite, text.CollectionID), Data: text.Text,
+		Description: text.Description}
+}
+
+// ToTextData converts the message to service.TextData
+func ToTextData(text *TextData) service.TextData {
+	return service.TextData{Model: toModel(text.GetMeta()), Text: text.GetData(), Description: text.GetDescription(),
+		Overwrite: text.GetMeta().GetOverwrite(), Revision: text.GetMeta().GetRevision(),
+		CollectionID: uint(text.GetMeta().GetCollectionId())}
+}
+
+// FromCreditCard converts service.CreditCard to its message
+func FromCreditCard(card service.CreditCard) *CreditCard {
+	return &CreditCard{Meta: fromModel(card.Model, card.Revision, card.Overwrite, card.CollectionID), Number: card.Number,
+		Holder: card.Holder, DueDate: card.DueDate, Cvv: card.CVV, Description: card.Description}
+}
+
+// ToCreditCard converts the message to service.CreditCard
+func ToCreditCard(card *CreditCard) service.CreditCard {
+	return service.CreditCard{Model: toModel(card.GetMeta()), Number: card.GetNumber(), Holder: card.GetHolder(),
+		DueDate: card.GetDueDate(), CVV: card.GetCvv(), Description: card.GetDescription(),
+		Overwrite: card.GetMeta().GetOverwrite(), Revision: card.GetMeta().GetRevision(),
+		CollectionID: uint(card.GetMeta().GetCollectionId())}
+}
+
+// FromBinaryData converts service.BinaryData to its message
+func FromBinaryData(binary service.BinaryData) *BinaryData {
+	return &BinaryData{Meta: fromModel(binary.Model, binary.Revision, binary.Overwrite, binary.CollectionID), Binary: binary.Binary,
+		Description: binary.Description, Size: binary.Size, ChunkSize: binary.ChunkSize,
+		ChunkCount: int32(binary.ChunkCount)}
+}
+
+// ToBinaryData converts the message to service.BinaryData
+func ToBinaryData(binary *BinaryData) service.BinaryData {
+	return service.BinaryData{Model: toModel(binary.GetMeta()), Binary: binary.GetBinary(),
+		Description: binary.GetDescription(), Size: binary.GetSize(), ChunkSize: binary.GetChunkSize(),
+		ChunkCount: int(binary.GetChunkCount()), Overwrite: binary.GetMeta().GetOverwrite(),
+		Revision: binary.GetMeta().GetRevision(), CollectionID: uint(binary.GetMeta().GetCollectionId())}
+}
+
+// FromSyncData converts service.SyncData to its message
+func FromSyncData(changes service.SyncData) *SyncData {
+	result := &SyncData{Cursor: changes.Cursor, Complete: changes.Complete}
+	for _, logoPass := range changes.LogoPasses {
+		result.LogoPasses = append(result.LogoPasses, FromLogoPass(logoPass))
+	}
+	for _, text := range changes.Texts {
+		result.Texts = append(result.Texts, FromTextData(text))
+	}
+	for _, card := range changes.CreditCards {
+		result.CreditCards = append(result.CreditCards, FromCreditCard(card))
+	}
+	for _, binary := range changes.Binaries {
+		result.Binaries = append(result.Binaries, FromBinaryData(binary))
+	}
+	return result
+}
+
+// ToSyncData converts the message to service.SyncData
+func ToSyncData(changes *SyncData) service.SyncData {
+	result := service.SyncData{Cursor: changes.GetCursor(), Complete: changes.GetComplete()}
+	for _, logoPass := range changes.GetLogoPasses() {
+		result.LogoPasses = append(result.LogoPasses, ToLogoPass(logoPass))
+	}
+	for _, text := range changes.GetTexts() {
+		result.Texts = append(result.Texts, ToTextData(text))
+	}
+	for _, card := range changes.GetCreditCards() {
+		result.CreditCards = append(result.CreditCards, ToCreditCard(card))
+	}
+	for _, binary := range changes.GetBinaries() {
+		result.Binaries = append(result.Binaries, ToBinaryData(binary))
+	}
+	return result
+}
+
+// FromPasswordChange converts service.PasswordChange to its message
+func FromPasswordChange(change service.PasswordChange) *PasswordChange {
+	result := &PasswordChange{OldPassword: change.OldPassword, NewPassword: change.NewPassword,
+		Cursor: change.Cursor, Uploads: change.Uploads, PrivateKey: change.PrivateKey}
+	for _, logoPass := range change.LogoPasses {
+		result.LogoPasses = append(result.LogoPasses, FromLogoPass(logoPass))
+	}
+	for _, text := range change.Texts {
+		result.Texts = append(result.Texts, FromTextData(text))
+	}
+	for _, card := range change.CreditCards {
+		result.CreditCards = append(result.CreditCards, FromCreditCard(card))
+	}
+	for _, binary := range change.Binaries {
+		result.Binaries = append(result.Binaries, FromBinaryData(binary))
+	}
+	for _, version := range change.Versions {
+		result.Versions = append(result.Versions, FromSecretVersion(version))
+	}
+	return result
+}
+
+// ToPasswordChange converts the message to service.PasswordChange
+func ToPasswordChange(change *PasswordChange) service.PasswordChange {
+	result := service.PasswordChange{OldPassword: change.GetOldPassword(), NewPassword: change.GetNewPassword(),
+		Cursor: change.GetCursor(), Uploads: change.GetUploads(), PrivateKey: change.GetPrivateKey()}
+	for _, logoPass := range change.GetLogoPasses() {
+		result.LogoPasses = append(result.LogoPasses, ToLogoPass(logoPass))
+	}
+	for _, text := range change.GetTexts() {
+		result.Texts = append(result.Texts, ToTextData(text))
+	}
+	for _, card := range change.GetCreditCards() {
+		result.CreditCards = append(result.CreditCards, ToCreditCard(card))
+	}
+	for _, binary := range change.GetBinaries() {
+		result.Binaries = append(result.Binaries, ToBinaryData(binary))
+	}
+	for _, version := range change.GetVersions() {
+		result.Versions = append(result.Versions, ToSecretVersion(version))
+	}
+	return result
+}
+
+// FromSecretVersion converts service.SecretVersion to its message
+func FromSecretVersion(version service.SecretVersion) *SecretVersion {
+	result := &SecretVersion{VersionId: uint64(version.ID), Item: version.Item, ItemId: uint64(version.ItemID),
+		CreatedAt: timestamppb.New(version.CreatedAt), CollectionId: uint64(version.CollectionID),
+		SecretRevision: version.SecretRevision}
+	if version.LogoPass != nil {
+		result.LogoPass = FromLogoPass(*version.LogoPass)
+	}
+	if version.Text != nil {
+		result.Text = FromTextData(*version.Text)
+	}
+	if version.CreditCard != nil {
+		result.CreditCard = FromCreditCard(*version.CreditCard)
+	}
+	return result
+}
+
+// ToSecretVersion converts the message to service.SecretVersion
+func ToSecretVersion(version *SecretVersion) service.SecretVersion {
+	result := service.SecretVersion{ID: uint(version.GetVersionId()), Item: version.GetItem(),
+		ItemID: uint(version.GetItemId()), CollectionID: uint(version.GetCollectionId()),
+		SecretRevision: version.GetSecretRevision()}
+	if version.GetLogoPass() != nil {
+		logoPass := ToLogoPass(version.GetLogoPass())
+		result.LogoPass = &logoPass
+	}
+	if version.GetText() != nil {
+		text := ToTextData(version.GetText())
+		result.Text = &text
+	}
+	if version.GetCreditCard() != nil {
+		card := ToCreditCard(version.GetCreditCard())
+		result.CreditCard = &card
+	}
+	if version.GetCreatedAt() != nil {
+		result.CreatedAt = version.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromKeyPair converts service.KeyPair to its message
+func FromKeyPair(keys service.KeyPair) *KeyPair {
+	return &KeyPair{PublicKey: keys.PublicKey, PrivateKey: keys.PrivateKey, UpdatedAt: timestamppb.New(keys.UpdatedAt)}
+}
+
+// ToKeyPair converts the message to service.KeyPair
+func ToKeyPair(keys *KeyPair) service.KeyPair {
+	result := service.KeyPair{PublicKey: keys.GetPublicKey(), PrivateKey: keys.GetPrivateKey()}
+	if keys.GetUpdatedAt() != nil {
+		result.UpdatedAt = keys.GetUpdatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromShare converts service.Share to its message
+func FromShare(share service.Share) *Share {
+	result := &Share{ShareId: uint64(share.ID), Owner: share.Owner, Recipient: share.Recipient, Item: share.Item,
+		ItemId: uint64(share.ItemID), Access: share.Access, Accepted: share.Accepted, Revision: share.Revision,
+		Pending: share.Pending, CreatedAt: timestamppb.New(share.CreatedAt)}
+	if share.LogoPass != nil {
+		result.LogoPass = FromLogoPass(*share.LogoPass)
+	}
+	if share.Text != nil {
+		result.Text = FromTextData(*share.Text)
+	}
+	if share.CreditCard != nil {
+		result.CreditCard = FromCreditCard(*share.CreditCard)
+	}
+	return result
+}
+
+// ToShare converts the message to service.Share
+func ToShare(share *Share) service.Share {
+	result := service.Share{ID: uint(share.GetShareId()), Owner: share.GetOwner(), Recipient: share.GetRecipient(),
+		Item: share.GetItem(), ItemID: uint(share.GetItemId()), Access: share.GetAccess(),
+		Accepted: share.GetAccepted(), Revision: share.GetRevision(), Pending: share.GetPending()}
+	if share.GetLogoPass() != nil {
+		logoPass := ToLogoPass(share.GetLogoPass())
+		result.LogoPass = &logoPass
+	}
+	if share.GetText() != nil {
+		text := ToTextData(share.GetText())
+		result.Text = &text
+	}
+	if share.GetCreditCard() != nil {
+		card := ToCreditCard(share.GetCreditCard())
+		result.CreditCard = &card
+	}
+	if share.GetCreatedAt() != nil {
+		result.CreatedAt = share.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromChangeEvent converts service.ChangeEvent to its message
+func FromChangeEvent(event service.ChangeEvent) *ChangeEvent {
+	return &ChangeEvent{Item: event.Item, CollectionId: uint64(event.CollectionID)}
+}
+
+// ToChangeEvent converts the message to service.ChangeEvent
+func ToChangeEvent(event *ChangeEvent) service.ChangeEvent {
+	return service.ChangeEvent{Item: event.GetItem(), CollectionID: uint(event.GetCollectionId())}
+}
+
+// FromUsage converts service.Usage to its message
+func FromUsage(usage service.Usage) *Usage {
+	return &Usage{Bytes: usage.Bytes, Items: usage.Items, MaxBytes: usage.MaxBytes,
+		MaxBinarySize: usage.MaxBinarySize, MaxItems: usage.MaxItems}
+}
+
+// ToUsage converts the message to service.Usage
+func ToUsage(usage *Usage) service.Usage {
+	return service.Usage{Bytes: usage.GetBytes(), Items: usage.GetItems(), MaxBytes: usage.GetMaxBytes(),
+		MaxBinarySize: usage.GetMaxBinarySize(), MaxItems: usage.GetMaxItems()}
+}
+
+// FromAuditPage converts service.AuditPage to its message
+func FromAuditPage(page service.AuditPage) *AuditPage {
+	events := make([]*AuditEvent, 0, len(page.Events))
+	for _, event := range page.Events {
+		events = append(events, &AuditEvent{Id: uint64(event.ID), Action: event.Action, Item: event.Item,
+			ItemId: uint64(event.ItemID), Description: event.Description, Ip: event.IP, UserAgent: event.UserAgent,
+			CreatedAt: timestamppb.New(event.CreatedAt), CollectionId: uint64(event.CollectionID)})
+	}
+	return &AuditPage{Events: events, Next: page.Next}
+}
+
+// ToAuditPage converts the message to service.AuditPage
+func ToAuditPage(page *AuditPage) service.AuditPage {
+	events := make([]service.AuditEvent, 0, len(page.GetEvents()))
+	for _, event := range page.GetEvents() {
+		result := service.AuditEvent{ID: uint(event.GetId()), Action: event.GetAction(), Item: event.GetItem(),
+			ItemID: uint(event.GetItemId()), Description: event.GetDescription(), IP: event.GetIp(),
+			UserAgent: event.GetUserAgent(), CollectionID: uint(event.GetCollectionId())}
+		if event.GetCreatedAt() != nil {
+			result.CreatedAt = event.GetCreatedAt().AsTime().Local()
+		}
+		events = append(events, result)
+	}
+	return service.AuditPage{Events: events, Next: page.GetNext()}
+}
+
+// FromUserSummary converts service.UserSummary to its message
+func FromUserSummary(user service.UserSummary) *UserSummary {
+	return &UserSummary{Login: user.Login, Role: user.Role, Disabled: user.Disabled,
+		CreatedAt: timestamppb.New(user.CreatedAt), Usage: FromUsage(user.Usage)}
+}
+
+// ToUserSummary converts the message to service.UserSummary
+func ToUserSummary(user *UserSummary) service.UserSummary {
+	return service.UserSummary{Login: user.GetLogin(), Role: user.GetRole(), Disabled: user.GetDisabled(),
+		CreatedAt: user.GetCreatedAt().AsTime(), Usage: ToUsage(user.GetUsage())}
+}
+
+// FromUploadSession converts service.UploadSession to its message
+func FromUploadSession(session service.UploadSession) *UploadSession {
+	received := make([]int32, 0, len(session.Received))
+	for _, part := range session.Received {
+		received = append(received, int32(part))
+	}
+	return &UploadSession{UploadId: session.ID, Description: session.Description,
+		ChunkCount: int32(session.ChunkCount), Overwrite: session.Overwrite,
+		UpdatedAt: timestamppb.New(session.UpdatedAt), Received: received, Revision: session.Revision,
+		CollectionId: uint64(session.CollectionID)}
+}
+
+// ToUploadSession converts the message to service.UploadSession
+func ToUploadSession(session *UploadSession) service.UploadSession {
+	received := make([]int, 0, len(session.GetReceived()))
+	for _, part := range session.GetReceived() {
+		received = append(received, int(part))
+	}
+	result := service.UploadSession{ID: session.GetUploadId(), Description: session.GetDescription(),
+		ChunkCount: int(session.GetChunkCount()), Overwrite: session.GetOverwrite(), Received: received,
+		Revision: session.GetRevision(), CollectionID: uint(session.GetCollectionId())}
+	if session.GetUpdatedAt() != nil {
+		result.UpdatedAt = session.GetUpdatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromTokens converts service.Tokens to its message
+func FromTokens(tokens service.Tokens) *Tokens {
+	return &Tokens{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken, TokenType: tokens.TokenType,
+		ExpiresIn: tokens.ExpiresIn}
+}
+
+// ToTokens converts the message to service.Tokens
+func ToTokens(tokens *Tokens) service.Tokens {
+	return service.Tokens{AccessToken: tokens.GetAccessToken(), RefreshToken: tokens.GetRefreshToken(),
+		TokenType: tokens.GetTokenType(), ExpiresIn: tokens.GetExpiresIn()}
+}
+
+// FromSession converts service.Session to its message
+func FromSession(session service.Session) *Session {
+	return &Session{SessionId: session.ID, Device: session.Device, Ip: session.IP,
+		LastSeen: timestamppb.New(session.LastSeen), CreatedAt: timestamppb.New(session.CreatedAt),
+		Current: session.Current}
+}
+
+// ToSession converts the message to service.Session
+func ToSession(session *Session) service.Session {
+	result := service.Session{ID: session.GetSessionId(), Device: session.GetDevice(), IP: session.GetIp(),
+		Current: session.GetCurrent()}
+	if session.GetLastSeen() != nil {
+		result.LastSeen = session.GetLastSeen().AsTime().Local()
+	}
+	if session.GetCreatedAt() != nil {
+		result.CreatedAt = session.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromOrganization converts service.Organization to its message
+func FromOrganization(org service.Organization) *Organization {
+	result := &Organization{OrgId: uint64(org.ID), Name: org.Name, Role: org.Role, OrgKey: org.OrgKey,
+		CreatedAt: timestamppb.New(org.CreatedAt)}
+	for _, member := range org.Members {
+		result.Members = append(result.Members, FromOrgMember(member))
+	}
+	for _, collection := range org.Collections {
+		result.Collections = append(result.Collections, FromCollection(collection))
+	}
+	return result
+}
+
+// ToOrganization converts the message to service.Organization
+func ToOrganization(org *Organization) service.Organization {
+	result := service.Organization{ID: uint(org.GetOrgId()), Name: org.GetName(), Role: org.GetRole(),
+		OrgKey: org.GetOrgKey()}
+	for _, member := range org.GetMembers() {
+		result.Members = append(result.Members, ToOrgMember(member))
+	}
+	for _, collection := range org.GetCollections() {
+		result.Collections = append(result.Collections, ToCollection(collection))
+	}
+	if org.GetCreatedAt() != nil {
+		result.CreatedAt = org.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromOrgMember converts service.OrgMember to its message
+func FromOrgMember(member service.OrgMember) *OrgMember {
+	return &OrgMember{OrgId: uint64(member.OrgID), Login: member.Login, Role: member.Role, OrgKey: member.OrgKey,
+		CreatedAt: timestamppb.New(member.CreatedAt)}
+}
+
+// ToOrgMember converts the message to service.OrgMember
+func ToOrgMember(member *OrgMember) service.OrgMember {
+	result := service.OrgMember{OrgID: uint(member.GetOrgId()), Login: member.GetLogin(), Role: member.GetRole(),
+		OrgKey: member.GetOrgKey()}
+	if member.GetCreatedAt() != nil {
+		result.CreatedAt = member.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromCollection converts service.Collection to its message
+func FromCollection(collection service.Collection) *Collection {
+	return &Collection{CollectionId: uint64(collection.ID), OrgId: uint64(collection.OrgID), Name: collection.Name,
+		Members: collection.Members, CreatedAt: timestamppb.New(collection.CreatedAt)}
+}
+
+// ToCollection converts the message to service.Collection
+func ToCollection(collection *Collection) service.Collection {
+	result := service.Collection{ID: uint(collection.GetCollectionId()), OrgID: uint(collection.GetOrgId()),
+		Name: collection.GetName(), Members: collection.GetMembers()}
+	if collection.GetCreatedAt() != nil {
+		result.CreatedAt = collection.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
+
+// FromSend converts service.Send to its message
+func FromSend(send service.Send) *Send {
+	return &Send{SendId: send.ID, Item: send.Item, Name: send.Name, Data: send.Data, MaxViews: int32(send.MaxViews),
+		Views: int32(send.Views), ExpiresIn: send.ExpiresIn, ExpiresAt: timestamppb.New(send.ExpiresAt),
+		CreatedAt: timestamppb.New(send.CreatedAt)}
+}
+
+// ToSend converts the message to service.Send
+func ToSend(send *Send) service.Send {
+	result := service.Send{ID: send.GetSendId(), Item: send.GetItem(), Name: send.GetName(), Data: send.GetData(),
+		MaxViews: int(send.GetMaxViews()), Views: int(send.GetViews()), ExpiresIn: send.GetExpiresIn()}
+	if send.GetExpiresAt() != nil {
+		result.ExpiresAt = send.GetExpiresAt().AsTime().Local()
+	}
+	if send.GetCreatedAt() != nil {
+		result.CreatedAt = send.GetCreatedAt().AsTime().Local()
+	}
+	return result
+}
@@ -0,0 +1,2530 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: gophkeeper.proto
+
+// gRPC API of Gophkeeper secrets manager.
+// It provides the same operations as the REST API of the App.
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Keeper_Register_FullMethodName               = "/gophkeeper.Keeper/Register"
+	Keeper_Login_FullMethodName                  = "/gophkeeper.Keeper/Login"
+	Keeper_LoginTwoFactor_FullMethodName         = "/gophkeeper.Keeper/LoginTwoFactor"
+	Keeper_RefreshToken_FullMethodName           = "/gophkeeper.Keeper/RefreshToken"
+	Keeper_Logout_FullMethodName                 = "/gophkeeper.Keeper/Logout"
+	Keeper_ChangePassword_FullMethodName         = "/gophkeeper.Keeper/ChangePassword"
+	Keeper_ExportVault_FullMethodName            = "/gophkeeper.Keeper/ExportVault"
+	Keeper_DeleteAccount_FullMethodName          = "/gophkeeper.Keeper/DeleteAccount"
+	Keeper_GetSessions_FullMethodName            = "/gophkeeper.Keeper/GetSessions"
+	Keeper_RevokeSession_FullMethodName          = "/gophkeeper.Keeper/RevokeSession"
+	Keeper_EnrollTwoFactor_FullMethodName        = "/gophkeeper.Keeper/EnrollTwoFactor"
+	Keeper_VerifyTwoFactor_FullMethodName        = "/gophkeeper.Keeper/VerifyTwoFactor"
+	Keeper_DisableTwoFactor_FullMethodName       = "/gophkeeper.Keeper/DisableTwoFactor"
+	Keeper_PutLogoPass_FullMethodName            = "/gophkeeper.Keeper/PutLogoPass"
+	Keeper_GetLogoPasses_FullMethodName          = "/gophkeeper.Keeper/GetLogoPasses"
+	Keeper_DeleteLogoPass_FullMethodName         = "/gophkeeper.Keeper/DeleteLogoPass"
+	Keeper_PutText_FullMethodName                = "/gophkeeper.Keeper/PutText"
+	Keeper_GetTexts_FullMethodName               = "/gophkeeper.Keeper/GetTexts"
+	Keeper_DeleteText_FullMethodName             = "/gophkeeper.Keeper/DeleteText"
+	Keeper_PutCreditCard_FullMethodName          = "/gophkeeper.Keeper/PutCreditCard"
+	Keeper_GetCreditCards_FullMethodName         = "/gophkeeper.Keeper/GetCreditCards"
+	Keeper_DeleteCreditCard_FullMethodName       = "/gophkeeper.Keeper/DeleteCreditCard"
+	Keeper_PutBinary_FullMethodName              = "/gophkeeper.Keeper/PutBinary"
+	Keeper_GetBinaryList_FullMethodName          = "/gophkeeper.Keeper/GetBinaryList"
+	Keeper_GetBinary_FullMethodName              = "/gophkeeper.Keeper/GetBinary"
+	Keeper_DeleteBinary_FullMethodName           = "/gophkeeper.Keeper/DeleteBinary"
+	Keeper_StartBinaryUpload_FullMethodName      = "/gophkeeper.Keeper/StartBinaryUpload"
+	Keeper_GetBinaryUpload_FullMethodName        = "/gophkeeper.Keeper/GetBinaryUpload"
+	Keeper_UploadBinaryChunk_FullMethodName      = "/gophkeeper.Keeper/UploadBinaryChunk"
+	Keeper_CompleteBinaryUpload_FullMethodName   = "/gophkeeper.Keeper/CompleteBinaryUpload"
+	Keeper_DownloadBinary_FullMethodName         = "/gophkeeper.Keeper/DownloadBinary"
+	Keeper_Sync_FullMethodName                   = "/gophkeeper.Keeper/Sync"
+	Keeper_Events_FullMethodName                 = "/gophkeeper.Keeper/Events"
+	Keeper_GetUsage_FullMethodName               = "/gophkeeper.Keeper/GetUsage"
+	Keeper_GetAuditLog_FullMethodName            = "/gophkeeper.Keeper/GetAuditLog"
+	Keeper_GetHistory_FullMethodName             = "/gophkeeper.Keeper/GetHistory"
+	Keeper_RestoreVersion_FullMethodName         = "/gophkeeper.Keeper/RestoreVersion"
+	Keeper_GetKeyPair_FullMethodName             = "/gophkeeper.Keeper/GetKeyPair"
+	Keeper_PutKeyPair_FullMethodName             = "/gophkeeper.Keeper/PutKeyPair"
+	Keeper_GetShares_FullMethodName              = "/gophkeeper.Keeper/GetShares"
+	Keeper_PutShare_FullMethodName               = "/gophkeeper.Keeper/PutShare"
+	Keeper_AcceptShare_FullMethodName            = "/gophkeeper.Keeper/AcceptShare"
+	Keeper_ChangeShare_FullMethodName            = "/gophkeeper.Keeper/ChangeShare"
+	Keeper_DeleteShare_FullMethodName            = "/gophkeeper.Keeper/DeleteShare"
+	Keeper_GetOrgs_FullMethodName                = "/gophkeeper.Keeper/GetOrgs"
+	Keeper_CreateOrg_FullMethodName              = "/gophkeeper.Keeper/CreateOrg"
+	Keeper_DeleteOrg_FullMethodName              = "/gophkeeper.Keeper/DeleteOrg"
+	Keeper_PutMember_FullMethodName              = "/gophkeeper.Keeper/PutMember"
+	Keeper_DeleteMember_FullMethodName           = "/gophkeeper.Keeper/DeleteMember"
+	Keeper_PutCollection_FullMethodName          = "/gophkeeper.Keeper/PutCollection"
+	Keeper_DeleteCollection_FullMethodName       = "/gophkeeper.Keeper/DeleteCollection"
+	Keeper_PutCollectionMember_FullMethodName    = "/gophkeeper.Keeper/PutCollectionMember"
+	Keeper_DeleteCollectionMember_FullMethodName = "/gophkeeper.Keeper/DeleteCollectionMember"
+	Keeper_CreateSend_FullMethodName             = "/gophkeeper.Keeper/CreateSend"
+	Keeper_GetSend_FullMethodName                = "/gophkeeper.Keeper/GetSend"
+	Keeper_ListUsers_FullMethodName              = "/gophkeeper.Keeper/ListUsers"
+	Keeper_DisableUser_FullMethodName            = "/gophkeeper.Keeper/DisableUser"
+	Keeper_EnableUser_FullMethodName             = "/gophkeeper.Keeper/EnableUser"
+	Keeper_LogoutUser_FullMethodName             = "/gophkeeper.Keeper/LogoutUser"
+	Keeper_DeleteUser_FullMethodName             = "/gophkeeper.Keeper/DeleteUser"
+)
+
+// KeeperClient is the client API for Keeper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KeeperClient interface {
+	// Register creates a new user and authorizes it right away
+	Register(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*Tokens, error)
+	// Login authorizes the user, the users with two-factor authentication enabled get a challenge instead of the tokens
+	Login(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// LoginTwoFactor finishes the login with the challenge and TOTP code or a recovery code
+	LoginTwoFactor(ctx context.Context, in *TwoFactorLogin, opts ...grpc.CallOption) (*Tokens, error)
+	// RefreshToken exchanges a refresh token for a new pair of tokens, the refresh token can't be used again
+	RefreshToken(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*Tokens, error)
+	// Logout revokes the session of the access token
+	Logout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ChangePassword replaces the password along with every secret re-encrypted with the new key at once
+	// and revokes the other sessions. PermissionDenied is returned if the old password is wrong,
+	// FailedPrecondition if the secrets have changed since the cursor.
+	ChangePassword(ctx context.Context, in *PasswordChange, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ExportVault streams every secret of the user as a zip archive laid out just like the one of the http api,
+	// the messages carry the data only
+	ExportVault(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Keeper_ExportVaultClient, error)
+	// DeleteAccount deletes the user along with all the data, the password is to be given once again,
+	// the login is taken from the access token. PermissionDenied is returned if the password is wrong,
+	// FailedPrecondition if the user is the last owner of an organization that has other members.
+	DeleteAccount(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetSessions returns all the devices signed in by the user, the most recently seen ones first
+	GetSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SessionList, error)
+	// RevokeSession revokes any session of the user
+	RevokeSession(ctx context.Context, in *Session, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// EnrollTwoFactor generates a new TOTP secret, two-factor authentication is enabled once it is verified
+	EnrollTwoFactor(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TOTPEnrollment, error)
+	// VerifyTwoFactor enables two-factor authentication with TOTP code and returns the recovery codes
+	VerifyTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*RecoveryCodes, error)
+	// DisableTwoFactor turns two-factor authentication off with TOTP code or a recovery code
+	DisableTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// The calls storing, deleting and getting a single secret work with the collection of an organization
+	// named by collection_id of its meta, the listing ones without a request return the personal secrets only,
+	// Sync returns the secrets of a collection.
+	PutLogoPass(ctx context.Context, in *LogoPass, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetLogoPasses(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*LogoPassList, error)
+	DeleteLogoPass(ctx context.Context, in *LogoPass, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PutText(ctx context.Context, in *TextData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetTexts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TextDataList, error)
+	DeleteText(ctx context.Context, in *TextData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PutCreditCard(ctx context.Context, in *CreditCard, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetCreditCards(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CreditCardList, error)
+	DeleteCreditCard(ctx context.Context, in *CreditCard, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// PutBinary stores the whole binary at once, meant for small ones only
+	PutBinary(ctx context.Context, in *BinaryData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetBinaryList returns binaries without their content
+	GetBinaryList(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BinaryDataList, error)
+	// GetBinary returns the whole binary stored by PutBinary
+	GetBinary(ctx context.Context, in *BinaryData, opts ...grpc.CallOption) (*BinaryData, error)
+	DeleteBinary(ctx context.Context, in *BinaryData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// StartBinaryUpload starts a chunked upload of a binary
+	StartBinaryUpload(ctx context.Context, in *UploadSession, opts ...grpc.CallOption) (*UploadSession, error)
+	// GetBinaryUpload returns the state of a chunked upload with the parts already received
+	GetBinaryUpload(ctx context.Context, in *UploadSession, opts ...grpc.CallOption) (*UploadSession, error)
+	// UploadBinaryChunk stores a single part of a chunked upload, the same part can be safely sent again
+	UploadBinaryChunk(ctx context.Context, in *BinaryChunk, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// CompleteBinaryUpload turns all the parts of an upload into a binary
+	CompleteBinaryUpload(ctx context.Context, in *UploadSession, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DownloadBinary streams the encrypted content of a chunked binary starting at the offset.
+	// The first message carries the size of the binary and the size of its chunks.
+	DownloadBinary(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (Keeper_DownloadBinaryClient, error)
+	// Sync returns all the secrets created, updated or deleted since the cursor along with the cursor for the next call.
+	// Every secret is returned if the cursor is empty or unknown, complete being set then.
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncData, error)
+	// Events streams the changes of the user's secrets made since the call, until the client cancels it,
+	// the session of the access token is revoked or the server shuts down. The events carry no secrets,
+	// the client is to sync to get them.
+	Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Keeper_EventsClient, error)
+	// GetUsage returns how much space the user takes and the quotas limiting it
+	GetUsage(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Usage, error)
+	// GetAuditLog returns a page of the user's audit log, the most recent events first: the logins, the failed ones
+	// and every access to the secrets, the secrets themselves are never recorded
+	GetAuditLog(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditPage, error)
+	// GetHistory returns the previous versions of the user's secrets, the most recent ones first.
+	// A version is kept every time a logo-pass pair, a text or a credit card is overwritten, deleted or restored.
+	GetHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*SecretVersionList, error)
+	// RestoreVersion makes the version with version_id the current one, bringing the secret back if it has been deleted.
+	// Aborted is returned if the secret has been changed since secret_revision
+	RestoreVersion(ctx context.Context, in *SecretVersion, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetKeyPair returns the key pair of the user if login is empty, the public key of the user named by login otherwise
+	GetKeyPair(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*KeyPair, error)
+	// PutKeyPair stores the key pair of the user, the private key encrypted with the key derived from the password.
+	// The shares made for the previous public key are dropped.
+	PutKeyPair(ctx context.Context, in *KeyPair, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetShares returns the shares made by the user and the ones made for the user
+	GetShares(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ShareList, error)
+	// PutShare shares the secret with the recipient encrypted with the public key of the recipient,
+	// or replaces the secret of the share made before. Returns FailedPrecondition if the recipient has no key pair.
+	PutShare(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Share, error)
+	// AcceptShare makes the share with share_id made for the user listed along with the user's own secrets
+	AcceptShare(ctx context.Context, in *ShareRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ChangeShare keeps the change of the secret shared for writing, encrypted with the public key of the owner,
+	// for the owner to take in. Returns PermissionDenied for read-only shares.
+	ChangeShare(ctx context.Context, in *Share, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteShare revokes the share made by the user or declines the one made for the user
+	DeleteShare(ctx context.Context, in *ShareRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetOrgs returns the organizations of the user with the role of the user and the key of the organization
+	// wrapped for the user, their members and the collections the user can see
+	GetOrgs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*OrgList, error)
+	// CreateOrg creates an organization owned by the user, org_key being the key of the organization
+	// encrypted with the public key of the user. Returns AlreadyExists if the name is taken.
+	CreateOrg(ctx context.Context, in *Organization, opts ...grpc.CallOption) (*Organization, error)
+	// DeleteOrg removes the organization along with all of its collections, only the owners can
+	DeleteOrg(ctx context.Context, in *OrgRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// PutMember adds the user to the organization or changes the role of the member, org_key being the key
+	// of the organization encrypted with the public key of the member. Owners and admins manage the members,
+	// only owners manage other owners and admins. Returns FailedPrecondition if the member has no key pair
+	// or the last owner is demoted.
+	PutMember(ctx context.Context, in *OrgMember, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteMember removes the member from the organization, every member can leave
+	DeleteMember(ctx context.Context, in *OrgMember, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// PutCollection creates a collection in the organization, or renames the one with collection_id.
+	// Only owners and admins manage the collections.
+	PutCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*Collection, error)
+	// DeleteCollection removes the collection with collection_id along with all of its secrets
+	DeleteCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// PutCollectionMember adds the member of the organization to the collection
+	PutCollectionMember(ctx context.Context, in *CollectionMember, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteCollectionMember(ctx context.Context, in *CollectionMember, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// CreateSend stores a text or a small binary encrypted with a key the server never sees, to be handed
+	// by a link to someone without an account. Returns InvalidArgument if the send is invalid or too large.
+	CreateSend(ctx context.Context, in *Send, opts ...grpc.CallOption) (*Send, error)
+	// GetSend returns the send to anyone, no authorization is needed. Every call counts as a view, NotFound
+	// is returned once the send has expired or has been viewed as many times as allowed.
+	GetSend(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*Send, error)
+	// The admin calls are available to the users with the admin role only, others get PermissionDenied.
+	// ListUsers returns all the accounts along with the space they take, never the secrets.
+	ListUsers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*UserSummaryList, error)
+	// DisableUser signs the user out everywhere and keeps them from signing in, admins can't disable themselves
+	DisableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	EnableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// LogoutUser revokes all the sessions of the user
+	LogoutUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteUser removes the account along with all its data, admins can't delete themselves.
+	// FailedPrecondition is returned if the user is the last owner of an organization that has other members.
+	DeleteUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type keeperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeeperClient(cc grpc.ClientConnInterface) KeeperClient {
+	return &keeperClient{cc}
+}
+
+func (c *keeperClient) Register(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*Tokens, error) {
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Keeper_Register_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) Login(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, Keeper_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) LoginTwoFactor(ctx context.Context, in *TwoFactorLogin, opts ...grpc.CallOption) (*Tokens, error) {
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Keeper_LoginTwoFactor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) RefreshToken(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*Tokens, error) {
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Keeper_RefreshToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) Logout(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_Logout_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) ChangePassword(ctx context.Context, in *PasswordChange, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_ChangePassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) ExportVault(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Keeper_ExportVaultClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Keeper_ServiceDesc.Streams[0], Keeper_ExportVault_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keeperExportVaultClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Keeper_ExportVaultClient interface {
+	Recv() (*BinaryChunk, error)
+	grpc.ClientStream
+}
+
+type keeperExportVaultClient struct {
+	grpc.ClientStream
+}
+
+func (x *keeperExportVaultClient) Recv() (*BinaryChunk, error) {
+	m := new(BinaryChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keeperClient) DeleteAccount(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SessionList, error) {
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, Keeper_GetSessions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) RevokeSession(ctx context.Context, in *Session, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_RevokeSession_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) EnrollTwoFactor(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TOTPEnrollment, error) {
+	out := new(TOTPEnrollment)
+	err := c.cc.Invoke(ctx, Keeper_EnrollTwoFactor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) VerifyTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*RecoveryCodes, error) {
+	out := new(RecoveryCodes)
+	err := c.cc.Invoke(ctx, Keeper_VerifyTwoFactor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DisableTwoFactor(ctx context.Context, in *TwoFactorCode, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DisableTwoFactor_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutLogoPass(ctx context.Context, in *LogoPass, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutLogoPass_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetLogoPasses(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*LogoPassList, error) {
+	out := new(LogoPassList)
+	err := c.cc.Invoke(ctx, Keeper_GetLogoPasses_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteLogoPass(ctx context.Context, in *LogoPass, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteLogoPass_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutText(ctx context.Context, in *TextData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutText_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetTexts(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TextDataList, error) {
+	out := new(TextDataList)
+	err := c.cc.Invoke(ctx, Keeper_GetTexts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteText(ctx context.Context, in *TextData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteText_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutCreditCard(ctx context.Context, in *CreditCard, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutCreditCard_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetCreditCards(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CreditCardList, error) {
+	out := new(CreditCardList)
+	err := c.cc.Invoke(ctx, Keeper_GetCreditCards_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteCreditCard(ctx context.Context, in *CreditCard, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteCreditCard_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutBinary(ctx context.Context, in *BinaryData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutBinary_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetBinaryList(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*BinaryDataList, error) {
+	out := new(BinaryDataList)
+	err := c.cc.Invoke(ctx, Keeper_GetBinaryList_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetBinary(ctx context.Context, in *BinaryData, opts ...grpc.CallOption) (*BinaryData, error) {
+	out := new(BinaryData)
+	err := c.cc.Invoke(ctx, Keeper_GetBinary_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteBinary(ctx context.Context, in *BinaryData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteBinary_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) StartBinaryUpload(ctx context.Context, in *UploadSession, opts ...grpc.CallOption) (*UploadSession, error) {
+	out := new(UploadSession)
+	err := c.cc.Invoke(ctx, Keeper_StartBinaryUpload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetBinaryUpload(ctx context.Context, in *UploadSession, opts ...grpc.CallOption) (*UploadSession, error) {
+	out := new(UploadSession)
+	err := c.cc.Invoke(ctx, Keeper_GetBinaryUpload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) UploadBinaryChunk(ctx context.Context, in *BinaryChunk, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_UploadBinaryChunk_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) CompleteBinaryUpload(ctx context.Context, in *UploadSession, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_CompleteBinaryUpload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DownloadBinary(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (Keeper_DownloadBinaryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Keeper_ServiceDesc.Streams[1], Keeper_DownloadBinary_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keeperDownloadBinaryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Keeper_DownloadBinaryClient interface {
+	Recv() (*BinaryChunk, error)
+	grpc.ClientStream
+}
+
+type keeperDownloadBinaryClient struct {
+	grpc.ClientStream
+}
+
+func (x *keeperDownloadBinaryClient) Recv() (*BinaryChunk, error) {
+	m := new(BinaryChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keeperClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncData, error) {
+	out := new(SyncData)
+	err := c.cc.Invoke(ctx, Keeper_Sync_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) Events(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Keeper_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Keeper_ServiceDesc.Streams[2], Keeper_Events_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keeperEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Keeper_EventsClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type keeperEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *keeperEventsClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keeperClient) GetUsage(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Usage, error) {
+	out := new(Usage)
+	err := c.cc.Invoke(ctx, Keeper_GetUsage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetAuditLog(ctx context.Context, in *AuditRequest, opts ...grpc.CallOption) (*AuditPage, error) {
+	out := new(AuditPage)
+	err := c.cc.Invoke(ctx, Keeper_GetAuditLog_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*SecretVersionList, error) {
+	out := new(SecretVersionList)
+	err := c.cc.Invoke(ctx, Keeper_GetHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) RestoreVersion(ctx context.Context, in *SecretVersion, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_RestoreVersion_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetKeyPair(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*KeyPair, error) {
+	out := new(KeyPair)
+	err := c.cc.Invoke(ctx, Keeper_GetKeyPair_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutKeyPair(ctx context.Context, in *KeyPair, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutKeyPair_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetShares(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ShareList, error) {
+	out := new(ShareList)
+	err := c.cc.Invoke(ctx, Keeper_GetShares_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutShare(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Share, error) {
+	out := new(Share)
+	err := c.cc.Invoke(ctx, Keeper_PutShare_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) AcceptShare(ctx context.Context, in *ShareRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_AcceptShare_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) ChangeShare(ctx context.Context, in *Share, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_ChangeShare_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteShare(ctx context.Context, in *ShareRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteShare_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetOrgs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*OrgList, error) {
+	out := new(OrgList)
+	err := c.cc.Invoke(ctx, Keeper_GetOrgs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) CreateOrg(ctx context.Context, in *Organization, opts ...grpc.CallOption) (*Organization, error) {
+	out := new(Organization)
+	err := c.cc.Invoke(ctx, Keeper_CreateOrg_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteOrg(ctx context.Context, in *OrgRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteOrg_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutMember(ctx context.Context, in *OrgMember, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteMember(ctx context.Context, in *OrgMember, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*Collection, error) {
+	out := new(Collection)
+	err := c.cc.Invoke(ctx, Keeper_PutCollection_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteCollection(ctx context.Context, in *Collection, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteCollection_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) PutCollectionMember(ctx context.Context, in *CollectionMember, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_PutCollectionMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteCollectionMember(ctx context.Context, in *CollectionMember, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteCollectionMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) CreateSend(ctx context.Context, in *Send, opts ...grpc.CallOption) (*Send, error) {
+	out := new(Send)
+	err := c.cc.Invoke(ctx, Keeper_CreateSend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetSend(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*Send, error) {
+	out := new(Send)
+	err := c.cc.Invoke(ctx, Keeper_GetSend_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) ListUsers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*UserSummaryList, error) {
+	out := new(UserSummaryList)
+	err := c.cc.Invoke(ctx, Keeper_ListUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DisableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DisableUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) EnableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_EnableUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) LogoutUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_LogoutUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) DeleteUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Keeper_DeleteUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KeeperServer is the server API for Keeper service.
+// All implementations must embed UnimplementedKeeperServer
+// for forward compatibility
+type KeeperServer interface {
+	// Register creates a new user and authorizes it right away
+	Register(context.Context, *AuthRequest) (*Tokens, error)
+	// Login authorizes the user, the users with two-factor authentication enabled get a challenge instead of the tokens
+	Login(context.Context, *AuthRequest) (*LoginResponse, error)
+	// LoginTwoFactor finishes the login with the challenge and TOTP code or a recovery code
+	LoginTwoFactor(context.Context, *TwoFactorLogin) (*Tokens, error)
+	// RefreshToken exchanges a refresh token for a new pair of tokens, the refresh token can't be used again
+	RefreshToken(context.Context, *RefreshRequest) (*Tokens, error)
+	// Logout revokes the session of the access token
+	Logout(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// ChangePassword replaces the password along with every secret re-encrypted with the new key at once
+	// and revokes the other sessions. PermissionDenied is returned if the old password is wrong,
+	// FailedPrecondition if the secrets have changed since the cursor.
+	ChangePassword(context.Context, *PasswordChange) (*emptypb.Empty, error)
+	// ExportVault streams every secret of the user as a zip archive laid out just like the one of the http api,
+	// the messages carry the data only
+	ExportVault(*emptypb.Empty, Keeper_ExportVaultServer) error
+	// DeleteAccount deletes the user along with all the data, the password is to be given once again,
+	// the login is taken from the access token. PermissionDenied is returned if the password is wrong,
+	// FailedPrecondition if the user is the last owner of an organization that has other members.
+	DeleteAccount(context.Context, *AuthRequest) (*emptypb.Empty, error)
+	// GetSessions returns all the devices signed in by the user, the most recently seen ones first
+	GetSessions(context.Context, *emptypb.Empty) (*SessionList, error)
+	// RevokeSession revokes any session of the user
+	RevokeSession(context.Context, *Session) (*emptypb.Empty, error)
+	// EnrollTwoFactor generates a new TOTP secret, two-factor authentication is enabled once it is verified
+	EnrollTwoFactor(context.Context, *emptypb.Empty) (*TOTPEnrollment, error)
+	// VerifyTwoFactor enables two-factor authentication with TOTP code and returns the recovery codes
+	VerifyTwoFactor(context.Context, *TwoFactorCode) (*RecoveryCodes, error)
+	// DisableTwoFactor turns two-factor authentication off with TOTP code or a recovery code
+	DisableTwoFactor(context.Context, *TwoFactorCode) (*emptypb.Empty, error)
+	// The calls storing, deleting and getting a single secret work with the collection of an organization
+	// named by collection_id of its meta, the listing ones without a request return the personal secrets only,
+	// Sync returns the secrets of a collection.
+	PutLogoPass(context.Context, *LogoPass) (*emptypb.Empty, error)
+	GetLogoPasses(context.Context, *emptypb.Empty) (*LogoPassList, error)
+	DeleteLogoPass(context.Context, *LogoPass) (*emptypb.Empty, error)
+	PutText(context.Context, *TextData) (*emptypb.Empty, error)
+	GetTexts(context.Context, *emptypb.Empty) (*TextDataList, error)
+	DeleteText(context.Context, *TextData) (*emptypb.Empty, error)
+	PutCreditCard(context.Context, *CreditCard) (*emptypb.Empty, error)
+	GetCreditCards(context.Context, *emptypb.Empty) (*CreditCardList, error)
+	DeleteCreditCard(context.Context, *CreditCard) (*emptypb.Empty, error)
+	// PutBinary stores the whole binary at once, meant for small ones only
+	PutBinary(context.Context, *BinaryData) (*emptypb.Empty, error)
+	// GetBinaryList returns binaries without their content
+	GetBinaryList(context.Context, *emptypb.Empty) (*BinaryDataList, error)
+	// GetBinary returns the whole binary stored by PutBinary
+	GetBinary(context.Context, *BinaryData) (*BinaryData, error)
+	DeleteBinary(context.Context, *BinaryData) (*emptypb.Empty, error)
+	// StartBinaryUpload starts a chunked upload of a binary
+	StartBinaryUpload(context.Context, *UploadSession) (*UploadSession, error)
+	// GetBinaryUpload returns the state of a chunked upload with the parts already received
+	GetBinaryUpload(context.Context, *UploadSession) (*UploadSession, error)
+	// UploadBinaryChunk stores a single part of a chunked upload, the same part can be safely sent again
+	UploadBinaryChunk(context.Context, *BinaryChunk) (*emptypb.Empty, error)
+	// CompleteBinaryUpload turns all the parts of an upload into a binary
+	CompleteBinaryUpload(context.Context, *UploadSession) (*emptypb.Empty, error)
+	// DownloadBinary streams the encrypted content of a chunked binary starting at the offset.
+	// The first message carries the size of the binary and the size of its chunks.
+	DownloadBinary(*DownloadRequest, Keeper_DownloadBinaryServer) error
+	// Sync returns all the secrets created, updated or deleted since the cursor along with the cursor for the next call.
+	// Every secret is returned if the cursor is empty or unknown, complete being set then.
+	Sync(context.Context, *SyncRequest) (*SyncData, error)
+	// Events streams the changes of the user's secrets made since the call, until the client cancels it,
+	// the session of the access token is revoked or the server shuts down. The events carry no secrets,
+	// the client is to sync to get them.
+	Events(*emptypb.Empty, Keeper_EventsServer) error
+	// GetUsage returns how much space the user takes and the quotas limiting it
+	GetUsage(context.Context, *emptypb.Empty) (*Usage, error)
+	// GetAuditLog returns a page of the user's audit log, the most recent events first: the logins, the failed ones
+	// and every access to the secrets, the secrets themselves are never recorded
+	GetAuditLog(context.Context, *AuditRequest) (*AuditPage, error)
+	// GetHistory returns the previous versions of the user's secrets, the most recent ones first.
+	// A version is kept every time a logo-pass pair, a text or a credit card is overwritten, deleted or restored.
+	GetHistory(context.Context, *HistoryRequest) (*SecretVersionList, error)
+	// RestoreVersion makes the version with version_id the current one, bringing the secret back if it has been deleted.
+	// Aborted is returned if the secret has been changed since secret_revision
+	RestoreVersion(context.Context, *SecretVersion) (*emptypb.Empty, error)
+	// GetKeyPair returns the key pair of the user if login is empty, the public key of the user named by login otherwise
+	GetKeyPair(context.Context, *UserRequest) (*KeyPair, error)
+	// PutKeyPair stores the key pair of the user, the private key encrypted with the key derived from the password.
+	// The shares made for the previous public key are dropped.
+	PutKeyPair(context.Context, *KeyPair) (*emptypb.Empty, error)
+	// GetShares returns the shares made by the user and the ones made for the user
+	GetShares(context.Context, *emptypb.Empty) (*ShareList, error)
+	// PutShare shares the secret with the recipient encrypted with the public key of the recipient,
+	// or replaces the secret of the share made before. Returns FailedPrecondition if the recipient has no key pair.
+	PutShare(context.Context, *Share) (*Share, error)
+	// AcceptShare makes the share with share_id made for the user listed along with the user's own secrets
+	AcceptShare(context.Context, *ShareRequest) (*emptypb.Empty, error)
+	// ChangeShare keeps the change of the secret shared for writing, encrypted with the public key of the owner,
+	// for the owner to take in. Returns PermissionDenied for read-only shares.
+	ChangeShare(context.Context, *Share) (*emptypb.Empty, error)
+	// DeleteShare revokes the share made by the user or declines the one made for the user
+	DeleteShare(context.Context, *ShareRequest) (*emptypb.Empty, error)
+	// GetOrgs returns the organizations of the user with the role of the user and the key of the organization
+	// wrapped for the user, their members and the collections the user can see
+	GetOrgs(context.Context, *emptypb.Empty) (*OrgList, error)
+	// CreateOrg creates an organization owned by the user, org_key being the key of the organization
+	// encrypted with the public key of the user. Returns AlreadyExists if the name is taken.
+	CreateOrg(context.Context, *Organization) (*Organization, error)
+	// DeleteOrg removes the organization along with all of its collections, only the owners can
+	DeleteOrg(context.Context, *OrgRequest) (*emptypb.Empty, error)
+	// PutMember adds the user to the organization or changes the role of the member, org_key being the key
+	// of the organization encrypted with the public key of the member. Owners and admins manage the members,
+	// only owners manage other owners and admins. Returns FailedPrecondition if the member has no key pair
+	// or the last owner is demoted.
+	PutMember(context.Context, *OrgMember) (*emptypb.Empty, error)
+	// DeleteMember removes the member from the organization, every member can leave
+	DeleteMember(context.Context, *OrgMember) (*emptypb.Empty, error)
+	// PutCollection creates a collection in the organization, or renames the one with collection_id.
+	// Only owners and admins manage the collections.
+	PutCollection(context.Context, *Collection) (*Collection, error)
+	// DeleteCollection removes the collection with collection_id along with all of its secrets
+	DeleteCollection(context.Context, *Collection) (*emptypb.Empty, error)
+	// PutCollectionMember adds the member of the organization to the collection
+	PutCollectionMember(context.Context, *CollectionMember) (*emptypb.Empty, error)
+	DeleteCollectionMember(context.Context, *CollectionMember) (*emptypb.Empty, error)
+	// CreateSend stores a text or a small binary encrypted with a key the server never sees, to be handed
+	// by a link to someone without an account. Returns InvalidArgument if the send is invalid or too large.
+	CreateSend(context.Context, *Send) (*Send, error)
+	// GetSend returns the send to anyone, no authorization is needed. Every call counts as a view, NotFound
+	// is returned once the send has expired or has been viewed as many times as allowed.
+	GetSend(context.Context, *SendRequest) (*Send, error)
+	// The admin calls are available to the users with the admin role only, others get PermissionDenied.
+	// ListUsers returns all the accounts along with the space they take, never the secrets.
+	ListUsers(context.Context, *emptypb.Empty) (*UserSummaryList, error)
+	// DisableUser signs the user out everywhere and keeps them from signing in, admins can't disable themselves
+	DisableUser(context.Context, *UserRequest) (*emptypb.Empty, error)
+	EnableUser(context.Context, *UserRequest) (*emptypb.Empty, error)
+	// LogoutUser revokes all the sessions of the user
+	LogoutUser(context.Context, *UserRequest) (*emptypb.Empty, error)
+	// DeleteUser removes the account along with all its data, admins can't delete themselves.
+	// FailedPrecondition is returned if the user is the last owner of an organization that has other members.
+	DeleteUser(context.Context, *UserRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedKeeperServer()
+}
+
+// UnimplementedKeeperServer must be embedded to have forward compatible implementations.
+type UnimplementedKeeperServer struct {
+}
+
+func (UnimplementedKeeperServer) Register(context.Context, *AuthRequest) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedKeeperServer) Login(context.Context, *AuthRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedKeeperServer) LoginTwoFactor(context.Context, *TwoFactorLogin) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginTwoFactor not implemented")
+}
+func (UnimplementedKeeperServer) RefreshToken(context.Context, *RefreshRequest) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshToken not implemented")
+}
+func (UnimplementedKeeperServer) Logout(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedKeeperServer) ChangePassword(context.Context, *PasswordChange) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedKeeperServer) ExportVault(*emptypb.Empty, Keeper_ExportVaultServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportVault not implemented")
+}
+func (UnimplementedKeeperServer) DeleteAccount(context.Context, *AuthRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccount not implemented")
+}
+func (UnimplementedKeeperServer) GetSessions(context.Context, *emptypb.Empty) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSessions not implemented")
+}
+func (UnimplementedKeeperServer) RevokeSession(context.Context, *Session) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedKeeperServer) EnrollTwoFactor(context.Context, *emptypb.Empty) (*TOTPEnrollment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTwoFactor not implemented")
+}
+func (UnimplementedKeeperServer) VerifyTwoFactor(context.Context, *TwoFactorCode) (*RecoveryCodes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyTwoFactor not implemented")
+}
+func (UnimplementedKeeperServer) DisableTwoFactor(context.Context, *TwoFactorCode) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTwoFactor not implemented")
+}
+func (UnimplementedKeeperServer) PutLogoPass(context.Context, *LogoPass) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutLogoPass not implemented")
+}
+func (UnimplementedKeeperServer) GetLogoPasses(context.Context, *emptypb.Empty) (*LogoPassList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogoPasses not implemented")
+}
+func (UnimplementedKeeperServer) DeleteLogoPass(context.Context, *LogoPass) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLogoPass not implemented")
+}
+func (UnimplementedKeeperServer) PutText(context.Context, *TextData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutText not implemented")
+}
+func (UnimplementedKeeperServer) GetTexts(context.Context, *emptypb.Empty) (*TextDataList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTexts not implemented")
+}
+func (UnimplementedKeeperServer) DeleteText(context.Context, *TextData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteText not implemented")
+}
+func (UnimplementedKeeperServer) PutCreditCard(context.Context, *CreditCard) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutCreditCard not implemented")
+}
+func (UnimplementedKeeperServer) GetCreditCards(context.Context, *emptypb.Empty) (*CreditCardList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCreditCards not implemented")
+}
+func (UnimplementedKeeperServer) DeleteCreditCard(context.Context, *CreditCard) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCreditCard not implemented")
+}
+func (UnimplementedKeeperServer) PutBinary(context.Context, *BinaryData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutBinary not implemented")
+}
+func (UnimplementedKeeperServer) GetBinaryList(context.Context, *emptypb.Empty) (*BinaryDataList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBinaryList not implemented")
+}
+func (UnimplementedKeeperServer) GetBinary(context.Context, *BinaryData) (*BinaryData, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBinary not implemented")
+}
+func (UnimplementedKeeperServer) DeleteBinary(context.Context, *BinaryData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBinary not implemented")
+}
+func (UnimplementedKeeperServer) StartBinaryUpload(context.Context, *UploadSession) (*UploadSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartBinaryUpload not implemented")
+}
+func (UnimplementedKeeperServer) GetBinaryUpload(context.Context, *UploadSession) (*UploadSession, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBinaryUpload not implemented")
+}
+func (UnimplementedKeeperServer) UploadBinaryChunk(context.Context, *BinaryChunk) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UploadBinaryChunk not implemented")
+}
+func (UnimplementedKeeperServer) CompleteBinaryUpload(context.Context, *UploadSession) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteBinaryUpload not implemented")
+}
+func (UnimplementedKeeperServer) DownloadBinary(*DownloadRequest, Keeper_DownloadBinaryServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadBinary not implemented")
+}
+func (UnimplementedKeeperServer) Sync(context.Context, *SyncRequest) (*SyncData, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
+func (UnimplementedKeeperServer) Events(*emptypb.Empty, Keeper_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedKeeperServer) GetUsage(context.Context, *emptypb.Empty) (*Usage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedKeeperServer) GetAuditLog(context.Context, *AuditRequest) (*AuditPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAuditLog not implemented")
+}
+func (UnimplementedKeeperServer) GetHistory(context.Context, *HistoryRequest) (*SecretVersionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedKeeperServer) RestoreVersion(context.Context, *SecretVersion) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreVersion not implemented")
+}
+func (UnimplementedKeeperServer) GetKeyPair(context.Context, *UserRequest) (*KeyPair, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeyPair not implemented")
+}
+func (UnimplementedKeeperServer) PutKeyPair(context.Context, *KeyPair) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutKeyPair not implemented")
+}
+func (UnimplementedKeeperServer) GetShares(context.Context, *emptypb.Empty) (*ShareList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShares not implemented")
+}
+func (UnimplementedKeeperServer) PutShare(context.Context, *Share) (*Share, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutShare not implemented")
+}
+func (UnimplementedKeeperServer) AcceptShare(context.Context, *ShareRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptShare not implemented")
+}
+func (UnimplementedKeeperServer) ChangeShare(context.Context, *Share) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeShare not implemented")
+}
+func (UnimplementedKeeperServer) DeleteShare(context.Context, *ShareRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteShare not implemented")
+}
+func (UnimplementedKeeperServer) GetOrgs(context.Context, *emptypb.Empty) (*OrgList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrgs not implemented")
+}
+func (UnimplementedKeeperServer) CreateOrg(context.Context, *Organization) (*Organization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrg not implemented")
+}
+func (UnimplementedKeeperServer) DeleteOrg(context.Context, *OrgRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrg not implemented")
+}
+func (UnimplementedKeeperServer) PutMember(context.Context, *OrgMember) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutMember not implemented")
+}
+func (UnimplementedKeeperServer) DeleteMember(context.Context, *OrgMember) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMember not implemented")
+}
+func (UnimplementedKeeperServer) PutCollection(context.Context, *Collection) (*Collection, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutCollection not implemented")
+}
+func (UnimplementedKeeperServer) DeleteCollection(context.Context, *Collection) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCollection not implemented")
+}
+func (UnimplementedKeeperServer) PutCollectionMember(context.Context, *CollectionMember) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutCollectionMember not implemented")
+}
+func (UnimplementedKeeperServer) DeleteCollectionMember(context.Context, *CollectionMember) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCollectionMember not implemented")
+}
+func (UnimplementedKeeperServer) CreateSend(context.Context, *Send) (*Send, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSend not implemented")
+}
+func (UnimplementedKeeperServer) GetSend(context.Context, *SendRequest) (*Send, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSend not implemented")
+}
+func (UnimplementedKeeperServer) ListUsers(context.Context, *emptypb.Empty) (*UserSummaryList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedKeeperServer) DisableUser(context.Context, *UserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableUser not implemented")
+}
+func (UnimplementedKeeperServer) EnableUser(context.Context, *UserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableUser not implemented")
+}
+func (UnimplementedKeeperServer) LogoutUser(context.Context, *UserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogoutUser not implemented")
+}
+func (UnimplementedKeeperServer) DeleteUser(context.Context, *UserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedKeeperServer) mustEmbedUnimplementedKeeperServer() {}
+
+// UnsafeKeeperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KeeperServer will
+// result in compilation errors.
+type UnsafeKeeperServer interface {
+	mustEmbedUnimplementedKeeperServer()
+}
+
+func RegisterKeeperServer(s grpc.ServiceRegistrar, srv KeeperServer) {
+	s.RegisterService(&Keeper_ServiceDesc, srv)
+}
+
+func _Keeper_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).Register(ctx, req.(*AuthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).Login(ctx, req.(*AuthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_LoginTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorLogin)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).LoginTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_LoginTwoFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).LoginTwoFactor(ctx, req.(*TwoFactorLogin))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_RefreshToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).RefreshToken(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).Logout(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordChange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).ChangePassword(ctx, req.(*PasswordChange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_ExportVault_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeeperServer).ExportVault(m, &keeperExportVaultServer{stream})
+}
+
+type Keeper_ExportVaultServer interface {
+	Send(*BinaryChunk) error
+	grpc.ServerStream
+}
+
+type keeperExportVaultServer struct {
+	grpc.ServerStream
+}
+
+func (x *keeperExportVaultServer) Send(m *BinaryChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Keeper_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteAccount(ctx, req.(*AuthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Session)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_RevokeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).RevokeSession(ctx, req.(*Session))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_EnrollTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).EnrollTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_EnrollTwoFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).EnrollTwoFactor(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_VerifyTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).VerifyTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_VerifyTwoFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).VerifyTwoFactor(ctx, req.(*TwoFactorCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DisableTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DisableTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DisableTwoFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DisableTwoFactor(ctx, req.(*TwoFactorCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutLogoPass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoPass)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutLogoPass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutLogoPass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutLogoPass(ctx, req.(*LogoPass))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetLogoPasses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetLogoPasses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetLogoPasses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetLogoPasses(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteLogoPass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoPass)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteLogoPass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteLogoPass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteLogoPass(ctx, req.(*LogoPass))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TextData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutText_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutText(ctx, req.(*TextData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetTexts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetTexts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetTexts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetTexts(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TextData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteText_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteText(ctx, req.(*TextData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutCreditCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreditCard)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutCreditCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutCreditCard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutCreditCard(ctx, req.(*CreditCard))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetCreditCards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetCreditCards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetCreditCards_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetCreditCards(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteCreditCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreditCard)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteCreditCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteCreditCard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteCreditCard(ctx, req.(*CreditCard))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BinaryData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutBinary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutBinary(ctx, req.(*BinaryData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetBinaryList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetBinaryList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetBinaryList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetBinaryList(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BinaryData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetBinary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetBinary(ctx, req.(*BinaryData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteBinary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BinaryData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteBinary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteBinary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteBinary(ctx, req.(*BinaryData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_StartBinaryUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSession)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).StartBinaryUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_StartBinaryUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).StartBinaryUpload(ctx, req.(*UploadSession))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetBinaryUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSession)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetBinaryUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetBinaryUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetBinaryUpload(ctx, req.(*UploadSession))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_UploadBinaryChunk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BinaryChunk)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).UploadBinaryChunk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_UploadBinaryChunk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).UploadBinaryChunk(ctx, req.(*BinaryChunk))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_CompleteBinaryUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UploadSession)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).CompleteBinaryUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_CompleteBinaryUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).CompleteBinaryUpload(ctx, req.(*UploadSession))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DownloadBinary_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeeperServer).DownloadBinary(m, &keeperDownloadBinaryServer{stream})
+}
+
+type Keeper_DownloadBinaryServer interface {
+	Send(*BinaryChunk) error
+	grpc.ServerStream
+}
+
+type keeperDownloadBinaryServer struct {
+	grpc.ServerStream
+}
+
+func (x *keeperDownloadBinaryServer) Send(m *BinaryChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Keeper_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_Sync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).Sync(ctx, req.(*SyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeeperServer).Events(m, &keeperEventsServer{stream})
+}
+
+type Keeper_EventsServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type keeperEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *keeperEventsServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Keeper_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetUsage(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetAuditLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetAuditLog(ctx, req.(*AuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetHistory(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_RestoreVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretVersion)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).RestoreVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_RestoreVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).RestoreVersion(ctx, req.(*SecretVersion))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetKeyPair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetKeyPair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetKeyPair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetKeyPair(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutKeyPair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyPair)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutKeyPair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutKeyPair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutKeyPair(ctx, req.(*KeyPair))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetShares_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetShares(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetShares_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetShares(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Share)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutShare(ctx, req.(*Share))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_AcceptShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).AcceptShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_AcceptShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).AcceptShare(ctx, req.(*ShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_ChangeShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Share)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).ChangeShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_ChangeShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).ChangeShare(ctx, req.(*Share))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteShare(ctx, req.(*ShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetOrgs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetOrgs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetOrgs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetOrgs(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_CreateOrg_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Organization)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).CreateOrg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_CreateOrg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).CreateOrg(ctx, req.(*Organization))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteOrg_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrgRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteOrg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteOrg_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteOrg(ctx, req.(*OrgRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrgMember)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutMember(ctx, req.(*OrgMember))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrgMember)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteMember(ctx, req.(*OrgMember))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Collection)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutCollection(ctx, req.(*Collection))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Collection)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteCollection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteCollection(ctx, req.(*Collection))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_PutCollectionMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionMember)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).PutCollectionMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_PutCollectionMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).PutCollectionMember(ctx, req.(*CollectionMember))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteCollectionMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionMember)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteCollectionMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteCollectionMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteCollectionMember(ctx, req.(*CollectionMember))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_CreateSend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Send)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).CreateSend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_CreateSend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).CreateSend(ctx, req.(*Send))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetSend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetSend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetSend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetSend(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).ListUsers(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DisableUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DisableUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DisableUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DisableUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_EnableUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).EnableUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_EnableUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).EnableUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_LogoutUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).LogoutUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_LogoutUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).LogoutUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_DeleteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).DeleteUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Keeper_ServiceDesc is the grpc.ServiceDesc for Keeper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Keeper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gophkeeper.Keeper",
+	HandlerType: (*KeeperServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _Keeper_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _Keeper_Login_Handler,
+		},
+		{
+			MethodName: "LoginTwoFactor",
+			Handler:    _Keeper_LoginTwoFactor_Handler,
+		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _Keeper_RefreshToken_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _Keeper_Logout_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _Keeper_ChangePassword_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _Keeper_DeleteAccount_Handler,
+		},
+		{
+			MethodName: "GetSessions",
+			Handler:    _Keeper_GetSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _Keeper_RevokeSession_Handler,
+		},
+		{
+			MethodName: "EnrollTwoFactor",
+			Handler:    _Keeper_EnrollTwoFactor_Handler,
+		},
+		{
+			MethodName: "VerifyTwoFactor",
+			Handler:    _Keeper_VerifyTwoFactor_Handler,
+		},
+		{
+			MethodName: "DisableTwoFactor",
+			Handler:    _Keeper_DisableTwoFactor_Handler,
+		},
+		{
+			MethodName: "PutLogoPass",
+			Handler:    _Keeper_PutLogoPass_Handler,
+		},
+		{
+			MethodName: "GetLogoPasses",
+			Handler:    _Keeper_GetLogoPasses_Handler,
+		},
+		{
+			MethodName: "DeleteLogoPass",
+			Handler:    _Keeper_DeleteLogoPass_Handler,
+		},
+		{
+			MethodName: "PutText",
+			Handler:    _Keeper_PutText_Handler,
+		},
+		{
+			MethodName: "GetTexts",
+			Handler:    _Keeper_GetTexts_Handler,
+		},
+		{
+			MethodName: "DeleteText",
+			Handler:    _Keeper_DeleteText_Handler,
+		},
+		{
+			MethodName: "PutCreditCard",
+			Handler:    _Keeper_PutCreditCard_Handler,
+		},
+		{
+			MethodName: "GetCreditCards",
+			Handler:    _Keeper_GetCreditCards_Handler,
+		},
+		{
+			MethodName: "DeleteCreditCard",
+			Handler:    _Keeper_DeleteCreditCard_Handler,
+		},
+		{
+			MethodName: "PutBinary",
+			Handler:    _Keeper_PutBinary_Handler,
+		},
+		{
+			MethodName: "GetBinaryList",
+			Handler:    _Keeper_GetBinaryList_Handler,
+		},
+		{
+			MethodName: "GetBinary",
+			Handler:    _Keeper_GetBinary_Handler,
+		},
+		{
+			MethodName: "DeleteBinary",
+			Handler:    _Keeper_DeleteBinary_Handler,
+		},
+		{
+			MethodName: "StartBinaryUpload",
+			Handler:    _Keeper_StartBinaryUpload_Handler,
+		},
+		{
+			MethodName: "GetBinaryUpload",
+			Handler:    _Keeper_GetBinaryUpload_Handler,
+		},
+		{
+			MethodName: "UploadBinaryChunk",
+			Handler:    _Keeper_UploadBinaryChunk_Handler,
+		},
+		{
+			MethodName: "CompleteBinaryUpload",
+			Handler:    _Keeper_CompleteBinaryUpload_Handler,
+		},
+		{
+			MethodName: "Sync",
+			Handler:    _Keeper_Sync_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _Keeper_GetUsage_Handler,
+		},
+		{
+			MethodName: "GetAuditLog",
+			Handler:    _Keeper_GetAuditLog_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _Keeper_GetHistory_Handler,
+		},
+		{
+			MethodName: "RestoreVersion",
+			Handler:    _Keeper_RestoreVersion_Handler,
+		},
+		{
+			MethodName: "GetKeyPair",
+			Handler:    _Keeper_GetKeyPair_Handler,
+		},
+		{
+			MethodName: "PutKeyPair",
+			Handler:    _Keeper_PutKeyPair_Handler,
+		},
+		{
+			MethodName: "GetShares",
+			Handler:    _Keeper_GetShares_Handler,
+		},
+		{
+			MethodName: "PutShare",
+			Handler:    _Keeper_PutShare_Handler,
+		},
+		{
+			MethodName: "AcceptShare",
+			Handler:    _Keeper_AcceptShare_Handler,
+		},
+		{
+			MethodName: "ChangeShare",
+			Handler:    _Keeper_ChangeShare_Handler,
+		},
+		{
+			MethodName: "DeleteShare",
+			Handler:    _Keeper_DeleteShare_Handler,
+		},
+		{
+			MethodName: "GetOrgs",
+			Handler:    _Keeper_GetOrgs_Handler,
+		},
+		{
+			MethodName: "CreateOrg",
+			Handler:    _Keeper_CreateOrg_Handler,
+		},
+		{
+			MethodName: "DeleteOrg",
+			Handler:    _Keeper_DeleteOrg_Handler,
+		},
+		{
+			MethodName: "PutMember",
+			Handler:    _Keeper_PutMember_Handler,
+		},
+		{
+			MethodName: "DeleteMember",
+			Handler:    _Keeper_DeleteMember_Handler,
+		},
+		{
+			MethodName: "PutCollection",
+			Handler:    _Keeper_PutCollection_Handler,
+		},
+		{
+			MethodName: "DeleteCollection",
+			Handler:    _Keeper_DeleteCollection_Handler,
+		},
+		{
+			MethodName: "PutCollectionMember",
+			Handler:    _Keeper_PutCollectionMember_Handler,
+		},
+		{
+			MethodName: "DeleteCollectionMember",
+			Handler:    _Keeper_DeleteCollectionMember_Handler,
+		},
+		{
+			MethodName: "CreateSend",
+			Handler:    _Keeper_CreateSend_Handler,
+		},
+		{
+			MethodName: "GetSend",
+			Handler:    _Keeper_GetSend_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _Keeper_ListUsers_Handler,
+		},
+		{
+			MethodName: "DisableUser",
+			Handler:    _Keeper_DisableUser_Handler,
+		},
+		{
+			MethodName: "EnableUser",
+			Handler:    _Keeper_EnableUser_Handler,
+		},
+		{
+			MethodName: "LogoutUser",
+			Handler:    _Keeper_LogoutUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _Keeper_DeleteUser_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportVault",
+			Handler:       _Keeper_ExportVault_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DownloadBinary",
+			Handler:       _Keeper_DownloadBinary_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Events",
+			Handler:       _Keeper_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gophkeeper.proto",
+}
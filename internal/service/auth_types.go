@@ -1,19 +1,158 @@
-// Package service holds universal types used in the App for creating DB and sending requests
+// Package service holds universal types used in the App for creating DB and sending requests.
+// The fields tagged with `log:"redact"` hold secrets, they are masked whenever the values are logged.
 package service
 
 import (
 	"gorm.io/gorm"
+	"time"
 )
 
-// User struct holds unique App user
+// User struct holds unique App user. Device is the name of the device the user registers from, used for api only.
+// Revision counts the changes of the user's secrets, Role is RoleUser or RoleAdmin and Disabled users can't sign in,
+// none of them is ever sent over api.
 type User struct {
 	gorm.Model
 	Login    string `json:"login" gorm:"unique"`
-	Password string `json:"password"`
+	Password string `json:"password" log:"redact"`
+	Device   string `json:"device,omitempty" gorm:"-"`
+	Revision int64  `json:"-" gorm:"default:0"`
+	Role     string `json:"-" gorm:"default:user"`
+	Disabled bool   `json:"-" gorm:"default:false"`
+}
+
+// roles of the users
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// UserSummary struct holds what admins see about a user: the account and how much space the secrets take,
+// never the secrets themselves
+type UserSummary struct {
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+	Usage     Usage     `json:"usage"`
 }
 
 // Authentication struct is same as user, but doesn't have gorm.Model. Used only for auth requests.
+// Device is the name of the device the user signs in from, it is shown in the list of sessions.
 type Authentication struct {
 	Login    string `json:"login"`
-	Password string `json:"password"`
+	Password string `json:"password" log:"redact"`
+	Device   string `json:"device,omitempty"`
+}
+
+// PasswordChange struct holds the new password of the user along with every secret of the user re-encrypted
+// with the key derived from it. Cursor is the one of the sync the secrets were taken from, the change is refused
+// if anything has changed since. Binaries are the ones stored as a whole, chunked ones are uploaded beforehand
+// and Uploads hold the IDs of their upload sessions. Versions hold every previous version of the secrets.
+// PrivateKey is the private key of the user's KeyPair re-encrypted, it is required if the user has one.
+// Login, Session and Revision are used by the server only.
+type PasswordChange struct {
+	OldPassword string          `json:"old_password" log:"redact"`
+	NewPassword string          `json:"new_password" log:"redact"`
+	Cursor      string          `json:"cursor"`
+	LogoPasses  []LogoPass      `json:"logo_passes"`
+	Texts       []TextData      `json:"texts"`
+	CreditCards []CreditCard    `json:"credit_cards"`
+	Binaries    []BinaryData    `json:"binaries"`
+	Uploads     []string        `json:"uploads"`
+	Versions    []SecretVersion `json:"versions"`
+	PrivateKey  string          `json:"private_key,omitempty" log:"redact"`
+	Login       string          `json:"-"`
+	Session     string          `json:"-"`
+	Revision    int64           `json:"-"`
+}
+
+// Tokens struct holds the pair of tokens issued to an authorized user. AccessToken is sent in
+// `Authorization: Bearer` header of every request and expires in ExpiresIn seconds, after that
+// RefreshToken is exchanged for a new pair. Every RefreshToken can be used only once.
+type Tokens struct {
+	AccessToken  string `json:"access_token" log:"redact"`
+	RefreshToken string `json:"refresh_token" log:"redact"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// RefreshRequest struct holds the refresh token to be exchanged for a new pair of Tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" log:"redact"`
+}
+
+// RefreshToken struct holds a refresh token stored on the server, only the hash of the token itself is kept.
+// Tokens issued one after another by rotation share the Family, so that the reuse of an old token
+// revokes the whole family at once.
+type RefreshToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	Login     string `gorm:"index"`
+	Family    string `gorm:"index"`
+	TokenHash string `gorm:"uniqueIndex" log:"redact"`
+	Used      bool
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Session struct holds a device signed in by a user. Its ID is the family of the refresh tokens issued
+// to the device, so that revoking the session makes all of them invalid along with the access tokens.
+// Current marks the session that made the request and is used for api only.
+type Session struct {
+	ID        string    `json:"session_id" gorm:"primaryKey"`
+	Login     string    `json:"-" gorm:"index"`
+	Device    string    `json:"device"`
+	IP        string    `json:"ip"`
+	LastSeen  time.Time `json:"last_seen"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current" gorm:"-"`
+}
+
+// TwoFactor struct holds TOTP two-factor authentication of a user. The secret is enrolled first and
+// is Enabled once the user proves to have set it up by sending a valid code. LastStep is the TOTP period
+// of the last accepted code, so that no code can be used twice.
+type TwoFactor struct {
+	Login     string `gorm:"primaryKey"`
+	Secret    string `log:"redact"`
+	Enabled   bool
+	LastStep  int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RecoveryCode struct holds a one-time code that replaces TOTP code if the user loses the authenticator,
+// only the hash of the code itself is kept
+type RecoveryCode struct {
+	ID       uint   `gorm:"primaryKey"`
+	Login    string `gorm:"index"`
+	CodeHash string `gorm:"uniqueIndex" log:"redact"`
+}
+
+// TOTPEnrollment struct holds a new TOTP secret of a user along with otpauth:// URI to set up the authenticator with
+type TOTPEnrollment struct {
+	Secret string `json:"secret" log:"redact"`
+	URI    string `json:"uri" log:"redact"`
+}
+
+// TwoFactorCode struct holds TOTP code or a recovery code sent by a user
+type TwoFactorCode struct {
+	Code string `json:"code" log:"redact"`
+}
+
+// RecoveryCodes struct holds the recovery codes issued to a user once two-factor authentication is enabled
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" log:"redact"`
+}
+
+// TwoFactorChallenge struct is sent on login instead of Tokens to the users with two-factor authentication enabled.
+// ChallengeToken is sent back along with the code to finish the login, it expires in ExpiresIn seconds.
+type TwoFactorChallenge struct {
+	Status         string `json:"status"`
+	ChallengeToken string `json:"challenge_token" log:"redact"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+// TwoFactorLogin struct holds the second step of the login: the challenge token and TOTP code or a recovery code
+type TwoFactorLogin struct {
+	ChallengeToken string `json:"challenge_token" log:"redact"`
+	Code           string `json:"code" log:"redact"`
 }
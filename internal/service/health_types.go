@@ -0,0 +1,16 @@
+package service
+
+// HealthCheck struct holds the result of a single readiness check: Status is "ok" or "fail",
+// LatencyMS is how long the check took and Error tells what is wrong if it failed
+type HealthCheck struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Readiness struct holds the readiness of the App: Status is "ok", "fail" or "shutting_down"
+// and Checks hold the results of every dependency check by its name
+type Readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
@@ -0,0 +1,57 @@
+package service
+
+import "time"
+
+// Organization struct holds a team sharing the secrets of its collections. The secrets of the collections are
+// encrypted with the key of the organization, it is generated by the client of the owner and kept by the server
+// only wrapped for every member with the member's public key, so the server never learns it.
+// Role and OrgKey are the ones of the user asking for the organization, Members and Collections
+// are sent over api only.
+type Organization struct {
+	ID          uint         `json:"org_id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex"`
+	Role        string       `json:"role,omitempty" gorm:"-"`
+	OrgKey      string       `json:"org_key,omitempty" gorm:"-" log:"redact"`
+	Members     []OrgMember  `json:"members,omitempty" gorm:"-"`
+	Collections []Collection `json:"collections,omitempty" gorm:"-"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// OrgMember struct holds the membership of the user with Login in the organization with OrgID.
+// OrgKey is the key of the organization encrypted with the public key of the member,
+// it is sent to the member only.
+type OrgMember struct {
+	OrgID     uint      `json:"org_id" gorm:"primaryKey"`
+	Login     string    `json:"login" gorm:"primaryKey;index"`
+	Role      string    `json:"role"`
+	OrgKey    string    `json:"org_key,omitempty" log:"redact"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// roles of the members of an organization. Owners and admins manage the members and the collections and see
+// every collection of the organization, members and read-only members see only the collections they are added to,
+// the latter can't change the secrets. Only owners make other owners and admins.
+const (
+	OrgRoleOwner    = "owner"
+	OrgRoleAdmin    = "admin"
+	OrgRoleMember   = "member"
+	OrgRoleReadOnly = "read-only"
+)
+
+// Collection struct holds a vault of the organization with OrgID. Revision counts the changes of its secrets
+// in the same way User.Revision counts the ones of the personal vault. Members are the logins of the members
+// added to the collection, they are sent over api only.
+type Collection struct {
+	ID        uint      `json:"collection_id" gorm:"primaryKey"`
+	OrgID     uint      `json:"org_id" gorm:"uniqueIndex:idx_collection"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_collection"`
+	Revision  int64     `json:"-" gorm:"not null;default:0"`
+	Members   []string  `json:"members,omitempty" gorm:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CollectionMember struct holds the member of the organization with Login added to the collection
+type CollectionMember struct {
+	CollectionID uint   `json:"collection_id" gorm:"primaryKey"`
+	Login        string `json:"login" gorm:"primaryKey;index"`
+}
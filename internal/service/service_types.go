@@ -2,44 +2,241 @@ package service
 
 import (
 	"gorm.io/gorm"
+	"time"
 )
 
 // LogoPass struct holds the secret login and password pair along with description. Overwrite flag used for api.
+// Revision is assigned by the server every time the secret is stored, the same goes for the other kinds of secrets.
+// The secrets with CollectionID belong to the collection of an organization instead of the personal vault of Login,
+// Login is the member who stored them last then.
 type LogoPass struct {
 	gorm.Model
-	Login       string `json:"-"`
-	SecretLogin string `json:"secret_login"`
-	SecretPass  string `json:"secret"`
-	Description string `json:"description"`
-	Overwrite   bool   `json:"overwrite" gorm:"-"`
+	Login        string `json:"-"`
+	CollectionID uint   `json:"collection_id,omitempty" gorm:"index;not null;default:0"`
+	Revision     int64  `json:"revision" gorm:"index"`
+	SecretLogin  string `json:"secret_login" log:"redact"`
+	SecretPass   string `json:"secret" log:"redact"`
+	Description  string `json:"description"`
+	Overwrite    bool   `json:"overwrite" gorm:"-"`
 }
 
 // TextData struct holds the secret string data of any kind along with description. Overwrite flag used for api.
 type TextData struct {
 	gorm.Model
-	Login       string `json:"-"`
-	Text        string `json:"data"`
-	Description string `json:"description"`
-	Overwrite   bool   `json:"overwrite" gorm:"-"`
+	Login        string `json:"-"`
+	CollectionID uint   `json:"collection_id,omitempty" gorm:"index;not null;default:0"`
+	Revision     int64  `json:"revision" gorm:"index"`
+	Text         string `json:"data" log:"redact"`
+	Description  string `json:"description"`
+	Overwrite    bool   `json:"overwrite" gorm:"-"`
 }
 
 // CreditCard struct holds the credit card data along with description. Overwrite flag used for api.
 type CreditCard struct {
 	gorm.Model
-	Login       string `json:"-"`
-	Number      string `json:"number"`
-	Holder      string `json:"holder"`
-	DueDate     string `json:"due_date"`
-	CVV         string `json:"cvv"`
-	Description string `json:"description,omitempty"`
-	Overwrite   bool   `json:"overwrite" gorm:"-"`
+	Login        string `json:"-"`
+	CollectionID uint   `json:"collection_id,omitempty" gorm:"index;not null;default:0"`
+	Revision     int64  `json:"revision" gorm:"index"`
+	Number       string `json:"number" log:"redact"`
+	Holder       string `json:"holder" log:"redact"`
+	DueDate      string `json:"due_date" log:"redact"`
+	CVV          string `json:"cvv" log:"redact"`
+	Description  string `json:"description,omitempty"`
+	Overwrite    bool   `json:"overwrite" gorm:"-"`
 }
 
 // BinaryData struct holds arbitrary binary data along with description. Overwrite flag used for api.
+// Binaries uploaded in chunks keep 'binary' empty and have their content stored as BinaryChunk entries,
+// 'size' being the total size of the encrypted content and 'chunk_size' the size of every chunk but the last one.
 type BinaryData struct {
 	gorm.Model
-	Login       string `json:"-"`
-	Binary      string `json:"binary"`
-	Description string `json:"description"`
-	Overwrite   bool   `json:"overwrite" gorm:"-"`
+	Login        string `json:"-"`
+	CollectionID uint   `json:"collection_id,omitempty" gorm:"index;not null;default:0"`
+	Revision     int64  `json:"revision" gorm:"index"`
+	Binary       string `json:"binary" log:"redact"`
+	Description  string `json:"description"`
+	Size         int64  `json:"size"`
+	ChunkSize    int64  `json:"chunk_size"`
+	ChunkCount   int    `json:"chunk_count"`
+	Overwrite    bool   `json:"overwrite" gorm:"-"`
+}
+
+// SecretVersion struct holds a previous version of a logo-pass pair, a text or a credit card, the kind being named
+// by Item. It is kept every time the secret with ItemID is overwritten, deleted or restored, CreatedAt being
+// the time it was replaced. Entry is the json of the secret as it was, its fields stay encrypted.
+// The version is sent over api with the secret in LogoPass, Text or CreditCard instead.
+// SecretRevision is the revision of the current secret the client restores the version over.
+type SecretVersion struct {
+	ID             uint        `json:"version_id" gorm:"primaryKey"`
+	Login          string      `json:"-" gorm:"index"`
+	Revision       int64       `json:"-"`
+	Item           string      `json:"item"`
+	ItemID         uint        `json:"item_id" gorm:"index"`
+	CollectionID   uint        `json:"collection_id,omitempty" gorm:"index;not null;default:0"`
+	Entry          string      `json:"-" log:"redact"`
+	LogoPass       *LogoPass   `json:"logo_pass,omitempty" gorm:"-"`
+	Text           *TextData   `json:"text,omitempty" gorm:"-"`
+	CreditCard     *CreditCard `json:"credit_card,omitempty" gorm:"-"`
+	CreatedAt      time.Time   `json:"created_at"`
+	SecretRevision int64       `json:"-" gorm:"-"`
+}
+
+// KeyPair struct holds the keys a user shares the secrets with. PublicKey is known to everyone,
+// PrivateKey is encrypted on the client with the key derived from the user's password, so the server never learns it.
+// PrivateKey is sent to the owner of the keys only.
+type KeyPair struct {
+	Login      string    `json:"-" gorm:"primaryKey"`
+	PublicKey  string    `json:"public_key"`
+	PrivateKey string    `json:"private_key,omitempty" log:"redact"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Share struct holds a logo-pass pair, a text or a credit card with ItemID shared by Owner with Recipient,
+// the kind being named by Item. Access is AccessRead or AccessWrite, the recipient sees the secret once the share
+// is Accepted. Revision is the revision of the owner's secret the share was made of.
+// Entry is the json of the secret encrypted with the public key of the recipient. Change is the json of the secret
+// changed by the recipient of a read-write share, encrypted with the public key of the owner, it stays
+// until the owner shares the secret once again.
+// The share is sent over api with the secret in LogoPass, Text or CreditCard instead: the recipient gets the entry,
+// the owner gets the change if there is one, Pending being set then.
+type Share struct {
+	ID         uint        `json:"share_id" gorm:"primaryKey"`
+	Owner      string      `json:"owner" gorm:"uniqueIndex:idx_share"`
+	Recipient  string      `json:"recipient" gorm:"uniqueIndex:idx_share;index"`
+	Item       string      `json:"item" gorm:"uniqueIndex:idx_share"`
+	ItemID     uint        `json:"item_id" gorm:"uniqueIndex:idx_share"`
+	Access     string      `json:"access"`
+	Accepted   bool        `json:"accepted"`
+	Revision   int64       `json:"revision"`
+	Entry      string      `json:"-" log:"redact"`
+	Change     string      `json:"-" log:"redact"`
+	Pending    bool        `json:"pending" gorm:"-"`
+	LogoPass   *LogoPass   `json:"logo_pass,omitempty" gorm:"-"`
+	Text       *TextData   `json:"text,omitempty" gorm:"-"`
+	CreditCard *CreditCard `json:"credit_card,omitempty" gorm:"-"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// access to the shared secrets
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+// Send struct holds a text or a small binary sent by the user with Login to anyone holding the link to it,
+// the kind being named by Item. Data and Name, the file name of a binary, are encrypted by the client
+// with a random key that is carried by the fragment of the link only, so the server never learns it.
+// The send can be viewed MaxViews times until ExpiresAt, Views counting the views made so far, it is purged after that.
+// ExpiresIn is the lifetime in seconds asked for on creation and is used for api only.
+type Send struct {
+	ID        string    `json:"send_id" gorm:"primaryKey"`
+	Login     string    `json:"-" gorm:"index"`
+	Item      string    `json:"item"`
+	Name      string    `json:"name,omitempty" log:"redact"`
+	Data      []byte    `json:"data" log:"redact"`
+	MaxViews  int       `json:"max_views"`
+	Views     int       `json:"views"`
+	ExpiresIn int64     `json:"expires_in,omitempty" gorm:"-"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SyncData struct holds all the secrets created, updated or deleted since the cursor of a sync request,
+// deleted ones coming as tombstones. Every secret keeps the Revision of the user's data it was last stored at,
+// Cursor is the revision the lists are complete up to, it is sent with the next request to get only newer changes.
+// Complete is set when the lists hold every secret of the user, as the cursor was empty or unknown.
+type SyncData struct {
+	LogoPasses  []LogoPass   `json:"logo_passes"`
+	Texts       []TextData   `json:"texts"`
+	CreditCards []CreditCard `json:"credit_cards"`
+	Binaries    []BinaryData `json:"binaries"`
+	Cursor      string       `json:"cursor"`
+	Complete    bool         `json:"complete"`
+}
+
+// Usage struct holds how much space the user takes and the quotas limiting it, a zero limit means there is none.
+// Bytes is the total size of the secrets, the parts of unfinished uploads included,
+// Items is the number of the secrets of every kind, deleted ones are not counted.
+type Usage struct {
+	Bytes         int64            `json:"bytes"`
+	Items         map[string]int64 `json:"items"`
+	MaxBytes      int64            `json:"max_bytes"`
+	MaxBinarySize int64            `json:"max_binary_size"`
+	MaxItems      int64            `json:"max_items"`
+}
+
+// ChangeEvent struct announces a change of the user's secrets of the kind named by Item,
+// CollectionID names the collection changed if the secrets are not the personal ones.
+// It carries no secrets, the clients sync to get them.
+type ChangeEvent struct {
+	Item         string `json:"item"`
+	CollectionID uint   `json:"collection_id,omitempty"`
+}
+
+// AuditEvent struct holds a single record of the user's audit log: a login, a failed one or an access to the secrets.
+// Item is the kind of the secrets accessed, it is empty if all of them are. ItemID and Description name the secret
+// if a single one is accessed, ItemID is left out when the storage doesn't tell it. CollectionID names the collection
+// the secrets belong to, it is 0 for the personal ones. No secret content is recorded.
+type AuditEvent struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Login        string    `json:"-" gorm:"index"`
+	Action       string    `json:"action"`
+	Item         string    `json:"item,omitempty"`
+	ItemID       uint      `json:"item_id,omitempty"`
+	CollectionID uint      `json:"collection_id,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// actions recorded in the audit log
+const (
+	AuditLogin       = "login"
+	AuditLoginFailed = "login_failed"
+	AuditUpload      = "upload"
+	AuditOverwrite   = "overwrite"
+	AuditDelete      = "delete"
+	AuditDownload    = "download"
+	AuditFetch       = "fetch"
+	AuditRestore     = "restore"
+	AuditShare       = "share"
+	AuditUnshare     = "unshare"
+)
+
+// AuditPage struct holds a page of the audit log, the most recent events first.
+// Next is the cursor of the following page, it is empty on the last one.
+type AuditPage struct {
+	Events []AuditEvent `json:"events"`
+	Next   string       `json:"next,omitempty"`
+}
+
+// UploadSession struct holds the state of a chunked binary upload, so that it could be resumed after a disconnect.
+// UpdatedAt is the time of the binary change on the client, it is passed to BinaryData once the upload is complete.
+// Revision is the revision of the binary the upload is meant to replace, it is 0 for a new one.
+// Received holds the parts already stored on the server and is used for api only.
+type UploadSession struct {
+	ID           string    `json:"upload_id" gorm:"primaryKey"`
+	Login        string    `json:"-"`
+	CollectionID uint      `json:"collection_id,omitempty" gorm:"not null;default:0"`
+	Description  string    `json:"description"`
+	ChunkCount   int       `json:"chunk_count"`
+	Overwrite    bool      `json:"overwrite"`
+	Revision     int64     `json:"revision"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime:false"`
+	CreatedAt    time.Time `json:"created_at"`
+	Received     []int     `json:"received" gorm:"-"`
+}
+
+// BinaryChunk struct holds a single encrypted part of a binary. It belongs to an UploadSession until
+// the upload is complete, and to a BinaryData afterwards.
+type BinaryChunk struct {
+	ID       uint   `gorm:"primaryKey"`
+	UploadID string `gorm:"index"`
+	BinaryID uint   `gorm:"index"`
+	Part     int
+	Size     int64
+	Checksum string
+	Data     []byte `log:"redact"`
 }
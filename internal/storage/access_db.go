@@ -7,8 +7,13 @@ import (
 	"gophkeeper/internal/service"
 	"gophkeeper/internal/tools"
 	"gorm.io/gorm"
+	"time"
 )
 
+// dummyPasswordHash is checked against the passwords of unknown logins. It has the same cost
+// as the hashes made by tools.GeneratePasswordHash, so that checking it takes as long as checking a real one.
+const dummyPasswordHash = "$2a$14$vkgvBmHGVmZAjSHK/5.lreA3gMeQCC6rc3OEG5DJYShMiHT04Nkmy"
+
 // RegisterUser puts user login and password to DB while checking that user.Login stays unique
 func (dbStorage DBStorage) RegisterUser(user service.User, ctx context.Context) error {
 	var dbUser service.User
@@ -31,13 +36,15 @@ func (dbStorage DBStorage) RegisterUser(user service.User, ctx context.Context)
 	return ErrUserExists
 }
 
-// CheckUserAuth checks if the login and password provided are valid
+// CheckUserAuth checks if the login and password provided are valid and the account is not disabled
 func (dbStorage DBStorage) CheckUserAuth(authDetails service.Authentication, ctx context.Context) error {
 	var authUser service.User
 
 	err := dbStorage.db.WithContext(ctx).Where("login  = 	?", authDetails.Login).First(&authUser).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// the password is checked anyway, so that unknown logins can't be told by the response time
+			tools.CheckPasswordHash(authDetails.Password, dummyPasswordHash)
 			return ErrInvalidCredentials
 		}
 		return err
@@ -46,134 +53,171 @@ func (dbStorage DBStorage) CheckUserAuth(authDetails service.Authentication, ctx
 	if !tools.CheckPasswordHash(authDetails.Password, authUser.Password) {
 		return ErrInvalidCredentials
 	}
+	// the password is checked first, so that the disabled accounts can't be told by anyone but their owners
+	if authUser.Disabled {
+		return ErrAccountDisabled
+	}
 	return nil
 }
 
-// PutLogoPass puts a secret logo-pass pair while checking that descriptions stays unique for a user
-func (dbStorage DBStorage) PutLogoPass(logoPass service.LogoPass, ctx context.Context) error {
-	var checkEntry service.LogoPass
-
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?  AND description = ?",
-		logoPass.Login, logoPass.Description).First(&checkEntry).Error
+// PutLogoPass puts a secret logo-pass pair while checking that descriptions stays unique in a vault.
+// A stored pair is replaced only if the client has edited its revision, see matchRevision.
+// The version overwritten is kept in the history of the secret. Returns the revision the pair is stored at.
+func (dbStorage DBStorage) PutLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error) {
+	v, err := openVault(dbStorage.db.WithContext(ctx), logoPass.Login, logoPass.CollectionID, true)
 	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	logoPass.DeletedAt = gorm.DeletedAt{}
+
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &logoPass.Revision, &logoPass, func(tx *gorm.DB) error {
+		var checkEntry service.LogoPass
+		err := findEntry(tx, v, &checkEntry, "description = ?", logoPass.Description)
+		if err != nil {
 			return err
 		}
-	}
-	if !logoPass.Overwrite {
-		if checkEntry.Login != "" {
+		if !logoPass.Overwrite && checkEntry.Login != "" && !checkEntry.DeletedAt.Valid {
 			return ErrAlreadyExists
 		}
-	}
-
-	logoPass.ID = checkEntry.ID
-
-	if checkEntry.UpdatedAt.After(logoPass.UpdatedAt) {
-		return ErrOldData
-	}
-	err = dbStorage.db.WithContext(ctx).Save(&logoPass).Error
+		logoPass.ID = checkEntry.ID
+
+		change := quotaChange{item: ItemLogoPass, added: checkEntry.Login == "" || checkEntry.DeletedAt.Valid,
+			bytes: logoPassSize(logoPass) - logoPassSize(checkEntry)}
+		return runSteps(tx,
+			checkRevision(&service.LogoPass{}, checkEntry.ID, logoPass.Revision),
+			dbStorage.withinQuota(v, change),
+			keepVersion(v, ItemLogoPass, checkEntry.ID, checkEntry, checkEntry.DeletedAt.Valid))
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return logoPass.Revision, nil
 }
 
-// PutText puts a secret text data while checking that descriptions stays unique for a user
-func (dbStorage DBStorage) PutText(secret service.TextData, ctx context.Context) error {
-	var checkEntry service.TextData
-
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?  AND description = ?",
-		secret.Login, secret.Description).First(&checkEntry).Error
+// PutText puts a secret text data while checking that descriptions stays unique in a vault.
+// A stored text is replaced only if the client has edited its revision, see matchRevision.
+// The version overwritten is kept in the history of the secret. Returns the revision the text is stored at.
+func (dbStorage DBStorage) PutText(secret service.TextData, ctx context.Context) (int64, error) {
+	v, err := openVault(dbStorage.db.WithContext(ctx), secret.Login, secret.CollectionID, true)
 	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	secret.DeletedAt = gorm.DeletedAt{}
+
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &secret.Revision, &secret, func(tx *gorm.DB) error {
+		var checkEntry service.TextData
+		err := findEntry(tx, v, &checkEntry, "description = ?", secret.Description)
+		if err != nil {
 			return err
 		}
-	}
-	if !secret.Overwrite {
-		if checkEntry.Login != "" {
+		if !secret.Overwrite && checkEntry.Login != "" && !checkEntry.DeletedAt.Valid {
 			return ErrAlreadyExists
 		}
-	}
-
-	secret.ID = checkEntry.ID
-
-	if checkEntry.UpdatedAt.After(secret.UpdatedAt) {
-		return ErrOldData
-	}
-	err = dbStorage.db.WithContext(ctx).Save(&secret).Error
+		secret.ID = checkEntry.ID
+
+		change := quotaChange{item: ItemText, added: checkEntry.Login == "" || checkEntry.DeletedAt.Valid,
+			bytes: textSize(secret) - textSize(checkEntry)}
+		return runSteps(tx,
+			checkRevision(&service.TextData{}, checkEntry.ID, secret.Revision),
+			dbStorage.withinQuota(v, change),
+			keepVersion(v, ItemText, checkEntry.ID, checkEntry, checkEntry.DeletedAt.Valid))
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return secret.Revision, nil
 }
 
-// PutCreditCard puts a credit card data while checking that creditCard.Number stays unique for a user
-func (dbStorage DBStorage) PutCreditCard(card service.CreditCard, ctx context.Context) error {
-	var checkEntry service.CreditCard
-
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?  AND number = ?",
-		card.Login, card.Number).First(&checkEntry).Error
+// PutCreditCard puts a credit card data while checking that creditCard.Number stays unique in a vault.
+// A stored card is replaced only if the client has edited its revision, see matchRevision.
+// The version overwritten is kept in the history of the secret. Returns the revision the card is stored at.
+func (dbStorage DBStorage) PutCreditCard(card service.CreditCard, ctx context.Context) (int64, error) {
+	v, err := openVault(dbStorage.db.WithContext(ctx), card.Login, card.CollectionID, true)
 	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	card.DeletedAt = gorm.DeletedAt{}
+
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &card.Revision, &card, func(tx *gorm.DB) error {
+		var checkEntry service.CreditCard
+		err := findEntry(tx, v, &checkEntry, "number = ?", card.Number)
+		if err != nil {
 			return err
 		}
-	}
-	if !card.Overwrite {
-		if checkEntry.Login != "" {
+		if !card.Overwrite && checkEntry.Login != "" && !checkEntry.DeletedAt.Valid {
 			return ErrAlreadyExists
 		}
-	}
-
-	card.ID = checkEntry.ID
+		card.ID = checkEntry.ID
 
-	if checkEntry.UpdatedAt.After(card.UpdatedAt) {
-		return ErrOldData
-	}
-	err = dbStorage.db.WithContext(ctx).Save(&card).Error
+		change := quotaChange{item: ItemCreditCard, added: checkEntry.Login == "" || checkEntry.DeletedAt.Valid,
+			bytes: creditCardSize(card)}
+		if !checkEntry.DeletedAt.Valid {
+			// tombstones of the cards keep the number
+			change.bytes -= creditCardSize(checkEntry)
+		}
+		return runSteps(tx,
+			checkRevision(&service.CreditCard{}, checkEntry.ID, card.Revision),
+			dbStorage.withinQuota(v, change),
+			keepVersion(v, ItemCreditCard, checkEntry.ID, checkEntry, checkEntry.DeletedAt.Valid))
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return card.Revision, nil
 }
 
-// PutBinary puts an arbitrary binary data while checking that descriptions stays unique for a user
-func (dbStorage DBStorage) PutBinary(binary service.BinaryData, ctx context.Context) error {
-	var checkEntry service.BinaryData
-
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?  AND description = ?",
-		binary.Login, binary.Description).First(&checkEntry).Error
+// PutBinary puts an arbitrary binary data while checking that descriptions stays unique in a vault.
+// A stored binary is replaced only if the client has edited its revision, see matchRevision.
+// Returns the revision the binary is stored at.
+func (dbStorage DBStorage) PutBinary(binary service.BinaryData, ctx context.Context) (int64, error) {
+	v, err := openVault(dbStorage.db.WithContext(ctx), binary.Login, binary.CollectionID, true)
 	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	binary.DeletedAt = gorm.DeletedAt{}
+	binary.Size = int64(len(binary.Binary))
+	binary.ChunkSize = 0
+	binary.ChunkCount = 0
+
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &binary.Revision, &binary, func(tx *gorm.DB) error {
+		var checkEntry service.BinaryData
+		err := findEntry(tx.Select("id", "login", "size", "deleted_at"), v, &checkEntry,
+			"description = ?", binary.Description)
+		if err != nil {
 			return err
 		}
-	}
-	if !binary.Overwrite {
-		if checkEntry.Login != "" {
+		if !binary.Overwrite && checkEntry.Login != "" && !checkEntry.DeletedAt.Valid {
 			return ErrAlreadyExists
 		}
-	}
-
-	binary.ID = checkEntry.ID
-
-	if checkEntry.UpdatedAt.After(binary.UpdatedAt) {
-		return ErrOldData
-	}
-	err = dbStorage.db.WithContext(ctx).Save(&binary).Error
+		binary.ID = checkEntry.ID
+
+		change := quotaChange{item: ItemBinary, added: checkEntry.Login == "" || checkEntry.DeletedAt.Valid,
+			bytes: binary.Size - checkEntry.Size, binarySize: binary.Size}
+		return runSteps(tx,
+			checkRevision(&service.BinaryData{}, checkEntry.ID, binary.Revision),
+			dbStorage.withinQuota(v, change), dropChunks(checkEntry.ID))
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return binary.Revision, nil
 }
 
-// BatchGetLogoPasses is used to get the list of all user's stored logo-pass pairs
-func (dbStorage DBStorage) BatchGetLogoPasses(login string, ctx context.Context) ([]service.LogoPass, error) {
+// BatchGetLogoPasses is used to get the list of all stored logo-pass pairs of the vault including tombstones
+// of deleted ones: the personal vault of the user if collection is 0, the collection otherwise.
+// ErrForbidden is returned if the user can't see the collection.
+func (dbStorage DBStorage) BatchGetLogoPasses(login string, collection uint, ctx context.Context) ([]service.LogoPass, error) {
 	var listLogoPasses []service.LogoPass
 
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?", login).Find(&listLogoPasses).Error
+	v, err := openVault(dbStorage.db.WithContext(ctx), login, collection, false)
+	if err != nil {
+		return nil, err
+	}
+	err = dbStorage.db.WithContext(ctx).Unscoped().Scopes(v.scope).Find(&listLogoPasses).Error
 	if len(listLogoPasses) == 0 {
 		return nil, ErrEmpty
 	}
@@ -184,11 +228,17 @@ func (dbStorage DBStorage) BatchGetLogoPasses(login string, ctx context.Context)
 	return listLogoPasses, nil
 }
 
-// BatchGetTexts is used to get the list of all user's stored secret texts
-func (dbStorage DBStorage) BatchGetTexts(login string, ctx context.Context) ([]service.TextData, error) {
+// BatchGetTexts is used to get the list of all stored secret texts of the vault including tombstones
+// of deleted ones: the personal vault of the user if collection is 0, the collection otherwise.
+// ErrForbidden is returned if the user can't see the collection.
+func (dbStorage DBStorage) BatchGetTexts(login string, collection uint, ctx context.Context) ([]service.TextData, error) {
 	var listTexts []service.TextData
 
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?", login).Find(&listTexts).Error
+	v, err := openVault(dbStorage.db.WithContext(ctx), login, collection, false)
+	if err != nil {
+		return nil, err
+	}
+	err = dbStorage.db.WithContext(ctx).Unscoped().Scopes(v.scope).Find(&listTexts).Error
 	if len(listTexts) == 0 {
 		return nil, ErrEmpty
 	}
@@ -199,11 +249,17 @@ func (dbStorage DBStorage) BatchGetTexts(login string, ctx context.Context) ([]s
 	return listTexts, nil
 }
 
-// BatchGetCreditCards is used to get the list of all user's stored secret texts
-func (dbStorage DBStorage) BatchGetCreditCards(login string, ctx context.Context) ([]service.CreditCard, error) {
+// BatchGetCreditCards is used to get the list of all stored credit cards of the vault including tombstones
+// of deleted ones: the personal vault of the user if collection is 0, the collection otherwise.
+// ErrForbidden is returned if the user can't see the collection.
+func (dbStorage DBStorage) BatchGetCreditCards(login string, collection uint, ctx context.Context) ([]service.CreditCard, error) {
 	var listCards []service.CreditCard
 
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	?", login).Find(&listCards).Error
+	v, err := openVault(dbStorage.db.WithContext(ctx), login, collection, false)
+	if err != nil {
+		return nil, err
+	}
+	err = dbStorage.db.WithContext(ctx).Unscoped().Scopes(v.scope).Find(&listCards).Error
 	if len(listCards) == 0 {
 		return nil, ErrEmpty
 	}
@@ -214,11 +270,17 @@ func (dbStorage DBStorage) BatchGetCreditCards(login string, ctx context.Context
 	return listCards, nil
 }
 
-func (dbStorage DBStorage) GetBinaryList(login string, ctx context.Context) ([]service.BinaryData, error) {
+// GetBinaryList is used to get the list of the binaries of the vault without their data, including tombstones
+// of deleted ones. ErrForbidden is returned if the user can't see the collection.
+func (dbStorage DBStorage) GetBinaryList(login string, collection uint, ctx context.Context) ([]service.BinaryData, error) {
 	var binaryList []service.BinaryData
 
-	err := dbStorage.db.WithContext(ctx).Table("binary_data").Select("id, login, description, updated_at").
-		Where("login  = 	?", login).Find(&binaryList).Error
+	v, err := openVault(dbStorage.db.WithContext(ctx), login, collection, false)
+	if err != nil {
+		return nil, err
+	}
+	err = dbStorage.db.WithContext(ctx).Unscoped().Table("binary_data").Select("id, login, collection_id, revision, description, size, chunk_size, chunk_count, updated_at, deleted_at").
+		Scopes(v.scope).Find(&binaryList).Error
 	if err != nil {
 		return binaryList, err
 	}
@@ -229,9 +291,14 @@ func (dbStorage DBStorage) GetBinaryList(login string, ctx context.Context) ([]s
 	return binaryList, nil
 }
 
+// GetBinary returns the binary of the vault with the description given along with its data
 func (dbStorage DBStorage) GetBinary(binary service.BinaryData, ctx context.Context) (service.BinaryData, error) {
-	err := dbStorage.db.WithContext(ctx).Where("login  = 	? AND description = ?",
-		binary.Login, binary.Description).First(&binary).Error
+	v, err := openVault(dbStorage.db.WithContext(ctx), binary.Login, binary.CollectionID, false)
+	if err != nil {
+		return binary, err
+	}
+	err = dbStorage.db.WithContext(ctx).Scopes(v.scope).Where("description = ?", binary.Description).
+		First(&binary).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return binary, ErrEmpty
@@ -241,10 +308,164 @@ func (dbStorage DBStorage) GetBinary(binary service.BinaryData, ctx context.Cont
 	return binary, nil
 }
 
+// DeleteLogoPass wipes a secret logo-pass pair leaving only a tombstone, so that the removal reaches all the clients.
+// The deletion is refused unless the client has edited the revision of the stored pair.
+// The version deleted is kept in the history of the secret and its shares are revoked.
+// Returns the revision of the tombstone.
+func (dbStorage DBStorage) DeleteLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error) {
+	var checkEntry service.LogoPass
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), logoPass.Login, logoPass.CollectionID, true)
+	if err != nil {
+		return 0, err
+	}
+	err = dbStorage.db.WithContext(ctx).Scopes(v.scope).Where("description = ?", logoPass.Description).First(&checkEntry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrEmpty
+		}
+		return 0, err
+	}
+
+	replaced := checkEntry
+	checkEntry.SecretLogin = ""
+	checkEntry.SecretPass = ""
+	checkEntry.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	checkEntry.Login = v.login
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &checkEntry.Revision, &checkEntry,
+		checkRevision(&service.LogoPass{}, checkEntry.ID, logoPass.Revision),
+		keepVersion(v, ItemLogoPass, checkEntry.ID, replaced, false),
+		dropShares(checkEntry.Login, ItemLogoPass, checkEntry.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	return checkEntry.Revision, nil
+}
+
+// DeleteText wipes a secret text data leaving only a tombstone, so that the removal reaches all the clients.
+// The deletion is refused unless the client has edited the revision of the stored text.
+// The version deleted is kept in the history of the secret and its shares are revoked.
+// Returns the revision of the tombstone.
+func (dbStorage DBStorage) DeleteText(secret service.TextData, ctx context.Context) (int64, error) {
+	var checkEntry service.TextData
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), secret.Login, secret.CollectionID, true)
+	if err != nil {
+		return 0, err
+	}
+	err = dbStorage.db.WithContext(ctx).Scopes(v.scope).Where("description = ?", secret.Description).First(&checkEntry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrEmpty
+		}
+		return 0, err
+	}
+
+	replaced := checkEntry
+	checkEntry.Text = ""
+	checkEntry.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	checkEntry.Login = v.login
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &checkEntry.Revision, &checkEntry,
+		checkRevision(&service.TextData{}, checkEntry.ID, secret.Revision),
+		keepVersion(v, ItemText, checkEntry.ID, replaced, false),
+		dropShares(checkEntry.Login, ItemText, checkEntry.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	return checkEntry.Revision, nil
+}
+
+// DeleteCreditCard wipes a credit card data leaving only a tombstone, so that the removal reaches all the clients.
+// The deletion is refused unless the client has edited the revision of the stored card.
+// The version deleted is kept in the history of the secret and its shares are revoked.
+// Returns the revision of the tombstone.
+func (dbStorage DBStorage) DeleteCreditCard(card service.CreditCard, ctx context.Context) (int64, error) {
+	var checkEntry service.CreditCard
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), card.Login, card.CollectionID, true)
+	if err != nil {
+		return 0, err
+	}
+	err = dbStorage.db.WithContext(ctx).Scopes(v.scope).Where("number = ?", card.Number).First(&checkEntry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrEmpty
+		}
+		return 0, err
+	}
+
+	replaced := checkEntry
+	checkEntry.Holder = ""
+	checkEntry.DueDate = ""
+	checkEntry.CVV = ""
+	checkEntry.Description = ""
+	checkEntry.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	checkEntry.Login = v.login
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &checkEntry.Revision, &checkEntry,
+		checkRevision(&service.CreditCard{}, checkEntry.ID, card.Revision),
+		keepVersion(v, ItemCreditCard, checkEntry.ID, replaced, false),
+		dropShares(checkEntry.Login, ItemCreditCard, checkEntry.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	return checkEntry.Revision, nil
+}
+
+// DeleteBinary wipes a binary data leaving only a tombstone, so that the removal reaches all the clients.
+// The deletion is refused unless the client has edited the revision of the stored binary.
+// Returns the revision of the tombstone.
+func (dbStorage DBStorage) DeleteBinary(binary service.BinaryData, ctx context.Context) (int64, error) {
+	var checkEntry service.BinaryData
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), binary.Login, binary.CollectionID, true)
+	if err != nil {
+		return 0, err
+	}
+	err = dbStorage.db.WithContext(ctx).Scopes(v.scope).Where("description = ?", binary.Description).First(&checkEntry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrEmpty
+		}
+		return 0, err
+	}
+
+	checkEntry.Binary = ""
+	checkEntry.Size = 0
+	checkEntry.ChunkSize = 0
+	checkEntry.ChunkCount = 0
+	checkEntry.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	checkEntry.Login = v.login
+	err = saveRevised(dbStorage.db.WithContext(ctx), v, &checkEntry.Revision, &checkEntry,
+		checkRevision(&service.BinaryData{}, checkEntry.ID, binary.Revision), dropChunks(checkEntry.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	return checkEntry.Revision, nil
+}
+
 func (dbStorage DBStorage) DeleteAll() {
 	dbStorage.db.Exec("DELETE FROM users")
 	dbStorage.db.Exec("DELETE FROM logo_passes")
 	dbStorage.db.Exec("DELETE FROM text_data")
 	dbStorage.db.Exec("DELETE FROM credit_cards")
 	dbStorage.db.Exec("DELETE FROM binary_data")
+	dbStorage.db.Exec("DELETE FROM upload_sessions")
+	dbStorage.db.Exec("DELETE FROM binary_chunks")
+	dbStorage.db.Exec("DELETE FROM refresh_tokens")
+	dbStorage.db.Exec("DELETE FROM sessions")
+	dbStorage.db.Exec("DELETE FROM two_factors")
+	dbStorage.db.Exec("DELETE FROM recovery_codes")
+	dbStorage.db.Exec("DELETE FROM audit_events")
+	dbStorage.db.Exec("DELETE FROM secret_versions")
+	dbStorage.db.Exec("DELETE FROM key_pairs")
+	dbStorage.db.Exec("DELETE FROM shares")
+	dbStorage.db.Exec("DELETE FROM organizations")
+	dbStorage.db.Exec("DELETE FROM org_members")
+	dbStorage.db.Exec("DELETE FROM collections")
+	dbStorage.db.Exec("DELETE FROM collection_members")
+	dbStorage.db.Exec("DELETE FROM sends")
 }
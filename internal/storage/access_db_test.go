@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"sync"
+	"testing"
+)
+
+func TestPutTextConcurrently(t *testing.T) {
+	dbStorage := testStorage(t)
+	ctx := context.Background()
+
+	suffix, err := tools.GenerateRandomString(4)
+	require.NoError(t, err)
+	login := "put-" + suffix
+	require.NoError(t, dbStorage.RegisterUser(service.User{Login: login, Password: "password"}, ctx))
+	defer func() {
+		_ = dbStorage.DeleteUser(login, ctx)
+	}()
+
+	// the texts with the same description are created at once, only one of them may be stored
+	const puts = 4
+	errs := make([]error, puts)
+	var wg sync.WaitGroup
+	for i := 0; i < puts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = dbStorage.PutText(service.TextData{Login: login, Text: "never gonna",
+				Description: "concurrent"}, ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	var stored int
+	for _, err := range errs {
+		if err == nil {
+			stored++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrAlreadyExists)
+	}
+	assert.Equal(t, 1, stored)
+
+	texts, err := dbStorage.BatchGetTexts(login, 0, ctx)
+	require.NoError(t, err)
+	assert.Len(t, texts, 1)
+}
@@ -0,0 +1,219 @@
+package storage
+
+// Here is the management of the accounts by the admins: they see the accounts and how much space they take,
+// but never the secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetUser returns the account of a user without the password hash
+func (dbStorage DBStorage) GetUser(login string, ctx context.Context) (service.User, error) {
+	var user service.User
+
+	err := dbStorage.db.WithContext(ctx).Select("id, login, role, disabled, created_at").
+		Where("login = ?", login).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, ErrEmpty
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// SetRole gives the role to a user
+func (dbStorage DBStorage) SetRole(login string, role string, ctx context.Context) error {
+	result := dbStorage.db.WithContext(ctx).Model(&service.User{}).Where("login = ?", login).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmpty
+	}
+	return nil
+}
+
+// DemoteAdmins takes the admin role away from every admin whose login is not among the ones kept,
+// returns the logins demoted
+func (dbStorage DBStorage) DemoteAdmins(keep []string, ctx context.Context) ([]string, error) {
+	var demoted []string
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&service.User{}).Where("role = ?", service.RoleAdmin)
+		if len(keep) > 0 {
+			query = query.Where("login NOT IN ?", keep)
+		}
+		err := query.Clauses(clause.Locking{Strength: "UPDATE"}).Pluck("login", &demoted).Error
+		if err != nil || len(demoted) == 0 {
+			return err
+		}
+		return tx.Model(&service.User{}).Where("login IN ?", demoted).Update("role", service.RoleUser).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return demoted, nil
+}
+
+// ListUsers returns all the accounts along with the usage of the storage by each of them
+func (dbStorage DBStorage) ListUsers(ctx context.Context) ([]service.UserSummary, error) {
+	var users []service.User
+
+	err := dbStorage.db.WithContext(ctx).Select("login, role, disabled, created_at").Order("login").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]service.UserSummary, 0, len(users))
+	for _, user := range users {
+		usage, err := dbStorage.GetUsage(user.Login, ctx)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, service.UserSummary{Login: user.Login, Role: user.Role,
+			Disabled: user.Disabled, CreatedAt: user.CreatedAt, Usage: usage})
+	}
+	return summaries, nil
+}
+
+// SetDisabled disables or enables the account of a user. All the sessions of a disabled user are revoked,
+// so that the user is signed out everywhere at once.
+func (dbStorage DBStorage) SetDisabled(login string, disabled bool, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&service.User{}).Where("login = ?", login).Update("disabled", disabled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+		if !disabled {
+			return nil
+		}
+		return deleteSessions(tx, login)
+	})
+}
+
+// DeleteSessions revokes all the sessions of a user along with their refresh tokens
+func (dbStorage DBStorage) DeleteSessions(login string, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user service.User
+		err := tx.Select("id").Where("login = ?", login).First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmpty
+			}
+			return err
+		}
+		return deleteSessions(tx, login)
+	})
+}
+
+// deleteSessions revokes all the sessions of a user within the transaction
+func deleteSessions(tx *gorm.DB, login string) error {
+	err := tx.Where("login = ?", login).Delete(&service.Session{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("login = ?", login).Delete(&service.RefreshToken{}).Error
+}
+
+// DeleteUser removes the account of a user along with all the personal secrets, tombstones included, and everything
+// else stored for the user in a single transaction. The user leaves the organizations, the secrets stored
+// in their collections stay. The organizations left without members are removed along with their collections.
+// ErrLastOwner is returned if the user is the last owner of an organization that has other members.
+func (dbStorage DBStorage) DeleteUser(login string, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("login = ?", login).Delete(&service.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+
+		personal := personalVault(login)
+		err := tx.Where("binary_id IN (?) OR upload_id IN (?)",
+			tx.Unscoped().Model(&service.BinaryData{}).Select("id").Scopes(personal.scope),
+			tx.Model(&service.UploadSession{}).Select("id").Where("login = ?", login)).
+			Delete(&service.BinaryChunk{}).Error
+		if err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&service.LogoPass{}, &service.TextData{}, &service.CreditCard{},
+			&service.BinaryData{}, &service.SecretVersion{}} {
+			err = tx.Unscoped().Scopes(personal.scope).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, model := range []interface{}{&service.UploadSession{}, &service.TwoFactor{}, &service.RecoveryCode{},
+			&service.AuditEvent{}, &service.KeyPair{}, &service.CollectionMember{}, &service.Send{}} {
+			err = tx.Unscoped().Where("login = ?", login).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = leaveOrgs(tx, login)
+		if err != nil {
+			return err
+		}
+		err = tx.Where("owner = ? OR recipient = ?", login, login).Delete(&service.Share{}).Error
+		if err != nil {
+			return err
+		}
+		return deleteSessions(tx, login)
+	})
+}
+
+// leaveOrgs removes the user from all the organizations within the transaction,
+// the organizations left without members are removed. ErrLastOwner is returned if the user is the last owner
+// of an organization that has other members, the ownership is to be handed over or the organization removed first.
+func leaveOrgs(tx *gorm.DB, login string) error {
+	var memberships []service.OrgMember
+	err := tx.Where("login = ?", login).Find(&memberships).Error
+	if err != nil || len(memberships) == 0 {
+		return err
+	}
+	orgs := make([]uint, 0, len(memberships))
+	for _, member := range memberships {
+		orgs = append(orgs, member.OrgID)
+		var others int64
+		err = tx.Model(&service.OrgMember{}).Where("org_id = ? AND login <> ?", member.OrgID, login).
+			Count(&others).Error
+		if err != nil {
+			return err
+		}
+		if others == 0 {
+			continue
+		}
+		err = keepOwner(tx, member)
+		if err != nil {
+			return fmt.Errorf("organization %d: %w", member.OrgID, err)
+		}
+	}
+	err = tx.Where("login = ?", login).Delete(&service.OrgMember{}).Error
+	if err != nil {
+		return err
+	}
+
+	var abandoned []uint
+	err = tx.Model(&service.Organization{}).Where("id IN ? AND id NOT IN (?)", orgs,
+		tx.Model(&service.OrgMember{}).Select("org_id")).Pluck("id", &abandoned).Error
+	if err != nil {
+		return err
+	}
+	for _, org := range abandoned {
+		err = deleteOrg(tx, org)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
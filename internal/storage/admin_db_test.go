@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"github.com/caarlos0/env/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gophkeeper/internal/config"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"testing"
+)
+
+// Чтобы запустить тест, необходимо поднять Postgres базу и написать url в DATABASE_URI,
+// без базы тест пропускается
+
+// testStorage opens the database of the config, the test is skipped if it can't be reached
+func testStorage(t *testing.T) *DBStorage {
+	var cfg config.Config
+	require.NoError(t, env.Parse(&cfg))
+	connection, err := gorm.Open(postgres.Open(cfg.DatabaseDSN), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Skipf("database is not available: %s", err)
+	}
+	initializeTables(connection)
+	return &DBStorage{db: connection}
+}
+
+func TestDeleteUserLastOwner(t *testing.T) {
+	dbStorage := testStorage(t)
+	ctx := context.Background()
+
+	suffix, err := tools.GenerateRandomString(4)
+	require.NoError(t, err)
+	owner, member := "owner-"+suffix, "member-"+suffix
+	for _, login := range []string{owner, member} {
+		require.NoError(t, dbStorage.RegisterUser(service.User{Login: login, Password: "password"}, ctx))
+		require.NoError(t, dbStorage.PutKeyPair(service.KeyPair{Login: login, PublicKey: "public"}, ctx))
+	}
+	defer func() {
+		_ = dbStorage.DeleteUser(member, ctx)
+		_ = dbStorage.DeleteUser(owner, ctx)
+	}()
+
+	org, err := dbStorage.CreateOrg(service.Organization{Name: "org-" + suffix},
+		service.OrgMember{Login: owner, OrgKey: "key"}, ctx)
+	require.NoError(t, err)
+	require.NoError(t, dbStorage.PutMember(owner, service.OrgMember{OrgID: org.ID, Login: member,
+		Role: service.OrgRoleMember, OrgKey: "key"}, ctx))
+
+	// the last owner can't leave the members behind, nothing is deleted
+	err = dbStorage.DeleteUser(owner, ctx)
+	assert.ErrorIs(t, err, ErrLastOwner)
+	_, err = dbStorage.GetUser(owner, ctx)
+	assert.NoError(t, err)
+	orgs, err := dbStorage.GetOrgs(member, ctx)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Len(t, orgs[0].Members, 2)
+
+	// once the ownership is handed over the owner leaves and the organization stays
+	require.NoError(t, dbStorage.PutMember(owner, service.OrgMember{OrgID: org.ID, Login: member,
+		Role: service.OrgRoleOwner}, ctx))
+	require.NoError(t, dbStorage.DeleteUser(owner, ctx))
+	orgs, err = dbStorage.GetOrgs(member, ctx)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Equal(t, service.OrgRoleOwner, orgs[0].Role)
+
+	// the sole member leaves and the organization is gone
+	require.NoError(t, dbStorage.DeleteUser(member, ctx))
+	var orgCount int64
+	require.NoError(t, dbStorage.db.Model(&service.Organization{}).Where("id = ?", org.ID).Count(&orgCount).Error)
+	assert.Zero(t, orgCount)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"gophkeeper/internal/service"
+)
+
+// PutAuditEvent records an event of a user's audit log
+func (dbStorage DBStorage) PutAuditEvent(event service.AuditEvent, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Create(&event).Error
+}
+
+// GetAuditEvents returns up to limit events of a user's audit log that came before the event with the ID given,
+// the most recent ones first. The latest events are returned if before is 0.
+func (dbStorage DBStorage) GetAuditEvents(login string, before uint, limit int, ctx context.Context) ([]service.AuditEvent, error) {
+	var events []service.AuditEvent
+
+	query := dbStorage.db.WithContext(ctx).Where("login = ?", login)
+	if before > 0 {
+		query = query.Where("id < ?", before)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrEmpty
+	}
+	return events, nil
+}
@@ -0,0 +1,221 @@
+package storage
+
+// Here is the history of the secrets. Every time a logo-pass pair, a text or a credit card is overwritten,
+// deleted or restored, the version it replaces is kept, so that a wrong edit or a bad sync could be undone.
+// Binaries have no history, as the previous content of a chunked one would take all of its chunks.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"time"
+)
+
+// maxVersions is the number of the previous versions kept for every secret, older ones are dropped
+const maxVersions = 10
+
+// keepVersion returns the step of saveRevised that keeps the version of the secret the entry replaces
+// and drops the oldest versions of the secret beyond maxVersions. Tombstones have nothing to keep.
+func keepVersion(v vault, item string, id uint, replaced interface{}, deleted bool) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if id == 0 || deleted {
+			return nil
+		}
+		entry, err := json.Marshal(replaced)
+		if err != nil {
+			return err
+		}
+		revision, err := v.revision(tx)
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&service.SecretVersion{Login: v.login, CollectionID: v.collection, Revision: revision,
+			Item: item, ItemID: id, Entry: string(entry)}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Scopes(v.scope).Where("item = ? AND item_id = ? AND id NOT IN (?)", item, id,
+			tx.Model(&service.SecretVersion{}).Select("id").Scopes(v.scope).Where("item = ? AND item_id = ?",
+				item, id).Order("id DESC").Limit(maxVersions)).
+			Delete(&service.SecretVersion{}).Error
+	}
+}
+
+// decodeVersion fills the secret of the version from its entry
+func decodeVersion(version *service.SecretVersion) error {
+	var err error
+	switch version.Item {
+	case ItemLogoPass:
+		version.LogoPass = &service.LogoPass{}
+		err = json.Unmarshal([]byte(version.Entry), version.LogoPass)
+	case ItemText:
+		version.Text = &service.TextData{}
+		err = json.Unmarshal([]byte(version.Entry), version.Text)
+	case ItemCreditCard:
+		version.CreditCard = &service.CreditCard{}
+		err = json.Unmarshal([]byte(version.Entry), version.CreditCard)
+	default:
+		return fmt.Errorf("version %d: %w", version.ID, ErrUnknownItem)
+	}
+	if err != nil {
+		return fmt.Errorf("version %d: %w", version.ID, err)
+	}
+	return nil
+}
+
+// GetVersions returns the previous versions of the secret of the kind and with the ID given kept in the vault:
+// the personal one of the user if collection is 0, the collection otherwise. The most recent versions come first.
+// Every version of the kind is returned if id is 0, every version of the vault if item is empty.
+// ErrForbidden is returned if the user can't see the collection.
+func (dbStorage DBStorage) GetVersions(login string, collection uint, item string, id uint, ctx context.Context) ([]service.SecretVersion, error) {
+	var versions []service.SecretVersion
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), login, collection, false)
+	if err != nil {
+		return nil, err
+	}
+	query := dbStorage.db.WithContext(ctx).Scopes(v.scope)
+	if item != "" {
+		query = query.Where("item = ?", item)
+	}
+	if id != 0 {
+		query = query.Where("item_id = ?", id)
+	}
+	err = query.Order("id DESC").Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrEmpty
+	}
+	for i := range versions {
+		err = decodeVersion(&versions[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// restoredAt returns the time the restored secret is updated at, it is never older than the version replaced,
+// so that the devices holding that one don't take the restored secret for the older one
+func restoredAt(replaced time.Time) time.Time {
+	now := time.Now()
+	if replaced.After(now) {
+		return replaced
+	}
+	return now
+}
+
+// RestoreVersion makes the version of the secret the current one, the secret is brought back
+// if it has been deleted. The version replaced is kept in its turn, so the restore can be undone as well.
+// The versions of the collections are restored by their members, version.Login being the member restoring it,
+// ErrForbidden is returned unless the member can change the secrets of the collection.
+// The secret is replaced only if the client has edited its revision, version.SecretRevision, see matchRevision.
+// Returns the version restored along with its secret.
+func (dbStorage DBStorage) RestoreVersion(version service.SecretVersion, ctx context.Context) (service.SecretVersion, error) {
+	db := dbStorage.db.WithContext(ctx)
+	login := version.Login
+	err := db.Where("id = ?", version.ID).First(&version).Error
+	if err == nil && version.CollectionID == 0 && version.Login != login {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return version, ErrEmpty
+		}
+		return version, err
+	}
+	version.Login = login
+	v, err := openVault(db, login, version.CollectionID, true)
+	if err != nil {
+		return version, err
+	}
+	err = decodeVersion(&version)
+	if err != nil {
+		return version, err
+	}
+
+	// the secret is loaded under the lock of the vault, so that no change made meanwhile is overwritten unseen,
+	// the revision is taken by saveRevised before that
+	var revision int64
+	switch version.Item {
+	case ItemLogoPass:
+		var restored service.LogoPass
+		err = saveRevised(db, v, &revision, &restored, func(tx *gorm.DB) error {
+			var current service.LogoPass
+			err := tx.Unscoped().Scopes(v.scope).Where("id = ?", version.ItemID).First(&current).Error
+			if err != nil {
+				return err
+			}
+			restored = current
+			restored.Revision = revision
+			restored.Login = version.Login
+			restored.SecretLogin = version.LogoPass.SecretLogin
+			restored.SecretPass = version.LogoPass.SecretPass
+			restored.UpdatedAt = restoredAt(current.UpdatedAt)
+			restored.DeletedAt = gorm.DeletedAt{}
+			change := quotaChange{item: ItemLogoPass, added: current.DeletedAt.Valid,
+				bytes: logoPassSize(restored) - logoPassSize(current)}
+			return runSteps(tx,
+				checkRevision(&service.LogoPass{}, current.ID, version.SecretRevision),
+				dbStorage.withinQuota(v, change),
+				keepVersion(v, ItemLogoPass, current.ID, current, current.DeletedAt.Valid))
+		})
+	case ItemText:
+		var restored service.TextData
+		err = saveRevised(db, v, &revision, &restored, func(tx *gorm.DB) error {
+			var current service.TextData
+			err := tx.Unscoped().Scopes(v.scope).Where("id = ?", version.ItemID).First(&current).Error
+			if err != nil {
+				return err
+			}
+			restored = current
+			restored.Revision = revision
+			restored.Login = version.Login
+			restored.Text = version.Text.Text
+			restored.UpdatedAt = restoredAt(current.UpdatedAt)
+			restored.DeletedAt = gorm.DeletedAt{}
+			change := quotaChange{item: ItemText, added: current.DeletedAt.Valid,
+				bytes: textSize(restored) - textSize(current)}
+			return runSteps(tx,
+				checkRevision(&service.TextData{}, current.ID, version.SecretRevision),
+				dbStorage.withinQuota(v, change),
+				keepVersion(v, ItemText, current.ID, current, current.DeletedAt.Valid))
+		})
+	case ItemCreditCard:
+		var restored service.CreditCard
+		err = saveRevised(db, v, &revision, &restored, func(tx *gorm.DB) error {
+			var current service.CreditCard
+			err := tx.Unscoped().Scopes(v.scope).Where("id = ?", version.ItemID).First(&current).Error
+			if err != nil {
+				return err
+			}
+			restored = current
+			restored.Revision = revision
+			restored.Login = version.Login
+			restored.Holder = version.CreditCard.Holder
+			restored.DueDate = version.CreditCard.DueDate
+			restored.CVV = version.CreditCard.CVV
+			restored.Description = version.CreditCard.Description
+			restored.UpdatedAt = restoredAt(current.UpdatedAt)
+			restored.DeletedAt = gorm.DeletedAt{}
+			change := quotaChange{item: ItemCreditCard, added: current.DeletedAt.Valid, bytes: creditCardSize(restored)}
+			if !current.DeletedAt.Valid {
+				// tombstones of the cards keep the number
+				change.bytes -= creditCardSize(current)
+			}
+			return runSteps(tx,
+				checkRevision(&service.CreditCard{}, current.ID, version.SecretRevision),
+				dbStorage.withinQuota(v, change),
+				keepVersion(v, ItemCreditCard, current.ID, current, current.DeletedAt.Valid))
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return version, ErrEmpty
+	}
+	return version, err
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"gophkeeper/internal/service"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -9,11 +10,13 @@ import (
 
 // DBStorage hold pointer to gorm type DB
 type DBStorage struct {
-	db *gorm.DB
+	db     *gorm.DB
+	quotas Quotas
 }
 
-// NewUserStorage is used to open a connection to a postgres db and migrate all the tables needed
-func NewUserStorage(databaseURL string) *DBStorage {
+// NewUserStorage is used to open a connection to a postgres db and migrate all the tables needed.
+// The secrets every user stores are limited by quotas.
+func NewUserStorage(databaseURL string, quotas Quotas) *DBStorage {
 	connection, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("database failed to open: %s", err)
@@ -30,10 +33,33 @@ func NewUserStorage(databaseURL string) *DBStorage {
 	log.Printf("Database connection successful")
 
 	initializeTables(connection)
+	err = registerQueryMetrics(connection)
+	if err != nil {
+		log.Fatalf("database failed to register metrics: %s", err)
+	}
 
 	return &DBStorage{
-		db: connection,
+		db:     connection,
+		quotas: quotas,
+	}
+}
+
+// Ping checks that the database can be reached
+func (dbStorage DBStorage) Ping(ctx context.Context) error {
+	sqlDB, err := dbStorage.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connection pool of the database, the storage can't be used after that
+func (dbStorage DBStorage) Close() error {
+	sqlDB, err := dbStorage.db.DB()
+	if err != nil {
+		return err
 	}
+	return sqlDB.Close()
 }
 
 func initializeTables(connection *gorm.DB) {
@@ -57,4 +83,64 @@ func initializeTables(connection *gorm.DB) {
 	if err != nil {
 		log.Fatalf("database failed to create user table: %s", err)
 	}
+	err = connection.AutoMigrate(service.UploadSession{})
+	if err != nil {
+		log.Fatalf("database failed to create upload session table: %s", err)
+	}
+	err = connection.AutoMigrate(service.BinaryChunk{})
+	if err != nil {
+		log.Fatalf("database failed to create binary chunk table: %s", err)
+	}
+	err = connection.AutoMigrate(service.RefreshToken{})
+	if err != nil {
+		log.Fatalf("database failed to create refresh token table: %s", err)
+	}
+	err = connection.AutoMigrate(service.Session{})
+	if err != nil {
+		log.Fatalf("database failed to create session table: %s", err)
+	}
+	err = connection.AutoMigrate(service.TwoFactor{})
+	if err != nil {
+		log.Fatalf("database failed to create two factor table: %s", err)
+	}
+	err = connection.AutoMigrate(service.RecoveryCode{})
+	if err != nil {
+		log.Fatalf("database failed to create recovery code table: %s", err)
+	}
+	err = connection.AutoMigrate(service.AuditEvent{})
+	if err != nil {
+		log.Fatalf("database failed to create audit event table: %s", err)
+	}
+	err = connection.AutoMigrate(service.SecretVersion{})
+	if err != nil {
+		log.Fatalf("database failed to create secret version table: %s", err)
+	}
+	err = connection.AutoMigrate(service.KeyPair{})
+	if err != nil {
+		log.Fatalf("database failed to create key pair table: %s", err)
+	}
+	err = connection.AutoMigrate(service.Share{})
+	if err != nil {
+		log.Fatalf("database failed to create share table: %s", err)
+	}
+	err = connection.AutoMigrate(service.Organization{})
+	if err != nil {
+		log.Fatalf("database failed to create organization table: %s", err)
+	}
+	err = connection.AutoMigrate(service.OrgMember{})
+	if err != nil {
+		log.Fatalf("database failed to create organization member table: %s", err)
+	}
+	err = connection.AutoMigrate(service.Collection{})
+	if err != nil {
+		log.Fatalf("database failed to create collection table: %s", err)
+	}
+	err = connection.AutoMigrate(service.CollectionMember{})
+	if err != nil {
+		log.Fatalf("database failed to create collection member table: %s", err)
+	}
+	err = connection.AutoMigrate(service.Send{})
+	if err != nil {
+		log.Fatalf("database failed to create send table: %s", err)
+	}
 }
@@ -0,0 +1,91 @@
+package storage
+
+// Here is the instrumentation of the database: latency of every query is observed by gorm callbacks
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"time"
+)
+
+// queryStartKey is the key of gorm instance settings holding the start time of a query
+const queryStartKey = "metrics:query_start"
+
+// Kinds of the secrets counted by CountItems
+const (
+	ItemLogoPass   = "logopass"
+	ItemText       = "text"
+	ItemCreditCard = "credit_card"
+	ItemBinary     = "binary"
+)
+
+// itemModels are the models of the secrets of every kind
+var itemModels = map[string]interface{}{
+	ItemLogoPass:   &service.LogoPass{},
+	ItemText:       &service.TextData{},
+	ItemCreditCard: &service.CreditCard{},
+	ItemBinary:     &service.BinaryData{},
+}
+
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "gophkeeper",
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "Latency of the database queries by operation and table.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table"})
+
+// registerQueryMetrics makes gorm observe the latency of every query of the connection
+func registerQueryMetrics(connection *gorm.DB) error {
+	callbacks := connection.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("*").Register("metrics:start_create", startQuery),
+		callbacks.Create().After("*").Register("metrics:observe_create", observeQuery("create")),
+		callbacks.Query().Before("*").Register("metrics:start_query", startQuery),
+		callbacks.Query().After("*").Register("metrics:observe_query", observeQuery("query")),
+		callbacks.Update().Before("*").Register("metrics:start_update", startQuery),
+		callbacks.Update().After("*").Register("metrics:observe_update", observeQuery("update")),
+		callbacks.Delete().Before("*").Register("metrics:start_delete", startQuery),
+		callbacks.Delete().After("*").Register("metrics:observe_delete", observeQuery("delete")),
+		callbacks.Row().Before("*").Register("metrics:start_row", startQuery),
+		callbacks.Row().After("*").Register("metrics:observe_row", observeQuery("row")),
+		callbacks.Raw().Before("*").Register("metrics:start_raw", startQuery),
+		callbacks.Raw().After("*").Register("metrics:observe_raw", observeQuery("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		start, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		queryDuration.WithLabelValues(operation, db.Statement.Table).Observe(time.Since(start.(time.Time)).Seconds())
+	}
+}
+
+// CountItems returns the number of stored secrets of every kind, deleted ones are not counted
+func (dbStorage DBStorage) CountItems(ctx context.Context) (map[string]int64, error) {
+	counts := make(map[string]int64, len(itemModels))
+	for item, model := range itemModels {
+		var count int64
+		err := dbStorage.db.WithContext(ctx).Model(model).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		counts[item] = count
+	}
+	return counts, nil
+}
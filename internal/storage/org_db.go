@@ -0,0 +1,480 @@
+package storage
+
+// Here are the organizations. An organization keeps its secrets in collections, every collection being a vault
+// of its own along with the personal vaults of the users. The secrets of a collection are seen by the members
+// added to it and by the owners and the admins of the organization, all of them but the read-only members
+// change the secrets as well. The secrets are encrypted by the clients with the key of the organization,
+// the server keeps it only wrapped with the public key of every member.
+// Every query of the secrets is limited to a single vault, see vault.scope.
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// vault is where the secrets are kept: the personal vault of login if collection is 0, the collection otherwise.
+// The secrets of a collection keep the login of the member who stored them last.
+type vault struct {
+	login      string
+	collection uint
+}
+
+// personalVault returns the personal vault of the user
+func personalVault(login string) vault {
+	return vault{login: login}
+}
+
+// scope limits the query to the secrets of the vault, it suits every model having both login and collection_id
+func (v vault) scope(db *gorm.DB) *gorm.DB {
+	if v.collection == 0 {
+		return db.Where("login = ? AND collection_id = 0", v.login)
+	}
+	return db.Where("collection_id = ?", v.collection)
+}
+
+// owner returns the query of the row the revision of the vault is kept in: the user or the collection
+func (v vault) owner(db *gorm.DB) *gorm.DB {
+	if v.collection == 0 {
+		return db.Model(&service.User{}).Where("login = ?", v.login)
+	}
+	return db.Model(&service.Collection{}).Where("id = ?", v.collection)
+}
+
+// revision returns the revision of the vault's data, gorm.ErrRecordNotFound is returned if there is no such vault
+func (v vault) revision(db *gorm.DB) (int64, error) {
+	var revisions []int64
+	err := v.owner(db).Limit(1).Pluck("revision", &revisions).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(revisions) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return revisions[0], nil
+}
+
+// manager reports whether the role lets the member manage the organization and see all of its collections
+func manager(role string) bool {
+	return role == service.OrgRoleOwner || role == service.OrgRoleAdmin
+}
+
+// openVault returns the vault of the collection the user asks for, the personal vault of the user if collection is 0.
+// ErrForbidden is returned unless the user can see the collection, or change its secrets if write is set.
+func openVault(db *gorm.DB, login string, collection uint, write bool) (vault, error) {
+	if collection == 0 {
+		return personalVault(login), nil
+	}
+	role, err := collectionRole(db, login, collection)
+	if err != nil {
+		return vault{}, err
+	}
+	if write && role == service.OrgRoleReadOnly {
+		return vault{}, ErrForbidden
+	}
+	return vault{login: login, collection: collection}, nil
+}
+
+// collectionRole returns the role of the user in the organization of the collection,
+// ErrForbidden is returned if the user can't see the collection
+func collectionRole(db *gorm.DB, login string, collection uint) (string, error) {
+	var member service.OrgMember
+	err := db.Where("login = ? AND org_id = (?)", login,
+		db.Model(&service.Collection{}).Select("org_id").Where("id = ?", collection)).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrForbidden
+		}
+		return "", err
+	}
+	if manager(member.Role) {
+		return member.Role, nil
+	}
+
+	var added int64
+	err = db.Model(&service.CollectionMember{}).Where("collection_id = ? AND login = ?", collection, login).
+		Count(&added).Error
+	if err != nil {
+		return "", err
+	}
+	if added == 0 {
+		return "", ErrForbidden
+	}
+	return member.Role, nil
+}
+
+// orgRole returns the role of the user in the organization, ErrEmpty is returned if the user is not a member
+func orgRole(tx *gorm.DB, login string, org uint) (string, error) {
+	var member service.OrgMember
+	err := tx.Select("role").Where("org_id = ? AND login = ?", org, login).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrEmpty
+	}
+	return member.Role, err
+}
+
+// orgManager returns ErrForbidden unless the user manages the organization, and the role of the user if so
+func orgManager(tx *gorm.DB, login string, org uint) (string, error) {
+	role, err := orgRole(tx, login, org)
+	if err != nil {
+		return "", err
+	}
+	if !manager(role) {
+		return "", ErrForbidden
+	}
+	return role, nil
+}
+
+// keepOwner returns ErrLastOwner if the member to be removed or demoted is the last owner of the organization
+func keepOwner(tx *gorm.DB, member service.OrgMember) error {
+	if member.Role != service.OrgRoleOwner {
+		return nil
+	}
+	var owners int64
+	err := tx.Model(&service.OrgMember{}).Where("org_id = ? AND role = ?", member.OrgID, service.OrgRoleOwner).
+		Count(&owners).Error
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// CreateOrg creates the organization with the user as its owner, the owner holding the key of the organization
+// wrapped with the public key of the user. ErrAlreadyExists is returned if the name is taken.
+// Returns the organization created.
+func (dbStorage DBStorage) CreateOrg(org service.Organization, owner service.OrgMember, ctx context.Context) (service.Organization, error) {
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		err := tx.Model(&service.Organization{}).Where("name = ?", org.Name).Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrAlreadyExists
+		}
+
+		org = service.Organization{Name: org.Name, CreatedAt: time.Now()}
+		err = tx.Create(&org).Error
+		if err != nil {
+			return err
+		}
+		owner.OrgID = org.ID
+		owner.Role = service.OrgRoleOwner
+		return tx.Create(&owner).Error
+	})
+	org.Role = owner.Role
+	org.OrgKey = owner.OrgKey
+	return org, err
+}
+
+// GetOrgs returns the organizations of the user along with their members and the collections the user can see.
+// Every organization comes with the role of the user and the key of the organization wrapped for the user,
+// the keys of other members are never returned.
+func (dbStorage DBStorage) GetOrgs(login string, ctx context.Context) ([]service.Organization, error) {
+	db := dbStorage.db.WithContext(ctx)
+
+	var memberships []service.OrgMember
+	err := db.Where("login = ?", login).Order("org_id").Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return nil, ErrEmpty
+	}
+
+	orgs := make([]service.Organization, 0, len(memberships))
+	for _, membership := range memberships {
+		var org service.Organization
+		err = db.Where("id = ?", membership.OrgID).First(&org).Error
+		if err != nil {
+			return nil, err
+		}
+		org.Role = membership.Role
+		org.OrgKey = membership.OrgKey
+
+		err = db.Select("org_id, login, role, created_at").Where("org_id = ?", org.ID).Order("login").
+			Find(&org.Members).Error
+		if err != nil {
+			return nil, err
+		}
+		query := db.Where("org_id = ?", org.ID)
+		if !manager(org.Role) {
+			query = query.Where("id IN (?)",
+				db.Model(&service.CollectionMember{}).Select("collection_id").Where("login = ?", login))
+		}
+		err = query.Order("name").Find(&org.Collections).Error
+		if err != nil {
+			return nil, err
+		}
+		for i := range org.Collections {
+			err = db.Model(&service.CollectionMember{}).Where("collection_id = ?", org.Collections[i].ID).
+				Order("login").Pluck("login", &org.Collections[i].Members).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, nil
+}
+
+// DeleteOrg removes the organization along with its collections and all of their secrets, only the owners can.
+// ErrEmpty is returned if the user is not a member of the organization.
+func (dbStorage DBStorage) DeleteOrg(login string, id uint, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := orgRole(tx, login, id)
+		if err != nil {
+			return err
+		}
+		if role != service.OrgRoleOwner {
+			return ErrForbidden
+		}
+		return deleteOrg(tx, id)
+	})
+}
+
+// deleteOrg removes the organization along with its collections within the transaction
+func deleteOrg(tx *gorm.DB, id uint) error {
+	var collections []uint
+	err := tx.Model(&service.Collection{}).Where("org_id = ?", id).Pluck("id", &collections).Error
+	if err != nil {
+		return err
+	}
+	err = deleteCollections(tx, collections)
+	if err != nil {
+		return err
+	}
+	err = tx.Where("org_id = ?", id).Delete(&service.OrgMember{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("id = ?", id).Delete(&service.Organization{}).Error
+}
+
+// deleteCollections removes the collections along with all of their secrets, tombstones, unfinished uploads
+// and previous versions included, within the transaction
+func deleteCollections(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := tx.Where("binary_id IN (?) OR upload_id IN (?)",
+		tx.Unscoped().Model(&service.BinaryData{}).Select("id").Where("collection_id IN ?", ids),
+		tx.Model(&service.UploadSession{}).Select("id").Where("collection_id IN ?", ids)).
+		Delete(&service.BinaryChunk{}).Error
+	if err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&service.LogoPass{}, &service.TextData{}, &service.CreditCard{},
+		&service.BinaryData{}, &service.UploadSession{}, &service.SecretVersion{}, &service.CollectionMember{}} {
+		err = tx.Unscoped().Where("collection_id IN ?", ids).Delete(model).Error
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN ?", ids).Delete(&service.Collection{}).Error
+}
+
+// PutMember adds the user to the organization with the role given or changes the role of a member,
+// the key of the organization is replaced as well unless member.OrgKey is empty. Owners and admins manage
+// the members, only owners manage other owners and admins. ErrNoKeyPair is returned if the user added
+// has no keys to wrap the key of the organization with or it is not wrapped, ErrLastOwner if the last owner is demoted.
+func (dbStorage DBStorage) PutMember(login string, member service.OrgMember, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := orgManager(tx, login, member.OrgID)
+		if err != nil {
+			return err
+		}
+
+		var stored service.OrgMember
+		err = tx.Where("org_id = ? AND login = ?", member.OrgID, member.Login).First(&stored).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if role != service.OrgRoleOwner && (manager(member.Role) || manager(stored.Role)) {
+			return ErrForbidden
+		}
+		if stored.Login == "" {
+			var keys int64
+			err = tx.Model(&service.KeyPair{}).Where("login = ?", member.Login).Count(&keys).Error
+			if err != nil {
+				return err
+			}
+			if keys == 0 || member.OrgKey == "" {
+				return ErrNoKeyPair
+			}
+		} else if member.Role != service.OrgRoleOwner {
+			err = keepOwner(tx, stored)
+			if err != nil {
+				return err
+			}
+		}
+
+		columns := []string{"role"}
+		if member.OrgKey != "" {
+			columns = append(columns, "org_key")
+		}
+		member.CreatedAt = time.Now()
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "org_id"}, {Name: "login"}},
+			DoUpdates: clause.AssignmentColumns(columns),
+		}).Create(&member).Error
+	})
+}
+
+// DeleteMember removes the member from the organization and from all of its collections. Owners and admins
+// remove the members, only owners remove other owners and admins, and every member can leave.
+// ErrEmpty is returned if there is no such member, ErrLastOwner if the member is the last owner.
+func (dbStorage DBStorage) DeleteMember(login string, member service.OrgMember, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role := service.OrgRoleOwner
+		if member.Login != login {
+			var err error
+			role, err = orgManager(tx, login, member.OrgID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Where("org_id = ? AND login = ?", member.OrgID, member.Login).First(&member).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmpty
+			}
+			return err
+		}
+		if role != service.OrgRoleOwner && manager(member.Role) {
+			return ErrForbidden
+		}
+		err = keepOwner(tx, member)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("login = ? AND collection_id IN (?)", member.Login,
+			tx.Model(&service.Collection{}).Select("id").Where("org_id = ?", member.OrgID)).
+			Delete(&service.CollectionMember{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("org_id = ? AND login = ?", member.OrgID, member.Login).Delete(&service.OrgMember{}).Error
+	})
+}
+
+// PutCollection creates the collection in the organization, or renames it if collection.ID is set.
+// Only owners and admins can. ErrAlreadyExists is returned if the organization has a collection with the name,
+// ErrEmpty if there is no such collection to rename. Returns the collection stored.
+func (dbStorage DBStorage) PutCollection(login string, collection service.Collection, ctx context.Context) (service.Collection, error) {
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := orgManager(tx, login, collection.OrgID)
+		if err != nil {
+			return err
+		}
+
+		var taken int64
+		err = tx.Model(&service.Collection{}).Where("org_id = ? AND name = ? AND id <> ?", collection.OrgID,
+			collection.Name, collection.ID).Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrAlreadyExists
+		}
+
+		if collection.ID == 0 {
+			collection = service.Collection{OrgID: collection.OrgID, Name: collection.Name, CreatedAt: time.Now()}
+			return tx.Create(&collection).Error
+		}
+		result := tx.Model(&collection).Clauses(clause.Returning{}).
+			Where("id = ? AND org_id = ?", collection.ID, collection.OrgID).Update("name", collection.Name)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+		return nil
+	})
+	return collection, err
+}
+
+// managedCollection returns the collection with the ID, ErrEmpty is returned if there is no such collection
+// in the organizations of the user and ErrForbidden unless the user manages its organization
+func managedCollection(tx *gorm.DB, login string, id uint) (service.Collection, error) {
+	var collection service.Collection
+	err := tx.Where("id = ?", id).First(&collection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return collection, ErrEmpty
+		}
+		return collection, err
+	}
+	_, err = orgManager(tx, login, collection.OrgID)
+	return collection, err
+}
+
+// DeleteCollection removes the collection with the ID along with all of its secrets, only owners and admins can
+func (dbStorage DBStorage) DeleteCollection(login string, id uint, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := managedCollection(tx, login, id)
+		if err != nil {
+			return err
+		}
+		return deleteCollections(tx, []uint{id})
+	})
+}
+
+// PutCollectionMember adds the member of the organization to its collection, only owners and admins can.
+// ErrEmpty is returned if there is no such collection or member.
+func (dbStorage DBStorage) PutCollectionMember(login string, member service.CollectionMember, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		collection, err := managedCollection(tx, login, member.CollectionID)
+		if err != nil {
+			return err
+		}
+		_, err = orgRole(tx, member.Login, collection.OrgID)
+		if err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+	})
+}
+
+// DeleteCollectionMember removes the member from the collection, only owners and admins can.
+// ErrEmpty is returned if there is no such collection or the member has not been added to it.
+func (dbStorage DBStorage) DeleteCollectionMember(login string, member service.CollectionMember, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := managedCollection(tx, login, member.CollectionID)
+		if err != nil {
+			return err
+		}
+		result := tx.Where("collection_id = ? AND login = ?", member.CollectionID, member.Login).
+			Delete(&service.CollectionMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+		return nil
+	})
+}
+
+// GetCollectionLogins returns the logins of all the users who can see the collection with the ID:
+// the members added to it along with the owners and the admins of its organization
+func (dbStorage DBStorage) GetCollectionLogins(id uint, ctx context.Context) ([]string, error) {
+	db := dbStorage.db.WithContext(ctx)
+
+	var logins []string
+	err := db.Model(&service.OrgMember{}).Where("org_id = (?) AND (role IN ? OR login IN (?))",
+		db.Model(&service.Collection{}).Select("org_id").Where("id = ?", id),
+		[]string{service.OrgRoleOwner, service.OrgRoleAdmin},
+		db.Model(&service.CollectionMember{}).Select("login").Where("collection_id = ?", id)).
+		Order("login").Pluck("login", &logins).Error
+	return logins, err
+}
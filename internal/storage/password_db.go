@@ -0,0 +1,240 @@
+package storage
+
+// Here is the change of the password. The secrets are encrypted with the key derived from the password,
+// so they are replaced with the ones re-encrypted by the client along with the password itself.
+// Only the personal vault is re-encrypted, the collections are encrypted with the keys of their organizations.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChangePassword replaces the password of the user and every secret of the user with the re-encrypted ones
+// in a single transaction, so that the secrets are never left encrypted with different keys.
+// ErrOldData is returned if the secrets have changed since change.Revision or some of them are missing,
+// the client is to sync and re-encrypt them again. The uploads of change.Uploads must replace the chunked binaries,
+// other unfinished uploads are dropped as their parts are encrypted with the old key.
+// The previous versions of the secrets and the private key are replaced with the re-encrypted ones as well.
+// All the sessions of the user but change.Session are revoked.
+func (dbStorage DBStorage) ChangePassword(change service.PasswordChange, ctx context.Context) error {
+	hashedPassword, err := tools.GeneratePasswordHash(change.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user service.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, revision").
+			Where("login = ?", change.Login).First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmpty
+			}
+			return err
+		}
+		if user.Revision != change.Revision {
+			return ErrOldData
+		}
+		err = checkVaultComplete(tx, change)
+		if err != nil {
+			return err
+		}
+
+		revision, err := nextRevision(tx, personalVault(change.Login))
+		if err != nil {
+			return err
+		}
+		for _, logoPass := range change.LogoPasses {
+			err = reencrypt(tx, &service.LogoPass{}, change.Login, logoPass.ID, revision,
+				map[string]interface{}{"secret_login": logoPass.SecretLogin, "secret_pass": logoPass.SecretPass,
+					"updated_at": logoPass.UpdatedAt})
+			if err != nil {
+				return err
+			}
+		}
+		for _, text := range change.Texts {
+			err = reencrypt(tx, &service.TextData{}, change.Login, text.ID, revision,
+				map[string]interface{}{"text": text.Text, "updated_at": text.UpdatedAt})
+			if err != nil {
+				return err
+			}
+		}
+		for _, card := range change.CreditCards {
+			err = reencrypt(tx, &service.CreditCard{}, change.Login, card.ID, revision,
+				map[string]interface{}{"holder": card.Holder, "due_date": card.DueDate, "cvv": card.CVV,
+					"updated_at": card.UpdatedAt})
+			if err != nil {
+				return err
+			}
+		}
+		for _, binary := range change.Binaries {
+			err = reencrypt(tx, &service.BinaryData{}, change.Login, binary.ID, revision,
+				map[string]interface{}{"binary": binary.Binary, "updated_at": binary.UpdatedAt})
+			if err != nil {
+				return err
+			}
+		}
+		for _, version := range change.Versions {
+			err = reencryptVersion(tx, change.Login, version, revision)
+			if err != nil {
+				return err
+			}
+		}
+		err = reencryptPrivateKey(tx, change)
+		if err != nil {
+			return err
+		}
+		for _, upload := range change.Uploads {
+			_, err = dbStorage.completeUpload(tx, service.UploadSession{ID: upload, Login: change.Login})
+			if err != nil {
+				return err
+			}
+		}
+
+		personal := personalVault(change.Login)
+		err = tx.Where("upload_id IN (?)", tx.Model(&service.UploadSession{}).Select("id").
+			Scopes(personal.scope)).Delete(&service.BinaryChunk{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Scopes(personal.scope).Delete(&service.UploadSession{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&service.User{}).Where("login = ?", change.Login).Update("password", hashedPassword).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("login = ? AND id <> ?", change.Login, change.Session).Delete(&service.Session{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("login = ? AND family <> ?", change.Login, change.Session).
+			Delete(&service.RefreshToken{}).Error
+	})
+}
+
+// checkVaultComplete returns ErrOldData unless the change holds every secret of the personal vault of the user:
+// the binaries stored as a whole among the secrets and the chunked ones among the uploads
+func checkVaultComplete(tx *gorm.DB, change service.PasswordChange) error {
+	personal := personalVault(change.Login)
+	counts := []struct {
+		query *gorm.DB
+		count int
+	}{
+		{tx.Model(&service.LogoPass{}).Scopes(personal.scope), len(change.LogoPasses)},
+		{tx.Model(&service.TextData{}).Scopes(personal.scope), len(change.Texts)},
+		{tx.Model(&service.CreditCard{}).Scopes(personal.scope), len(change.CreditCards)},
+		{tx.Model(&service.BinaryData{}).Scopes(personal.scope).Where("chunk_count = 0"), len(change.Binaries)},
+		{tx.Model(&service.BinaryData{}).Scopes(personal.scope).Where("chunk_count > 0"), len(change.Uploads)},
+		{tx.Model(&service.SecretVersion{}).Scopes(personal.scope), len(change.Versions)},
+	}
+	for _, check := range counts {
+		var count int64
+		err := check.query.Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count != int64(check.count) {
+			return ErrOldData
+		}
+	}
+	if len(change.Uploads) == 0 {
+		return nil
+	}
+
+	// every upload must replace a different chunked binary
+	var replaced int64
+	err := tx.Model(&service.BinaryData{}).Scopes(personal.scope).Where("chunk_count > 0 AND description IN (?)",
+		tx.Model(&service.UploadSession{}).Select("description").Scopes(personal.scope).
+			Where("id IN ?", change.Uploads)).Count(&replaced).Error
+	if err != nil {
+		return err
+	}
+	if replaced != int64(len(change.Uploads)) {
+		return ErrOldData
+	}
+	return nil
+}
+
+// reencrypt replaces the secret fields of the entry with the re-encrypted ones, ErrOldData is returned
+// if the personal vault of the user has no such entry. An entry is replaced only once, as it gets the revision of the change,
+// so that a change holding one entry twice and missing another is refused.
+func reencrypt(tx *gorm.DB, model interface{}, login string, id uint, revision int64, fields map[string]interface{}) error {
+	fields["revision"] = revision
+	result := tx.Model(model).Scopes(personalVault(login).scope).Where("id = ? AND revision < ?", id, revision).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOldData
+	}
+	return nil
+}
+
+// reencryptPrivateKey replaces the private key of the user with the re-encrypted one, ErrOldData is returned
+// if the user has a key pair and the change comes without it. The public key stays, so do the shares.
+func reencryptPrivateKey(tx *gorm.DB, change service.PasswordChange) error {
+	var keys int64
+	err := tx.Model(&service.KeyPair{}).Where("login = ?", change.Login).Count(&keys).Error
+	if err != nil {
+		return err
+	}
+	if keys == 0 {
+		return nil
+	}
+	if change.PrivateKey == "" {
+		return ErrOldData
+	}
+	return tx.Model(&service.KeyPair{}).Where("login = ?", change.Login).
+		Update("private_key", change.PrivateKey).Error
+}
+
+// reencryptVersion replaces the secret fields of the stored version with the ones of the re-encrypted version,
+// the rest of the version stays as it is. ErrOldData is returned if the user has no such version
+// or the version comes without the secret of its kind.
+func reencryptVersion(tx *gorm.DB, login string, version service.SecretVersion, revision int64) error {
+	var stored service.SecretVersion
+	err := tx.Scopes(personalVault(login).scope).Where("id = ?", version.ID).First(&stored).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOldData
+		}
+		return err
+	}
+	err = decodeVersion(&stored)
+	if err != nil {
+		return err
+	}
+
+	var entry interface{}
+	switch {
+	case stored.LogoPass != nil && version.LogoPass != nil:
+		stored.LogoPass.SecretLogin = version.LogoPass.SecretLogin
+		stored.LogoPass.SecretPass = version.LogoPass.SecretPass
+		entry = stored.LogoPass
+	case stored.Text != nil && version.Text != nil:
+		stored.Text.Text = version.Text.Text
+		entry = stored.Text
+	case stored.CreditCard != nil && version.CreditCard != nil:
+		stored.CreditCard.Holder = version.CreditCard.Holder
+		stored.CreditCard.DueDate = version.CreditCard.DueDate
+		stored.CreditCard.CVV = version.CreditCard.CVV
+		entry = stored.CreditCard
+	default:
+		return ErrOldData
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return reencrypt(tx, &service.SecretVersion{}, login, version.ID, revision,
+		map[string]interface{}{"entry": string(encoded)})
+}
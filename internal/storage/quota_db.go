@@ -0,0 +1,152 @@
+package storage
+
+// Here are the quotas: they keep a single user from taking up all the space of the database.
+// Every collection of an organization is limited by the same quotas as a personal vault is.
+
+import (
+	"context"
+	"fmt"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Quotas limit what a single user can store, a zero limit means there is none.
+// MaxBytes limits the total size of the user's secrets, the parts of unfinished uploads included,
+// MaxBinarySize limits the size of a single binary and MaxItems limits the number of the secrets of every kind.
+type Quotas struct {
+	MaxBytes      int64
+	MaxBinarySize int64
+	MaxItems      int64
+}
+
+// itemSizes are the expressions of the size of a secret of every kind, only the secret fields are counted
+var itemSizes = map[string]string{
+	ItemLogoPass:   "octet_length(secret_login) + octet_length(secret_pass)",
+	ItemText:       "octet_length(text)",
+	ItemCreditCard: "octet_length(number) + octet_length(holder) + octet_length(due_date) + octet_length(cvv)",
+	ItemBinary:     "size",
+}
+
+// sizes of the secrets counted in the same way itemSizes do
+func logoPassSize(logoPass service.LogoPass) int64 {
+	return int64(len(logoPass.SecretLogin) + len(logoPass.SecretPass))
+}
+
+func textSize(text service.TextData) int64 {
+	return int64(len(text.Text))
+}
+
+func creditCardSize(card service.CreditCard) int64 {
+	return int64(len(card.Number) + len(card.Holder) + len(card.DueDate) + len(card.CVV))
+}
+
+// quotaChange is how a change of a secret affects the usage of the user.
+// added is set if the change adds a new secret instead of replacing a stored one, bytes is the difference
+// of the size of the secret and binarySize is the size of the binary stored, if it is one.
+type quotaChange struct {
+	item       string
+	added      bool
+	bytes      int64
+	binarySize int64
+}
+
+// checkQuota returns ErrQuotaExceeded if the change takes the vault over the quotas, or ErrBinaryTooLarge
+// if the binary is larger than allowed. The row of the user or the collection is locked till the end
+// of the transaction, so that the concurrent changes of the same vault can't exceed the quotas together.
+func (dbStorage DBStorage) checkQuota(tx *gorm.DB, v vault, change quotaChange) error {
+	quotas := dbStorage.quotas
+	if quotas.MaxBinarySize > 0 && change.binarySize > quotas.MaxBinarySize {
+		return fmt.Errorf("%w: %d bytes at most", ErrBinaryTooLarge, quotas.MaxBinarySize)
+	}
+	countItem := quotas.MaxItems > 0 && change.added
+	countBytes := quotas.MaxBytes > 0 && change.bytes > 0
+	if !countItem && !countBytes {
+		return nil
+	}
+
+	var locked []uint
+	err := v.owner(tx).Clauses(clause.Locking{Strength: "UPDATE"}).Pluck("id", &locked).Error
+	if err != nil {
+		return err
+	}
+	if len(locked) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	if countItem {
+		var count int64
+		err = tx.Model(itemModels[change.item]).Scopes(v.scope).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count >= quotas.MaxItems {
+			return fmt.Errorf("%w: %d items of %s at most", ErrQuotaExceeded, quotas.MaxItems, change.item)
+		}
+	}
+	if countBytes {
+		used, err := usedBytes(tx, v)
+		if err != nil {
+			return err
+		}
+		if used+change.bytes > quotas.MaxBytes {
+			return fmt.Errorf("%w: %d bytes at most", ErrQuotaExceeded, quotas.MaxBytes)
+		}
+	}
+	return nil
+}
+
+// withinQuota returns the check of the change made by saveRevised
+func (dbStorage DBStorage) withinQuota(v vault, change quotaChange) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return dbStorage.checkQuota(tx, v, change)
+	}
+}
+
+// usedBytes returns the total size of the secrets of the vault along with the parts of the unfinished uploads
+func usedBytes(db *gorm.DB, v vault) (int64, error) {
+	var total int64
+	for item, size := range itemSizes {
+		var used int64
+		err := db.Model(itemModels[item]).Select("COALESCE(SUM(" + size + "), 0)").
+			Scopes(v.scope).Scan(&used).Error
+		if err != nil {
+			return 0, err
+		}
+		total += used
+	}
+
+	var pending int64
+	err := db.Model(&service.BinaryChunk{}).Select("COALESCE(SUM(size), 0)").
+		Where("upload_id IN (?)", db.Model(&service.UploadSession{}).Select("id").Scopes(v.scope)).
+		Scan(&pending).Error
+	if err != nil {
+		return 0, err
+	}
+	return total + pending, nil
+}
+
+// GetUsage returns how much space the personal vault of the user takes along with the quotas of the storage
+func (dbStorage DBStorage) GetUsage(login string, ctx context.Context) (service.Usage, error) {
+	usage := service.Usage{
+		Items:         make(map[string]int64, len(itemModels)),
+		MaxBytes:      dbStorage.quotas.MaxBytes,
+		MaxBinarySize: dbStorage.quotas.MaxBinarySize,
+		MaxItems:      dbStorage.quotas.MaxItems,
+	}
+
+	var err error
+	usage.Bytes, err = usedBytes(dbStorage.db.WithContext(ctx), personalVault(login))
+	if err != nil {
+		return usage, err
+	}
+	for item, model := range itemModels {
+		var count int64
+		err = dbStorage.db.WithContext(ctx).Model(model).Scopes(personalVault(login).scope).Count(&count).Error
+		if err != nil {
+			return usage, err
+		}
+		usage.Items[item] = count
+	}
+	return usage, nil
+}
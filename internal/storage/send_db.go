@@ -0,0 +1,92 @@
+package storage
+
+// Here are the sends: a text or a small binary handed to someone without an account by a link. The content is
+// encrypted by the client of the sender with a random key carried by the fragment of the link only, so the server
+// keeps the ciphertext it can't open and hands it to anyone asking for the send until it expires or runs out of views.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ItemSend is the kind of the sends recorded to the audit log of the sender
+const ItemSend = "send"
+
+// PutSend stores the send made by the user, the sends of all the users that have expired are purged along the way.
+// ErrQuotaExceeded is returned if the user already has as many sends alive as the quota of the items allows,
+// the row of the user is locked while the sends are counted, so that concurrent sends can't exceed the quota together.
+// Returns the send stored with its ID set.
+func (dbStorage DBStorage) PutSend(send service.Send, ctx context.Context) (service.Send, error) {
+	err := dbStorage.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&service.Send{}).Error
+	if err != nil {
+		return send, err
+	}
+
+	send.ID, err = tools.GenerateRandomString(16)
+	if err != nil {
+		return send, err
+	}
+	send.Views = 0
+	send.CreatedAt = time.Now()
+	err = dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if dbStorage.quotas.MaxItems > 0 {
+			var locked []uint
+			err := personalVault(send.Login).owner(tx).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Pluck("id", &locked).Error
+			if err != nil {
+				return err
+			}
+			if len(locked) == 0 {
+				return gorm.ErrRecordNotFound
+			}
+
+			var sends int64
+			err = tx.Model(&service.Send{}).Where("login = ?", send.Login).Count(&sends).Error
+			if err != nil {
+				return err
+			}
+			if sends >= dbStorage.quotas.MaxItems {
+				return fmt.Errorf("%w: %d sends at most", ErrQuotaExceeded, dbStorage.quotas.MaxItems)
+			}
+		}
+		return tx.Create(&send).Error
+	})
+	return send, err
+}
+
+// GetSend returns the send with the id counting the view, the send is purged once it is viewed for the last time.
+// ErrEmpty is returned if there is no such send or it has expired, the expired one is purged then.
+func (dbStorage DBStorage) GetSend(id string, ctx context.Context) (service.Send, error) {
+	var send service.Send
+	var expired bool
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&send).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmpty
+			}
+			return err
+		}
+		if send.ExpiresAt.Before(time.Now()) {
+			// the expired send is purged without rolling back, so it is not returned as an error here
+			expired = true
+			return tx.Delete(&send).Error
+		}
+
+		send.Views++
+		if send.Views >= send.MaxViews {
+			return tx.Delete(&send).Error
+		}
+		return tx.Model(&send).Update("views", send.Views).Error
+	})
+	if err == nil && expired {
+		return service.Send{}, ErrEmpty
+	}
+	return send, err
+}
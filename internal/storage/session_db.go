@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"time"
+)
+
+// sessionTouchInterval is how often the last-seen time of a session is updated, so that not every request writes
+const sessionTouchInterval = time.Minute
+
+// CreateSession stores a new session of a user. Sessions of the user that have no valid refresh tokens left
+// can't be used anymore, so they are purged along with the expired tokens.
+func (dbStorage DBStorage) CreateSession(session service.Session, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("login = ? AND expires_at < ?", session.Login, time.Now()).Delete(&service.RefreshToken{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("login = ? AND id NOT IN (?)", session.Login,
+			tx.Model(&service.RefreshToken{}).Select("family").Where("login = ? AND used = ?", session.Login, false)).
+			Delete(&service.Session{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&session).Error
+	})
+}
+
+// CheckSession checks that the session of a user has not been revoked.
+// The last-seen time and the address of the session are updated along the way.
+func (dbStorage DBStorage) CheckSession(session service.Session, ctx context.Context) error {
+	var checkEntry service.Session
+
+	err := dbStorage.db.WithContext(ctx).Where("id = ? AND login = ?", session.ID, session.Login).
+		First(&checkEntry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEmpty
+		}
+		return err
+	}
+	if time.Since(checkEntry.LastSeen) < sessionTouchInterval && checkEntry.IP == session.IP {
+		return nil
+	}
+
+	return dbStorage.db.WithContext(ctx).Model(&checkEntry).
+		Updates(map[string]interface{}{"last_seen": time.Now(), "ip": session.IP}).Error
+}
+
+// GetSessions returns all the sessions of a user, the most recently seen ones first
+func (dbStorage DBStorage) GetSessions(login string, ctx context.Context) ([]service.Session, error) {
+	var sessions []service.Session
+
+	err := dbStorage.db.WithContext(ctx).Where("login = ?", login).Order("last_seen DESC").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, ErrEmpty
+	}
+	return sessions, nil
+}
+
+// DeleteSession revokes a session of a user along with all the refresh tokens issued to it
+func (dbStorage DBStorage) DeleteSession(session service.Session, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND login = ?", session.ID, session.Login).Delete(&service.Session{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+		return tx.Where("family = ?", session.ID).Delete(&service.RefreshToken{}).Error
+	})
+}
@@ -0,0 +1,237 @@
+package storage
+
+// Here is the sharing of the secrets. Every user has a key pair, the public key is known to everyone and the private
+// one is encrypted with the key derived from the user's password. A logo-pass pair, a text or a credit card is shared
+// encrypted by the owner's client with the public key of the recipient, so the server never learns the secret.
+// The recipients of read-write shares send their changes encrypted with the public key of the owner,
+// it is the owner's client that takes them in and shares the secret once again.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ItemShare is the kind of the changes announced to the users when a share of theirs changes
+const ItemShare = "share"
+
+// PutKeyPair stores the key pair of the user replacing the one stored before. The shares made for the old
+// public key can't be decrypted anymore, so they are dropped along with it.
+func (dbStorage DBStorage) PutKeyPair(keys service.KeyPair, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored service.KeyPair
+		err := tx.Where("login = ?", keys.Login).First(&stored).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && stored.PublicKey != keys.PublicKey {
+			err = tx.Where("recipient = ?", keys.Login).Delete(&service.Share{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Save(&keys).Error
+	})
+}
+
+// GetKeyPair returns the key pair of the user, ErrNoKeyPair is returned if the user has none
+func (dbStorage DBStorage) GetKeyPair(login string, ctx context.Context) (service.KeyPair, error) {
+	var keys service.KeyPair
+	err := dbStorage.db.WithContext(ctx).Where("login = ?", login).First(&keys).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return keys, ErrNoKeyPair
+	}
+	return keys, err
+}
+
+// encodeShare returns the json of the secret the share holds, ErrUnknownItem is returned
+// if the share holds no secret of its kind
+func encodeShare(share service.Share) (string, error) {
+	var entry interface{}
+	switch {
+	case share.Item == ItemLogoPass && share.LogoPass != nil:
+		entry = share.LogoPass
+	case share.Item == ItemText && share.Text != nil:
+		entry = share.Text
+	case share.Item == ItemCreditCard && share.CreditCard != nil:
+		entry = share.CreditCard
+	default:
+		return "", ErrUnknownItem
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// decodeShare fills the secret of the share from the entry
+func decodeShare(share *service.Share, entry string) error {
+	var err error
+	switch share.Item {
+	case ItemLogoPass:
+		share.LogoPass = &service.LogoPass{}
+		err = json.Unmarshal([]byte(entry), share.LogoPass)
+	case ItemText:
+		share.Text = &service.TextData{}
+		err = json.Unmarshal([]byte(entry), share.Text)
+	case ItemCreditCard:
+		share.CreditCard = &service.CreditCard{}
+		err = json.Unmarshal([]byte(entry), share.CreditCard)
+	default:
+		return fmt.Errorf("share %d: %w", share.ID, ErrUnknownItem)
+	}
+	if err != nil {
+		return fmt.Errorf("share %d: %w", share.ID, err)
+	}
+	return nil
+}
+
+// PutShare shares the owner's secret with the recipient or replaces the secret of the share made before,
+// the change of the recipient is dropped then as the owner has taken it in. A new share waits for the recipient
+// to accept it. Only the personal secrets are shared, the collections are shared with the members of their
+// organizations. ErrEmpty is returned if the owner has no such secret, ErrNoKeyPair if the recipient has no keys
+// to share with. Returns the share stored.
+func (dbStorage DBStorage) PutShare(share service.Share, ctx context.Context) (service.Share, error) {
+	model, ok := itemModels[share.Item]
+	if !ok || share.Item == ItemBinary {
+		return share, ErrUnknownItem
+	}
+	entry, err := encodeShare(share)
+	if err != nil {
+		return share, err
+	}
+
+	err = dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var revisions []int64
+		err := tx.Model(model).Scopes(personalVault(share.Owner).scope).Where("id = ?", share.ItemID).Limit(1).
+			Pluck("revision", &revisions).Error
+		if err != nil {
+			return err
+		}
+		if len(revisions) == 0 {
+			return ErrEmpty
+		}
+		var recipients int64
+		err = tx.Model(&service.KeyPair{}).Where("login = ?", share.Recipient).Count(&recipients).Error
+		if err != nil {
+			return err
+		}
+		if recipients == 0 {
+			return ErrNoKeyPair
+		}
+
+		share.ID = 0
+		share.Accepted = false
+		share.Revision = revisions[0]
+		share.Entry = entry
+		share.Change = ""
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "owner"}, {Name: "recipient"}, {Name: "item"}, {Name: "item_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"access", "revision", "entry", "change", "updated_at"}),
+		}).Create(&share).Error
+		if err != nil {
+			return err
+		}
+		// the share replaced keeps being accepted
+		return tx.Select("accepted", "created_at").Where("id = ?", share.ID).First(&share).Error
+	})
+	return share, err
+}
+
+// GetShares returns the shares made by the user and the ones made for the user. The recipient gets the secret
+// of the share, the owner gets the change made by the recipient if there is one.
+func (dbStorage DBStorage) GetShares(login string, ctx context.Context) ([]service.Share, error) {
+	var shares []service.Share
+	err := dbStorage.db.WithContext(ctx).Where("owner = ? OR recipient = ?", login, login).Order("id").
+		Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return nil, ErrEmpty
+	}
+	for i := range shares {
+		switch {
+		case shares[i].Recipient == login:
+			err = decodeShare(&shares[i], shares[i].Entry)
+		case shares[i].Change != "":
+			shares[i].Pending = true
+			err = decodeShare(&shares[i], shares[i].Change)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
+}
+
+// AcceptShare makes the share with the ID visible to the recipient. Returns the share accepted.
+func (dbStorage DBStorage) AcceptShare(login string, id uint, ctx context.Context) (service.Share, error) {
+	var share service.Share
+	result := dbStorage.db.WithContext(ctx).Model(&share).Clauses(clause.Returning{}).
+		Where("id = ? AND recipient = ?", id, login).Update("accepted", true)
+	if result.Error != nil {
+		return share, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return share, ErrEmpty
+	}
+	return share, nil
+}
+
+// ChangeShare keeps the change of the secret made by the recipient of a read-write share for the owner to take in,
+// the secret is to be encrypted with the public key of the owner. ErrReadOnly is returned for read-only shares.
+// Returns the share changed.
+func (dbStorage DBStorage) ChangeShare(share service.Share, ctx context.Context) (service.Share, error) {
+	recipient := share.Recipient
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var stored service.Share
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND recipient = ?", share.ID, recipient).
+			First(&stored).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmpty
+			}
+			return err
+		}
+		if stored.Access != service.AccessWrite {
+			return ErrReadOnly
+		}
+		share.Item = stored.Item
+		change, err := encodeShare(share)
+		if err != nil {
+			return err
+		}
+		share = stored
+		share.Change = change
+		return tx.Model(&share).Update("change", change).Error
+	})
+	return share, err
+}
+
+// DeleteShare removes the share with the ID, the owner revokes it and the recipient declines it in the same way.
+// Returns the share removed.
+func (dbStorage DBStorage) DeleteShare(login string, id uint, ctx context.Context) (service.Share, error) {
+	var shares []service.Share
+	result := dbStorage.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("id = ? AND (owner = ? OR recipient = ?)", id, login, login).Delete(&shares)
+	if result.Error != nil {
+		return service.Share{}, result.Error
+	}
+	if len(shares) == 0 {
+		return service.Share{}, ErrEmpty
+	}
+	return shares[0], nil
+}
+
+// dropShares returns the step of saveRevised that removes the shares of the deleted secret
+func dropShares(login string, item string, id uint) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Where("owner = ? AND item = ? AND item_id = ?", login, item, id).Delete(&service.Share{}).Error
+	}
+}
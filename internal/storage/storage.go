@@ -4,6 +4,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"gophkeeper/internal/service"
 )
 
@@ -11,16 +12,78 @@ import (
 type UserStorage interface {
 	CheckUserAuth(authDetails service.Authentication, ctx context.Context) error
 	RegisterUser(user service.User, ctx context.Context) error
-	PutLogoPass(logoPass service.LogoPass, ctx context.Context) error
-	BatchGetLogoPasses(login string, ctx context.Context) ([]service.LogoPass, error)
-	PutText(secret service.TextData, ctx context.Context) error
-	BatchGetTexts(login string, ctx context.Context) ([]service.TextData, error)
-	PutCreditCard(card service.CreditCard, ctx context.Context) error
-	BatchGetCreditCards(login string, ctx context.Context) ([]service.CreditCard, error)
-	PutBinary(binary service.BinaryData, ctx context.Context) error
-	GetBinaryList(login string, ctx context.Context) ([]service.BinaryData, error)
+	PutLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error)
+	BatchGetLogoPasses(login string, collection uint, ctx context.Context) ([]service.LogoPass, error)
+	PutText(secret service.TextData, ctx context.Context) (int64, error)
+	BatchGetTexts(login string, collection uint, ctx context.Context) ([]service.TextData, error)
+	PutCreditCard(card service.CreditCard, ctx context.Context) (int64, error)
+	BatchGetCreditCards(login string, collection uint, ctx context.Context) ([]service.CreditCard, error)
+	PutBinary(binary service.BinaryData, ctx context.Context) (int64, error)
+	GetBinaryList(login string, collection uint, ctx context.Context) ([]service.BinaryData, error)
 	GetBinary(binary service.BinaryData, ctx context.Context) (service.BinaryData, error)
+	DeleteLogoPass(logoPass service.LogoPass, ctx context.Context) (int64, error)
+	DeleteText(secret service.TextData, ctx context.Context) (int64, error)
+	DeleteCreditCard(card service.CreditCard, ctx context.Context) (int64, error)
+	DeleteBinary(binary service.BinaryData, ctx context.Context) (int64, error)
+	CreateUploadSession(session service.UploadSession, ctx context.Context) (service.UploadSession, error)
+	GetUploadSession(session service.UploadSession, ctx context.Context) (service.UploadSession, error)
+	PutBinaryChunk(session service.UploadSession, chunk service.BinaryChunk, ctx context.Context) error
+	CompleteUpload(session service.UploadSession, ctx context.Context) (int64, error)
+	GetBinaryChunkList(binary service.BinaryData, ctx context.Context) (service.BinaryData, []service.BinaryChunk, error)
+	GetBinaryChunk(chunk service.BinaryChunk, ctx context.Context) (service.BinaryChunk, error)
+	PutRefreshToken(token service.RefreshToken, ctx context.Context) error
+	RotateRefreshToken(old service.RefreshToken, next service.RefreshToken, ctx context.Context) (service.RefreshToken, error)
+	CreateSession(session service.Session, ctx context.Context) error
+	CheckSession(session service.Session, ctx context.Context) error
+	GetSessions(login string, ctx context.Context) ([]service.Session, error)
+	DeleteSession(session service.Session, ctx context.Context) error
+	PutTwoFactor(twoFactor service.TwoFactor, ctx context.Context) error
+	GetTwoFactor(login string, ctx context.Context) (service.TwoFactor, error)
+	EnableTwoFactor(twoFactor service.TwoFactor, codes []service.RecoveryCode, ctx context.Context) error
+	UseTOTPStep(twoFactor service.TwoFactor, ctx context.Context) error
+	UseRecoveryCode(code service.RecoveryCode, ctx context.Context) error
+	DeleteTwoFactor(login string, ctx context.Context) error
+	GetChanges(login string, collection uint, since int64, ctx context.Context) (service.SyncData, int64, error)
+	GetVersions(login string, collection uint, item string, id uint, ctx context.Context) ([]service.SecretVersion, error)
+	RestoreVersion(version service.SecretVersion, ctx context.Context) (service.SecretVersion, error)
+	PutKeyPair(keys service.KeyPair, ctx context.Context) error
+	GetKeyPair(login string, ctx context.Context) (service.KeyPair, error)
+	PutShare(share service.Share, ctx context.Context) (service.Share, error)
+	GetShares(login string, ctx context.Context) ([]service.Share, error)
+	AcceptShare(login string, id uint, ctx context.Context) (service.Share, error)
+	ChangeShare(share service.Share, ctx context.Context) (service.Share, error)
+	DeleteShare(login string, id uint, ctx context.Context) (service.Share, error)
+	CreateOrg(org service.Organization, owner service.OrgMember, ctx context.Context) (service.Organization, error)
+	GetOrgs(login string, ctx context.Context) ([]service.Organization, error)
+	DeleteOrg(login string, id uint, ctx context.Context) error
+	PutMember(login string, member service.OrgMember, ctx context.Context) error
+	DeleteMember(login string, member service.OrgMember, ctx context.Context) error
+	PutCollection(login string, collection service.Collection, ctx context.Context) (service.Collection, error)
+	DeleteCollection(login string, id uint, ctx context.Context) error
+	PutCollectionMember(login string, member service.CollectionMember, ctx context.Context) error
+	DeleteCollectionMember(login string, member service.CollectionMember, ctx context.Context) error
+	GetCollectionLogins(id uint, ctx context.Context) ([]string, error)
+	PutSend(send service.Send, ctx context.Context) (service.Send, error)
+	GetSend(id string, ctx context.Context) (service.Send, error)
+	GetUsage(login string, ctx context.Context) (service.Usage, error)
+	GetUser(login string, ctx context.Context) (service.User, error)
+	SetRole(login string, role string, ctx context.Context) error
+	DemoteAdmins(keep []string, ctx context.Context) ([]string, error)
+	ListUsers(ctx context.Context) ([]service.UserSummary, error)
+	SetDisabled(login string, disabled bool, ctx context.Context) error
+	DeleteSessions(login string, ctx context.Context) error
+	DeleteUser(login string, ctx context.Context) error
+	ChangePassword(change service.PasswordChange, ctx context.Context) error
+	CountItems(ctx context.Context) (map[string]int64, error)
 	DeleteAll()
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// AuditStorage is an interface that holds the audit log of the users
+type AuditStorage interface {
+	PutAuditEvent(event service.AuditEvent, ctx context.Context) error
+	GetAuditEvents(login string, before uint, limit int, ctx context.Context) ([]service.AuditEvent, error)
 }
 
 // Errors for the package, self-explanatory
@@ -30,4 +93,36 @@ var (
 	ErrAlreadyExists      = errors.New("already exists")
 	ErrEmpty              = errors.New("no data")
 	ErrOldData            = errors.New("newer data available on remote storage")
+	ErrInvalidChunk       = errors.New("invalid chunk")
+	ErrIncompleteUpload   = errors.New("upload is not complete")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidCode        = errors.New("invalid one-time code")
+	ErrQuotaExceeded      = errors.New("quota exceeded")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrUnknownItem        = errors.New("unknown item")
+	ErrRevisionMismatch   = errors.New("secret has been changed since the revision edited")
+	ErrRevisionRequired   = errors.New("revision of the secret edited is required")
+	ErrNoKeyPair          = errors.New("no key pair")
+	ErrReadOnly           = errors.New("share is read-only")
+	ErrForbidden          = errors.New("not allowed for the role in the organization")
+	ErrLastOwner          = errors.New("organization must keep an owner")
+	// ErrBinaryTooLarge is ErrQuotaExceeded returned for a single binary being larger than allowed
+	ErrBinaryTooLarge = fmt.Errorf("%w: binary is too large", ErrQuotaExceeded)
+	// ErrTokenReused is ErrInvalidToken returned for a refresh token used again, its family is revoked by then
+	ErrTokenReused = fmt.Errorf("%w: token reused", ErrInvalidToken)
 )
+
+// RevisionError is ErrRevisionMismatch returned along with the secret as it is stored, Current is nil
+// if there is none. Revision is the revision of the stored secret.
+type RevisionError struct {
+	Revision int64
+	Current  interface{}
+}
+
+func (err *RevisionError) Error() string {
+	return ErrRevisionMismatch.Error()
+}
+
+func (err *RevisionError) Unwrap() error {
+	return ErrRevisionMismatch
+}
@@ -0,0 +1,168 @@
+package storage
+
+// Here is the delta sync: every change of a secret gets the next revision of the data of its vault,
+// so that the clients could ask for the changes made since the revision they have already seen.
+// The revision of a secret is its version as well: a client overwrites or deletes a secret only if it has
+// edited the revision stored, so no change made on another device is lost whatever the clocks of the devices say.
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+)
+
+// nextRevision increments the revision of the vault's data and returns it. The row of the user or the collection
+// stays locked till the end of the transaction, so that the changes of the same vault are committed in order
+// of their revisions.
+func nextRevision(tx *gorm.DB, v vault) (int64, error) {
+	err := v.owner(tx).UpdateColumn("revision", gorm.Expr("revision + 1")).Error
+	if err != nil {
+		return 0, err
+	}
+	return v.revision(tx)
+}
+
+// saveRevised saves the entry, tombstones included, along with the next revision of the vault's data.
+// The steps, such as the checks of the quotas, are run in the same transaction before the entry is saved,
+// the row of the user or the collection being locked already.
+func saveRevised(db *gorm.DB, v vault, revision *int64, entry interface{}, steps ...func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		*revision, err = nextRevision(tx, v)
+		if err != nil {
+			return err
+		}
+		err = runSteps(tx, steps...)
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Save(entry).Error
+	})
+}
+
+// runSteps runs the steps of saveRevised one by one, so that a step could run the ones depending on what it has found
+func runSteps(tx *gorm.DB, steps ...func(tx *gorm.DB) error) error {
+	for _, step := range steps {
+		err := step(tx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEntry loads the entry of the vault matching the query to entry, tombstones included, the entry is left empty
+// if there is none. It is to be run as a step of saveRevised: every change of the vault locks the row
+// of its revision first, so the entry found stays the stored one until the save, and no entry with the same
+// description can be stored by another change meanwhile.
+func findEntry(tx *gorm.DB, v vault, entry interface{}, query string, args ...interface{}) error {
+	err := tx.Unscoped().Scopes(v.scope).Where(query, args...).First(entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// matchRevision checks the revision the client has edited the entry at against the stored one.
+// No revision is needed to store a new entry or one over a tombstone, nor to replace an entry stored
+// before the revisions were introduced. Other entries are replaced only if the client has edited the stored revision.
+func matchRevision(edited int64, stored int64, live bool) error {
+	switch {
+	case edited == 0 && live && stored != 0:
+		return ErrRevisionRequired
+	case edited != 0 && edited != stored:
+		return ErrRevisionMismatch
+	}
+	return nil
+}
+
+// checkRevision returns the step of saveRevised that checks the revision the client has edited against the one
+// of the stored entry with the ID given, current being a pointer to an empty entry of the same kind.
+// The row of the vault's revision is locked by then, so the entry can't change between the check and the save,
+// as long as the ID has been found within the same transaction, see findEntry.
+// The stored entry is loaded to current and returned along with RevisionError if the check fails,
+// binaries come without data as they do in the list.
+func checkRevision(current interface{}, id uint, edited int64) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if id == 0 {
+			if matchRevision(edited, 0, false) != nil {
+				return &RevisionError{}
+			}
+			return nil
+		}
+		var stored struct {
+			Revision  int64
+			DeletedAt gorm.DeletedAt
+		}
+		err := tx.Unscoped().Model(current).Select("revision", "deleted_at").Where("id = ?", id).
+			Scan(&stored).Error
+		if err != nil {
+			return err
+		}
+		err = matchRevision(edited, stored.Revision, !stored.DeletedAt.Valid)
+		if !errors.Is(err, ErrRevisionMismatch) {
+			return err
+		}
+
+		err = tx.Unscoped().First(current, id).Error
+		if err != nil {
+			return err
+		}
+		if binary, ok := current.(*service.BinaryData); ok {
+			binary.Binary = ""
+		}
+		return &RevisionError{Revision: stored.Revision, Current: current}
+	}
+}
+
+// GetChanges returns all the secrets of the vault stored since the revision given, tombstones of deleted ones included:
+// the personal secrets of the user if collection is 0, the ones of the collection otherwise.
+// Binaries come without data. The revision returned is the one the changes are complete up to.
+// If since is 0 or unknown to the storage, every secret of the vault is returned and SyncData.Complete is set.
+// ErrForbidden is returned if the user can't see the collection.
+func (dbStorage DBStorage) GetChanges(login string, collection uint, since int64, ctx context.Context) (service.SyncData, int64, error) {
+	var changes service.SyncData
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), login, collection, false)
+	if err != nil {
+		return changes, 0, err
+	}
+	revision, err := v.revision(dbStorage.db.WithContext(ctx))
+	if err != nil {
+		return changes, 0, err
+	}
+	if since <= 0 || since > revision {
+		changes.Complete = true
+	}
+
+	query := func() *gorm.DB {
+		tx := dbStorage.db.WithContext(ctx).Unscoped().Scopes(v.scope)
+		if changes.Complete {
+			// the secrets stored before the revisions were introduced have none
+			return tx
+		}
+		// changes committed after the revision was read are left for the next request
+		return tx.Where("revision > ? AND revision <= ?", since, revision)
+	}
+	err = query().Find(&changes.LogoPasses).Error
+	if err != nil {
+		return changes, 0, err
+	}
+	err = query().Find(&changes.Texts).Error
+	if err != nil {
+		return changes, 0, err
+	}
+	err = query().Find(&changes.CreditCards).Error
+	if err != nil {
+		return changes, 0, err
+	}
+	err = query().Table("binary_data").
+		Select("id, login, collection_id, revision, description, size, chunk_size, chunk_count, created_at, updated_at, deleted_at").
+		Find(&changes.Binaries).Error
+	if err != nil {
+		return changes, 0, err
+	}
+
+	return changes, revision, nil
+}
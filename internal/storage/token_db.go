@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PutRefreshToken stores a refresh token issued on login. Expired tokens of the user are purged along the way.
+func (dbStorage DBStorage) PutRefreshToken(token service.RefreshToken, ctx context.Context) error {
+	err := dbStorage.db.WithContext(ctx).Where("login = ? AND expires_at < ?", token.Login, time.Now()).
+		Delete(&service.RefreshToken{}).Error
+	if err != nil {
+		return err
+	}
+	return dbStorage.db.WithContext(ctx).Create(&token).Error
+}
+
+// RotateRefreshToken exchanges the old refresh token found by its hash for the next one in a single transaction.
+// The next token inherits the login and the family of the old one and is returned with them set.
+// If the old token has already been used, it must have been stolen, so the whole family is revoked
+// along with its session and ErrTokenReused is returned with the login and the family of the token set.
+// The token is marked used only if it is still unused, so that of the concurrent
+// refreshes with the same token only one gets the next token and the rest revoke the family.
+func (dbStorage DBStorage) RotateRefreshToken(old service.RefreshToken, next service.RefreshToken,
+	ctx context.Context) (service.RefreshToken, error) {
+	var revoked bool
+
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("token_hash = ?", old.TokenHash).First(&old).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if old.Used {
+			revoked = true
+			return revokeFamily(tx, old.Family)
+		}
+		if old.ExpiresAt.Before(time.Now()) {
+			return ErrInvalidToken
+		}
+
+		result := tx.Model(&old).Where("used = ?", false).Update("used", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// another refresh has used the token since it was read
+			revoked = true
+			return revokeFamily(tx, old.Family)
+		}
+		next.Login = old.Login
+		next.Family = old.Family
+		return tx.Create(&next).Error
+	})
+	if err != nil {
+		return next, err
+	}
+	if revoked {
+		return service.RefreshToken{Login: old.Login, Family: old.Family}, ErrTokenReused
+	}
+	return next, nil
+}
+
+// revokeFamily removes all the refresh tokens of the family along with its session within the transaction
+func revokeFamily(tx *gorm.DB, family string) error {
+	err := tx.Where("family = ?", family).Delete(&service.RefreshToken{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("id = ?", family).Delete(&service.Session{}).Error
+}
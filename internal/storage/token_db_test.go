@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotateRefreshTokenConcurrently(t *testing.T) {
+	dbStorage := testStorage(t)
+	ctx := context.Background()
+
+	suffix, err := tools.GenerateRandomString(4)
+	require.NoError(t, err)
+	login, family := "refresh-"+suffix, "family-"+suffix
+	require.NoError(t, dbStorage.RegisterUser(service.User{Login: login, Password: "password"}, ctx))
+	defer func() {
+		_ = dbStorage.DeleteUser(login, ctx)
+	}()
+	require.NoError(t, dbStorage.CreateSession(service.Session{ID: family, Login: login, LastSeen: time.Now()}, ctx))
+	old := service.RefreshToken{Login: login, Family: family, TokenHash: "old-" + suffix,
+		ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, dbStorage.PutRefreshToken(old, ctx))
+
+	// both refreshes send the same token at once, at most one of them may get the next token
+	const refreshes = 2
+	errs := make([]error, refreshes)
+	var wg sync.WaitGroup
+	for i := 0; i < refreshes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			next := service.RefreshToken{TokenHash: "next-" + suffix + "-" + strconv.Itoa(i),
+				ExpiresAt: time.Now().Add(time.Hour)}
+			_, errs[i] = dbStorage.RotateRefreshToken(service.RefreshToken{TokenHash: old.TokenHash}, next, ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrTokenReused)
+	}
+	assert.LessOrEqual(t, succeeded, 1)
+
+	// the reuse is noticed either way, so the family is revoked along with its session
+	var tokens int64
+	require.NoError(t, dbStorage.db.Model(&service.RefreshToken{}).Where("family = ?", family).Count(&tokens).Error)
+	assert.Zero(t, tokens)
+	assert.ErrorIs(t, dbStorage.CheckSession(service.Session{ID: family, Login: login}, ctx), ErrEmpty)
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/service"
+	"gorm.io/gorm"
+)
+
+// PutTwoFactor stores a new TOTP secret of a user replacing the one not yet enabled.
+// Returns ErrAlreadyExists if two-factor authentication is already enabled for the user.
+func (dbStorage DBStorage) PutTwoFactor(twoFactor service.TwoFactor, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var checkEntry service.TwoFactor
+		err := tx.Where("login = ?", twoFactor.Login).First(&checkEntry).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if checkEntry.Enabled {
+			return ErrAlreadyExists
+		}
+
+		twoFactor.Enabled = false
+		twoFactor.CreatedAt = checkEntry.CreatedAt
+		return tx.Save(&twoFactor).Error
+	})
+}
+
+// GetTwoFactor returns TOTP two-factor authentication of a user, enabled or not.
+// Returns ErrEmpty if the user has never enrolled.
+func (dbStorage DBStorage) GetTwoFactor(login string, ctx context.Context) (service.TwoFactor, error) {
+	var twoFactor service.TwoFactor
+
+	err := dbStorage.db.WithContext(ctx).Where("login = ?", login).First(&twoFactor).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return twoFactor, ErrEmpty
+		}
+		return twoFactor, err
+	}
+	return twoFactor, nil
+}
+
+// EnableTwoFactor enables two-factor authentication of a user once the enrolled secret is confirmed
+// and replaces the recovery codes of the user with the new ones.
+// Returns ErrEmpty if there is no secret enrolled or two-factor authentication is already enabled.
+func (dbStorage DBStorage) EnableTwoFactor(twoFactor service.TwoFactor, codes []service.RecoveryCode, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&service.TwoFactor{}).
+			Where("login = ? AND secret = ? AND enabled = ?", twoFactor.Login, twoFactor.Secret, false).
+			Updates(map[string]interface{}{"enabled": true, "last_step": twoFactor.LastStep})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+
+		err := tx.Where("login = ?", twoFactor.Login).Delete(&service.RecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseTOTPStep marks the TOTP period of the code just accepted as used.
+// Returns ErrInvalidCode if a code of this or a later period has already been used.
+func (dbStorage DBStorage) UseTOTPStep(twoFactor service.TwoFactor, ctx context.Context) error {
+	result := dbStorage.db.WithContext(ctx).Model(&service.TwoFactor{}).
+		Where("login = ? AND last_step < ?", twoFactor.Login, twoFactor.LastStep).
+		Update("last_step", twoFactor.LastStep)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// UseRecoveryCode spends a recovery code of a user, so that it can't be used again.
+// Returns ErrInvalidCode if there is no such code.
+func (dbStorage DBStorage) UseRecoveryCode(code service.RecoveryCode, ctx context.Context) error {
+	result := dbStorage.db.WithContext(ctx).Where("login = ? AND code_hash = ?", code.Login, code.CodeHash).
+		Delete(&service.RecoveryCode{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// DeleteTwoFactor disables two-factor authentication of a user and drops the recovery codes
+func (dbStorage DBStorage) DeleteTwoFactor(login string, ctx context.Context) error {
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("login = ?", login).Delete(&service.TwoFactor{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmpty
+		}
+		return tx.Where("login = ?", login).Delete(&service.RecoveryCode{}).Error
+	})
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"gophkeeper/internal/service"
+	"gophkeeper/internal/tools"
+	"gorm.io/gorm"
+	"time"
+)
+
+// uploadSessionTTL is how long an unfinished upload can be resumed before it is purged
+const uploadSessionTTL = 24 * time.Hour
+
+// CreateUploadSession starts a chunked upload of a binary to the vault of the session, the personal vault
+// of the user unless session.CollectionID is set. It checks the same conditions PutBinary does,
+// the number of the binaries of the user included, so that the client would not waste time uploading data
+// that is going to be refused anyway.
+// Upload sessions of the user that are older than uploadSessionTTL are purged along with their chunks.
+func (dbStorage DBStorage) CreateUploadSession(session service.UploadSession, ctx context.Context) (service.UploadSession, error) {
+	if session.ChunkCount <= 0 {
+		return session, ErrInvalidChunk
+	}
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), session.Login, session.CollectionID, true)
+	if err != nil {
+		return session, err
+	}
+	err = dbStorage.purgeUploadSessions(session.Login, ctx)
+	if err != nil {
+		return session, err
+	}
+
+	err = checkBinaryOverwrite(dbStorage.db.WithContext(ctx), v, session)
+	if err != nil {
+		return session, err
+	}
+	added, err := binaryAdded(dbStorage.db.WithContext(ctx), v, session)
+	if err != nil {
+		return session, err
+	}
+	err = dbStorage.checkQuota(dbStorage.db.WithContext(ctx), v, quotaChange{item: ItemBinary, added: added})
+	if err != nil {
+		return session, err
+	}
+
+	session.ID, err = tools.GenerateRandomString(16)
+	if err != nil {
+		return session, err
+	}
+	session.CreatedAt = time.Now()
+	err = dbStorage.db.WithContext(ctx).Create(&session).Error
+	if err != nil {
+		return session, err
+	}
+
+	session.Received = []int{}
+	return session, nil
+}
+
+// GetUploadSession returns the upload session of a user along with the list of parts already received
+func (dbStorage DBStorage) GetUploadSession(session service.UploadSession, ctx context.Context) (service.UploadSession, error) {
+	err := dbStorage.db.WithContext(ctx).Where("id = ? AND login = ?", session.ID, session.Login).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, ErrEmpty
+		}
+		return session, err
+	}
+
+	session.Received = []int{}
+	err = dbStorage.db.WithContext(ctx).Model(&service.BinaryChunk{}).Where("upload_id = ?", session.ID).
+		Order("part").Pluck("part", &session.Received).Error
+	if err != nil {
+		return session, err
+	}
+	return session, nil
+}
+
+// PutBinaryChunk stores a part of an upload. A part that has already been received is replaced,
+// so that the client can safely retry it after a disconnect. The parts received count in the quotas of the user.
+func (dbStorage DBStorage) PutBinaryChunk(session service.UploadSession, chunk service.BinaryChunk, ctx context.Context) error {
+	err := dbStorage.db.WithContext(ctx).Where("id = ? AND login = ?", session.ID, session.Login).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEmpty
+		}
+		return err
+	}
+	if chunk.Part < 0 || chunk.Part >= session.ChunkCount {
+		return ErrInvalidChunk
+	}
+
+	chunk.UploadID = session.ID
+	chunk.Size = int64(len(chunk.Data))
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("upload_id = ? AND part = ?", chunk.UploadID, chunk.Part).Delete(&service.BinaryChunk{}).Error
+		if err != nil {
+			return err
+		}
+
+		var received int64
+		err = tx.Model(&service.BinaryChunk{}).Select("COALESCE(SUM(size), 0)").
+			Where("upload_id = ?", chunk.UploadID).Scan(&received).Error
+		if err != nil {
+			return err
+		}
+		err = dbStorage.checkQuota(tx, vault{login: session.Login, collection: session.CollectionID},
+			quotaChange{item: ItemBinary, bytes: chunk.Size,
+				binarySize: received + chunk.Size})
+		if err != nil {
+			return err
+		}
+		return tx.Create(&chunk).Error
+	})
+}
+
+// CompleteUpload turns all the parts of an upload into a BinaryData entry in a single transaction.
+// Previous content of the binary is replaced, if the upload is allowed to overwrite it and has been started
+// at the revision stored. Returns the revision the binary is stored at.
+func (dbStorage DBStorage) CompleteUpload(session service.UploadSession, ctx context.Context) (int64, error) {
+	var revision int64
+	err := dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		revision, err = dbStorage.completeUpload(tx, session)
+		return err
+	})
+	return revision, err
+}
+
+// completeUpload turns all the parts of an upload into a BinaryData entry within the transaction
+func (dbStorage DBStorage) completeUpload(tx *gorm.DB, session service.UploadSession) (int64, error) {
+	err := tx.Where("id = ? AND login = ?", session.ID, session.Login).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrEmpty
+		}
+		return 0, err
+	}
+
+	var chunks []service.BinaryChunk
+	err = tx.Select("id, part, size").Where("upload_id = ?", session.ID).Order("part").Find(&chunks).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(chunks) != session.ChunkCount {
+		return 0, ErrIncompleteUpload
+	}
+	// the access might have been taken away while the parts were uploaded
+	v, err := openVault(tx, session.Login, session.CollectionID, true)
+	if err != nil {
+		return 0, err
+	}
+
+	binary := service.BinaryData{Login: session.Login, CollectionID: session.CollectionID, Description: session.Description,
+		ChunkSize: chunks[0].Size, ChunkCount: session.ChunkCount}
+	for i, chunk := range chunks {
+		// every chunk but the last one must be of the same size, so that the binary could be read in ranges
+		if chunk.Part != i || (i < len(chunks)-1 && chunk.Size != binary.ChunkSize) {
+			return 0, ErrInvalidChunk
+		}
+		binary.Size += chunk.Size
+	}
+
+	binary.UpdatedAt = session.UpdatedAt
+	err = saveRevised(tx, v, &binary.Revision, &binary, func(tx *gorm.DB) error {
+		var checkEntry service.BinaryData
+		err := findEntry(tx.Select("id", "login", "size", "deleted_at"), v, &checkEntry,
+			"description = ?", session.Description)
+		if err != nil {
+			return err
+		}
+		if !session.Overwrite && checkEntry.Login != "" && !checkEntry.DeletedAt.Valid {
+			return ErrAlreadyExists
+		}
+		binary.ID = checkEntry.ID
+
+		// the parts are counted in the quotas already, only the content replaced is freed
+		change := quotaChange{item: ItemBinary, added: checkEntry.Login == "" || checkEntry.DeletedAt.Valid,
+			bytes: -checkEntry.Size, binarySize: binary.Size}
+		return runSteps(tx,
+			checkRevision(&service.BinaryData{}, checkEntry.ID, session.Revision),
+			dbStorage.withinQuota(v, change), dropChunks(checkEntry.ID))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Model(&service.BinaryChunk{}).Where("upload_id = ?", session.ID).
+		Updates(map[string]interface{}{"binary_id": binary.ID, "upload_id": ""}).Error
+	if err != nil {
+		return 0, err
+	}
+	return binary.Revision, tx.Delete(&session).Error
+}
+
+// GetBinaryChunkList returns a chunked binary along with the list of its chunks in order.
+// Chunks come without data, as all of it might not fit in memory. Use GetBinaryChunk to get it one by one.
+func (dbStorage DBStorage) GetBinaryChunkList(binary service.BinaryData, ctx context.Context) (service.BinaryData, []service.BinaryChunk, error) {
+	var chunks []service.BinaryChunk
+
+	v, err := openVault(dbStorage.db.WithContext(ctx), binary.Login, binary.CollectionID, false)
+	if err != nil {
+		return binary, nil, err
+	}
+	err = dbStorage.db.WithContext(ctx).Select("id, login, collection_id, revision, description, size, chunk_size, chunk_count, updated_at").
+		Scopes(v.scope).Where("description = ?", binary.Description).First(&binary).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return binary, nil, ErrEmpty
+		}
+		return binary, nil, err
+	}
+	if binary.ChunkCount == 0 {
+		return binary, nil, ErrEmpty
+	}
+
+	err = dbStorage.db.WithContext(ctx).Select("id, binary_id, part, size, checksum").
+		Where("binary_id = ?", binary.ID).Order("part").Find(&chunks).Error
+	if err != nil {
+		return binary, nil, err
+	}
+	return binary, chunks, nil
+}
+
+// GetBinaryChunk returns a chunk with its data
+func (dbStorage DBStorage) GetBinaryChunk(chunk service.BinaryChunk, ctx context.Context) (service.BinaryChunk, error) {
+	err := dbStorage.db.WithContext(ctx).Where("id = ?", chunk.ID).First(&chunk).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return chunk, ErrEmpty
+		}
+		return chunk, err
+	}
+	return chunk, nil
+}
+
+// checkBinaryOverwrite checks if a binary can be replaced by the upload in the same way PutBinary does,
+// so that an upload bound to fail is refused before it starts
+func checkBinaryOverwrite(db *gorm.DB, v vault, session service.UploadSession) error {
+	var checkEntry service.BinaryData
+
+	err := db.Unscoped().Select("id, login, revision, deleted_at").Scopes(v.scope).
+		Where("description = ?", session.Description).First(&checkEntry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !session.Overwrite && !checkEntry.DeletedAt.Valid {
+		return ErrAlreadyExists
+	}
+	return matchRevision(session.Revision, checkEntry.Revision, !checkEntry.DeletedAt.Valid)
+}
+
+// binaryAdded reports whether the upload is going to add a new binary instead of replacing a stored one
+func binaryAdded(db *gorm.DB, v vault, session service.UploadSession) (bool, error) {
+	var count int64
+	err := db.Model(&service.BinaryData{}).Scopes(v.scope).Where("description = ?", session.Description).
+		Count(&count).Error
+	return count == 0, err
+}
+
+// dropChunks returns the step of saveRevised that removes the chunks of the binary with the ID given,
+// the binary might have been uploaded in chunks before it is replaced or deleted
+func dropChunks(id uint) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		if id == 0 {
+			return nil
+		}
+		return tx.Where("binary_id = ?", id).Delete(&service.BinaryChunk{}).Error
+	}
+}
+
+// purgeUploadSessions removes user's upload sessions that have expired along with their chunks
+func (dbStorage DBStorage) purgeUploadSessions(login string, ctx context.Context) error {
+	var expired []string
+
+	err := dbStorage.db.WithContext(ctx).Model(&service.UploadSession{}).
+		Where("login = ? AND created_at < ?", login, time.Now().Add(-uploadSessionTTL)).Pluck("id", &expired).Error
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+
+	return dbStorage.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("upload_id IN ?", expired).Delete(&service.BinaryChunk{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", expired).Delete(&service.UploadSession{}).Error
+	})
+}
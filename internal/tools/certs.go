@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// certificateTTL is how long the generated certificates are valid for
+const certificateTTL = 365 * 24 * time.Hour
+
+// GenerateCertificate generates an ECDSA key and a certificate for the hosts, which are either DNS names or IPs.
+// The certificate is signed by the parent or is self-signed if the parent is nil, a self-signed certificate
+// can be used as the CA to sign the others. Returns PEM encoded certificate and key.
+func GenerateCertificate(hosts []string, parent *tls.Certificate) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"Gophkeeper"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(certificateTTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	if len(hosts) != 0 {
+		template.Subject.CommonName = hosts[0]
+	}
+
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		if parent.Leaf == nil {
+			parent.Leaf, err = x509.ParseCertificate(parent.Certificate[0])
+			if err != nil {
+				return nil, nil, fmt.Errorf("parse parent certificate: %w", err)
+			}
+		}
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// EnsureSelfSignedCertificate generates a self-signed certificate for the hosts and writes it to the files,
+// unless the certificate file already exists. The certificate doubles as the CA bundle for the clients.
+func EnsureSelfSignedCertificate(certFile string, keyFile string, hosts []string) (bool, error) {
+	_, err := os.Stat(certFile)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	certPEM, keyPEM, err := GenerateCertificate(hosts, nil)
+	if err != nil {
+		return false, err
+	}
+	err = os.WriteFile(keyFile, keyPEM, 0600)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(certFile, certPEM, 0644)
+}
+
+// LoadCertPool reads PEM encoded CA certificates from the file
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return pool, nil
+}
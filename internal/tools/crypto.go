@@ -8,32 +8,31 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/nacl/box"
 )
 
-// EncryptString is used to encrypt string data
-func EncryptString(plaintext string, key string) (string, error) {
-	plaintextBytes := []byte(plaintext)
-	keyBytes := []byte(key)
+// CipherOverhead is the number of bytes EncryptBytes adds to the plaintext: GCM nonce and tag
+const CipherOverhead = 12 + 16
 
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
-	}
+var (
+	// ErrShortCiphertext is returned when the data is too short to be decrypted
+	ErrShortCiphertext = errors.New("ciphertext too short")
+	// ErrInvalidKey is returned for the public and private keys of wrong size
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrSealedData is returned when the data can't be opened with the key pair
+	ErrSealedData = errors.New("sealed data can't be opened")
+)
 
-	gcm, err := cipher.NewGCM(block)
+// EncryptString is used to encrypt string data
+func EncryptString(plaintext string, key string) (string, error) {
+	ciphertext, err := EncryptBytes([]byte(plaintext), key)
 	if err != nil {
 		return "", err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := rand.Read(nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, plaintextBytes, nil)
 	ciphertextString := base64.StdEncoding.EncodeToString(ciphertext)
-
 	return ciphertextString, nil
 }
 
@@ -44,27 +43,54 @@ func DecryptString(ciphertextString string, key string) (string, error) {
 		return "", err
 	}
 
-	keyBytes := []byte(key)
-
-	block, err := aes.NewCipher(keyBytes)
+	plaintextBytes, err := DecryptBytes(ciphertext, key)
 	if err != nil {
 		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	plaintext := string(plaintextBytes)
+	return plaintext, nil
+}
+
+// EncryptBytes is used to encrypt raw binary data, e.g. a single chunk of a file.
+// The nonce is prepended to the result, so the ciphertext is CipherOverhead bytes longer than the plaintext.
+func EncryptBytes(plaintext []byte, key string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// DecryptBytes is used to decrypt raw binary data encrypted with EncryptBytes
+func DecryptBytes(ciphertext []byte, key string) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrShortCiphertext
+	}
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintextBytes, err := gcm.Open(nil, nonce, ciphertext, nil)
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// newGCM creates AES-GCM cipher out of a key
+func newGCM(key string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher([]byte(key))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	plaintext := string(plaintextBytes)
-	return plaintext, nil
+	return cipher.NewGCM(block)
 }
 
 // GenerateKey used to generate a size 16 key from a string
@@ -86,3 +112,73 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// GenerateRandomString returns a hex encoded string of size random bytes, suitable for identifiers and tokens
+func GenerateRandomString(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// ChecksumBytes returns hex encoded sha256 checksum of the data
+func ChecksumBytes(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateKeyPair returns a new pair of base64 encoded public and private keys to seal the data for the owner of the pair
+func GenerateKeyPair() (string, string, error) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(publicKey[:]), base64.StdEncoding.EncodeToString(privateKey[:]), nil
+}
+
+// SealString encrypts string data with the base64 encoded public key, so that only the owner of the private key
+// could open it
+func SealString(plaintext string, publicKey string) (string, error) {
+	public, err := decodeKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := box.SealAnonymous(nil, []byte(plaintext), public, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenString decrypts string data sealed with SealString by the base64 encoded key pair
+func OpenString(sealedString string, publicKey string, privateKey string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(sealedString)
+	if err != nil {
+		return "", err
+	}
+	public, err := decodeKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	private, err := decodeKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, ok := box.OpenAnonymous(nil, sealed, public, private)
+	if !ok {
+		return "", ErrSealedData
+	}
+	return string(plaintext), nil
+}
+
+// decodeKey decodes the base64 encoded key of the pair
+func decodeKey(encoded string) (*[32]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != 32 {
+		return nil, ErrInvalidKey
+	}
+	var key [32]byte
+	copy(key[:], decoded)
+	return &key, nil
+}
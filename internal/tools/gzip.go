@@ -1,17 +1,72 @@
 package tools
 
 import (
-	"bytes"
 	"compress/gzip"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"io"
 	"log"
 	"net/http"
 	"strings"
 )
 
+// gzipBytesIn counts the bytes of gzipped requests before and after decompression,
+// gzipBytesOut counts the bytes of responses before and after compression
+var (
+	gzipBytesIn = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gophkeeper",
+		Subsystem: "gzip",
+		Name:      "in_bytes_total",
+		Help:      "Bytes of gzipped request bodies, compressed and decompressed.",
+	}, []string{"encoding"})
+	gzipBytesOut = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gophkeeper",
+		Subsystem: "gzip",
+		Name:      "out_bytes_total",
+		Help:      "Bytes of gzipped response bodies, before and after compression.",
+	}, []string{"encoding"})
+)
+
+// MaxGzipBodySize is the largest size a gzipped request body is decompressed to, the handler reading more
+// gets *http.MaxBytesError. It is large enough for a binary of the default quota sent as base64 in one piece.
+var MaxGzipBodySize int64 = 1 << 30
+
+// gzipBody is the decompressed body of a request closing the decompressor
+type gzipBody struct {
+	io.Reader
+	io.Closer
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	io.Writer
+	counter prometheus.Counter
+}
+
+// Write writes to the underlying writer and counts the bytes written
+func (writer countingWriter) Write(b []byte) (int, error) {
+	n, err := writer.Writer.Write(b)
+	writer.counter.Add(float64(n))
+	return n, err
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	counter prometheus.Counter
+}
+
+// Read reads from the underlying reader and counts the bytes read
+func (reader countingReader) Read(b []byte) (int, error) {
+	n, err := reader.Reader.Read(b)
+	reader.counter.Add(float64(n))
+	return n, err
+}
+
 type gzipWriter struct {
 	http.ResponseWriter
-	Writer io.Writer
+	Writer     io.Writer
+	compressor *gzip.Writer
 }
 
 // Write is definitely very useful for writing so that anything would be written in th right way
@@ -19,6 +74,18 @@ func (gzipWriter gzipWriter) Write(b []byte) (int, error) {
 	return gzipWriter.Writer.Write(b)
 }
 
+// Flush sends everything compressed so far, so that the streaming handlers could flush through the writer
+func (gzipWriter gzipWriter) Flush() {
+	err := gzipWriter.compressor.Flush()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if flusher, ok := gzipWriter.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // GzipMiddleware is used by App to gzip all incoming and outgoing data
 func GzipMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -29,7 +96,8 @@ func GzipMiddleware(next http.Handler) http.Handler {
 		}
 
 		if strings.Contains(request.Header.Get("Content-Encoding"), "gzip") {
-			gzipReader, err := gzip.NewReader(request.Body)
+			gzipReader, err := gzip.NewReader(countingReader{Reader: request.Body,
+				counter: gzipBytesIn.WithLabelValues("gzip")})
 			if err != nil {
 				http.Error(writer, err.Error(), http.StatusInternalServerError)
 				return
@@ -38,17 +106,16 @@ func GzipMiddleware(next http.Handler) http.Handler {
 				_ = gzipReader.Close()
 			}()
 
-			body, err := io.ReadAll(gzipReader)
-			if err != nil {
-				http.Error(writer, err.Error(), http.StatusInternalServerError)
-				return
-			}
-
-			request.Body = io.NopCloser(bytes.NewReader(body))
-			request.ContentLength = int64(len(body))
+			// the body is decompressed while the handler reads it, the cap keeps a small bomb from
+			// inflating into an endless one
+			request.Body = http.MaxBytesReader(writer, gzipBody{Reader: countingReader{Reader: gzipReader,
+				counter: gzipBytesIn.WithLabelValues("identity")}, Closer: gzipReader}, MaxGzipBodySize)
+			request.ContentLength = -1
+			request.Header.Del("Content-Length")
 		}
 
-		gzipReader, err := gzip.NewWriterLevel(writer, gzip.BestSpeed)
+		gzipReader, err := gzip.NewWriterLevel(countingWriter{Writer: writer,
+			counter: gzipBytesOut.WithLabelValues("gzip")}, gzip.BestSpeed)
 		if err != nil {
 			log.Println(err)
 			if _, err = io.WriteString(writer, err.Error()); err != nil {
@@ -65,6 +132,7 @@ func GzipMiddleware(next http.Handler) http.Handler {
 		}()
 
 		writer.Header().Set("Content-Encoding", "gzip")
-		next.ServeHTTP(gzipWriter{ResponseWriter: writer, Writer: gzipReader}, request)
+		next.ServeHTTP(gzipWriter{ResponseWriter: writer, Writer: countingWriter{Writer: gzipReader,
+			counter: gzipBytesOut.WithLabelValues("identity")}, compressor: gzipReader}, request)
 	})
 }